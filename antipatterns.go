@@ -0,0 +1,221 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * antipatterns.go
+ */
+
+package hatchet
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	SEVERITY_HIGH   = "high"
+	SEVERITY_MEDIUM = "medium"
+	SEVERITY_LOW    = "low"
+
+	RULE_CASE_INSENSITIVE = "case-insensitive $regex"
+	RULE_FACET            = "unbounded $facet"
+	RULE_FUNCTION         = "$function"
+	RULE_IN_SORT          = "in-memory sort"
+	RULE_LARGE_IN         = "large $in"
+	RULE_LARGE_SKIP       = "large skip"
+	RULE_LOOKUP           = "unindexed $lookup"
+	RULE_NEGATION         = "$ne/$nin"
+	RULE_UNANCHORED       = "unanchored $regex"
+	RULE_WHERE            = "$where"
+
+	LARGE_IN_SIZE = 200   // number of $in elements to be a large $in
+	LARGE_SKIP    = 10000 // skip value to be a large skip
+)
+
+// ANTI_PATTERN_RULES maps anti-pattern rules to severities
+var ANTI_PATTERN_RULES = map[string]string{
+	RULE_CASE_INSENSITIVE: SEVERITY_MEDIUM,
+	RULE_FACET:            SEVERITY_MEDIUM,
+	RULE_FUNCTION:         SEVERITY_HIGH,
+	RULE_IN_SORT:          SEVERITY_MEDIUM,
+	RULE_LARGE_IN:         SEVERITY_MEDIUM,
+	RULE_LARGE_SKIP:       SEVERITY_MEDIUM,
+	RULE_LOOKUP:           SEVERITY_HIGH,
+	RULE_NEGATION:         SEVERITY_LOW,
+	RULE_UNANCHORED:       SEVERITY_MEDIUM,
+	RULE_WHERE:            SEVERITY_HIGH,
+}
+
+// Finding stores an anti-pattern found in a slow op
+type Finding struct {
+	Count        int    `json:"count"`
+	Detail       string `json:"detail"`
+	Namespace    string `json:"ns"`
+	Op           string `json:"op"`
+	QueryPattern string `json:"query_pattern"`
+	Rule         string `json:"rule"`
+	Severity     string `json:"severity"`
+
+	index int // log line of the slow op
+}
+
+// AntiPatternDetector detects anti-patterns of slow ops, and checks $lookup foreign fields against
+// indexes used by slow ops of foreign collections
+type AntiPatternDetector struct {
+	indexes map[string]map[string]bool // namespace to first fields of indexes used
+	lookups []*Finding
+}
+
+// NewAntiPatternDetector returns an AntiPatternDetector
+func NewAntiPatternDetector() *AntiPatternDetector {
+	return &AntiPatternDetector{indexes: map[string]map[string]bool{}}
+}
+
+// Analyze returns anti-patterns of a slow op
+func (ptr *AntiPatternDetector) Analyze(index int, doc *Logv2Info, stat *OpStat) []*Finding {
+	findings := []*Finding{}
+	if stat == nil || stat.Op == "" {
+		return findings
+	}
+	attrMap := doc.Attr.Map()
+	if field := getIndexFirstField(stat.Index); field != "" {
+		if ptr.indexes[stat.Namespace] == nil {
+			ptr.indexes[stat.Namespace] = map[string]bool{}
+		}
+		ptr.indexes[stat.Namespace][field] = true
+	}
+	command, _ := attrMap["command"].(bson.D)
+	if originating, ok := attrMap["originatingCommand"].(bson.D); ok && stat.Op == cmdGetMore {
+		command = originating
+	}
+	add := func(rule string, detail string) {
+		for _, finding := range findings {
+			if finding.Rule == rule {
+				return
+			}
+		}
+		findings = append(findings, &Finding{Detail: detail, Namespace: stat.Namespace, Op: stat.Op,
+			QueryPattern: stat.QueryPattern, Rule: rule, Severity: ANTI_PATTERN_RULES[rule], index: index})
+	}
+	walkDoc(command, func(key string, value interface{}, parent bson.D) {
+		switch key {
+		case "$where":
+			add(RULE_WHERE, "")
+		case "$function", "$accumulator":
+			add(RULE_FUNCTION, key)
+		case "$ne", "$nin":
+			add(RULE_NEGATION, key)
+		case "$in":
+			if arr, ok := value.(bson.A); ok && len(arr) >= LARGE_IN_SIZE {
+				add(RULE_LARGE_IN, fmt.Sprintf("%d elements", len(arr)))
+			}
+		case "$regex":
+			options, _ := parent.Map()["$options"].(string)
+			if pattern, ok := value.(string); ok {
+				checkRegex(pattern, options, add)
+			} else if re, ok := value.(primitive.Regex); ok {
+				checkRegex(re.Pattern, re.Options+options, add)
+			}
+		case "$facet":
+			if facets, ok := value.(bson.D); ok {
+				for _, facet := range facets {
+					if !hasStage(facet.Value, "$limit") {
+						add(RULE_FACET, facet.Key)
+					}
+				}
+			}
+		case "$lookup":
+			if lookup, ok := value.(bson.D); ok {
+				lookupMap := lookup.Map()
+				from, _ := lookupMap["from"].(string)
+				field, _ := lookupMap["foreignField"].(string)
+				if from != "" && field != "" && field != "_id" {
+					ns := strings.SplitN(stat.Namespace, ".", 2)[0] + "." + from
+					ptr.lookups = append(ptr.lookups, &Finding{Detail: fmt.Sprintf("%v.%v", ns, field),
+						Namespace: stat.Namespace, Op: stat.Op, QueryPattern: stat.QueryPattern, Rule: RULE_LOOKUP,
+						Severity: ANTI_PATTERN_RULES[RULE_LOOKUP], index: index})
+				}
+			}
+		case "$skip", "skip":
+			if n := ToInt(value); n >= LARGE_SKIP {
+				add(RULE_LARGE_SKIP, fmt.Sprintf("skip %d", n))
+			}
+		default:
+			if re, ok := value.(primitive.Regex); ok {
+				checkRegex(re.Pattern, re.Options, add)
+			}
+		}
+	})
+	if hasSortStage, ok := attrMap["hasSortStage"].(bool); ok && hasSortStage {
+		add(RULE_IN_SORT, "")
+	}
+	return findings
+}
+
+// Close returns $lookup of which foreign fields are not the first field of any index used
+func (ptr *AntiPatternDetector) Close() []*Finding {
+	findings := []*Finding{}
+	for _, finding := range ptr.lookups {
+		pos := strings.LastIndex(finding.Detail, ".")
+		ns, field := finding.Detail[:pos], finding.Detail[pos+1:]
+		if !ptr.indexes[ns][field] {
+			findings = append(findings, finding)
+		}
+	}
+	sort.Slice(findings, func(i, j int) bool {
+		return findings[i].index < findings[j].index
+	})
+	ptr.lookups = nil
+	return findings
+}
+
+// checkRegex adds unanchored and case-insensitive $regex findings
+func checkRegex(pattern string, options string, add func(string, string)) {
+	if !strings.HasPrefix(pattern, "^") && !strings.HasPrefix(pattern, `\A`) {
+		add(RULE_UNANCHORED, "/"+pattern+"/")
+	}
+	if strings.Contains(options, "i") {
+		add(RULE_CASE_INSENSITIVE, "/"+pattern+"/"+options)
+	}
+}
+
+// hasStage returns true if a pipeline has a stage
+func hasStage(pipeline interface{}, stage string) bool {
+	stages, ok := pipeline.(bson.A)
+	if !ok {
+		return false
+	}
+	for _, s := range stages {
+		if doc, ok := s.(bson.D); ok && len(doc) > 0 && doc[0].Key == stage {
+			return true
+		}
+	}
+	return false
+}
+
+// getIndexFirstField returns the first field of an index, e.g. status of { status:1, _id:1 }
+func getIndexFirstField(index string) string {
+	re := regexp.MustCompile(`^{\s*"?([^:"\s]+)"?\s*:`)
+	if matches := re.FindStringSubmatch(index); len(matches) > 1 {
+		return matches[1]
+	}
+	return ""
+}
+
+// walkDoc calls a function of each key and value of a document recursively
+func walkDoc(value interface{}, fn func(key string, value interface{}, parent bson.D)) {
+	switch v := value.(type) {
+	case bson.D:
+		for _, elem := range v {
+			fn(elem.Key, elem.Value, v)
+			walkDoc(elem.Value, fn)
+		}
+	case bson.A:
+		for _, elem := range v {
+			walkDoc(elem, fn)
+		}
+	}
+}
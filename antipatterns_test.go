@@ -0,0 +1,88 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * antipatterns_test.go
+ */
+
+package hatchet
+
+import (
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestAntiPatternDetector(t *testing.T) {
+	tests := []struct {
+		str   string
+		rules []string
+	}{
+		{`{"t":{"$date":"2023-03-01T10:40:00.000+00:00"},"s":"I","c":"COMMAND","id":51803,"ctx":"conn401","msg":"Slow query","attr":{"type":"command","ns":"shop.users","command":{"find":"users","filter":{"name":{"$regex":"smith","$options":"i"}},"$db":"shop"},"planSummary":"COLLSCAN","durationMillis":320}}`,
+			[]string{RULE_UNANCHORED, RULE_CASE_INSENSITIVE}},
+		{`{"t":{"$date":"2023-03-01T10:40:01.000+00:00"},"s":"I","c":"COMMAND","id":51803,"ctx":"conn401","msg":"Slow query","attr":{"type":"command","ns":"shop.users","command":{"find":"users","filter":{"name":{"$regex":"^smith"}},"$db":"shop"},"planSummary":"IXSCAN { name: 1 }","durationMillis":120}}`,
+			[]string{}},
+		{`{"t":{"$date":"2023-03-01T10:40:02.000+00:00"},"s":"I","c":"COMMAND","id":51803,"ctx":"conn401","msg":"Slow query","attr":{"type":"command","ns":"shop.users","command":{"find":"users","filter":{"$where":"this.a > this.b","status":{"$ne":"closed"}},"skip":20000,"$db":"shop"},"planSummary":"COLLSCAN","hasSortStage":true,"durationMillis":820}}`,
+			[]string{RULE_WHERE, RULE_NEGATION, RULE_LARGE_SKIP, RULE_IN_SORT}},
+		{`{"t":{"$date":"2023-03-01T10:40:03.000+00:00"},"s":"I","c":"COMMAND","id":51803,"ctx":"conn402","msg":"Slow query","attr":{"type":"command","ns":"shop.orders","command":{"aggregate":"orders","pipeline":[{"$match":{"$expr":{"$function":{"body":"function(a) { return a; }","args":["$a"],"lang":"js"}}}},{"$facet":{"bySku":[{"$group":{"_id":"$sku"}}],"top":[{"$sort":{"qty":-1}},{"$limit":10}]}}],"$db":"shop"},"planSummary":"COLLSCAN","durationMillis":1020}}`,
+			[]string{RULE_FUNCTION, RULE_FACET}},
+	}
+	detector := NewAntiPatternDetector()
+	for i, test := range tests {
+		doc := Logv2Info{}
+		if err := bson.UnmarshalExtJSON([]byte(test.str), false, &doc); err != nil {
+			t.Fatalf("bson unmarshal error %v", err)
+		}
+		stat, _ := AnalyzeSlowOp(&doc)
+		findings := detector.Analyze(i, &doc, stat)
+		rules := []string{}
+		for _, finding := range findings {
+			rules = append(rules, finding.Rule)
+			if finding.Severity != ANTI_PATTERN_RULES[finding.Rule] {
+				t.Fatal("expected", ANTI_PATTERN_RULES[finding.Rule], "but got", finding.Severity)
+			}
+		}
+		if strings.Join(rules, ",") != strings.Join(test.rules, ",") {
+			t.Fatal("expected", test.rules, "but got", rules)
+		}
+	}
+}
+
+func TestAntiPatternDetectorLookup(t *testing.T) {
+	logs := []string{
+		`{"t":{"$date":"2023-03-01T10:41:00.000+00:00"},"s":"I","c":"COMMAND","id":51803,"ctx":"conn403","msg":"Slow query","attr":{"type":"command","ns":"shop.orders","command":{"aggregate":"orders","pipeline":[{"$lookup":{"from":"users","localField":"uid","foreignField":"uid","as":"user"}}],"$db":"shop"},"planSummary":"COLLSCAN","durationMillis":620}}`,
+		`{"t":{"$date":"2023-03-01T10:41:01.000+00:00"},"s":"I","c":"COMMAND","id":51803,"ctx":"conn403","msg":"Slow query","attr":{"type":"command","ns":"shop.orders","command":{"aggregate":"orders","pipeline":[{"$lookup":{"from":"items","localField":"sku","foreignField":"sku","as":"item"}}],"$db":"shop"},"planSummary":"COLLSCAN","durationMillis":620}}`,
+		`{"t":{"$date":"2023-03-01T10:41:02.000+00:00"},"s":"I","c":"COMMAND","id":51803,"ctx":"conn404","msg":"Slow query","attr":{"type":"command","ns":"shop.items","command":{"find":"items","filter":{"sku":"x"},"$db":"shop"},"planSummary":"IXSCAN { sku: 1, qty: -1 }","durationMillis":120}}`,
+	}
+	detector := NewAntiPatternDetector()
+	for i, str := range logs {
+		doc := Logv2Info{}
+		if err := bson.UnmarshalExtJSON([]byte(str), false, &doc); err != nil {
+			t.Fatalf("bson unmarshal error %v", err)
+		}
+		stat, _ := AnalyzeSlowOp(&doc)
+		if findings := detector.Analyze(i, &doc, stat); len(findings) != 0 {
+			t.Fatal("expected no findings but got", len(findings))
+		}
+	}
+	findings := detector.Close()
+	if len(findings) != 1 {
+		t.Fatal("expected 1 but got", len(findings))
+	}
+	if findings[0].Rule != RULE_LOOKUP || findings[0].Detail != "shop.users.uid" || findings[0].index != 0 {
+		t.Fatal("unexpected", findings[0])
+	}
+}
+
+func TestGetIndexFirstField(t *testing.T) {
+	tests := map[string]string{
+		"{ status: 1, _id: 1 }": "status",
+		`{"sku":1}`:             "sku",
+		"COLLSCAN":              "",
+		"":                      "",
+	}
+	for index, field := range tests {
+		if value := getIndexFirstField(index); value != field {
+			t.Fatal("expected", field, "but got", value)
+		}
+	}
+}
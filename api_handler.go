@@ -7,23 +7,38 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 
 	"github.com/julienschmidt/httprouter"
 )
 
 // APIHandler responds to API calls
-func APIHandler(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+func (ptr *Server) APIHandler(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
 	/** APIs
 	 * /api/hatchet/v1.0/hatchets/{hatchet}/logs/all
 	 * /api/hatchet/v1.0/hatchets/{hatchet}/logs/slowops
 	 * /api/hatchet/v1.0/hatchets/{hatchet}/stats/slowops
+	 * /api/hatchet/v1.0/hatchets/{hatchet}/stats/concerns
+	 * /api/hatchet/v1.0/hatchets/{hatchet}/stats/connections
+	 * /api/hatchet/v1.0/hatchets/{hatchet}/stats/cursors
+	 * /api/hatchet/v1.0/hatchets/{hatchet}/stats/drivers
+	 * /api/hatchet/v1.0/hatchets/{hatchet}/stats/errors
+	 * /api/hatchet/v1.0/hatchets/{hatchet}/stats/findings
+	 * /api/hatchet/v1.0/hatchets/{hatchet}/stats/index-builds
+	 * /api/hatchet/v1.0/hatchets/{hatchet}/stats/uptime
+	 * /api/hatchet/v1.0/hatchets/{hatchet}/stats/replication
+	 * /api/hatchet/v1.0/hatchets/{hatchet}/stats/sharding
+	 * /api/hatchet/v1.0/hatchets/{hatchet}/stats/transactions
 	 */
-	w.WriteHeader(http.StatusOK)
 	w.Header().Set("Content-Type", "application/json")
 	hatchetName := params.ByName("hatchet")
 	attr := params.ByName("attr")
 	category := params.ByName("category")
-	dbase, err := GetDatabase(hatchetName)
+	if err := validateRequest(hatchetName, r); err != nil {
+		writeError(w, err)
+		return
+	}
+	dbase, err := GetDatabase(ptr.opts, hatchetName)
 	if err != nil {
 		json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
 		return
@@ -38,9 +53,10 @@ func APIHandler(w http.ResponseWriter, r *http.Request, params httprouter.Params
 		if orderBy == "" {
 			orderBy = "avg_ms"
 		}
-		ops, err := dbase.GetSlowOps(orderBy, "DESC", false)
+		ops, err := dbase.GetSlowOps(orderBy, r.URL.Query().Get("order"), false, r.URL.Query().Get("app"))
 		if err != nil {
-			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			writeError(w, err)
+			return
 		}
 		doc := map[string]interface{}{"hatchet": hatchetName, "has_more": false, "offset": 0, "limit": len(ops), "ops": ops}
 		b, err := json.Marshal(doc)
@@ -55,7 +71,201 @@ func APIHandler(w http.ResponseWriter, r *http.Request, params httprouter.Params
 		if err != nil {
 			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
 		}
-		doc := map[string]interface{}{"hatchet": hatchetName, "audit": data}
+		auth, err := dbase.GetAuthStats()
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		storage, err := dbase.GetStorageStats()
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		doc := map[string]interface{}{"hatchet": hatchetName, "audit": data, "auth": auth, "storage": storage,
+			"config": dbase.GetHatchetInfo().Config}
+		b, err := json.Marshal(doc)
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+		} else {
+			w.Write(b)
+		}
+		return
+	} else if category == "stats" && attr == T_TRANSACTIONS {
+		data, err := dbase.GetTransactionStats()
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		topN := ToInt(r.URL.Query().Get("topN"))
+		if topN == 0 {
+			topN = TOP_N
+		}
+		txns, err := dbase.GetLongestTransactions(topN)
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		doc := map[string]interface{}{"hatchet": hatchetName, "transactions": data, "longest": txns}
+		b, err := json.Marshal(doc)
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+		} else {
+			w.Write(b)
+		}
+		return
+	} else if category == "stats" && attr == T_CONNECTIONS {
+		data, err := getConnectionsData(dbase, r.URL.Query().Get("duration"))
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		doc := map[string]interface{}{"hatchet": hatchetName, "connections": data}
+		b, err := json.Marshal(doc)
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+		} else {
+			w.Write(b)
+		}
+		return
+	} else if category == "stats" && attr == T_INDEX_BUILDS {
+		builds, err := dbase.GetIndexBuilds(r.URL.Query().Get("duration"))
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		doc := map[string]interface{}{"hatchet": hatchetName, "index_builds": builds}
+		b, err := json.Marshal(doc)
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+		} else {
+			w.Write(b)
+		}
+		return
+	} else if category == "stats" && attr == T_CURSORS {
+		topN := ToInt(r.URL.Query().Get("topN"))
+		if topN == 0 {
+			topN = TOP_N
+		}
+		stats, err := dbase.GetCursorStats(topN)
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		doc := map[string]interface{}{"hatchet": hatchetName, "cursors": stats}
+		b, err := json.Marshal(doc)
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+		} else {
+			w.Write(b)
+		}
+		return
+	} else if category == "stats" && attr == T_CONCERNS {
+		topN := ToInt(r.URL.Query().Get("topN"))
+		if topN == 0 {
+			topN = TOP_N
+		}
+		stats, err := dbase.GetConcernStats(topN)
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		doc := map[string]interface{}{"hatchet": hatchetName, "concerns": stats}
+		b, err := json.Marshal(doc)
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+		} else {
+			w.Write(b)
+		}
+		return
+	} else if category == "stats" && attr == T_FINDINGS {
+		findings, err := dbase.GetFindings()
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		doc := map[string]interface{}{"hatchet": hatchetName, "findings": findings}
+		b, err := json.Marshal(doc)
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+		} else {
+			w.Write(b)
+		}
+		return
+	} else if category == "stats" && attr == T_DRIVERS {
+		drivers, err := getDriversData(dbase)
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		doc := map[string]interface{}{"hatchet": hatchetName, "version": dbase.GetHatchetInfo().Version,
+			"drivers": drivers, "releases": SERVER_RELEASES}
+		b, err := json.Marshal(doc)
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+		} else {
+			w.Write(b)
+		}
+		return
+	} else if category == "stats" && attr == T_ERROR_CODES {
+		codes, err := dbase.GetErrorCodes()
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		doc := map[string]interface{}{"hatchet": hatchetName, "errors": codes}
+		b, err := json.Marshal(doc)
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+		} else {
+			w.Write(b)
+		}
+		return
+	} else if category == "stats" && attr == T_UPTIME {
+		doc := map[string]interface{}{"hatchet": hatchetName}
+		if id := r.URL.Query().Get("id"); id != "" {
+			detail, err := dbase.GetCrashDetail(ToInt(id))
+			if err != nil {
+				json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+				return
+			}
+			doc["crash"] = detail
+		} else {
+			segments, err := dbase.GetUptimeSegments()
+			if err != nil {
+				json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+				return
+			}
+			doc["uptime"] = segments
+		}
+		b, err := json.Marshal(doc)
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+		} else {
+			w.Write(b)
+		}
+		return
+	} else if category == "stats" && attr == T_REPLICATION {
+		events, err := dbase.GetReplEvents("", "")
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		info := dbase.GetHatchetInfo()
+		doc := map[string]interface{}{"hatchet": hatchetName, "replication": GetReplicationData(events, info.End)}
+		b, err := json.Marshal(doc)
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+		} else {
+			w.Write(b)
+		}
+		return
+	} else if category == "stats" && attr == T_SHARDING {
+		stats, err := dbase.GetShardingStats()
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		doc := map[string]interface{}{"hatchet": hatchetName, "sharding": stats}
 		b, err := json.Marshal(doc)
 		if err != nil {
 			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
@@ -86,15 +296,27 @@ func APIHandler(w http.ResponseWriter, r *http.Request, params httprouter.Params
 		context := r.URL.Query().Get("context")
 		severity := r.URL.Query().Get("severity")
 		duration := r.URL.Query().Get("duration")
+		txn := r.URL.Query().Get("txn")
+		errcode := r.URL.Query().Get("errcode")
 		limit := r.URL.Query().Get("limit")
 		if limit == "" {
 			limit = fmt.Sprintf("%v", LIMIT)
 		}
 		offset, nlimit := GetOffsetLimit(limit)
-		logs, err := dbase.GetLogs(fmt.Sprintf("component=%v", component), fmt.Sprintf("limit=%v", limit),
-			fmt.Sprintf("context=%v", context), fmt.Sprintf("severity=%v", severity), fmt.Sprintf("duration=%v", duration))
+		filters, err := GetAttrFilters(r.URL.RawQuery)
 		if err != nil {
-			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			writeError(w, err)
+			return
+		}
+		opts := []string{fmt.Sprintf("component=%v", component), fmt.Sprintf("limit=%v", limit),
+			fmt.Sprintf("context=%v", context), fmt.Sprintf("severity=%v", severity), fmt.Sprintf("duration=%v", duration),
+			fmt.Sprintf("txn=%v", txn), fmt.Sprintf("errcode=%v", errcode)}
+		for _, filter := range filters {
+			opts = append(opts, "attr="+filter)
+		}
+		logs, err := dbase.GetLogs(opts...)
+		if err != nil {
+			writeError(w, err)
 			return
 		}
 		hasMore = len(logs) > nlimit
@@ -103,6 +325,9 @@ func APIHandler(w http.ResponseWriter, r *http.Request, params httprouter.Params
 		}
 		var b []byte
 		doc := map[string]interface{}{"hatchet": hatchetName, "has_more": hasMore, "offset": offset, "limit": len(logs), "logs": logs}
+		if notice := getSearchNotice(dbase, context); notice != "" {
+			doc["notice"] = notice
+		}
 		if b, err = json.Marshal(doc); err != nil {
 			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
 			return
@@ -112,3 +337,72 @@ func APIHandler(w http.ResponseWriter, r *http.Request, params httprouter.Params
 	}
 	json.NewEncoder(w).Encode(map[string]interface{}{"ok": 1, "message": "Hello Hatchet API!"})
 }
+
+// HatchetsHandler responds to API calls managing hatchets
+func (ptr *Server) HatchetsHandler(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	/** APIs
+	 * GET /api/hatchet/v1.0/hatchets
+	 * GET /api/hatchet/v1.0/hatchets/{hatchet}
+	 * DELETE /api/hatchet/v1.0/hatchets/{hatchet}
+	 * PATCH /api/hatchet/v1.0/hatchets/{hatchet}?name={new name}
+	 * PATCH /api/hatchet/v1.0/hatchets/{hatchet}?pinned={true|false}
+	 * POST /api/hatchet/v1.0/vacuum
+	 */
+	w.Header().Set("Content-Type", "application/json")
+	hatchetName := params.ByName("hatchet")
+	if ptr.opts.Verbose {
+		log.Println("HatchetsHandler", r.Method, r.URL.Path, hatchetName)
+	}
+	if r.Method == http.MethodPost {
+		if err := Vacuum(ptr.opts); err != nil {
+			writeError(w, err)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"ok": 1})
+		return
+	} else if hatchetName == "" {
+		usages, err := GetHatchetUsages(ptr.opts)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"ok": 1, "hatchets": usages})
+		return
+	}
+	if err := validateRequest(hatchetName, r); err != nil {
+		writeError(w, err)
+		return
+	}
+	if r.Method == http.MethodDelete {
+		if err := DeleteHatchet(ptr.opts, hatchetName); err != nil {
+			writeError(w, err)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"ok": 1, "hatchet": hatchetName})
+	} else if r.Method == http.MethodPatch && r.URL.Query().Has("pinned") {
+		pinned, err := strconv.ParseBool(r.URL.Query().Get("pinned"))
+		if err != nil {
+			writeError(w, NewInvalidInputError("invalid pinned %v", r.URL.Query().Get("pinned")))
+			return
+		}
+		if err = PinHatchet(ptr.opts, hatchetName, pinned); err != nil {
+			writeError(w, err)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"ok": 1, "hatchet": hatchetName, "pinned": pinned})
+	} else if r.Method == http.MethodPatch {
+		name := r.URL.Query().Get("name")
+		if err := RenameHatchet(ptr.opts, hatchetName, name); err != nil {
+			writeError(w, err)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"ok": 1, "hatchet": name})
+	} else {
+		usage, err := GetHatchetUsage(ptr.opts, hatchetName)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"ok": 1, "hatchet": usage})
+	}
+}
@@ -6,6 +6,8 @@
 package hatchet
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"html/template"
 	"math/rand"
@@ -31,6 +33,33 @@ func GetAuditTablesTemplate() (*template.Template, error) {
 		</tr>
 	  </table>
 	</div>
+{{with .Info.Config}}
+{{if or .Host .Options}}
+	<table style='float: left; margin: 10px 10px; clear: left;'>
+		<caption><span style="font-size: 16px; padding: 5px 5px;"><i class="fa fa-cogs"></i></span>Server Configuration</caption>
+		<tr><th>Setting</th><th>Value</th></tr>
+		<tr><td>Host</td><td>{{.Host}}{{if .Port}}:{{.Port}}{{end}}</td></tr>
+		<tr><td>Replica Set</td><td>{{.ReplSetName}}</td></tr>
+		<tr><td>Storage Engine</td><td>{{.StorageEngine}}</td></tr>
+		<tr><td>Cache Size</td><td>{{if .CacheSizeGB}}{{printf "%.1f" .CacheSizeGB}} GB{{end}}</td></tr>
+		<tr><td>Slow Operation Threshold</td><td>{{.SlowMS}} ms</td></tr>
+		<tr><td>Profiling Mode</td><td>{{.Profile}}</td></tr>
+	{{if .Options}}
+		<tr><td>Options</td><td><details><summary>command line options</summary>
+			<pre style='font-size: 0.9em;'>{{getIndentedJSON .Options}}</pre></details></td></tr>
+	{{end}}
+	</table>
+{{end}}
+{{if .Warnings}}
+	<table style='float: left; margin: 10px 10px;'>
+		<caption><span style="font-size: 16px; padding: 5px 5px;"><i class="fa fa-exclamation-triangle"></i></span>Startup Warnings</caption>
+		<tr><th></th><th>Warning</th></tr>
+	{{range $n, $val := .Warnings}}
+		<tr><td align=right>{{add $n 1}}</td><td><span style='color: red;'>{{$val}}</span></td></tr>
+	{{end}}
+	</table>
+{{end}}
+{{end}}
 {{if hasData .Data "exception"}}
 	<table style='float: left; margin: 10px 10px; clear: left;'>
 		<caption><button class='btn'
@@ -64,6 +93,63 @@ func GetAuditTablesTemplate() (*template.Template, error) {
 	</table>
 {{end}}
 
+{{if hasData .Data "errcode"}}
+	<table style='float: left; margin: 10px 10px;'>
+		<caption><button class='btn'
+			onClick="javascript:location.href='/hatchets/{{.Hatchet}}/stats/errors'; return false;">
+			<i class='fa fa-exclamation-triangle'></i></button>Error Codes</caption>
+		<tr><th></th><th>Error</th><th>Total</th></tr>
+	{{range $n, $val := index .Data "errcode"}}
+		<tr><td align=right>{{add $n 1}}</td>
+			<td>
+				<button class='btn' onClick="javascript:location.href='/hatchets/{{$name}}/logs/all?errcode={{getErrorCode $val.Name}}'; return false;"><i class='fa fa-search'></i></button>{{$val.Name}}
+			</td>
+			<td align=right>{{getFormattedNumber $val.Values 0}}</td>
+		</tr>
+	{{end}}
+	</table>
+{{end}}
+
+{{if .Storage.Events}}
+	<table style='float: left; margin: 10px 10px; clear: left;'>
+		<caption><button class='btn'
+			onClick="javascript:location.href='/hatchets/{{.Hatchet}}/logs/all?component=STORAGE'; return false;">
+			<i class='fa fa-search'></i></button>Storage Events</caption>
+		<tr><th></th><th>Event</th><th>Total</th></tr>
+	{{range $n, $val := .Storage.Events}}
+		<tr><td align=right>{{add $n 1}}</td><td>{{$val.Name}}</td><td align=right>{{numPrinter $val.Value}}</td></tr>
+	{{end}}
+	</table>
+{{end}}
+
+{{if .Storage.Checkpoints}}
+	<table style='float: left; margin: 10px 10px;'>
+		<caption><button class='btn'
+			onClick="javascript:location.href='/hatchets/{{.Hatchet}}/charts/storage?type=checkpoints'; return false;">
+			<i class='fa fa-area-chart'></i></button>Checkpoints</caption>
+		<tr><th></th><th>Stat</th><th>Value</th></tr>
+	{{range $n, $val := .Storage.Checkpoints}}
+		<tr><td align=right>{{add $n 1}}</td><td>{{$val.Name}}</td><td align=right>{{numPrinter $val.Value}}</td></tr>
+	{{end}}
+	</table>
+{{end}}
+
+{{if .Storage.Warnings}}
+	<table style='float: left; margin: 10px 10px; clear: left;'>
+		<caption><span style="font-size: 16px; padding: 5px 5px;"><i class="fa fa-database"></i></span>Cache Pressure and Storage Warnings</caption>
+		<tr><th></th><th>Type</th><th>Message</th><th>Total</th></tr>
+	{{range $n, $val := .Storage.Warnings}}
+		<tr><td align=right>{{add $n 1}}</td>
+		{{if eq $val.Type "eviction"}}
+			<td><span style='color: orange;'>{{$val.Type}}</span></td>
+		{{else}}
+			<td><span style='color: red;'>{{$val.Type}}</span></td>
+		{{end}}
+			<td>{{$val.Message}}</td><td align=right>{{numPrinter $val.Count}}</td></tr>
+	{{end}}
+	</table>
+{{end}}
+
 {{if hasData .Data "op"}}
 	<table style='float: left; margin: 10px 10px; clear: left;'>
 		<caption><button class='btn'
@@ -126,6 +212,71 @@ func GetAuditTablesTemplate() (*template.Template, error) {
 	{{end}}
 	</table>
 {{end}}
+
+{{if .Auth.Mechanisms}}
+	<table style='float: left; margin: 10px 10px; clear: left;'>
+		<caption><button class='btn'
+			onClick="javascript:location.href='/hatchets/{{.Hatchet}}/logs/all?component=ACCESS'; return false;">
+			<i class='fa fa-search'></i></button>Authentication Mechanisms</caption>
+		<tr><th></th><th>Mechanism</th><th>Succeeded</th><th>Failed</th></tr>
+	{{range $n, $val := .Auth.Mechanisms}}
+		<tr><td align=right>{{add $n 1}}</td><td>{{$val.Name}}</td>
+		<td align=right>{{getFormattedNumber $val.Values 0}}</td><td align=right>{{getFormattedNumber $val.Values 1}}</td></tr>
+	{{end}}
+	</table>
+{{end}}
+
+{{if .Auth.Errors}}
+	<table style='float: left; margin: 10px 10px;'>
+		<caption><button class='btn'
+			onClick="javascript:location.href='/hatchets/{{.Hatchet}}/charts/auth?type=failures'; return false;">
+			<i class='fa fa-area-chart'></i></button>Authentication Errors</caption>
+		<tr><th></th><th>Error</th><th>Total</th></tr>
+	{{range $n, $val := .Auth.Errors}}
+		<tr><td align=right>{{add $n 1}}</td><td>{{$val.Name}}</td><td align=right>{{numPrinter $val.Value}}</td></tr>
+	{{end}}
+	</table>
+
+	<table style='float: left; margin: 10px 10px; clear: left;'>
+		<caption><span style="font-size: 16px; padding: 5px 5px;"><i class="fa fa-user-times"></i></span>Auth Failures by Users</caption>
+		<tr><th></th><th>User</th><th>Failures</th><th>IPs</th></tr>
+	{{range $n, $val := .Auth.FailuresByUser}}
+		<tr><td align=right>{{add $n 1}}</td>
+		<td>
+			<button class='btn' onClick="javascript:location.href='/hatchets/{{$name}}/logs/all?component=ACCESS&context={{getPrincipalName $val.Name}}'; return false;"><i class='fa fa-search'></i></button>{{$val.Name}}
+		</td>
+		<td align=right>{{getFormattedNumber $val.Values 0}}</td><td align=right>{{getFormattedNumber $val.Values 1}}</td></tr>
+	{{end}}
+	</table>
+
+	<table style='float: left; margin: 10px 10px;'>
+		<caption><span style="font-size: 16px; padding: 5px 5px;"><i class="fa fa-ban"></i></span>Auth Failures by IPs</caption>
+		<tr><th></th><th>IP</th><th>Failures</th><th>Users</th></tr>
+	{{range $n, $val := .Auth.FailuresByIP}}
+		<tr><td align=right>{{add $n 1}}</td>
+		<td>
+			<button class='btn' onClick="javascript:location.href='/hatchets/{{$name}}/logs/all?component=ACCESS&context={{$val.Name}}'; return false;"><i class='fa fa-search'></i></button>{{$val.Name}}
+		</td>
+		<td align=right>{{getFormattedNumber $val.Values 0}}</td><td align=right>{{getFormattedNumber $val.Values 1}}</td></tr>
+	{{end}}
+	</table>
+
+	<table style='float: left; margin: 10px 10px; clear: left;'>
+		<caption><span style="font-size: 16px; padding: 5px 5px;"><i class="fa fa-exclamation-triangle"></i></span>Possible Brute-Force Attempts
+			({{getBruteForceRule}})</caption>
+		<tr><th></th><th>IP</th><th>From</th><th>To</th><th>Failures</th><th>Users</th></tr>
+	{{range $n, $val := .Auth.Bursts}}
+		<tr><td align=right>{{add $n 1}}</td>
+		<td>
+			<button class='btn' onClick="javascript:location.href='/hatchets/{{$name}}/logs/all?component=ACCESS&context={{$val.IP}}&duration={{$val.Start}},{{$val.End}}.999Z'; return false;"><i class='fa fa-search'></i></button>{{$val.IP}}
+		</td>
+		<td>{{formatDateTime $val.Start}}</td><td>{{formatDateTime $val.End}}</td>
+		<td align=right><span style='color: red;'>{{numPrinter $val.Count}}</span></td><td align=right>{{numPrinter $val.Principals}}</td></tr>
+	{{else}}
+		<tr><td></td><td colspan=5>no brute-force attempts found</td></tr>
+	{{end}}
+	</table>
+{{end}}
 	<div style='clear: left;' align='center'><hr/><p/>@simagix</div>
 	`
 	html += "</body></html>"
@@ -140,6 +291,31 @@ func GetAuditTablesTemplate() (*template.Template, error) {
 			printer := message.NewPrinter(language.English)
 			return printer.Sprintf("%v", ToInt(n))
 		},
+		"getIndentedJSON": func(str string) string {
+			var buf bytes.Buffer
+			if err := json.Indent(&buf, []byte(str), "", "  "); err != nil {
+				return str
+			}
+			return buf.String()
+		},
+		"formatDateTime": func(str string) string {
+			return strings.Replace(str, "T", " ", 1)
+		},
+		"getBruteForceRule": func() string {
+			return fmt.Sprintf("%d+ failures within %d seconds", BRUTE_FORCE_THRESHOLD, BRUTE_FORCE_WINDOW)
+		},
+		"getErrorCode": func(s string) string { // e.g. MaxTimeMSExpired (50)
+			if i := strings.LastIndex(s, "("); i >= 0 {
+				return strings.TrimSuffix(s[i+1:], ")")
+			}
+			return s
+		},
+		"getPrincipalName": func(s string) string {
+			if i := strings.LastIndex(s, "@"); i > 0 {
+				return s[:i]
+			}
+			return s
+		},
 		"getContext": func(s string) string {
 			toks := strings.Split(s, " ")
 			if len(toks) == 0 {
@@ -158,7 +334,6 @@ func GetAuditTablesTemplate() (*template.Template, error) {
 			return printer.Sprintf("%v", numbers[i])
 		},
 		"coinToss": func() bool {
-			rand.Seed(time.Now().UnixNano())
 			randomNum := rand.Intn(2)
 			return (randomNum%2 == 0)
 		},
@@ -212,6 +387,10 @@ func GetAuditTablesTemplate() (*template.Template, error) {
 							gox.GetDurationFromSeconds(float64(seconds)))
 					}
 				}
+				if len(info.Config.Warnings) > 0 {
+					html += fmt.Sprintf("The server logged <span style='color: orange;'>%d</span> startup warnings, and <mark>you should review them in the Server Configuration section</mark>. ",
+						len(info.Config.Warnings))
+				}
 				if info.Provider != "" && info.Region != "" {
 					html += fmt.Sprintf("Bravo for the decision to host your servers on Atlas <span style='color: orange;'>%s</span> in the <span style='color: orange;'>%s</span> region. ",
 						info.Provider, info.Region)
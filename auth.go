@@ -0,0 +1,188 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * auth.go
+ */
+
+package hatchet
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	AUTH_FAILED       = "failed"
+	AUTH_SUCCEEDED    = "succeeded"
+	AUTH_UNAUTHORIZED = "unauthorized"
+
+	BRUTE_FORCE_THRESHOLD = 10 // failures from an IP within the window to be a burst
+	BRUTE_FORCE_WINDOW    = 60 // seconds
+)
+
+// AUTH_ERROR_CODES maps error names to codes when logs only have error messages
+var AUTH_ERROR_CODES = map[string]int{"AuthenticationFailed": 18, "BadValue": 2, "MechanismUnavailable": 334,
+	"ProtocolError": 17, "Unauthorized": 13, "UserNotFound": 11}
+
+// AuthEvent stores an authentication or authorization result
+type AuthEvent struct {
+	Database  string `json:"db"`
+	Date      string `json:"date"`
+	ErrCode   int    `json:"err_code"`
+	ErrName   string `json:"err_name"`
+	IP        string `json:"ip"`
+	Mechanism string `json:"mechanism"`
+	Principal string `json:"principal"`
+	Result    string `json:"result"`
+}
+
+// AuthBurst stores consecutive authentication failures from an IP
+type AuthBurst struct {
+	Count      int    `json:"count"`
+	End        string `json:"end"`
+	IP         string `json:"ip"`
+	Principals int    `json:"principals"`
+	Start      string `json:"start"`
+}
+
+// AuthStats stores authentication summaries
+type AuthStats struct {
+	Bursts         []AuthBurst  `json:"bursts"`
+	Errors         []NameValue  `json:"errors"`
+	FailuresByIP   []NameValues `json:"failures_by_ip"`   // [failures, principals]
+	FailuresByUser []NameValues `json:"failures_by_user"` // [failures, IPs]
+	Mechanisms     []NameValues `json:"mechanisms"`       // [succeeded, failed]
+}
+
+// AnalyzeAuthEvent analyzes authentication and authorization logs
+func AnalyzeAuthEvent(doc *Logv2Info) (*AuthEvent, error) {
+	if doc.Component != "ACCESS" {
+		return nil, errors.New("not an ACCESS log")
+	}
+	event := &AuthEvent{}
+	msg := doc.Msg
+	lower := strings.ToLower(msg)
+	if msg == "Authentication succeeded" || msg == "Successfully authenticated" {
+		event.Result = AUTH_SUCCEEDED
+	} else if msg == "Authentication failed" || msg == "Failed to authenticate" {
+		event.Result = AUTH_FAILED
+	} else if strings.Contains(lower, "authorization failed") || strings.Contains(lower, "not authorized") {
+		event.Result = AUTH_UNAUTHORIZED
+		event.ErrName = "Unauthorized"
+	} else {
+		return nil, errors.New("not an authentication log")
+	}
+	attrMap := doc.Attr.Map()
+	event.Principal, _ = attrMap["principalName"].(string)
+	if event.Principal == "" {
+		event.Principal, _ = attrMap["user"].(string)
+	}
+	event.Database, _ = attrMap["authenticationDatabase"].(string)
+	if event.Database == "" {
+		event.Database, _ = attrMap["db"].(string)
+	}
+	event.Mechanism, _ = attrMap["mechanism"].(string)
+	if remote, ok := attrMap["remote"].(string); ok {
+		event.IP = remote
+		if i := strings.LastIndex(remote, ":"); i > 0 {
+			event.IP = remote[:i]
+		}
+	}
+	if attrMap["result"] != nil {
+		event.ErrCode = ToInt(attrMap["result"])
+	}
+	switch e := attrMap["error"].(type) {
+	case string:
+		if i := strings.Index(e, ":"); i > 0 {
+			event.ErrName = e[:i]
+		} else {
+			event.ErrName = e
+		}
+	case bson.D:
+		m := e.Map()
+		event.ErrCode = ToInt(m["code"])
+		event.ErrName, _ = m["codeName"].(string)
+	}
+	if event.ErrCode == 0 && event.ErrName != "" {
+		event.ErrCode = AUTH_ERROR_CODES[event.ErrName]
+	}
+	if event.Result == AUTH_FAILED && event.ErrName == "" {
+		event.ErrName = "AuthenticationFailed"
+		event.ErrCode = AUTH_ERROR_CODES[event.ErrName]
+	}
+	return event, nil
+}
+
+// DetectBruteForce returns bursts of failures from the same IP within the window,
+// events are failures sorted by date
+func DetectBruteForce(events []AuthEvent) []AuthBurst {
+	bursts := []AuthBurst{}
+	layout := "2006-01-02T15:04:05"
+	times := map[string][]time.Time{}
+	principals := map[string][]string{}
+	ips := []string{}
+	for _, event := range events {
+		if len(event.Date) < len(layout) {
+			continue
+		}
+		t, err := time.Parse(layout, event.Date[:len(layout)])
+		if err != nil {
+			continue
+		}
+		if times[event.IP] == nil {
+			ips = append(ips, event.IP)
+		}
+		times[event.IP] = append(times[event.IP], t)
+		principals[event.IP] = append(principals[event.IP], getAuthPrincipal(event.Principal, event.Database))
+	}
+	window := BRUTE_FORCE_WINDOW * time.Second
+	for _, ip := range ips {
+		list := times[ip]
+		var burst *AuthBurst
+		users := map[string]bool{}
+		last := -1
+		for i, j := 0, 0; j < len(list); j++ {
+			for list[j].Sub(list[i]) > window {
+				i++
+			}
+			if j-i+1 < BRUTE_FORCE_THRESHOLD {
+				continue
+			}
+			if burst == nil || i > last {
+				if burst != nil {
+					burst.Principals = len(users)
+					bursts = append(bursts, *burst)
+				}
+				burst = &AuthBurst{IP: ip, Start: list[i].Format(layout)}
+				users = map[string]bool{}
+				last = i - 1
+			}
+			for k := last + 1; k <= j; k++ {
+				users[principals[ip][k]] = true
+			}
+			burst.Count += j - last
+			burst.End = list[j].Format(layout)
+			last = j
+		}
+		if burst != nil {
+			burst.Principals = len(users)
+			bursts = append(bursts, *burst)
+		}
+	}
+	sort.Slice(bursts, func(i, j int) bool {
+		return bursts[i].Count > bursts[j].Count
+	})
+	return bursts
+}
+
+// getAuthPrincipal returns user@db
+func getAuthPrincipal(principal string, db string) string {
+	if db == "" {
+		return principal
+	}
+	return fmt.Sprintf("%v@%v", principal, db)
+}
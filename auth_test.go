@@ -0,0 +1,56 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * auth_test.go
+ */
+
+package hatchet
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/simagix/gox"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestAnalyzeAuthEvent(t *testing.T) {
+	strs := []string{
+		`{"t":{"$date":"2023-03-01T11:30:00.000+00:00"},"s":"I",  "c":"ACCESS",   "id":20249,   "ctx":"conn90","msg":"Authentication failed","attr":{"mechanism":"SCRAM-SHA-256","speculative":false,"principalName":"admin","authenticationDatabase":"admin","remote":"10.0.0.66:5500","extraInfo":{},"error":"AuthenticationFailed: SCRAM authentication failed, storedKey mismatch"}}`,
+		`{"t":{"$date":"2023-03-01T11:31:00.000+00:00"},"s":"I",  "c":"ACCESS",   "id":20250,   "ctx":"conn80","msg":"Authentication succeeded","attr":{"mechanism":"SCRAM-SHA-256","speculative":true,"principalName":"app","authenticationDatabase":"admin","remote":"10.0.0.1:50010","extraInfo":{}}}`,
+		`{"t":{"$date":"2023-03-01T11:31:05.000+00:00"},"s":"I",  "c":"ACCESS",   "id":20436,   "ctx":"conn81","msg":"Checking authorization failed","attr":{"error":{"code":13,"codeName":"Unauthorized","errmsg":"not authorized on admin to execute command { shutdown: 1 }"}}}`,
+	}
+	expected := []AuthEvent{
+		{Database: "admin", ErrCode: 18, ErrName: "AuthenticationFailed", IP: "10.0.0.66", Mechanism: "SCRAM-SHA-256", Principal: "admin", Result: AUTH_FAILED},
+		{Database: "admin", IP: "10.0.0.1", Mechanism: "SCRAM-SHA-256", Principal: "app", Result: AUTH_SUCCEEDED},
+		{ErrCode: 13, ErrName: "Unauthorized", Result: AUTH_UNAUTHORIZED},
+	}
+	for i, str := range strs {
+		doc := Logv2Info{}
+		if err := bson.UnmarshalExtJSON([]byte(str), false, &doc); err != nil {
+			t.Fatalf("bson unmarshal error %v", err)
+		}
+		event, err := AnalyzeAuthEvent(&doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *event != expected[i] {
+			t.Fatal("expected", gox.Stringify(expected[i]), "but got", gox.Stringify(event))
+		}
+	}
+}
+
+func TestDetectBruteForce(t *testing.T) {
+	events := []AuthEvent{}
+	for i := 0; i < 12; i++ {
+		events = append(events, AuthEvent{Date: fmt.Sprintf("2023-03-01T11:30:%02d.000-0000", i*3),
+			IP: "10.0.0.66", Principal: fmt.Sprintf("admin%d", i%3), Database: "admin"})
+	}
+	events = append(events, AuthEvent{Date: "2023-03-01T11:30:10.000-0000", IP: "10.0.0.1", Principal: "app"})
+	bursts := DetectBruteForce(events)
+	if len(bursts) != 1 || bursts[0].Count != 12 || bursts[0].Principals != 3 || bursts[0].IP != "10.0.0.66" {
+		t.Fatal("unexpected", gox.Stringify(bursts))
+	}
+	if bursts = DetectBruteForce(events[:BRUTE_FORCE_THRESHOLD-1]); len(bursts) != 0 {
+		t.Fatal("expected no bursts but got", gox.Stringify(bursts))
+	}
+}
@@ -6,6 +6,8 @@ die() { echo "$*" 1>&2 ; exit 1; }
 VERSION="v$(cat version)-$(git log -1 --date=format:"%Y%m%d" --format="%ad")"
 REPO=$(basename "$(dirname "$(pwd)")")/$(basename "$(pwd)")
 LDFLAGS="-X main.version=$VERSION -X main.repo=$REPO"
+TAGS="sqlite_fts5"
+export CGO_CFLAGS="$CGO_CFLAGS -DSQLITE_ENABLE_DBSTAT_VTAB" # measures disk usages of hatchets
 TAG="simagix/hatchet"
 [[ "$(which go)" = "" ]] && die "go command not found"
 
@@ -30,10 +32,10 @@ if [ "$1" == "docker" ]; then
 elif [ "$1" == "dist" ]; then
   [[ "$(which uname)" = "" ]] && die "uname command not found"
   ofile="./dist/hatchet-$(uname|tr '[:upper:]' '[:lower:]')-$(uname -m)"
-  go build -ldflags "$LDFLAGS" -o ${ofile} main/hatchet.go
+  go build -tags "$TAGS" -ldflags "$LDFLAGS" -o ${ofile} main/hatchet.go
 else
   rm -f ./dist/hatchet
-  go build -ldflags "$LDFLAGS" -o ./dist/hatchet main/hatchet.go
+  go build -tags "$TAGS" -ldflags "$LDFLAGS" -o ./dist/hatchet main/hatchet.go
   if [[ -f ./dist/hatchet ]]; then
     ./dist/hatchet -version
   fi
@@ -10,23 +10,37 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 	"strings"
 
 	"github.com/julienschmidt/httprouter"
 )
 
 const (
-	BAR_CHART    = "bar_chart"
-	BUBBLE_CHART = "bubble_chart"
-	PIE_CHART    = "pie_chart"
+	BAR_CHART      = "bar_chart"
+	BUBBLE_CHART   = "bubble_chart"
+	LINE_CHART     = "line_chart"
+	PIE_CHART      = "pie_chart"
+	TIMELINE_CHART = "timeline_chart"
 
+	T_AUTH_FAILURES  = "auth-failures"
 	T_OPS            = "ops"
+	T_OPS_APPS       = "ops-apps"
 	T_RESLEN_UP      = "reslen-ip"
 	T_OPS_COUNTS     = "ops-counts"
 	T_CONNS_ACCEPTED = "connections-accepted"
+	T_CONNS_RATE     = "connections-rate"
 	T_CONNS_TIME     = "connections-time"
 	T_CONNS_TOTAL    = "connections-total"
+	T_ERRORS         = "error-codes"
 	T_RESLEN_NS      = "reslen-ns"
+	T_REPL_OPLOG     = "replication-oplog"
+	T_REPL_STATES    = "replication-states"
+	T_SHARD_MIGRATE  = "sharding-migrations"
+	T_SHARD_RANGE    = "sharding-range-deleter"
+	T_SHARD_STALE    = "sharding-stale-config"
+	T_STORAGE_CKPT   = "storage-checkpoints"
+	T_WRITE_CONCERN  = "write-concern"
 )
 
 type Chart struct {
@@ -52,16 +66,46 @@ var charts = map[string]Chart{
 		"Display total response length by client IPs", "/reslen-ip?ip="},
 	T_RESLEN_NS: {7, "Response Length by Namespaces ",
 		"Display total response length by namespaces", "/reslen-ns?ns="},
+	T_REPL_STATES: {8, "Replica Set Member States",
+		"Display replica set member states over a period of time", "/replication?type=states"},
+	T_REPL_OPLOG: {9, "Oplog Application Latency",
+		"Display average slow oplog application time over a period of time", "/replication?type=oplog"},
+	T_SHARD_MIGRATE: {10, "Chunk Migrations",
+		"Display chunk migrations durations over a period of time", "/sharding?type=migrations"},
+	T_SHARD_RANGE: {11, "Range Deleter Backlog",
+		"Display scheduled and finished range deletions over a period of time", "/sharding?type=range-deleter"},
+	T_SHARD_STALE: {12, "StaleConfig Errors",
+		"Display StaleConfig errors by namespaces over a period of time", "/sharding?type=stale"},
+	T_CONNS_RATE: {13, "Accepted Connections Rate",
+		"Display peak accepted connections per second and connection storms", "/connections?type=rate"},
+	T_AUTH_FAILURES: {14, "Authentication Failures",
+		"Display authentication failures by users and IPs", "/auth?type=failures"},
+	T_STORAGE_CKPT: {15, "Checkpoint Durations",
+		"Display WiredTiger checkpoint durations", "/storage?type=checkpoints"},
+	T_ERRORS: {16, "Error Codes",
+		"Display errors by codes and namespaces over a period of time", "/errors?type=codes"},
+	T_OPS_APPS: {17, "Operation Counts by Apps",
+		"Display total counts of operations by application names", "/ops?type=apps"},
+	T_WRITE_CONCERN: {18, "Write Concern Latency",
+		"Display average write operations time by write concerns over a period of time", "/concerns?type=write"},
 }
 
 // ChartsHandler responds to charts API calls
-func ChartsHandler(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+func (ptr *Server) ChartsHandler(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
 	/** APIs
+	 * /hatchets/{hatchet}/charts/concerns
+	 * /hatchets/{hatchet}/charts/connections
 	 * /hatchets/{hatchet}/charts/ops
+	 * /hatchets/{hatchet}/charts/replication
+	 * /hatchets/{hatchet}/charts/sharding
 	 */
 	hatchetName := params.ByName("hatchet")
 	attr := params.ByName("attr")
-	dbase, err := GetDatabase(hatchetName)
+	if err := validateRequest(hatchetName, r); err != nil {
+		writeError(w, err)
+		return
+	}
+	dbase, err := GetDatabase(ptr.opts, hatchetName)
 	if err != nil {
 		json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
 		return
@@ -77,13 +121,15 @@ func ChartsHandler(w http.ResponseWriter, r *http.Request, params httprouter.Par
 	if duration != "" {
 		start, end = getStartEndDates(duration)
 	}
+	uptime := getRestartSegments(dbase)
 
 	if attr == T_OPS {
 		chartType := r.URL.Query().Get("type")
 		op := r.URL.Query().Get("op")
+		app := r.URL.Query().Get("app")
 		if chartType == "stats" {
 			chartType := T_OPS
-			docs, err := dbase.GetAverageOpTime(op, duration)
+			docs, err := dbase.GetAverageOpTime(op, duration, app)
 			if len(docs) > 0 {
 				start = docs[0].Date
 				end = docs[len(docs)-1].Date
@@ -92,20 +138,55 @@ func ChartsHandler(w http.ResponseWriter, r *http.Request, params httprouter.Par
 				json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
 				return
 			}
+			if op == "" && app == "" { // overlay index builds
+				builds, err := dbase.GetIndexBuilds(duration)
+				if err != nil {
+					json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+					return
+				}
+				docs = append(docs, getIndexBuildOpCounts(builds)...)
+			}
 			templ, err := GetChartTemplate(BUBBLE_CHART)
 			if err != nil {
 				json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
 				return
 			}
-			doc := map[string]interface{}{"Hatchet": hatchetName, "OpCounts": docs, "Chart": charts[chartType],
-				"Type": chartType, "Summary": summary, "Start": start, "End": end, "VAxisLabel": "seconds"}
+			chart := charts[chartType]
+			if app != "" { // keep the application name when refreshing
+				chart.URL += "&app=" + url.QueryEscape(app)
+			}
+			doc := map[string]interface{}{"Hatchet": hatchetName, "OpCounts": docs, "Chart": chart,
+				"Type": chartType, "Summary": summary, "Uptime": uptime, "Start": start, "End": end, "VAxisLabel": "seconds"}
 			if err = templ.Execute(w, doc); err != nil {
 				json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
 				return
 			}
 		} else if chartType == "counts" {
 			chartType = T_OPS_COUNTS
-			docs, err := dbase.GetOpsCounts(duration)
+			docs, err := dbase.GetOpsCounts(duration, app)
+			if err != nil {
+				json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+				return
+			}
+			templ, err := GetChartTemplate(PIE_CHART)
+			if err != nil {
+				json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+				return
+			}
+			chart := charts[chartType]
+			if app != "" { // keep the application name when refreshing
+				chart.URL += "&app=" + url.QueryEscape(app)
+			}
+			doc := map[string]interface{}{"Hatchet": hatchetName, "NameValues": docs, "Chart": chart,
+				"Type": chartType, "Summary": summary, "Uptime": uptime, "Start": start, "End": end}
+			if err = templ.Execute(w, doc); err != nil {
+				json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+				return
+			}
+			return
+		} else if chartType == "apps" {
+			chartType = T_OPS_APPS
+			docs, err := dbase.GetAppCounts(duration)
 			if err != nil {
 				json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
 				return
@@ -116,7 +197,7 @@ func ChartsHandler(w http.ResponseWriter, r *http.Request, params httprouter.Par
 				return
 			}
 			doc := map[string]interface{}{"Hatchet": hatchetName, "NameValues": docs, "Chart": charts[chartType],
-				"Type": chartType, "Summary": summary, "Start": start, "End": end}
+				"Type": chartType, "Summary": summary, "Uptime": uptime, "Start": start, "End": end}
 			if err = templ.Execute(w, doc); err != nil {
 				json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
 				return
@@ -142,7 +223,26 @@ func ChartsHandler(w http.ResponseWriter, r *http.Request, params httprouter.Par
 				return
 			}
 			doc := map[string]interface{}{"Hatchet": hatchetName, "NameValues": docs, "Chart": charts[chartType],
-				"Type": chartType, "Summary": summary, "Start": start, "End": end}
+				"Type": chartType, "Summary": summary, "Uptime": uptime, "Start": start, "End": end}
+			if err = templ.Execute(w, doc); err != nil {
+				json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+				return
+			}
+			return
+		} else if chartType == "rate" {
+			chartType = T_CONNS_RATE
+			rate, err := dbase.GetAcceptedRate(duration)
+			if err != nil {
+				json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+				return
+			}
+			templ, err := GetChartTemplate(LINE_CHART)
+			if err != nil {
+				json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+				return
+			}
+			doc := map[string]interface{}{"Hatchet": hatchetName, "Rate": rate, "Chart": charts[chartType],
+				"Type": chartType, "Summary": summary, "Uptime": uptime, "Start": start, "End": end}
 			if err = templ.Execute(w, doc); err != nil {
 				json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
 				return
@@ -161,13 +261,187 @@ func ChartsHandler(w http.ResponseWriter, r *http.Request, params httprouter.Par
 				return
 			}
 			doc := map[string]interface{}{"Hatchet": hatchetName, "Remote": docs, "Chart": charts[chartType],
-				"Type": chartType, "Summary": summary, "Start": start, "End": end}
+				"Type": chartType, "Summary": summary, "Uptime": uptime, "Start": start, "End": end}
+			if err = templ.Execute(w, doc); err != nil {
+				json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+				return
+			}
+			return
+		}
+	} else if attr == "replication" {
+		chartType := r.URL.Query().Get("type")
+		if dbase.GetVerbose() {
+			log.Println("type", chartType, "duration", duration)
+		}
+		if chartType == "oplog" {
+			chartType = T_REPL_OPLOG
+			docs, err := dbase.GetOplogApplicationTime(duration)
+			if err != nil {
+				json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+				return
+			}
+			templ, err := GetChartTemplate(BUBBLE_CHART)
+			if err != nil {
+				json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+				return
+			}
+			doc := map[string]interface{}{"Hatchet": hatchetName, "OpCounts": docs, "Chart": charts[chartType],
+				"Type": chartType, "Summary": summary, "Uptime": uptime, "Start": start, "End": end, "VAxisLabel": "seconds"}
 			if err = templ.Execute(w, doc); err != nil {
 				json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
 				return
 			}
 			return
 		}
+		chartType = T_REPL_STATES
+		events, err := dbase.GetReplEvents(REPL_STATE, duration)
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		last := info.End
+		if toks, err := ParseDuration(duration); err == nil {
+			last = toks[1]
+		}
+		templ, err := GetChartTemplate(TIMELINE_CHART)
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		doc := map[string]interface{}{"Hatchet": hatchetName, "Segments": GetStateTimeline(events, last), "Chart": charts[chartType],
+			"Type": chartType, "Summary": summary, "Uptime": uptime, "Start": start, "End": end}
+		if err = templ.Execute(w, doc); err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		return
+	} else if attr == "sharding" {
+		chartType := r.URL.Query().Get("type")
+		if dbase.GetVerbose() {
+			log.Println("type", chartType, "duration", duration)
+		}
+		eventType := SHARD_STALE_CONFIG
+		label := "count"
+		if chartType == "migrations" {
+			chartType = T_SHARD_MIGRATE
+			eventType = SHARD_MIGRATION
+			label = "seconds"
+		} else if chartType == "range-deleter" {
+			chartType = T_SHARD_RANGE
+			eventType = SHARD_RANGE_DELETION
+		} else {
+			chartType = T_SHARD_STALE
+		}
+		docs, err := dbase.GetShardingTimeSeries(eventType, duration)
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		templ, err := GetChartTemplate(BUBBLE_CHART)
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		doc := map[string]interface{}{"Hatchet": hatchetName, "OpCounts": docs, "Chart": charts[chartType],
+			"Type": chartType, "Summary": summary, "Uptime": uptime, "Start": start, "End": end, "VAxisLabel": label}
+		if err = templ.Execute(w, doc); err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		return
+	} else if attr == "auth" {
+		chartType := T_AUTH_FAILURES
+		if dbase.GetVerbose() {
+			log.Println("type", chartType, "duration", duration)
+		}
+		docs, err := dbase.GetAuthFailures(duration)
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		templ, err := GetChartTemplate(BUBBLE_CHART)
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		doc := map[string]interface{}{"Hatchet": hatchetName, "OpCounts": docs, "Chart": charts[chartType],
+			"Type": chartType, "Summary": summary, "Uptime": uptime, "Start": start, "End": end, "VAxisLabel": "count"}
+		if err = templ.Execute(w, doc); err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		return
+	} else if attr == "errors" {
+		chartType := T_ERRORS
+		code := ToInt(r.URL.Query().Get("code"))
+		if dbase.GetVerbose() {
+			log.Println("type", chartType, "code", code, "duration", duration)
+		}
+		docs, err := dbase.GetErrorCounts(code, duration)
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		templ, err := GetChartTemplate(BUBBLE_CHART)
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		chart := charts[chartType]
+		if code != 0 { // keep the error code when refreshing
+			chart.URL += fmt.Sprintf("&code=%v", code)
+		}
+		doc := map[string]interface{}{"Hatchet": hatchetName, "OpCounts": docs, "Chart": chart,
+			"Type": chartType, "Summary": summary, "Uptime": uptime, "Start": start, "End": end, "VAxisLabel": "count"}
+		if err = templ.Execute(w, doc); err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		return
+	} else if attr == "storage" {
+		chartType := T_STORAGE_CKPT
+		if dbase.GetVerbose() {
+			log.Println("type", chartType, "duration", duration)
+		}
+		docs, err := dbase.GetCheckpointDurations(duration)
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		templ, err := GetChartTemplate(BUBBLE_CHART)
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		doc := map[string]interface{}{"Hatchet": hatchetName, "OpCounts": docs, "Chart": charts[chartType],
+			"Type": chartType, "Summary": summary, "Uptime": uptime, "Start": start, "End": end, "VAxisLabel": "seconds"}
+		if err = templ.Execute(w, doc); err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		return
+	} else if attr == "concerns" {
+		chartType := T_WRITE_CONCERN
+		if dbase.GetVerbose() {
+			log.Println("type", chartType, "duration", duration)
+		}
+		docs, err := dbase.GetWriteConcernLatency(duration)
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		templ, err := GetChartTemplate(BUBBLE_CHART)
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		doc := map[string]interface{}{"Hatchet": hatchetName, "OpCounts": docs, "Chart": charts[chartType],
+			"Type": chartType, "Summary": summary, "Uptime": uptime, "Start": start, "End": end, "VAxisLabel": "seconds"}
+		if err = templ.Execute(w, doc); err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		return
 	} else if attr == T_RESLEN_UP {
 		ip := r.URL.Query().Get("ip")
 		chartType := attr
@@ -189,7 +463,7 @@ func ChartsHandler(w http.ResponseWriter, r *http.Request, params httprouter.Par
 			chart.Title += fmt.Sprintf(" (%v)", ip)
 		}
 		doc := map[string]interface{}{"Hatchet": hatchetName, "NameValues": docs, "Chart": chart,
-			"Type": chartType, "Summary": summary, "Start": start, "End": end}
+			"Type": chartType, "Summary": summary, "Uptime": uptime, "Start": start, "End": end}
 		if err = templ.Execute(w, doc); err != nil {
 			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
 			return
@@ -216,7 +490,7 @@ func ChartsHandler(w http.ResponseWriter, r *http.Request, params httprouter.Par
 			chart.Title += fmt.Sprintf(" (%v)", ns)
 		}
 		doc := map[string]interface{}{"Hatchet": hatchetName, "NameValues": docs, "Chart": chart,
-			"Type": chartType, "Summary": summary, "Start": start, "End": end}
+			"Type": chartType, "Summary": summary, "Uptime": uptime, "Start": start, "End": end}
 		if err = templ.Execute(w, doc); err != nil {
 			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
 			return
@@ -238,3 +512,17 @@ func getStartEndDates(duration string) (string, string) {
 	}
 	return start, end
 }
+
+// getRestartSegments returns uptime segments if the log spans restarts or crashes
+func getRestartSegments(dbase Database) []UptimeSegment {
+	segments, err := dbase.GetUptimeSegments()
+	if err != nil { // hatchets analyzed before uptime segments were stored
+		return nil
+	}
+	for _, segment := range segments {
+		if len(segments) > 1 || segment.Status == UPTIME_CRASHED || segment.Status == UPTIME_UNCLEAN {
+			return segments
+		}
+	}
+	return nil
+}
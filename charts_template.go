@@ -22,6 +22,10 @@ func GetChartTemplate(chartType string) (*template.Template, error) {
 		html += getPieChart()
 	} else if chartType == BAR_CHART {
 		html += getConnectionsChart()
+	} else if chartType == LINE_CHART {
+		html += getRateChart()
+	} else if chartType == TIMELINE_CHART {
+		html += getTimelineChart()
 	}
 	html += `
 	<div style="float: left; width: 100%; clear: left;">
@@ -30,7 +34,11 @@ func GetChartTemplate(chartType string) (*template.Template, error) {
 		<button onClick="refreshChart(); return false;" class="button">Refresh</button>
   	</div>
   	<div id='hatchetChart' style="width: 100%; clear: left;"></div>
-  
+`
+	if chartType != PIE_CHART {
+		html += getUptimeChart()
+	}
+	html += `
 		</body></html>`
 
 	return template.New("hatchet").Funcs(template.FuncMap{
@@ -41,6 +49,10 @@ func GetChartTemplate(chartType string) (*template.Template, error) {
 			str := fmt.Sprintf("%v, QP: %v", v.Namespace, v.Filter)
 			return template.HTML(str)
 		},
+		"hasDuration": func(ctype string) bool {
+			return ctype == T_OPS || ctype == T_REPL_OPLOG || ctype == T_SHARD_MIGRATE || ctype == T_STORAGE_CKPT ||
+				ctype == T_WRITE_CONCERN
+		},
 		"toSeconds": func(n float64) float64 {
 			return n / 1000
 		},
@@ -65,14 +77,14 @@ func getOpStatsChart() string {
 
 	function drawChart() {
 		var data = google.visualization.arrayToDataTable([
-	{{if eq .Type "ops"}}
+	{{if hasDuration .Type}}
 			['op', 'date/time', 'duration (seconds)', 'ns', 'counts'],
 	{{else}}
 			['op', 'date/time', 'count', 'ns/filter'],
 	{{end}}
 	{{$ctype := .Type}}
 	{{range $i, $v := .OpCounts}}
-		{{if eq $ctype "ops"}}
+		{{if hasDuration $ctype}}
 			[{{$v.Op}}, new Date("{{$v.Date}}"), {{toSeconds $v.Milli}}, '{{descr $v}}', {{$v.Count}}],
 		{{else}}
 			[{{$v.Op}}, new Date("{{$v.Date}}"), {{$v.Count}}, '{{descr $v}}'],
@@ -90,7 +102,7 @@ func getOpStatsChart() string {
 			'height': 480,
 			'titleTextStyle': {'fontSize': 20},
 			'explorer': { actions: ['dragToZoom', 'rightClickToReset'] },
-	{{if eq $ctype "ops"}}
+	{{if hasDuration $ctype}}
 			'sizeAxis': {minValue: 0, minSize: 5, maxSize: 30},
 	{{else}}
 			'sizeAxis': {minValue: 0, minSize: 5, maxSize: 5},
@@ -192,3 +204,124 @@ func getConnectionsChart() string {
 <div align='center' class='btn'><span style='color: red'>no data found</span></div>
 {{end}}`
 }
+
+func getRateChart() string {
+	return `
+{{ if .Rate.Rates }}
+<script>
+	setChartType();
+	google.charts.load('current', {'packages':['corechart']});
+	google.charts.setOnLoadCallback(drawChart);
+
+	function drawChart() {
+		var data = google.visualization.arrayToDataTable([
+			['Date/Time', 'Peak per Second', 'Threshold', 'Spike'],
+	{{$threshold := .Rate.Threshold}}
+	{{range $i, $v := .Rate.Rates}}
+			[new Date("{{$v.Date}}"), {{$v.Peak}}, {{$threshold}}, {{if $v.Spike}}{{$v.Peak}}{{else}}null{{end}}],
+	{{end}}
+		]);
+		// Set chart options
+		var options = {
+			'backgroundColor': { 'fill': 'transparent' },
+			'title': '{{.Chart.Title}} (mean {{printf "%.2f" .Rate.Mean}}/s, {{len .Rate.Spikes}} spike seconds)',
+			'hAxis': { slantedText: true, slantedTextAngle: 30 },
+			'vAxis': {title: 'Accepted per Second', minValue: 0},
+			'width': '100%',
+			'height': 480,
+			'titleTextStyle': {'fontSize': 20},
+			'explorer': { actions: ['dragToZoom', 'rightClickToReset'] },
+			'series': { 1: { lineDashStyle: [4, 4] }, 2: { lineWidth: 0, pointSize: 8, color: 'red' } },
+			'legend': { 'position': 'right' } };
+		// Instantiate and draw our chart, passing in some options.
+		var chart = new google.visualization.LineChart(document.getElementById('hatchetChart'));
+		chart.draw(data, options);
+	}
+</script>
+{{else}}
+<div align='center' class='btn'><span style='color: red'>no data found</span></div>
+{{end}}`
+}
+
+func getTimelineChart() string {
+	return `
+{{ if .Segments }}
+<script>
+	setChartType();
+	google.charts.load('current', {'packages':['timeline']});
+	google.charts.setOnLoadCallback(drawChart);
+
+	function drawChart() {
+		var data = new google.visualization.DataTable();
+		data.addColumn({ type: 'string', id: 'Node' });
+		data.addColumn({ type: 'string', id: 'State' });
+		data.addColumn({ type: 'date', id: 'Start' });
+		data.addColumn({ type: 'date', id: 'End' });
+		data.addRows([
+	{{range $i, $v := .Segments}}
+			[{{$v.Node}}, {{$v.State}}, new Date("{{substr $v.Start 19}}"), new Date("{{substr $v.End 19}}")],
+	{{end}}
+		]);
+		// Set chart options
+		var options = {
+			'backgroundColor': { 'fill': 'transparent' },
+			'title': '{{.Chart.Title}}',
+			'width': '100%',
+			'height': 480,
+			'timeline': { 'showRowLabels': true } };
+		// Instantiate and draw our chart, passing in some options.
+		var chart = new google.visualization.Timeline(document.getElementById('hatchetChart'));
+		chart.draw(data, options);
+	}
+</script>
+{{else}}
+<div align='center' class='btn'><span style='color: red'>no data found</span></div>
+{{end}}`
+}
+
+// getUptimeChart returns a strip of process uptime segments shown below time charts when
+// restarts or crashes are found
+func getUptimeChart() string {
+	return `
+{{ if .Uptime }}
+<div id='uptimeChart' style="width: 100%; clear: left;"></div>
+<script>
+	google.charts.load('current', {'packages':['timeline']});
+	google.charts.setOnLoadCallback(drawUptime);
+
+	function drawUptime() {
+		var segments = [
+	{{range $i, $v := .Uptime}}
+			{ id: {{$v.ID}}, status: {{$v.Status}} },
+	{{end}}
+		];
+		var colors = { 'crashed': 'red', 'running': 'green', 'shutdown': 'green', 'unclean': 'orange' };
+		var data = new google.visualization.DataTable();
+		data.addColumn({ type: 'string', id: 'Process' });
+		data.addColumn({ type: 'string', id: 'Status' });
+		data.addColumn({ type: 'string', role: 'style' });
+		data.addColumn({ type: 'date', id: 'Start' });
+		data.addColumn({ type: 'date', id: 'End' });
+		data.addRows([
+	{{range $i, $v := .Uptime}}
+			['uptime', 'pid {{$v.PID}}: {{$v.Status}}', colors[{{$v.Status}}], new Date("{{substr $v.Start 19}}"), new Date("{{substr $v.End 19}}")],
+	{{end}}
+		]);
+		var options = {
+			'backgroundColor': { 'fill': 'transparent' },
+			'width': '100%',
+			'height': 100,
+			'timeline': { 'showRowLabels': true } };
+		var chart = new google.visualization.Timeline(document.getElementById('uptimeChart'));
+		google.visualization.events.addListener(chart, 'select', function() {
+			var selection = chart.getSelection();
+			if (selection.length > 0 && segments[selection[0].row].status != 'running' &&
+				segments[selection[0].row].status != 'shutdown') {
+				location.href = '/hatchets/{{.Hatchet}}/stats/uptime?id=' + segments[selection[0].row].id;
+			}
+		});
+		chart.draw(data, options);
+	}
+</script>
+{{end}}`
+}
@@ -0,0 +1,82 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * concerns.go
+ */
+
+package hatchet
+
+import (
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const CONCERN_DEFAULT = "default" // read or write concern or read preference not specified
+
+// READ_OPS and WRITE_OPS are ops of which read and write concerns are counted
+var READ_OPS = []string{cmdAggregate, cmdCount, cmdDistinct, cmdFind, cmdGetMore}
+var WRITE_OPS = []string{cmdDelete, cmdFindAndModify, "findAndModify", cmdInsert, cmdRemove, cmdUpdate}
+
+// ConcernUsage stores read and write concerns and read preference of ops of a namespace and an application
+type ConcernUsage struct {
+	AppName        string  `json:"app_name"`
+	AvgMilli       float64 `json:"avg_ms"`
+	Count          int     `json:"count"`
+	MaxMilli       int     `json:"max_ms"`
+	Namespace      string  `json:"ns"`
+	ReadConcern    string  `json:"read_concern"`
+	ReadPreference string  `json:"read_preference"`
+	WriteConcern   string  `json:"write_concern"`
+}
+
+// ConcernStats stores counts and average milliseconds, [count, avg_ms], of read and write concerns and
+// read preferences, and their usages by namespaces and applications
+type ConcernStats struct {
+	ReadConcerns    []NameValues   `json:"read_concerns"`
+	ReadPreferences []NameValues   `json:"read_preferences"`
+	Usages          []ConcernUsage `json:"usages"`
+	WriteConcerns   []NameValues   `json:"write_concerns"`
+}
+
+// getConcerns returns read concern level, write concern, e.g. w:majority,j:true, and read preference
+// mode from a command, or from attr.readConcern and attr.writeConcern in effect
+func getConcerns(doc *Logv2Info) (string, string, string) {
+	var readConcern, writeConcern, readPref string
+	attrMap := doc.Attr.Map()
+	var cmdMap map[string]interface{}
+	if command, ok := attrMap["command"].(bson.D); ok {
+		cmdMap = command.Map()
+	}
+	if rc, ok := cmdMap["readConcern"].(bson.D); ok {
+		readConcern, _ = rc.Map()["level"].(string)
+	} else if rc, ok := attrMap["readConcern"].(bson.D); ok {
+		readConcern, _ = rc.Map()["level"].(string)
+	}
+	if wc, ok := cmdMap["writeConcern"].(bson.D); ok {
+		writeConcern = getWriteConcern(wc)
+	} else if wc, ok := attrMap["writeConcern"].(bson.D); ok {
+		writeConcern = getWriteConcern(wc)
+	}
+	if pref, ok := cmdMap["$readPreference"].(bson.D); ok {
+		readPref, _ = pref.Map()["mode"].(string)
+	}
+	return readConcern, writeConcern, readPref
+}
+
+// getWriteConcern returns w and j of a write concern, e.g. w:1,j:false
+func getWriteConcern(wc bson.D) string {
+	strs := []string{}
+	wcMap := wc.Map()
+	if w, ok := wcMap["w"]; ok {
+		if _, isString := w.(string); isString {
+			strs = append(strs, fmt.Sprintf("w:%v", w))
+		} else {
+			strs = append(strs, fmt.Sprintf("w:%v", ToInt(w)))
+		}
+	}
+	if j, ok := wcMap["j"].(bool); ok {
+		strs = append(strs, fmt.Sprintf("j:%v", j))
+	}
+	return strings.Join(strs, ",")
+}
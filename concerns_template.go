@@ -0,0 +1,77 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * concerns_template.go
+ */
+
+package hatchet
+
+import (
+	"fmt"
+	"html/template"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// GetConcernsTemplate returns HTML
+func GetConcernsTemplate() (*template.Template, error) {
+	html := getContentHTML()
+	html += `{{$name := .Hatchet}}
+<script>
+	document.getElementById('nextReport').selectedIndex = {{.Report.Index}};
+</script>
+	<div style='clear: left; margin: 10px 10px;'>
+		<button class='button' onClick="javascript:location.href='/hatchets/{{$name}}/charts/concerns?type=write'; return false;">
+			<i class='fa fa-area-chart'></i> Write Concern Latency</button>
+	</div>
+` + getConcernsTable("Write Concerns", ".Data.WriteConcerns") +
+		getConcernsTable("Read Concerns", ".Data.ReadConcerns") +
+		getConcernsTable("Read Preferences", ".Data.ReadPreferences") + `
+{{if .Data.Usages}}
+	<table style='float: left; margin: 10px 10px; clear: left;'>
+		<caption><span style="font-size: 16px; padding: 5px 5px;"><i class="fa fa-cubes"></i></span>Usages by Namespaces and Applications</caption>
+		<tr><th></th><th>Namespace</th><th>Application</th><th>Read Concern</th><th>Write Concern</th><th>Read Preference</th>
+			<th>Count</th><th>Avg ms</th><th>Max ms</th></tr>
+	{{range $n, $val := .Data.Usages}}
+		<tr><td align=right>{{add $n 1}}</td>
+			<td>{{$val.Namespace}}</td>
+			<td>{{$val.AppName}}</td>
+			<td>{{$val.ReadConcern}}</td>
+			<td>{{$val.WriteConcern}}</td>
+			<td>{{$val.ReadPreference}}</td>
+			<td align=right>{{numPrinter $val.Count}}</td>
+			<td align=right>{{numPrinter $val.AvgMilli}}</td>
+			<td align=right>{{numPrinter $val.MaxMilli}}</td>
+		</tr>
+	{{end}}
+	</table>
+{{end}}
+	<div style='clear: left;' align='center'><hr/><p/>@simagix</div>
+</body></html>`
+	return template.New("hatchet").Funcs(template.FuncMap{
+		"add": func(a int, b int) int {
+			return a + b
+		},
+		"numPrinter": func(n interface{}) string {
+			printer := message.NewPrinter(language.English)
+			return printer.Sprintf("%v", ToInt(n))
+		}}).Parse(html)
+}
+
+// getConcernsTable returns HTML table of counts and average milliseconds of a field
+func getConcernsTable(title string, field string) string {
+	return fmt.Sprintf(`
+{{if %v}}
+	<table style='float: left; margin: 10px 10px;'>
+		<caption><span style="font-size: 16px; padding: 5px 5px;"><i class="fa fa-check-square-o"></i></span>%v</caption>
+		<tr><th></th><th>%v</th><th>Count</th><th>Avg ms</th></tr>
+	{{range $n, $val := %v}}
+		<tr><td align=right>{{add $n 1}}</td>
+			<td>{{$val.Name}}</td>
+			<td align=right>{{numPrinter (index $val.Values 0)}}</td>
+			<td align=right>{{numPrinter (index $val.Values 1)}}</td>
+		</tr>
+	{{end}}
+	</table>
+{{end}}`, field, title, title[:len(title)-1], field)
+}
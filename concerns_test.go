@@ -0,0 +1,38 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * concerns_test.go
+ */
+
+package hatchet
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestGetConcerns(t *testing.T) {
+	tests := []struct {
+		str                              string
+		readConcern, writeConcern, rpref string
+	}{
+		{`{"t":{"$date":"2023-03-01T10:31:00.000+00:00"},"s":"I","c":"COMMAND","id":51803,"ctx":"conn301","msg":"Slow query","attr":{"type":"command","ns":"shop.orders","command":{"insert":"orders","writeConcern":{"w":"majority","j":true},"$db":"shop"},"durationMillis":220}}`,
+			"", "w:majority,j:true", ""},
+		{`{"t":{"$date":"2023-03-01T10:31:01.000+00:00"},"s":"I","c":"COMMAND","id":51803,"ctx":"conn301","msg":"Slow query","attr":{"type":"command","ns":"shop.orders","command":{"insert":"orders","$db":"shop"},"writeConcern":{"w":1,"wtimeout":0,"provenance":"clientSupplied"},"durationMillis":105}}`,
+			"", "w:1", ""},
+		{`{"t":{"$date":"2023-03-01T10:31:02.000+00:00"},"s":"I","c":"COMMAND","id":51803,"ctx":"conn302","msg":"Slow query","attr":{"type":"command","ns":"shop.orders","command":{"find":"orders","filter":{"sku":"x"},"readConcern":{"level":"snapshot"},"$readPreference":{"mode":"secondaryPreferred"},"$db":"shop"},"durationMillis":180}}`,
+			"snapshot", "", "secondaryPreferred"},
+		{`{"t":{"$date":"2023-03-01T10:31:03.000+00:00"},"s":"I","c":"COMMAND","id":51803,"ctx":"conn302","msg":"Slow query","attr":{"type":"command","ns":"shop.orders","command":{"find":"orders","$db":"shop"},"readConcern":{"level":"local","provenance":"implicitDefault"},"durationMillis":130}}`,
+			"local", "", ""},
+	}
+	for _, test := range tests {
+		doc := Logv2Info{}
+		if err := bson.UnmarshalExtJSON([]byte(test.str), false, &doc); err != nil {
+			t.Fatalf("bson unmarshal error %v", err)
+		}
+		readConcern, writeConcern, rpref := getConcerns(&doc)
+		if readConcern != test.readConcern || writeConcern != test.writeConcern || rpref != test.rpref {
+			t.Fatal("unexpected", readConcern, writeConcern, rpref)
+		}
+	}
+}
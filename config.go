@@ -0,0 +1,123 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * config.go
+ */
+
+package hatchet
+
+import (
+	"regexp"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	DEFAULT_PROFILE = "off"
+	DEFAULT_SLOW_MS = 100
+)
+
+var wtCacheSizeRe = regexp.MustCompile(`cache_size=(\d+)([MG])`)
+
+// ServerConfig stores server options and startup warnings
+type ServerConfig struct {
+	CacheSizeGB   float64  `json:"cache_size_gb"`
+	Host          string   `json:"host"`
+	Options       string   `json:"options"` // options set by command line in JSON
+	Port          int      `json:"port"`
+	Profile       string   `json:"profile"`
+	ReplSetName   string   `json:"repl_set_name"`
+	SlowMS        int      `json:"slowms"`
+	StorageEngine string   `json:"storage_engine"`
+	Warnings      []string `json:"warnings"`
+}
+
+// NewServerConfig returns a ServerConfig with default profiling settings
+func NewServerConfig() *ServerConfig {
+	return &ServerConfig{Profile: DEFAULT_PROFILE, SlowMS: DEFAULT_SLOW_MS, Warnings: []string{}}
+}
+
+// Analyze extracts server configuration and startup warnings from a log, options of the last
+// restart are kept
+func (ptr *ServerConfig) Analyze(doc *Logv2Info) {
+	attrMap := doc.Attr.Map()
+	if doc.Component == "CONTROL" && doc.Msg == "MongoDB starting" {
+		ptr.Host, _ = attrMap["host"].(string)
+		ptr.Port = ToInt(attrMap["port"])
+	} else if doc.Component == "CONTROL" && doc.Msg == "Options set by command line" {
+		options, ok := attrMap["options"].(bson.D)
+		if !ok {
+			return
+		}
+		ptr.setOptions(options)
+	} else if doc.Component == "STORAGE" && doc.Msg == "Opening WiredTiger" {
+		if ptr.StorageEngine == "" {
+			ptr.StorageEngine = "wiredTiger"
+		}
+		config, _ := attrMap["config"].(string)
+		if matches := wtCacheSizeRe.FindStringSubmatch(config); matches != nil && ptr.CacheSizeGB == 0 {
+			ptr.CacheSizeGB = float64(ToInt(matches[1]))
+			if matches[2] == "M" {
+				ptr.CacheSizeGB /= 1024
+			}
+		}
+	}
+	if isStartupWarning(doc) {
+		for _, warning := range ptr.Warnings {
+			if warning == doc.Msg {
+				return
+			}
+		}
+		ptr.Warnings = append(ptr.Warnings, doc.Msg)
+	}
+}
+
+// setOptions sets configurations from options set by command line
+func (ptr *ServerConfig) setOptions(options bson.D) {
+	if b, err := bson.MarshalExtJSON(options, false, false); err == nil {
+		ptr.Options = string(b)
+	}
+	ptr.Profile, ptr.SlowMS, ptr.CacheSizeGB = DEFAULT_PROFILE, DEFAULT_SLOW_MS, 0
+	if port := getOptionValue(options, "net.port"); port != nil {
+		ptr.Port = ToInt(port)
+	}
+	ptr.ReplSetName, _ = getOptionValue(options, "replication.replSetName").(string)
+	ptr.StorageEngine, _ = getOptionValue(options, "storage.engine").(string)
+	if cacheSizeGB := getOptionValue(options, "storage.wiredTiger.engineConfig.cacheSizeGB"); cacheSizeGB != nil {
+		switch v := cacheSizeGB.(type) {
+		case float64:
+			ptr.CacheSizeGB = v
+		default:
+			ptr.CacheSizeGB = float64(ToInt(v))
+		}
+	}
+	if slowms := getOptionValue(options, "operationProfiling.slowOpThresholdMs"); slowms != nil {
+		ptr.SlowMS = ToInt(slowms)
+	}
+	if mode, ok := getOptionValue(options, "operationProfiling.mode").(string); ok {
+		ptr.Profile = mode
+	}
+}
+
+// getOptionValue returns value of a dotted path, e.g. net.port
+func getOptionValue(options bson.D, path string) interface{} {
+	var value interface{} = options
+	for _, key := range strings.Split(path, ".") {
+		doc, ok := value.(bson.D)
+		if !ok {
+			return nil
+		}
+		value = doc.Map()[key]
+	}
+	return value
+}
+
+// isStartupWarning returns true if a log is tagged as a startup warning
+func isStartupWarning(doc *Logv2Info) bool {
+	for _, tag := range doc.Tags {
+		if tag == "startupWarnings" {
+			return true
+		}
+	}
+	return false
+}
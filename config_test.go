@@ -0,0 +1,49 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * config_test.go
+ */
+
+package hatchet
+
+import (
+	"testing"
+
+	"github.com/simagix/gox"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestServerConfig(t *testing.T) {
+	strs := []string{
+		`{"t":{"$date":"2023-03-01T10:00:00.100+00:00"},"s":"I",  "c":"CONTROL",  "id":4615611, "ctx":"initandlisten","msg":"MongoDB starting","attr":{"pid":1234,"port":27017,"dbPath":"/data/db","architecture":"64-bit","host":"host1"}}`,
+		`{"t":{"$date":"2023-03-01T10:00:00.110+00:00"},"s":"I",  "c":"CONTROL",  "id":21951,   "ctx":"initandlisten","msg":"Options set by command line","attr":{"options":{"config":"/etc/mongod.conf","net":{"bindIp":"0.0.0.0","port":27017},"operationProfiling":{"mode":"slowOp","slowOpThresholdMs":50},"replication":{"replSetName":"rs0"},"storage":{"dbPath":"/data/db","wiredTiger":{"engineConfig":{"cacheSizeGB":1.5}}},"systemLog":{"destination":"file","path":"/var/log/mongod.log"}}}}`,
+		`{"t":{"$date":"2023-03-01T10:00:00.120+00:00"},"s":"I",  "c":"STORAGE",  "id":22315,   "ctx":"initandlisten","msg":"Opening WiredTiger","attr":{"config":"create,cache_size=1536M,session_max=33000,eviction=(threads_min=4,threads_max=4)"}}`,
+		`{"t":{"$date":"2023-03-01T10:00:00.130+00:00"},"s":"W",  "c":"CONTROL",  "id":22120,   "ctx":"initandlisten","msg":"Access control is not enabled for the database. Read and write access to data and configuration is unrestricted","tags":["startupWarnings"]}`,
+		`{"t":{"$date":"2023-03-01T10:00:00.140+00:00"},"s":"W",  "c":"CONTROL",  "id":22178,   "ctx":"initandlisten","msg":"/sys/kernel/mm/transparent_hugepage/enabled is 'always'. We suggest setting it to 'never'","tags":["startupWarnings"]}`,
+		`{"t":{"$date":"2023-03-01T10:00:00.150+00:00"},"s":"W",  "c":"STORAGE",  "id":22297,   "ctx":"initandlisten","msg":"Using the XFS filesystem is strongly recommended with the WiredTiger storage engine. See http://dochub.mongodb.org/core/prodnotes-filesystem","tags":["startupWarnings"]}`,
+	}
+	config := NewServerConfig()
+	for _, str := range strs {
+		doc := Logv2Info{}
+		if err := bson.UnmarshalExtJSON([]byte(str), false, &doc); err != nil {
+			t.Fatalf("bson unmarshal error %v", err)
+		}
+		config.Analyze(&doc)
+	}
+	if config.Port != 27017 || config.ReplSetName != "rs0" || config.StorageEngine != "wiredTiger" ||
+		config.CacheSizeGB != 1.5 || config.SlowMS != 50 || config.Profile != "slowOp" || config.Options == "" {
+		t.Fatal("unexpected", gox.Stringify(config))
+	}
+	if len(config.Warnings) != 3 {
+		t.Fatal("expected", 3, "but got", len(config.Warnings))
+	}
+}
+
+func TestGetOptionValue(t *testing.T) {
+	options := bson.D{{Key: "net", Value: bson.D{{Key: "port", Value: int32(27018)}}}}
+	if port := ToInt(getOptionValue(options, "net.port")); port != 27018 {
+		t.Fatal("expected", 27018, "but got", port)
+	}
+	if value := getOptionValue(options, "net.port.x"); value != nil {
+		t.Fatal("expected nil but got", value)
+	}
+}
@@ -0,0 +1,335 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * connections.go
+ */
+
+package hatchet
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	CONN_ENDED   = "ended"
+	CONN_OPEN    = "open"
+	CONN_RESTART = "restart"
+
+	GROWTH_SAMPLES = 10 // number of samples to check growing open connections
+	MIN_SPIKE_RATE = 10 // minimum accepted connections per second to be a spike
+	SPIKE_SIGMA    = 3  // standard deviations above the mean to be a spike
+)
+
+// CONN_BUCKETS defines lifetime distribution buckets of connections in milliseconds
+var CONN_BUCKETS = []NameValue{{"< 1s", 1000}, {"1s - 10s", 10000}, {"10s - 1m", 60000},
+	{"1m - 10m", 600000}, {"10m - 1h", 3600000}, {">= 1h", 0}}
+
+// ConnectionLifetime stores a connection from accepted to ended
+type ConnectionLifetime struct {
+	Accepted string `json:"accepted"`
+	AppName  string `json:"app_name"`
+	ConnID   int    `json:"connection_id"`
+	Ended    string `json:"ended"`
+	IP       string `json:"ip"`
+	Milli    int    `json:"duration_ms"`
+	Port     string `json:"port"`
+	Status   string `json:"status"`
+
+	driver string    // driver name and version from client metadata
+	index  int       // log line of connection accepted
+	start  time.Time // time of connection accepted
+}
+
+// ConnectionStat stores connection lifetimes of an IP and application
+type ConnectionStat struct {
+	Accepted int    `json:"accepted"`
+	AppName  string `json:"app_name"`
+	AvgMilli int    `json:"avg_ms"`
+	Ended    int    `json:"ended"`
+	Growing  bool   `json:"growing"` // open connections never decrease
+	IP       string `json:"ip"`
+	MaxMilli int    `json:"max_ms"`
+	Open     int    `json:"open"`
+}
+
+// ConnectionRate stores peak accepted connections per second of a time bucket
+type ConnectionRate struct {
+	Date  string `json:"date"`
+	Peak  int    `json:"peak"`
+	Spike bool   `json:"spike"`
+	Total int    `json:"total"`
+}
+
+// AcceptedRate stores accepted connections rates and spikes
+type AcceptedRate struct {
+	Mean      float64          `json:"mean"`
+	Rates     []ConnectionRate `json:"rates"`
+	Spikes    []NameValue      `json:"spikes"`
+	Threshold int              `json:"threshold"`
+}
+
+// ConnectionTracker pairs connection accepted and ended logs by connection id
+type ConnectionTracker struct {
+	conns map[int]*ConnectionLifetime
+}
+
+// NewConnectionTracker returns a ConnectionTracker
+func NewConnectionTracker() *ConnectionTracker {
+	return &ConnectionTracker{conns: map[int]*ConnectionLifetime{}}
+}
+
+// Analyze tracks a log and returns connections closed by it
+func (ptr *ConnectionTracker) Analyze(index int, doc *Logv2Info) []*ConnectionLifetime {
+	if doc.Component == "CONTROL" && doc.Msg == "MongoDB starting" {
+		return ptr.Close(getDateTimeStr(doc.Timestamp), CONN_RESTART)
+	}
+	if doc.Component != "NETWORK" {
+		return nil
+	}
+	attrMap := doc.Attr.Map()
+	connID := ToInt(attrMap["connectionId"])
+	if connID == 0 {
+		connID = getConnectionID(doc.Context)
+	}
+	if connID == 0 {
+		return nil
+	}
+	if doc.Msg == "Connection accepted" && doc.Client != nil {
+		ptr.conns[connID] = &ConnectionLifetime{Accepted: getDateTimeStr(doc.Timestamp), ConnID: connID,
+			IP: doc.Client.IP, Port: doc.Client.Port, Status: CONN_OPEN, index: index, start: doc.Timestamp}
+	} else if doc.Msg == "client metadata" {
+		if conn, ok := ptr.conns[connID]; ok {
+			conn.AppName = getAppName(attrMap)
+			conn.driver = getDriverName(attrMap)
+		}
+	} else if doc.Msg == "Connection ended" {
+		conn, ok := ptr.conns[connID]
+		if !ok {
+			return nil
+		}
+		delete(ptr.conns, connID)
+		conn.Ended = getDateTimeStr(doc.Timestamp)
+		conn.Milli = int(doc.Timestamp.Sub(conn.start).Milliseconds())
+		conn.Status = CONN_ENDED
+		return []*ConnectionLifetime{conn}
+	}
+	return nil
+}
+
+// GetClient returns the application name and the driver of an open connection by a context, e.g. conn123
+func (ptr *ConnectionTracker) GetClient(context string) (string, string) {
+	conn, ok := ptr.conns[getConnectionID(context)]
+	if !ok {
+		return "", ""
+	}
+	return conn.AppName, conn.driver
+}
+
+// Close returns all connections not yet ended with a status
+func (ptr *ConnectionTracker) Close(date string, status string) []*ConnectionLifetime {
+	conns := []*ConnectionLifetime{}
+	for _, conn := range ptr.conns {
+		conn.Status = status
+		if status != CONN_OPEN {
+			conn.Ended = date
+		}
+		conns = append(conns, conn)
+	}
+	sort.Slice(conns, func(i, j int) bool {
+		return conns[i].index < conns[j].index
+	})
+	ptr.conns = map[int]*ConnectionLifetime{}
+	return conns
+}
+
+// getConnectionID returns connection id from a context, e.g. conn123
+func getConnectionID(context string) int {
+	if !strings.HasPrefix(context, "conn") {
+		return 0
+	}
+	return ToInt(context[4:])
+}
+
+func getAppName(attrMap map[string]interface{}) string {
+	if doc, ok := attrMap["doc"].(bson.D); ok {
+		if application, ok := doc.Map()["application"].(bson.D); ok {
+			name, _ := application.Map()["name"].(string)
+			return name
+		}
+	}
+	return ""
+}
+
+// getDriverName returns driver name and version from client metadata, e.g. nodejs 4.10.0
+func getDriverName(attrMap map[string]interface{}) string {
+	if doc, ok := attrMap["doc"].(bson.D); ok {
+		return getDriverFromDoc(doc)
+	}
+	return ""
+}
+
+// getDriverFromDoc returns driver name and version from a document with a driver field
+func getDriverFromDoc(doc bson.D) string {
+	driver, ok := doc.Map()["driver"].(bson.D)
+	if !ok {
+		return ""
+	}
+	name, _ := driver.Map()["name"].(string)
+	version, _ := driver.Map()["version"].(string)
+	return strings.TrimSpace(name + " " + version)
+}
+
+// getAcceptedRate returns peak accepted connections per second of time buckets and spikes, of
+// accepted connections of each second and the time bucket of each second
+func getAcceptedRate(buckets []string, seconds []NameValue, totalSeconds int) AcceptedRate {
+	rate := AcceptedRate{Rates: []ConnectionRate{}, Spikes: []NameValue{}}
+	rate.Threshold, rate.Mean, rate.Spikes = DetectSpikes(seconds, totalSeconds)
+	for i, second := range seconds {
+		n := len(rate.Rates)
+		if n == 0 || rate.Rates[n-1].Date != buckets[i] {
+			rate.Rates = append(rate.Rates, ConnectionRate{Date: buckets[i]})
+			n++
+		}
+		bucket := &rate.Rates[n-1]
+		bucket.Total += second.Value
+		if second.Value > bucket.Peak {
+			bucket.Peak = second.Value
+		}
+		if second.Value >= rate.Threshold {
+			bucket.Spike = true
+		}
+	}
+	return rate
+}
+
+// summarizeConnections returns connection lifetimes by IPs and applications of connections in
+// the order of accepted, sorted by open connections
+func summarizeConnections(conns []ConnectionLifetime) []ConnectionStat {
+	docs := []ConnectionStat{}
+	type event struct {
+		date  string
+		delta int
+	}
+	stats := map[string]*ConnectionStat{}
+	events := map[string][]event{}
+	total := map[string]int{}
+	for _, conn := range conns {
+		key := conn.IP + "/" + conn.AppName
+		stat, ok := stats[key]
+		if !ok {
+			stat = &ConnectionStat{IP: conn.IP, AppName: conn.AppName}
+			stats[key] = stat
+		}
+		stat.Accepted++
+		events[key] = append(events[key], event{conn.Accepted, 1})
+		if conn.Status == CONN_OPEN {
+			stat.Open++
+			continue
+		}
+		events[key] = append(events[key], event{conn.Ended, -1})
+		if conn.Status == CONN_ENDED {
+			stat.Ended++
+			total[key] += conn.Milli
+			if conn.Milli > stat.MaxMilli {
+				stat.MaxMilli = conn.Milli
+			}
+		}
+	}
+	for key, stat := range stats {
+		if stat.Ended > 0 {
+			stat.AvgMilli = total[key] / stat.Ended
+		}
+		if stat.Open > 0 { // sample the lowest open connections of equal periods
+			sort.SliceStable(events[key], func(i, j int) bool {
+				return events[key][i].date < events[key][j].date
+			})
+			n := len(events[key])
+			samples := []int{}
+			open := 0
+			for i, e := range events[key] {
+				open += e.delta
+				s := i * GROWTH_SAMPLES / n
+				if s >= len(samples) {
+					samples = append(samples, open)
+				} else if open < samples[s] {
+					samples[s] = open
+				}
+			}
+			stat.Growing = IsGrowing(samples)
+		}
+		docs = append(docs, *stat)
+	}
+	sort.Slice(docs, func(i, j int) bool {
+		if docs[i].Open == docs[j].Open {
+			return docs[i].Accepted > docs[j].Accepted
+		}
+		return docs[i].Open > docs[j].Open
+	})
+	return docs
+}
+
+// DetectSpikes returns the threshold, the mean and seconds of which accepted connections are above
+// the threshold, the mean counts seconds without any accepted connection
+func DetectSpikes(seconds []NameValue, totalSeconds int) (int, float64, []NameValue) {
+	spikes := []NameValue{}
+	if len(seconds) == 0 {
+		return 0, 0, spikes
+	}
+	if totalSeconds < len(seconds) {
+		totalSeconds = len(seconds)
+	}
+	var total, squares float64
+	for _, second := range seconds {
+		total += float64(second.Value)
+	}
+	mean := total / float64(totalSeconds)
+	for _, second := range seconds {
+		squares += math.Pow(float64(second.Value)-mean, 2)
+	}
+	squares += float64(totalSeconds-len(seconds)) * mean * mean
+	threshold := int(math.Ceil(mean + SPIKE_SIGMA*math.Sqrt(squares/float64(totalSeconds))))
+	if threshold < MIN_SPIKE_RATE {
+		threshold = MIN_SPIKE_RATE
+	}
+	for _, second := range seconds {
+		if second.Value >= threshold {
+			spikes = append(spikes, second)
+		}
+	}
+	return threshold, mean, spikes
+}
+
+// IsGrowing returns true if values never decrease and the last is greater than the first
+func IsGrowing(values []int) bool {
+	if len(values) < 3 {
+		return false
+	}
+	for i := 1; i < len(values); i++ {
+		if values[i] < values[i-1] {
+			return false
+		}
+	}
+	return values[len(values)-1] > values[0]
+}
+
+// getDurationSeconds returns seconds between two date strings, e.g. 2023-03-01T10:00 or a log date
+func getDurationSeconds(start string, end string) int {
+	layout := "2006-01-02T15:04:05"
+	pad := "0000-01-01T00:00:00"
+	var err error
+	var stime, etime time.Time
+	times := []*time.Time{&stime, &etime}
+	for i, str := range []string{start, end} {
+		if len(str) < len(pad) {
+			str += pad[len(str):]
+		}
+		if *times[i], err = time.Parse(layout, str[:len(pad)]); err != nil {
+			return 0
+		}
+	}
+	return int(etime.Sub(stime).Seconds()) + 1
+}
@@ -0,0 +1,99 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * connections_template.go
+ */
+
+package hatchet
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// GetConnectionsTemplate returns HTML
+func GetConnectionsTemplate() (*template.Template, error) {
+	html := getContentHTML()
+	html += `{{$name := .Hatchet}}
+<script>
+	document.getElementById('nextReport').selectedIndex = {{.Report.Index}};
+</script>
+	<div style='clear: left; margin: 10px 10px;'>
+		<button class='button' onClick="javascript:location.href='/hatchets/{{$name}}/charts/connections?type=rate'; return false;">
+			<i class='fa fa-line-chart'></i> Accepted Connections Rate</button>
+		<button class='button' onClick="javascript:location.href='/hatchets/{{$name}}/charts/connections?type=time'; return false;">
+			<i class='fa fa-line-chart'></i> Accepted & Ended Connections</button>
+	</div>
+{{$lifetimes := index .Data "lifetimes"}}
+{{if $lifetimes}}
+	<table style='float: left; margin: 10px 10px; clear: left;'>
+		<caption><span style="font-size: 16px; padding: 5px 5px;"><i class="fa fa-hourglass-half"></i></span>Connection Lifetimes</caption>
+		<tr><th></th><th>Lifetime</th><th>Ended</th></tr>
+	{{range $n, $val := index .Data "distribution"}}
+		<tr><td align=right>{{add $n 1}}</td><td>{{$val.Name}}</td><td align=right>{{numPrinter $val.Value}}</td></tr>
+	{{end}}
+	</table>
+
+	<table style='float: left; margin: 10px 10px;'>
+		<caption><span style="font-size: 16px; padding: 5px 5px;"><i class="fa fa-bolt"></i></span>Connection Storms
+			(mean {{printf "%.2f" (index .Data "mean")}}/s, threshold {{index .Data "threshold"}}/s)</caption>
+		<tr><th></th><th>Second</th><th>Accepted</th></tr>
+	{{range $n, $val := index .Data "spikes"}}
+		<tr><td align=right>{{add $n 1}}</td>
+			<td><button class='btn' onClick="javascript:location.href='/hatchets/{{$name}}/logs/all?component=NETWORK&duration={{$val.Name}},{{$val.Name}}.999Z'; return false;">
+				<i class='fa fa-search'></i></button>{{formatDateTime $val.Name}}</td>
+			<td align=right><span style='color: red;'>{{numPrinter $val.Value}}</span></td></tr>
+	{{else}}
+		<tr><td></td><td colspan=2>no spikes found</td></tr>
+	{{end}}
+	</table>
+
+	<table style='float: left; margin: 10px 10px; clear: left;'>
+		<caption><span style="font-size: 16px; padding: 5px 5px;"><i class="fa fa-plug"></i></span>Connections by IPs and Applications</caption>
+		<tr><th></th><th>IP</th><th>Application</th><th>Accepted</th><th>Ended</th><th>Never Ended</th>
+			<th>Avg Lifetime</th><th>Max Lifetime</th><th>Leak Indicator</th></tr>
+	{{range $n, $val := $lifetimes}}
+		<tr><td align=right>{{add $n 1}}</td><td>{{$val.IP}}</td><td>{{$val.AppName}}</td>
+			<td align=right>{{numPrinter $val.Accepted}}</td><td align=right>{{numPrinter $val.Ended}}</td>
+			<td align=right>{{numPrinter $val.Open}}</td>
+			<td align=right>{{getDurationFromMillis $val.AvgMilli}}</td><td align=right>{{getDurationFromMillis $val.MaxMilli}}</td>
+		{{if $val.Growing}}
+			<td><span style='color: red;'><i class='fa fa-arrow-up'></i> growing</span></td>
+		{{else if gt $val.Open 0}}
+			<td>open</td>
+		{{else}}
+			<td></td>
+		{{end}}
+		</tr>
+	{{end}}
+	</table>
+{{else}}
+	<div style='clear: left;' align='center' class='btn'><span style='color: red'>no connections found</span></div>
+{{end}}
+	<div style='clear: left;' align='center'><hr/><p/>@simagix</div>
+</body></html>`
+	return template.New("hatchet").Funcs(template.FuncMap{
+		"add": func(a int, b int) int {
+			return a + b
+		},
+		"numPrinter": func(n interface{}) string {
+			printer := message.NewPrinter(language.English)
+			return printer.Sprintf("%v", ToInt(n))
+		},
+		"getDurationFromMillis": func(milli int) string {
+			if milli < 1000 {
+				return fmt.Sprintf("%d ms", milli)
+			} else if milli < 60000 {
+				return fmt.Sprintf("%.1f s", float64(milli)/1000)
+			} else if milli < 3600000 {
+				return fmt.Sprintf("%.1f m", float64(milli)/60000)
+			}
+			return fmt.Sprintf("%.1f h", float64(milli)/3600000)
+		},
+		"formatDateTime": func(str string) string {
+			return strings.Replace(str, "T", " ", 1)
+		}}).Parse(html)
+}
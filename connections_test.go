@@ -0,0 +1,72 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * connections_test.go
+ */
+
+package hatchet
+
+import (
+	"testing"
+
+	"github.com/simagix/gox"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestConnectionTracker(t *testing.T) {
+	strs := []string{
+		`{"t":{"$date":"2023-03-01T10:00:00.000+00:00"},"s":"I",  "c":"NETWORK",  "id":22943,   "ctx":"listener","msg":"Connection accepted","attr":{"remote":"10.0.0.1:50001","connectionId":11,"connectionCount":5}}`,
+		`{"t":{"$date":"2023-03-01T10:00:00.010+00:00"},"s":"I",  "c":"NETWORK",  "id":51800,   "ctx":"conn11","msg":"client metadata","attr":{"remote":"10.0.0.1:50001","client":"conn11","doc":{"application":{"name":"orders"},"driver":{"name":"nodejs","version":"4.10.0"}}}}`,
+		`{"t":{"$date":"2023-03-01T10:00:01.000+00:00"},"s":"I",  "c":"NETWORK",  "id":22943,   "ctx":"listener","msg":"Connection accepted","attr":{"remote":"10.0.0.1:50002","connectionId":12,"connectionCount":6}}`,
+		`{"t":{"$date":"2023-03-01T10:00:02.500+00:00"},"s":"I",  "c":"NETWORK",  "id":22944,   "ctx":"conn11","msg":"Connection ended","attr":{"remote":"10.0.0.1:50001","connectionId":11,"connectionCount":5}}`,
+	}
+	tracker := NewConnectionTracker()
+	ended := []*ConnectionLifetime{}
+	for i, str := range strs {
+		doc := Logv2Info{}
+		if err := bson.UnmarshalExtJSON([]byte(str), false, &doc); err != nil {
+			t.Fatalf("bson unmarshal error %v", err)
+		}
+		if err := AddLegacyString(&doc); err != nil {
+			t.Fatal(err)
+		}
+		ended = append(ended, tracker.Analyze(i+1, &doc)...)
+		if i == 1 {
+			if appName, driver := tracker.GetClient("conn11"); appName != "orders" || driver != "nodejs 4.10.0" {
+				t.Fatal("unexpected", appName, driver)
+			}
+		}
+	}
+	if len(ended) != 1 {
+		t.Fatal("expected", 1, "but got", len(ended))
+	}
+	conn := ended[0]
+	if conn.ConnID != 11 || conn.AppName != "orders" || conn.Milli != 2500 || conn.Status != CONN_ENDED {
+		t.Fatal("unexpected", gox.Stringify(conn))
+	}
+	open := tracker.Close("2023-03-01T10:00:03.000-0000", CONN_OPEN)
+	if len(open) != 1 || open[0].ConnID != 12 || open[0].Ended != "" {
+		t.Fatal("unexpected", gox.Stringify(open))
+	}
+	t.Log(gox.Stringify(conn, "", "  "))
+}
+
+func TestDetectSpikes(t *testing.T) {
+	seconds := []NameValue{{"2023-03-01T10:00:00", 1}, {"2023-03-01T10:00:05", 2},
+		{"2023-03-01T10:00:09", 120}, {"2023-03-01T10:00:30", 1}}
+	threshold, mean, spikes := DetectSpikes(seconds, 60)
+	if len(spikes) != 1 || spikes[0].Value != 120 {
+		t.Fatal("expected 1 spike of 120 but got", spikes, "threshold", threshold, "mean", mean)
+	}
+	if _, _, spikes = DetectSpikes(seconds[:2], 60); len(spikes) != 0 {
+		t.Fatal("expected no spikes but got", spikes)
+	}
+}
+
+func TestIsGrowing(t *testing.T) {
+	if !IsGrowing([]int{1, 2, 2, 5}) {
+		t.Fatal("expected growing")
+	}
+	if IsGrowing([]int{1, 3, 2, 5}) || IsGrowing([]int{2, 2, 2}) || IsGrowing([]int{1, 2}) {
+		t.Fatal("expected not growing")
+	}
+}
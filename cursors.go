@@ -0,0 +1,152 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * cursors.go
+ */
+
+package hatchet
+
+import (
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	CURSOR_EXHAUSTED = "exhausted"
+	CURSOR_KILLED    = "killed"
+	CURSOR_OPEN      = "open"
+	CURSOR_RESTART   = "restart"
+	CURSOR_TIMED_OUT = "timed-out"
+)
+
+// Cursor stores totals of an originating command and its getMore batches
+type Cursor struct {
+	Batches      int    `json:"batches"`
+	CursorID     int64  `json:"cursor_id"`
+	End          string `json:"end"`
+	Lifetime     int    `json:"lifetime_ms"`
+	Milli        int    `json:"duration_ms"` // total execution time of all batches
+	Namespace    string `json:"ns"`
+	NReturned    int    `json:"nreturned"`
+	Op           string `json:"op"`
+	QueryPattern string `json:"query_pattern"`
+	Start        string `json:"start"`
+	Status       string `json:"status"`
+
+	index int
+}
+
+// CursorStats stores cursor summaries
+type CursorStats struct {
+	Heaviest  []Cursor     `json:"heaviest"`   // cursors of the longest total execution time
+	LongLived []Cursor     `json:"long_lived"` // cursors of the longest lifetime
+	Statuses  []NameValues `json:"statuses"`   // [cursors, batches, docs returned]
+}
+
+// CursorTracker links getMore logs to cursors by cursor ids
+type CursorTracker struct {
+	cursors map[int64]*Cursor
+}
+
+// NewCursorTracker returns a CursorTracker
+func NewCursorTracker() *CursorTracker {
+	return &CursorTracker{cursors: map[int64]*Cursor{}}
+}
+
+// Analyze tracks a log and returns cursors exhausted, killed or timed out. Originating commands
+// are only logged when slow, getMore logs of unknown cursors start tracking from the first batch.
+func (ptr *CursorTracker) Analyze(index int, doc *Logv2Info, stat *OpStat) []*Cursor {
+	date := getDateTimeStr(doc.Timestamp)
+	if doc.Component == "CONTROL" && doc.Msg == "MongoDB starting" {
+		return ptr.Close(date, CURSOR_RESTART)
+	}
+	attrMap := doc.Attr.Map()
+	if doc.Component == "QUERY" && strings.HasPrefix(doc.Msg, "Cursor timed out") {
+		return ptr.end(getCursorID(attrMap["cursorId"]), date, CURSOR_TIMED_OUT)
+	}
+	command, ok := attrMap["command"].(bson.D)
+	if !ok || len(command) == 0 {
+		return nil
+	}
+	if command[0].Key == "killCursors" {
+		closed := []*Cursor{}
+		if ids, ok := command.Map()["cursors"].(bson.A); ok {
+			for _, id := range ids {
+				closed = append(closed, ptr.end(getCursorID(id), date, CURSOR_KILLED)...)
+			}
+		}
+		return closed
+	}
+	cursorID := getCursorID(attrMap["cursorid"])
+	if command[0].Key == cmdGetMore {
+		cursorID = getCursorID(command[0].Value)
+	}
+	if cursorID == 0 {
+		return nil
+	}
+	cursor := ptr.cursors[cursorID]
+	if cursor == nil {
+		if command[0].Key != cmdGetMore && attrMap["cursorid"] == nil {
+			return nil
+		}
+		cursor = &Cursor{CursorID: cursorID, Start: date, Status: CURSOR_OPEN, index: index}
+		if stat != nil {
+			cursor.Namespace, cursor.Op, cursor.QueryPattern = stat.Namespace, stat.Op, stat.QueryPattern
+		}
+		if origin, ok := attrMap["originatingCommand"].(bson.D); ok && len(origin) > 0 {
+			cursor.Op = origin[0].Key
+		}
+		if milli := ToInt(attrMap["durationMillis"]); milli > 0 { // starts before the first batch returned
+			cursor.Start = getDateTimeStr(doc.Timestamp.Add(-time.Duration(milli) * time.Millisecond))
+		}
+		ptr.cursors[cursorID] = cursor
+	}
+	cursor.Batches++
+	cursor.End = date
+	cursor.Milli += ToInt(attrMap["durationMillis"])
+	cursor.NReturned += ToInt(attrMap["nreturned"])
+	if name, _ := attrMap["errName"].(string); name == "CursorNotFound" || name == "CursorKilled" {
+		return ptr.end(cursorID, date, CURSOR_KILLED)
+	} else if exhausted, _ := attrMap["cursorExhausted"].(bool); exhausted {
+		return ptr.end(cursorID, date, CURSOR_EXHAUSTED)
+	}
+	return nil
+}
+
+// Close ends all open cursors
+func (ptr *CursorTracker) Close(date string, status string) []*Cursor {
+	closed := []*Cursor{}
+	for id := range ptr.cursors {
+		closed = append(closed, ptr.end(id, date, status)...)
+	}
+	return closed
+}
+
+// end removes a cursor from tracking, cursors left open keep the time of the last batch
+func (ptr *CursorTracker) end(id int64, date string, status string) []*Cursor {
+	cursor := ptr.cursors[id]
+	if cursor == nil {
+		return nil
+	}
+	delete(ptr.cursors, id)
+	cursor.Status = status
+	if status != CURSOR_OPEN {
+		cursor.End = date
+	}
+	cursor.Lifetime = getMilliseconds(cursor.Start, cursor.End)
+	return []*Cursor{cursor}
+}
+
+// getCursorID returns a 64-bit cursor id without losing precision
+func getCursorID(value interface{}) int64 {
+	switch v := value.(type) {
+	case int64:
+		return v
+	case int32:
+		return int64(v)
+	case float64:
+		return int64(v)
+	}
+	return 0
+}
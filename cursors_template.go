@@ -0,0 +1,92 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * cursors_template.go
+ */
+
+package hatchet
+
+import (
+	"fmt"
+	"html/template"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// GetCursorsTemplate returns HTML
+func GetCursorsTemplate() (*template.Template, error) {
+	html := getContentHTML()
+	html += `{{$name := .Hatchet}}
+<script>
+	document.getElementById('nextReport').selectedIndex = {{.Report.Index}};
+</script>
+{{if .Data.Statuses}}
+	<table style='float: left; margin: 10px 10px; clear: left;'>
+		<caption><span style="font-size: 16px; padding: 5px 5px;"><i class="fa fa-mouse-pointer"></i></span>Cursors</caption>
+		<tr><th></th><th>Status</th><th>Cursors</th><th>Batches</th><th>Docs Returned</th></tr>
+	{{range $n, $val := .Data.Statuses}}
+		<tr><td align=right>{{add $n 1}}</td>
+			<td><span style='color: {{getStatusColor $val.Name}};'>{{$val.Name}}</span></td>
+			<td align=right>{{numPrinter (index $val.Values 0)}}</td>
+			<td align=right>{{numPrinter (index $val.Values 1)}}</td>
+			<td align=right>{{numPrinter (index $val.Values 2)}}</td>
+		</tr>
+	{{end}}
+	</table>
+` + getCursorsTable("Long-lived Cursors", ".Data.LongLived") + getCursorsTable("Heaviest Cursors", ".Data.Heaviest") + `
+{{else}}
+	<div style='clear: left;' align='center' class='btn'><span style='color: red'>no cursors found</span></div>
+{{end}}
+	<div style='clear: left;' align='center'><hr/><p/>@simagix</div>
+</body></html>`
+	return template.New("hatchet").Funcs(template.FuncMap{
+		"add": func(a int, b int) int {
+			return a + b
+		},
+		"getDurationFromMillis": func(milli int) string {
+			if milli < 1000 {
+				return fmt.Sprintf("%d ms", milli)
+			} else if milli < 60000 {
+				return fmt.Sprintf("%.1f s", float64(milli)/1000)
+			} else if milli < 3600000 {
+				return fmt.Sprintf("%.1f m", float64(milli)/60000)
+			}
+			return fmt.Sprintf("%.1f h", float64(milli)/3600000)
+		},
+		"getStatusColor": func(status string) string {
+			if status == CURSOR_KILLED {
+				return "red"
+			} else if status == CURSOR_TIMED_OUT {
+				return "orange"
+			}
+			return "black"
+		},
+		"numPrinter": func(n interface{}) string {
+			printer := message.NewPrinter(language.English)
+			return printer.Sprintf("%v", ToInt(n))
+		}}).Parse(html)
+}
+
+// getCursorsTable returns HTML table of cursors of a field
+func getCursorsTable(title string, field string) string {
+	return fmt.Sprintf(`
+	<table style='float: left; margin: 10px 10px; clear: left;'>
+		<caption><span style="font-size: 16px; padding: 5px 5px;"><i class="fa fa-clock-o"></i></span>%v</caption>
+		<tr><th></th><th>Cursor ID</th><th>Namespace</th><th>Op</th><th>Query Pattern</th><th>Batches</th><th>Docs</th>
+			<th>Total Time</th><th>Lifetime</th><th>Status</th></tr>
+		{{range $n, $val := %v}}
+		<tr><td align=right>{{add $n 1}}</td>
+			<td><button class='btn' onClick="javascript:location.href='/hatchets/{{$name}}/logs/all?context={{$val.CursorID}}&duration={{$val.Start}},{{$val.End}}'; return false;">
+				<i class='fa fa-search'></i></button>{{$val.CursorID}}</td>
+			<td>{{$val.Namespace}}</td>
+			<td>{{$val.Op}}</td>
+			<td class='break'>{{$val.QueryPattern}}</td>
+			<td align=right>{{numPrinter $val.Batches}}</td>
+			<td align=right>{{numPrinter $val.NReturned}}</td>
+			<td align=right>{{getDurationFromMillis $val.Milli}}</td>
+			<td align=right>{{getDurationFromMillis $val.Lifetime}}</td>
+			<td><span style='color: {{getStatusColor $val.Status}};'>{{$val.Status}}</span></td>
+		</tr>
+		{{end}}
+	</table>`, title, field)
+}
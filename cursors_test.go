@@ -0,0 +1,52 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * cursors_test.go
+ */
+
+package hatchet
+
+import (
+	"testing"
+
+	"github.com/simagix/gox"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestCursorTracker(t *testing.T) {
+	strs := []string{
+		`{"t":{"$date":"2023-03-01T11:38:00.000+00:00"},"s":"I",  "c":"COMMAND",  "id":51803,   "ctx":"conn160","msg":"Slow query","attr":{"type":"command","ns":"shop.orders","command":{"find":"orders","filter":{"status":"A"},"$db":"shop"},"planSummary":"COLLSCAN","cursorid":8234567890123456789,"nreturned":101,"durationMillis":200}}`,
+		`{"t":{"$date":"2023-03-01T11:38:10.000+00:00"},"s":"I",  "c":"COMMAND",  "id":51803,   "ctx":"conn160","msg":"Slow query","attr":{"type":"command","ns":"shop.orders","command":{"getMore":8234567890123456789,"collection":"orders","$db":"shop"},"originatingCommand":{"find":"orders","filter":{"status":"A"},"$db":"shop"},"planSummary":"COLLSCAN","cursorid":8234567890123456789,"nreturned":1000,"durationMillis":300}}`,
+		`{"t":{"$date":"2023-03-01T11:38:30.000+00:00"},"s":"I",  "c":"COMMAND",  "id":51803,   "ctx":"conn160","msg":"Slow query","attr":{"type":"command","ns":"shop.orders","command":{"getMore":8234567890123456789,"collection":"orders","$db":"shop"},"originatingCommand":{"find":"orders","filter":{"status":"A"},"$db":"shop"},"planSummary":"COLLSCAN","cursorExhausted":true,"nreturned":500,"durationMillis":150}}`,
+		`{"t":{"$date":"2023-03-01T11:38:40.000+00:00"},"s":"I",  "c":"COMMAND",  "id":51803,   "ctx":"conn159","msg":"Slow query","attr":{"type":"command","ns":"shop.events","command":{"getMore":555,"collection":"events","$db":"shop"},"originatingCommand":{"aggregate":"events","pipeline":[{"$match":{"type":"x"}}],"cursor":{},"$db":"shop"},"cursorid":555,"nreturned":101,"durationMillis":120}}`,
+		`{"t":{"$date":"2023-03-01T11:38:45.000+00:00"},"s":"I",  "c":"COMMAND",  "id":51803,   "ctx":"conn159","msg":"Slow query","attr":{"type":"command","ns":"shop.$cmd","command":{"killCursors":"events","cursors":[555],"$db":"shop"},"durationMillis":101}}`,
+		`{"t":{"$date":"2023-03-01T11:38:50.000+00:00"},"s":"I",  "c":"COMMAND",  "id":51803,   "ctx":"conn160","msg":"Slow query","attr":{"type":"command","ns":"shop.items","command":{"find":"items","filter":{"sku":"a"},"$db":"shop"},"cursorid":777,"nreturned":101,"durationMillis":110}}`,
+		`{"t":{"$date":"2023-03-01T11:39:50.000+00:00"},"s":"I",  "c":"QUERY",    "id":20529,   "ctx":"clientcursormon","msg":"Cursor timed out","attr":{"cursorId":777}}`,
+	}
+	tracker := NewCursorTracker()
+	cursors := []*Cursor{}
+	for i, str := range strs {
+		doc := Logv2Info{}
+		if err := bson.UnmarshalExtJSON([]byte(str), false, &doc); err != nil {
+			t.Fatalf("bson unmarshal error %v", err)
+		}
+		stat, _ := AnalyzeSlowOp(&doc)
+		cursors = append(cursors, tracker.Analyze(i+1, &doc, stat)...)
+	}
+	expected := []Cursor{
+		{CursorID: 8234567890123456789, Batches: 3, NReturned: 1601, Milli: 650, Op: "find", Status: CURSOR_EXHAUSTED},
+		{CursorID: 555, Batches: 1, NReturned: 101, Milli: 120, Op: "aggregate", Status: CURSOR_KILLED},
+		{CursorID: 777, Batches: 1, NReturned: 101, Milli: 110, Op: "find", Status: CURSOR_TIMED_OUT},
+	}
+	if len(cursors) != len(expected) {
+		t.Fatal("expected", len(expected), "cursors but got", len(cursors))
+	}
+	for i, cursor := range cursors {
+		if cursor.CursorID != expected[i].CursorID || cursor.Batches != expected[i].Batches || cursor.Op != expected[i].Op ||
+			cursor.NReturned != expected[i].NReturned || cursor.Milli != expected[i].Milli || cursor.Status != expected[i].Status {
+			t.Fatal("expected", gox.Stringify(expected[i]), "but got", gox.Stringify(cursor))
+		}
+	}
+	if cursors[0].Lifetime != 30200 {
+		t.Fatal("expected lifetime 30200 but got", cursors[0].Lifetime)
+	}
+}
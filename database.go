@@ -5,6 +5,11 @@
 
 package hatchet
 
+// HATCHET_TABLES are suffixes of tables, or collections of MongoDB, of a hatchet
+var HATCHET_TABLES = []string{"", "_audit", "_auth", "_clients", "_clients_minute", "_config", "_conns", "_cursors",
+	"_drivers", "_errors", "_findings", "_fts", "_index_builds", "_ops", "_ops_minute", "_repl", "_sharding", "_storage",
+	"_txn_logs", "_txns", "_uptime"}
+
 type NameValue struct {
 	Name  string
 	Value int
@@ -20,30 +25,86 @@ type Database interface {
 	Close() error
 	Commit() error
 	CreateMetaData() error
+	DeleteHatchet() error
 	GetAcceptedConnsCounts(duration string) ([]NameValue, error)
+	GetAcceptedRate(duration string) (AcceptedRate, error)
 	GetAuditData() (map[string][]NameValues, error)
-	GetAverageOpTime(op string, duration string) ([]OpCount, error)
+	GetAuthFailures(duration string) ([]OpCount, error)
+	GetAuthStats() (AuthStats, error)
+	GetAppCounts(duration string) ([]NameValue, error)
+	GetAverageOpTime(op string, duration string, app string) ([]OpCount, error)
+	GetCheckpointDurations(duration string) ([]OpCount, error)
 	GetClientPreparedStmt() string
+	GetConcernStats(topN int) (ConcernStats, error)
+	GetConnectionDistribution(duration string) ([]NameValue, error)
+	GetConnectionLifetimes(duration string) ([]ConnectionStat, error)
 	GetConnectionStats(chartType string, duration string) ([]RemoteClient, error)
+	GetCrashDetail(id int) (CrashDetail, error)
+	GetCursorStats(topN int) (CursorStats, error)
+	GetDriverUsages() ([]DriverUsage, error)
+	GetErrorCodes() ([]ErrorCode, error)
+	GetErrorCounts(code int, duration string) ([]OpCount, error)
+	GetFindings() ([]Finding, error)
 	GetHatchetInfo() HatchetInfo
 	GetHatchetInitStmt() string
 	GetHatchetNames() ([]string, error)
 	GetHatchetPreparedStmt() string
+	GetHatchetSize() (int64, error)
+	GetHatchetStatuses() (map[string]string, error)
+	GetIndexBuilds(duration string) ([]IndexBuild, error)
 	GetLogs(opts ...string) ([]LegacyLog, error)
-	GetOpsCounts(duration string) ([]NameValue, error)
+	GetLongestTransactions(topN int) ([]Transaction, error)
+	GetOplogApplicationTime(duration string) ([]OpCount, error)
+	GetOpsCounts(duration string, app string) ([]NameValue, error)
+	GetReplEvents(eventType string, duration string) ([]ReplEvent, error)
 	GetReslenByNamespace(ip string, duration string) ([]NameValue, error)
 	GetReslenByIP(ip string, duration string) ([]NameValue, error)
-	GetSlowOps(orderBy string, order string, collscan bool) ([]OpStat, error)
+	GetShardingStats() (ShardingStats, error)
+	GetShardingTimeSeries(eventType string, duration string) ([]OpCount, error)
+	GetSlowOps(orderBy string, order string, collscan bool, app string) ([]OpStat, error)
 	GetSlowestLogs(topN int) ([]LegacyLog, error)
+	GetStorageStats() (StorageStats, error)
+	GetTransactionStats() (map[string][]NameValues, error)
+	GetUptimeSegments() ([]UptimeSegment, error)
 	GetVerbose() bool
+	GetWriteConcernLatency(duration string) ([]OpCount, error)
+	HasFullTextIndex() (bool, error)
+	InsertAuthEvent(index int, event *AuthEvent) error
 	InsertClientConn(index int, doc *Logv2Info) error
+	InsertConnection(index int, conn *ConnectionLifetime) error
+	InsertCursor(index int, cursor *Cursor) error
 	InsertDriver(index int, doc *Logv2Info) error
+	InsertFinding(index int, finding *Finding) error
+	InsertIndexBuild(index int, build *IndexBuild) error
 	InsertLog(index int, end string, doc *Logv2Info, stat *OpStat) error
+	InsertOpError(index int, opError *OpError) error
+	InsertReplEvent(index int, event *ReplEvent) error
+	InsertShardingEvent(index int, event *ShardingEvent) error
+	InsertStorageEvent(index int, event *StorageEvent) error
+	InsertTransaction(index int, txn *Transaction) error
+	InsertTxnLog(index int, lsid string, txnNumber int) error
+	InsertUptimeSegment(index int, segment *UptimeSegment) error
+	PinHatchet(pinned bool) error
+	RenameHatchet(name string) error
 	SearchLogs(opts ...string) ([]LegacyLog, error)
 	SetVerbose(v bool)
 	UpdateHatchetInfo(info HatchetInfo) error
+	Vacuum() error
+}
+
+// Options are settings of databases, passed to analyses, commands, and web servers
+type Options struct {
+	DBFile    string // SQLite3 data file
+	DBURI     string // MongoDB connection string, used instead of the data file if set
+	Retention RetentionPolicy
+	Verbose   bool
+	Version   string
 }
 
-func GetDatabase(hatchetName string) (Database, error) {
-	return GetSQLite3DB(hatchetName)
+// GetDatabase returns a MongoDB if the database URI is a MongoDB connection string, or a SQLite3DB
+func GetDatabase(opts Options, hatchetName string) (Database, error) {
+	if IsMongoURI(opts.DBURI) {
+		return GetMongoDB(opts, hatchetName)
+	}
+	return GetSQLite3DB(opts, hatchetName)
 }
@@ -0,0 +1,159 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * drivers.go
+ */
+
+package hatchet
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	DRIVER_EOL         = "eol"
+	DRIVER_SUPPORTED   = "supported"
+	DRIVER_UNKNOWN     = "unknown"
+	DRIVER_UNSUPPORTED = "unsupported"
+)
+
+// ServerRelease stores a server release, its end-of-life date, and minimum driver versions supporting it
+type ServerRelease struct {
+	Drivers map[string]string `json:"drivers"` // driver name to minimum driver version
+	EOL     string            `json:"eol"`
+	Version string            `json:"version"`
+}
+
+// SERVER_RELEASES is the driver compatibility matrix in ascending server versions, driver names are
+// from client metadata in lower cases
+var SERVER_RELEASES = []ServerRelease{
+	{map[string]string{"nodejs": "3.0", "pymongo": "3.6", "mongo-java-driver": "3.6", "mongo-go-driver": "1.0",
+		"mongo-csharp-driver": "2.5", "mongo-ruby-driver": "2.5", "mongoc": "1.9"}, "2021-04-30", "3.6"},
+	{map[string]string{"nodejs": "3.1", "pymongo": "3.7", "mongo-java-driver": "3.8", "mongo-go-driver": "1.0",
+		"mongo-csharp-driver": "2.7", "mongo-ruby-driver": "2.6", "mongoc": "1.11"}, "2022-04-30", "4.0"},
+	{map[string]string{"nodejs": "3.3", "pymongo": "3.9", "mongo-java-driver": "3.11", "mongo-go-driver": "1.1",
+		"mongo-csharp-driver": "2.9", "mongo-ruby-driver": "2.10", "mongo-rust-driver": "1.0", "mongoc": "1.15"}, "2023-04-30", "4.2"},
+	{map[string]string{"nodejs": "3.6", "pymongo": "3.11", "mongo-java-driver": "4.1", "mongo-go-driver": "1.4",
+		"mongo-csharp-driver": "2.11", "mongo-ruby-driver": "2.12", "mongo-rust-driver": "1.1", "mongoc": "1.17"}, "2024-02-29", "4.4"},
+	{map[string]string{"nodejs": "4.0", "pymongo": "3.12", "mongo-java-driver": "4.3", "mongo-go-driver": "1.7",
+		"mongo-csharp-driver": "2.13", "mongo-ruby-driver": "2.15", "mongo-rust-driver": "2.0", "mongoc": "1.18"}, "2024-10-31", "5.0"},
+	{map[string]string{"nodejs": "4.8", "pymongo": "4.2", "mongo-java-driver": "4.7", "mongo-go-driver": "1.10",
+		"mongo-csharp-driver": "2.17", "mongo-ruby-driver": "2.18", "mongo-rust-driver": "2.3", "mongoc": "1.22"}, "2025-07-31", "6.0"},
+	{map[string]string{"nodejs": "5.7", "pymongo": "4.4", "mongo-java-driver": "4.10", "mongo-go-driver": "1.12",
+		"mongo-csharp-driver": "2.20", "mongo-ruby-driver": "2.19", "mongo-rust-driver": "2.6", "mongoc": "1.24"}, "2026-08-31", "7.0"},
+	{map[string]string{"nodejs": "6.7", "pymongo": "4.9", "mongo-java-driver": "5.2", "mongo-go-driver": "1.17",
+		"mongo-csharp-driver": "2.28", "mongo-ruby-driver": "2.20", "mongo-rust-driver": "3.1", "mongoc": "1.28"}, "2029-10-31", "8.0"},
+}
+
+// DriverUsage stores a driver version, clients using it, and its compatibility with the server version
+type DriverUsage struct {
+	AppNames   []NameValue `json:"app_names"`
+	Count      int         `json:"count"`
+	Driver     string      `json:"driver"`
+	EOL        string      `json:"eol"` // EOL date of the last server release the driver supports
+	IPs        []NameValue `json:"ips"`
+	MinVersion string      `json:"min_version"` // minimum driver version of the server version
+	Status     string      `json:"status"`
+	Version    string      `json:"version"`
+}
+
+// GetDriverCompatibility checks drivers against a server version and a date, and returns unsupported
+// and EOL drivers first
+func GetDriverCompatibility(usages []DriverUsage, serverVersion string, date string) []DriverUsage {
+	order := map[string]int{DRIVER_UNSUPPORTED: 0, DRIVER_EOL: 1, DRIVER_UNKNOWN: 2, DRIVER_SUPPORTED: 3}
+	for i := range usages {
+		checkDriverUsage(&usages[i], serverVersion, date)
+	}
+	sort.SliceStable(usages, func(i, j int) bool {
+		return order[usages[i].Status] < order[usages[j].Status]
+	})
+	return usages
+}
+
+// checkDriverUsage sets the minimum driver version, the EOL date and the status of a driver
+func checkDriverUsage(usage *DriverUsage, serverVersion string, date string) {
+	name := getDriverKey(usage.Driver)
+	version := getDriverVersion(usage.Version)
+	if release := getServerRelease(serverVersion); release != nil {
+		usage.MinVersion = release.Drivers[name]
+	}
+	var latest *ServerRelease // last server release the driver supports
+	for i, release := range SERVER_RELEASES {
+		if minVersion, ok := release.Drivers[name]; ok && CompareVersions(version, minVersion) >= 0 {
+			latest = &SERVER_RELEASES[i]
+		}
+	}
+	if latest != nil {
+		usage.EOL = latest.EOL
+	}
+	if usage.MinVersion != "" && CompareVersions(version, usage.MinVersion) < 0 {
+		usage.Status = DRIVER_UNSUPPORTED
+	} else if latest == nil {
+		usage.Status = DRIVER_UNKNOWN
+		if _, ok := SERVER_RELEASES[0].Drivers[name]; ok { // older than the oldest release
+			usage.Status = DRIVER_EOL
+		}
+	} else if date != "" && usage.EOL < date {
+		usage.Status = DRIVER_EOL
+	} else {
+		usage.Status = DRIVER_SUPPORTED
+	}
+}
+
+// getServerRelease returns the release of a server version, e.g. 6.0 of 6.0.4, or the latest
+// release if the server version is newer
+func getServerRelease(serverVersion string) *ServerRelease {
+	if serverVersion == "" {
+		return nil
+	}
+	var release *ServerRelease
+	for i := range SERVER_RELEASES {
+		if CompareVersions(serverVersion, SERVER_RELEASES[i].Version) >= 0 {
+			release = &SERVER_RELEASES[i]
+		}
+	}
+	return release
+}
+
+// getDriverKey returns the driver name of the matrix, e.g. nodejs of nodejs|Mongoose
+func getDriverKey(driver string) string {
+	return strings.ToLower(strings.TrimSpace(strings.Split(driver, "|")[0]))
+}
+
+// getDriverVersion returns the driver version, e.g. 1.11.4 of v1.11.4 or 4.10.0 of 4.10.0|6.8.0
+func getDriverVersion(version string) string {
+	return strings.TrimPrefix(strings.TrimSpace(strings.Split(version, "|")[0]), "v")
+}
+
+// CompareVersions compares numeric parts of two versions and returns -1, 0, or 1, e.g. 4.10.0 > 4.8
+func CompareVersions(a string, b string) int {
+	as := strings.Split(a, ".")
+	bs := strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x = getLeadingInt(as[i])
+		}
+		if i < len(bs) {
+			y = getLeadingInt(bs[i])
+		}
+		if x < y {
+			return -1
+		} else if x > y {
+			return 1
+		}
+	}
+	return 0
+}
+
+// getLeadingInt returns the leading digits of a string as an integer, e.g. 3 of 3-rc1
+func getLeadingInt(str string) int {
+	n := 0
+	for _, c := range str {
+		if c < '0' || c > '9' {
+			break
+		}
+		n = n*10 + int(c-'0')
+	}
+	return n
+}
@@ -0,0 +1,92 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * drivers_template.go
+ */
+
+package hatchet
+
+import (
+	"fmt"
+	"html/template"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// GetDriversTemplate returns HTML
+func GetDriversTemplate() (*template.Template, error) {
+	html := getContentHTML()
+	html += `{{$name := .Hatchet}}{{$version := .Version}}
+<script>
+	document.getElementById('nextReport').selectedIndex = {{.Report.Index}};
+</script>
+{{if .Drivers}}
+	<table style='float: left; margin: 10px 10px; clear: left;'>
+		<caption><span style="font-size: 16px; padding: 5px 5px;"><i class="fa fa-plug"></i></span>Drivers of MongoDB {{$version}}</caption>
+		<tr><th></th><th>Status</th><th>Driver</th><th>Version</th><th>Min Version</th><th>EOL</th><th>Count</th>
+			<th>Client IPs</th><th>Applications</th></tr>
+	{{range $n, $val := .Drivers}}
+		<tr><td align=right>{{add $n 1}}</td>
+			<td><span style='color: {{getStatusColor $val.Status}};'>{{$val.Status}}</span></td>
+			<td>{{$val.Driver}}</td>
+			<td>{{$val.Version}}</td>
+			<td>{{$val.MinVersion}}</td>
+			<td>{{$val.EOL}}</td>
+			<td align=right>{{numPrinter $val.Count}}</td>
+			<td>{{getNameValues $val.IPs}}</td>
+			<td>{{getNameValues $val.AppNames}}</td>
+		</tr>
+	{{end}}
+	</table>
+{{else}}
+	<div style='clear: left;' align='center' class='btn'><span style='color: red'>no driver information found</span></div>
+{{end}}
+	<table style='float: left; margin: 10px 10px; clear: left;'>
+		<caption><span style="font-size: 16px; padding: 5px 5px;"><i class="fa fa-table"></i></span>Minimum Driver Versions</caption>
+		<tr><th>Server</th><th>EOL</th>{{range $d := getDriverNames .Releases}}<th>{{$d}}</th>{{end}}</tr>
+	{{range $n, $val := .Releases}}
+		<tr{{if hasPrefix $version $val.Version}} style='font-weight: bold;'{{end}}><td>{{$val.Version}}</td><td>{{$val.EOL}}</td>
+		{{range $d := getDriverNames $.Releases}}<td>{{index $val.Drivers $d}}</td>{{end}}</tr>
+	{{end}}
+	</table>
+	<div style='clear: left;' align='center'><hr/><p/>@simagix</div>
+</body></html>`
+	return template.New("hatchet").Funcs(template.FuncMap{
+		"add": func(a int, b int) int {
+			return a + b
+		},
+		"getDriverNames": func(releases []ServerRelease) []string {
+			names := []string{}
+			if len(releases) > 0 {
+				for name := range releases[len(releases)-1].Drivers {
+					names = append(names, name)
+				}
+			}
+			sort.Strings(names)
+			return names
+		},
+		"getNameValues": func(docs []NameValue) template.HTML {
+			strs := []string{}
+			for _, doc := range docs {
+				strs = append(strs, fmt.Sprintf("%v: %v", template.HTMLEscapeString(doc.Name), doc.Value))
+			}
+			return template.HTML(strings.Join(strs, "<br/>"))
+		},
+		"getStatusColor": func(status string) string {
+			if status == DRIVER_UNSUPPORTED {
+				return "red"
+			} else if status == DRIVER_EOL {
+				return "orange"
+			}
+			return "black"
+		},
+		"hasPrefix": func(str string, pre string) bool {
+			return strings.HasPrefix(str, pre+".")
+		},
+		"numPrinter": func(n interface{}) string {
+			printer := message.NewPrinter(language.English)
+			return printer.Sprintf("%v", ToInt(n))
+		}}).Parse(html)
+}
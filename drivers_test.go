@@ -0,0 +1,51 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * drivers_test.go
+ */
+
+package hatchet
+
+import (
+	"testing"
+
+	"github.com/simagix/gox"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"4.10.0", "4.8", 1}, {"4.8", "4.8.0", 0}, {"6.0.4", "7.0", -1}, {"1.11.4", "1.12", -1}, {"7.0.0-rc1", "7.0", 0},
+	}
+	for _, test := range tests {
+		if n := CompareVersions(test.a, test.b); n != test.expected {
+			t.Fatal(test.a, test.b, "expected", test.expected, "but got", n)
+		}
+	}
+}
+
+func TestGetDriverCompatibility(t *testing.T) {
+	usages := []DriverUsage{
+		{Driver: "nodejs|Mongoose", Version: "6.7.0|8.5.0"},
+		{Driver: "mongo-go-driver", Version: "v1.8.0"},
+		{Driver: "PyMongo", Version: "4.2.0"},
+		{Driver: "unknown-driver", Version: "1.0"},
+	}
+	usages = GetDriverCompatibility(usages, "6.0.4", "2025-10-01")
+	expected := []string{DRIVER_UNSUPPORTED, DRIVER_EOL, DRIVER_UNKNOWN, DRIVER_SUPPORTED}
+	for i, status := range expected {
+		if usages[i].Status != status {
+			t.Fatal("expected", status, "but got", gox.Stringify(usages[i]))
+		}
+	}
+	if usages[0].Driver != "mongo-go-driver" || usages[0].MinVersion != "1.10" {
+		t.Fatal("unexpected", gox.Stringify(usages[0]))
+	}
+	if usages[1].Driver != "PyMongo" || usages[1].EOL != "2025-07-31" {
+		t.Fatal("unexpected", gox.Stringify(usages[1]))
+	}
+	if usages[3].EOL != "2029-10-31" {
+		t.Fatal("unexpected", gox.Stringify(usages[3]))
+	}
+}
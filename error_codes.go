@@ -0,0 +1,74 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * error_codes.go
+ */
+
+package hatchet
+
+import (
+	"errors"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const MAX_ERROR_MESSAGE_LENGTH = 256
+
+// OpError stores a structured error of a slow op or a command
+type OpError struct {
+	Code      int    `json:"code"`
+	Message   string `json:"message"`
+	Name      string `json:"name"`
+	Namespace string `json:"ns"`
+	Op        string `json:"op"`
+}
+
+// ErrorCode stores counts of an error code by namespaces, ops and clients
+type ErrorCode struct {
+	Clients    []NameValue `json:"clients"`
+	Code       int         `json:"code"`
+	Count      int         `json:"count"`
+	Message    string      `json:"message"` // a sample error message
+	Name       string      `json:"name"`
+	Namespaces []NameValue `json:"namespaces"`
+	Ops        []NameValue `json:"ops"`
+}
+
+// AnalyzeOpError returns errCode, errName and errMsg of a log, or the code, codeName and
+// errmsg of an error document
+func AnalyzeOpError(doc *Logv2Info) (*OpError, error) {
+	attrMap := doc.Attr.Map()
+	opError := &OpError{}
+	if attrMap["errCode"] != nil || attrMap["errName"] != nil {
+		opError.Code = ToInt(attrMap["errCode"])
+		opError.Name, _ = attrMap["errName"].(string)
+		opError.Message, _ = attrMap["errMsg"].(string)
+	} else if edoc, ok := attrMap["error"].(bson.D); ok {
+		emap := edoc.Map()
+		if emap["code"] == nil {
+			return nil, errors.New("no error code found")
+		}
+		opError.Code = ToInt(emap["code"])
+		opError.Name, _ = emap["codeName"].(string)
+		opError.Message, _ = emap["errmsg"].(string)
+	} else {
+		return nil, errors.New("no error found")
+	}
+	if opError.Code == 0 && opError.Name == "" {
+		return nil, errors.New("no error code found")
+	}
+	if len(opError.Message) > MAX_ERROR_MESSAGE_LENGTH {
+		opError.Message = opError.Message[:MAX_ERROR_MESSAGE_LENGTH] + "..."
+	}
+	opError.Namespace, _ = attrMap["ns"].(string)
+	opError.Op, _ = attrMap["type"].(string)
+	if command, ok := attrMap["command"].(bson.D); ok && len(command) > 0 {
+		if opError.Op == "" || opError.Op == "command" || opError.Op == "none" {
+			opError.Op = command[0].Key
+		}
+		if db, ok := command[0].Value.(string); ok && strings.HasSuffix(opError.Namespace, ".$cmd") {
+			opError.Namespace = strings.TrimSuffix(opError.Namespace, "$cmd") + db
+		}
+	}
+	return opError, nil
+}
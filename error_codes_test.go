@@ -0,0 +1,49 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * error_codes_test.go
+ */
+
+package hatchet
+
+import (
+	"testing"
+
+	"github.com/simagix/gox"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestAnalyzeOpError(t *testing.T) {
+	strs := []string{
+		`{"t":{"$date":"2023-03-01T11:37:00.000+00:00"},"s":"I",  "c":"COMMAND",  "id":51803,   "ctx":"conn160","msg":"Slow query","attr":{"type":"command","ns":"shop.orders","command":{"find":"orders","filter":{"status":"A"},"maxTimeMS":100,"$db":"shop"},"ok":0,"errMsg":"operation exceeded time limit","errName":"MaxTimeMSExpired","errCode":50,"durationMillis":101}}`,
+		`{"t":{"$date":"2023-03-01T11:37:20.000+00:00"},"s":"I",  "c":"COMMAND",  "id":51803,   "ctx":"conn159","msg":"Slow query","attr":{"type":"command","ns":"shop.$cmd","command":{"insert":"users","ordered":true,"$db":"shop"},"ok":0,"errMsg":"E11000 duplicate key error","errName":"DuplicateKey","errCode":11000,"durationMillis":120}}`,
+		`{"t":{"$date":"2023-03-01T11:37:30.000+00:00"},"s":"I",  "c":"REPL",     "id":21402,   "ctx":"conn200","msg":"Error in heartbeat","attr":{"target":"host2:27017","error":{"code":6,"codeName":"HostUnreachable","errmsg":"Connection refused"}}}`,
+	}
+	expected := []OpError{
+		{Code: 50, Name: "MaxTimeMSExpired", Namespace: "shop.orders", Op: "find"},
+		{Code: 11000, Name: "DuplicateKey", Namespace: "shop.users", Op: "insert"},
+		{Code: 6, Name: "HostUnreachable", Message: "Connection refused"},
+	}
+	for i, str := range strs {
+		doc := Logv2Info{}
+		if err := bson.UnmarshalExtJSON([]byte(str), false, &doc); err != nil {
+			t.Fatalf("bson unmarshal error %v", err)
+		}
+		opError, err := AnalyzeOpError(&doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if opError.Code != expected[i].Code || opError.Name != expected[i].Name || opError.Namespace != expected[i].Namespace ||
+			opError.Op != expected[i].Op || (expected[i].Message != "" && opError.Message != expected[i].Message) {
+			t.Fatal("expected", gox.Stringify(expected[i]), "but got", gox.Stringify(opError))
+		}
+	}
+
+	str := `{"t":{"$date":"2023-03-01T11:37:40.000+00:00"},"s":"I",  "c":"COMMAND",  "id":51803,   "ctx":"conn1","msg":"Slow query","attr":{"type":"command","ns":"shop.orders","ok":1,"durationMillis":101}}`
+	doc := Logv2Info{}
+	if err := bson.UnmarshalExtJSON([]byte(str), false, &doc); err != nil {
+		t.Fatalf("bson unmarshal error %v", err)
+	}
+	if _, err := AnalyzeOpError(&doc); err == nil {
+		t.Fatal("expected no error found")
+	}
+}
@@ -0,0 +1,68 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * errors_template.go
+ */
+
+package hatchet
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// GetErrorCodesTemplate returns HTML
+func GetErrorCodesTemplate() (*template.Template, error) {
+	html := getContentHTML()
+	html += `{{$name := .Hatchet}}
+<script>
+	document.getElementById('nextReport').selectedIndex = {{.Report.Index}};
+</script>
+	<div style='clear: left; margin: 10px 10px;'>
+		<button class='button' onClick="javascript:location.href='/hatchets/{{$name}}/charts/errors?type=codes'; return false;">
+			<i class='fa fa-area-chart'></i> Error Codes over Time</button>
+	</div>
+{{if .ErrorCodes}}
+	<table style='float: left; margin: 10px 10px; clear: left;'>
+		<caption><span style="font-size: 16px; padding: 5px 5px;"><i class="fa fa-exclamation-triangle"></i></span>Error Codes</caption>
+		<tr><th></th><th>Code</th><th>Error</th><th>Count</th><th>Namespaces</th><th>Ops</th><th>Clients</th><th>Sample Message</th></tr>
+	{{range $n, $val := .ErrorCodes}}
+		<tr><td align=right>{{add $n 1}}</td>
+			<td align=right>
+				<button class='btn' onClick="javascript:location.href='/hatchets/{{$name}}/logs/all?errcode={{$val.Code}}'; return false;">
+					<i class='fa fa-search'></i></button>
+				<button class='btn' onClick="javascript:location.href='/hatchets/{{$name}}/charts/errors?type=codes&code={{$val.Code}}'; return false;">
+					<i class='fa fa-area-chart'></i></button>{{$val.Code}}</td>
+			<td>{{$val.Name}}</td>
+			<td align=right>{{numPrinter $val.Count}}</td>
+			<td>{{getNameValues $val.Namespaces}}</td>
+			<td>{{getNameValues $val.Ops}}</td>
+			<td>{{getNameValues $val.Clients}}</td>
+			<td class='break'>{{$val.Message}}</td>
+		</tr>
+	{{end}}
+	</table>
+{{else}}
+	<div style='clear: left;' align='center' class='btn'><span style='color: red'>no error codes found</span></div>
+{{end}}
+	<div style='clear: left;' align='center'><hr/><p/>@simagix</div>
+</body></html>`
+	return template.New("hatchet").Funcs(template.FuncMap{
+		"add": func(a int, b int) int {
+			return a + b
+		},
+		"getNameValues": func(docs []NameValue) template.HTML {
+			strs := []string{}
+			for _, doc := range docs {
+				strs = append(strs, fmt.Sprintf("%v: %v", template.HTMLEscapeString(doc.Name), doc.Value))
+			}
+			return template.HTML(strings.Join(strs, "<br/>"))
+		},
+		"numPrinter": func(n interface{}) string {
+			printer := message.NewPrinter(language.English)
+			return printer.Sprintf("%v", ToInt(n))
+		}}).Parse(html)
+}
@@ -0,0 +1,78 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * findings_template.go
+ */
+
+package hatchet
+
+import (
+	"html/template"
+	"sort"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// GetFindingsTemplate returns HTML
+func GetFindingsTemplate() (*template.Template, error) {
+	html := getContentHTML()
+	html += `{{$name := .Hatchet}}
+<script>
+	document.getElementById('nextReport').selectedIndex = {{.Report.Index}};
+</script>
+{{if .Findings}}
+	<table style='float: left; margin: 10px 10px; clear: left;'>
+		<caption><span style="font-size: 16px; padding: 5px 5px;"><i class="fa fa-exclamation-triangle"></i></span>Query Anti-Patterns</caption>
+		<tr><th></th><th>Severity</th><th>Rule</th><th>Op</th><th>Namespace</th><th>Count</th><th>Detail</th><th>Query Pattern</th></tr>
+	{{range $n, $val := .Findings}}
+		<tr><td align=right>{{add $n 1}}</td>
+			<td><span class='badge' style='background-color: {{getSeverityColor $val.Severity}};'>{{$val.Severity}}</span></td>
+			<td>{{$val.Rule}}</td>
+			<td>{{$val.Op}}</td>
+			<td>{{$val.Namespace}}</td>
+			<td align=right>{{numPrinter $val.Count}}</td>
+			<td class='break'>{{$val.Detail}}</td>
+			<td class='break'>{{$val.QueryPattern}}</td>
+		</tr>
+	{{end}}
+	</table>
+{{else}}
+	<div style='clear: left;' align='center' class='btn'><span style='color: green'>no query anti-patterns found</span></div>
+{{end}}
+	<table style='float: left; margin: 10px 10px; clear: left;'>
+		<caption><span style="font-size: 16px; padding: 5px 5px;"><i class="fa fa-list"></i></span>Rules</caption>
+		<tr><th>Rule</th><th>Severity</th></tr>
+	{{range $rule := getRules .Rules}}
+		<tr><td>{{$rule}}</td><td>{{index $.Rules $rule}}</td></tr>
+	{{end}}
+	</table>
+	<div style='clear: left;' align='center'><hr/><p/>@simagix</div>
+</body></html>`
+	return template.New("hatchet").Funcs(template.FuncMap{
+		"add": func(a int, b int) int {
+			return a + b
+		},
+		"getRules": func(rules map[string]string) []string {
+			names := []string{}
+			for name := range rules {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return names
+		},
+		"getSeverityColor": getSeverityColor,
+		"numPrinter": func(n interface{}) string {
+			printer := message.NewPrinter(language.English)
+			return printer.Sprintf("%v", ToInt(n))
+		}}).Parse(html)
+}
+
+// getSeverityColor returns the color of a severity
+func getSeverityColor(severity string) string {
+	if severity == SEVERITY_HIGH {
+		return "red"
+	} else if severity == SEVERITY_MEDIUM {
+		return "orange"
+	}
+	return "gray"
+}
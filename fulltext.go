@@ -0,0 +1,310 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * fulltext.go
+ */
+
+package hatchet
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+const (
+	FTS_AND = "AND"
+	FTS_NOT = "NOT"
+	FTS_OR  = "OR"
+)
+
+// FullTextQuery is a parsed search of terms, "phrases", prefix* terms, parentheses, and AND/OR/NOT operators.
+// Adjacent terms are implicitly ANDed, and NOT binds tighter than AND, which binds tighter than OR
+type FullTextQuery struct {
+	root *ftsNode
+}
+
+// ftsNode is an operator with operands or a term, a phrase or a prefix if op is empty
+type ftsNode struct {
+	op       string
+	text     string
+	prefix   bool
+	children []*ftsNode
+}
+
+// ftsToken is a lexical token of a search, an operator, a parenthesis, a term, or a phrase
+type ftsToken struct {
+	kind   string
+	text   string
+	prefix bool
+}
+
+// ParseFullTextQuery parses a search, e.g. "slow query" AND (orders OR users) NOT getMore
+func ParseFullTextQuery(search string) (*FullTextQuery, error) {
+	tokens, err := tokenizeFullTextQuery(search)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, NewInvalidInputError("empty search")
+	}
+	parser := &ftsParser{tokens: tokens}
+	root, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if parser.pos < len(tokens) {
+		return nil, NewInvalidInputError("unexpected %v in search", tokens[parser.pos].text)
+	}
+	return &FullTextQuery{root: root}, nil
+}
+
+// FTS5 returns the query in the FTS5 MATCH syntax with all terms quoted
+func (ptr *FullTextQuery) FTS5() string {
+	return ptr.root.fts5()
+}
+
+// LikeCondition returns a SQL condition of case-insensitive substrings of a column and its arguments
+func (ptr *FullTextQuery) LikeCondition(column string) (string, []interface{}) {
+	args := []interface{}{}
+	return ptr.root.like(column, &args), args
+}
+
+// Terms returns terms and phrases to be found, excluding negated ones
+func (ptr *FullTextQuery) Terms() []string {
+	terms := []string{}
+	ptr.root.collectTerms(&terms)
+	return terms
+}
+
+// GetSearchRegexps returns case-insensitive patterns of matched terms of a search, or of the search itself
+// if it is not a valid query
+func GetSearchRegexps(search string) []*regexp.Regexp {
+	query, err := ParseFullTextQuery(search)
+	if err != nil {
+		return []*regexp.Regexp{regexp.MustCompile("(?i)" + regexp.QuoteMeta(search))}
+	}
+	regexps := []*regexp.Regexp{}
+	for _, term := range query.Terms() {
+		regexps = append(regexps, regexp.MustCompile("(?i)"+getTermPattern(term)))
+	}
+	return regexps
+}
+
+// getTermPattern returns a pattern of a term, of which words of a phrase are separated by any punctuations
+func getTermPattern(term string) string {
+	words := strings.FieldsFunc(term, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+	if len(words) == 0 {
+		return regexp.QuoteMeta(term)
+	}
+	for i, word := range words {
+		words[i] = regexp.QuoteMeta(word)
+	}
+	return strings.Join(words, `[^\pL\pN_]+`)
+}
+
+func tokenizeFullTextQuery(search string) ([]ftsToken, error) {
+	tokens := []ftsToken{}
+	runes := []rune(search)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		if unicode.IsSpace(r) {
+			i++
+		} else if r == '(' || r == ')' {
+			tokens = append(tokens, ftsToken{kind: string(r), text: string(r)})
+			i++
+		} else if r == '"' {
+			var buf strings.Builder
+			i++
+			closed := false
+			for i < len(runes) {
+				if runes[i] == '"' && i+1 < len(runes) && runes[i+1] == '"' { // escaped quote
+					buf.WriteRune('"')
+					i += 2
+				} else if runes[i] == '"' {
+					closed = true
+					i++
+					break
+				} else {
+					buf.WriteRune(runes[i])
+					i++
+				}
+			}
+			if !closed {
+				return tokens, NewInvalidInputError("unterminated phrase in search")
+			}
+			token := ftsToken{kind: "phrase", text: buf.String()}
+			if i < len(runes) && runes[i] == '*' {
+				token.prefix = true
+				i++
+			}
+			if strings.TrimSpace(token.text) == "" {
+				return tokens, NewInvalidInputError("empty phrase in search")
+			}
+			tokens = append(tokens, token)
+		} else {
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && runes[i] != '(' && runes[i] != ')' && runes[i] != '"' {
+				i++
+			}
+			word := string(runes[start:i])
+			if word == FTS_AND || word == FTS_OR || word == FTS_NOT {
+				tokens = append(tokens, ftsToken{kind: "op", text: word})
+				continue
+			}
+			token := ftsToken{kind: "term", text: strings.TrimRight(word, "*")}
+			token.prefix = token.text != word
+			if token.text == "" {
+				return tokens, NewInvalidInputError("invalid term %v in search", word)
+			}
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens, nil
+}
+
+// ftsParser is a recursive descent parser of search tokens
+type ftsParser struct {
+	tokens []ftsToken
+	pos    int
+}
+
+func (ptr *ftsParser) peek() *ftsToken {
+	if ptr.pos < len(ptr.tokens) {
+		return &ptr.tokens[ptr.pos]
+	}
+	return nil
+}
+
+func (ptr *ftsParser) parseOr() (*ftsNode, error) {
+	left, err := ptr.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for token := ptr.peek(); token != nil && token.kind == "op" && token.text == FTS_OR; token = ptr.peek() {
+		ptr.pos++
+		right, err := ptr.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = newFTSNode(FTS_OR, left, right)
+	}
+	return left, nil
+}
+
+func (ptr *ftsParser) parseAnd() (*ftsNode, error) {
+	left, err := ptr.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for token := ptr.peek(); token != nil; token = ptr.peek() {
+		if token.kind == "op" && token.text == FTS_AND {
+			ptr.pos++
+		} else if token.kind != "term" && token.kind != "phrase" && token.kind != "(" {
+			break
+		}
+		right, err := ptr.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = newFTSNode(FTS_AND, left, right)
+	}
+	return left, nil
+}
+
+func (ptr *ftsParser) parseNot() (*ftsNode, error) {
+	left, err := ptr.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for token := ptr.peek(); token != nil && token.kind == "op" && token.text == FTS_NOT; token = ptr.peek() {
+		ptr.pos++
+		right, err := ptr.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		left = &ftsNode{op: FTS_NOT, children: []*ftsNode{left, right}}
+	}
+	return left, nil
+}
+
+func (ptr *ftsParser) parsePrimary() (*ftsNode, error) {
+	token := ptr.peek()
+	if token == nil {
+		return nil, NewInvalidInputError("incomplete search")
+	}
+	ptr.pos++
+	if token.kind == "term" || token.kind == "phrase" {
+		return &ftsNode{text: token.text, prefix: token.prefix}, nil
+	} else if token.kind == "(" {
+		node, err := ptr.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if next := ptr.peek(); next == nil || next.kind != ")" {
+			return nil, NewInvalidInputError("missing ) in search")
+		}
+		ptr.pos++
+		return node, nil
+	}
+	return nil, NewInvalidInputError("unexpected %v in search", token.text)
+}
+
+// newFTSNode returns an AND or OR node, of which operands of the same operator are flattened
+func newFTSNode(op string, left *ftsNode, right *ftsNode) *ftsNode {
+	node := &ftsNode{op: op}
+	for _, child := range []*ftsNode{left, right} {
+		if child.op == op {
+			node.children = append(node.children, child.children...)
+		} else {
+			node.children = append(node.children, child)
+		}
+	}
+	return node
+}
+
+func (ptr *ftsNode) fts5() string {
+	if ptr.op == "" {
+		str := `"` + strings.ReplaceAll(ptr.text, `"`, `""`) + `"`
+		if ptr.prefix {
+			str += "*"
+		}
+		return str
+	}
+	operands := []string{}
+	for _, child := range ptr.children {
+		operands = append(operands, child.fts5())
+	}
+	return "(" + strings.Join(operands, " "+ptr.op+" ") + ")"
+}
+
+func (ptr *ftsNode) like(column string, args *[]interface{}) string {
+	if ptr.op == "" {
+		replacer := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+		*args = append(*args, "%"+replacer.Replace(ptr.text)+"%")
+		return fmt.Sprintf(`%v LIKE ? ESCAPE '\'`, column)
+	} else if ptr.op == FTS_NOT {
+		return fmt.Sprintf("(%v AND NOT %v)", ptr.children[0].like(column, args), ptr.children[1].like(column, args))
+	}
+	conds := []string{}
+	for _, child := range ptr.children {
+		conds = append(conds, child.like(column, args))
+	}
+	return "(" + strings.Join(conds, " "+ptr.op+" ") + ")"
+}
+
+func (ptr *ftsNode) collectTerms(terms *[]string) {
+	if ptr.op == "" {
+		*terms = append(*terms, ptr.text)
+		return
+	}
+	children := ptr.children
+	if ptr.op == FTS_NOT {
+		children = children[:1]
+	}
+	for _, child := range children {
+		child.collectTerms(terms)
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2022-present Kuei-chun Chen. All rights reserved.
+
+package hatchet
+
+import (
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFullTextQuery(t *testing.T) {
+	tests := map[string]string{
+		`slow query`:                         `("slow" AND "query")`,
+		`"slow query" AND (orders OR users)`: `("slow query" AND ("orders" OR "users"))`,
+		`getMore NOT test.orders`:            `("getMore" NOT "test.orders")`,
+		`a OR b c NOT d`:                     `("a" OR ("b" AND ("c" NOT "d")))`,
+		`COLL* "say ""hi"""*`:                `("COLL"* AND "say ""hi"""*)`,
+		`(conn12 OR conn13) AND COMMAND`:     `(("conn12" OR "conn13") AND "COMMAND")`,
+		`x AND (y OR (z NOT w)) OR "v"`:      `(("x" AND ("y" OR ("z" NOT "w"))) OR "v")`,
+		`  spaces  `:                         `"spaces"`,
+	}
+	for search, expected := range tests {
+		query, err := ParseFullTextQuery(search)
+		if err != nil {
+			t.Fatal(search, err)
+		}
+		if query.FTS5() != expected {
+			t.Fatal("expected", expected, "but got", query.FTS5())
+		}
+	}
+	for _, search := range []string{"", `"open`, `""`, "AND x", "x OR", "(x", "x)", "x NOT", "*", "()"} {
+		if _, err := ParseFullTextQuery(search); !IsInvalidInput(err) {
+			t.Fatal("expected invalid input of", search, "but got", err)
+		}
+	}
+}
+
+func TestFullTextQueryConditions(t *testing.T) {
+	query, err := ParseFullTextQuery(`"slow query" (orders OR 100%) NOT getMore`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cond, args := query.LikeCondition("message")
+	expected := `(message LIKE ? ESCAPE '\' AND ((message LIKE ? ESCAPE '\' OR message LIKE ? ESCAPE '\') AND NOT message LIKE ? ESCAPE '\'))`
+	if cond != expected {
+		t.Fatal("expected", expected, "but got", cond)
+	}
+	if !reflect.DeepEqual(args, []interface{}{"%slow query%", "%orders%", `%100\%%`, "%getMore%"}) {
+		t.Fatal("unexpected", args)
+	}
+	if terms := query.Terms(); !reflect.DeepEqual(terms, []string{"slow query", "orders", "100%"}) {
+		t.Fatal("unexpected", terms)
+	}
+}
+
+func TestHighlightLogSearch(t *testing.T) {
+	log := `Slow query {"ns":"test.orders","planSummary":"COLLSCAN"} 120ms`
+	html := highlightLog(log, "test.orders OR mark NOT users")
+	if !strings.Contains(html, "<mark>test.orders</mark>") || strings.Contains(html, "<<mark>") {
+		t.Fatal("unexpected", html)
+	}
+	if html = highlightLog("a+b (c", "a+b (c"); html != "<mark>a+b (c</mark>" {
+		t.Fatal("expected", "<mark>a+b (c</mark>", "but got", html)
+	}
+}
+
+func TestSearchLogs(t *testing.T) {
+	dbfile := filepath.Join(t.TempDir(), "hatchet.db")
+	db, err := sql.Open("sqlite3", dbfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	sqlite := &SQLite3DB{db: newSQLite3Pool(db), hatchetName: "mongod_1b3d5f"}
+	if _, err = db.Exec(sqlite.GetHatchetInitStmt()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = db.Exec(`INSERT INTO mongod_1b3d5f (id, date, severity, component, context, message) VALUES
+		(1, '', 'I', 'COMMAND', 'conn1', 'Slow query find test.orders'),
+		(2, '', 'I', 'COMMAND', 'conn1', 'Slow query getMore test.orders'),
+		(3, '', 'I', 'COMMAND', 'conn2', 'Slow query find test.users'),
+		(4, '', 'D', 'NETWORK', 'conn2', 'Connection ended')`); err != nil {
+		t.Fatal(err)
+	}
+	for _, indexed := range []bool{false, true} {
+		if indexed {
+			if err = sqlite.createFullTextIndex(); err != nil {
+				t.Fatal(err)
+			}
+			if ok, _ := sqlite.HasFullTextIndex(); !ok {
+				t.Skip("FTS5 not available, built without the sqlite_fts5 tag")
+			}
+		}
+		if notice := getSearchNotice(sqlite, "find"); (notice == "") != indexed {
+			t.Fatal("unexpected notice", indexed, notice)
+		}
+		docs, err := sqlite.SearchLogs(`context="test.orders" NOT getMore OR conn*`, "severity=I")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(docs) != 1 || docs[0].Message != "Slow query find test.orders" {
+			t.Fatal("unexpected", indexed, docs)
+		}
+		if _, err = sqlite.SearchLogs(`context=(find`); !IsInvalidInput(err) {
+			t.Fatal("expected invalid input but got", err)
+		}
+	}
+}
@@ -15,9 +15,46 @@ const (
 	REST_API_PREFIX = "/api/hatchet/v1.0/hatchets/"
 )
 
+// Server serves web pages and APIs of hatchets of a database
+type Server struct {
+	opts Options
+}
+
+// NewServer returns a Server of database options
+func NewServer(opts Options) *Server {
+	return &Server{opts: opts}
+}
+
+// validateRequest returns an InvalidInputError if the hatchet name or the duration of a request is invalid
+func validateRequest(hatchetName string, r *http.Request) error {
+	if err := ValidateHatchetName(hatchetName); err != nil {
+		return err
+	}
+	if duration := r.URL.Query().Get("duration"); duration != "" {
+		if _, err := ParseDuration(duration); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeError writes an error, with status 400 if it is caused by invalid request parameters, or 503 if
+// the data file is busy and the request can be retried
+func writeError(w http.ResponseWriter, err error) {
+	if IsInvalidInput(err) {
+		w.WriteHeader(http.StatusBadRequest)
+	} else if IsBusy(err) {
+		w.Header().Set("Retry-After", "1")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": "database is busy, retry later"})
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+}
+
 // Handler responds to API calls
-func Handler(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
-	dbase, err := GetDatabase(GetLogv2().hatchetName) // main page
+func (ptr *Server) Handler(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+	dbase, err := GetDatabase(ptr.opts, "") // main page
 	if err != nil {
 		json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
 		return
@@ -31,12 +68,17 @@ func Handler(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
 		json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
 		return
 	}
+	statuses, err := dbase.GetHatchetStatuses()
+	if err != nil {
+		json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+		return
+	}
 	templ, err := GetTablesTemplate()
 	if err != nil {
 		json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
 		return
 	}
-	doc := map[string]interface{}{"Hatchets": hatchets, "Version": GetLogv2().version}
+	doc := map[string]interface{}{"Hatchets": hatchets, "Statuses": statuses, "Version": ptr.opts.Version}
 	if err = templ.Execute(w, doc); err != nil {
 		json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
 		return
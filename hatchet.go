@@ -13,7 +13,9 @@ import (
 	"log"
 	"net"
 	"net/http"
+	"os"
 	"regexp"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/mattn/go-sqlite3"
@@ -22,6 +24,8 @@ import (
 const SQLITE3_FILE = "./data/hatchet.db"
 
 func Run(fullVersion string) {
+	cmd := flag.String("cmd", "", "manage hatchets, i.e. list, info, rename, delete, pin, unpin, purge or vacuum")
+	dburi := flag.String("db", "", "MongoDB connection string, i.e. mongodb://localhost/hatchet")
 	dbfile := flag.String("dbfile", SQLITE3_FILE, "database file name")
 	digest := flag.Bool("digest", false, "HTTP digest")
 	endpoint := flag.String("endpoint-url", "", "AWS endpoint")
@@ -29,6 +33,8 @@ func Run(fullVersion string) {
 	legacy := flag.Bool("legacy", false, "view logs in legacy format")
 	port := flag.Int("port", 3721, "web server port number")
 	profile := flag.String("aws-profile", "default", "AWS profile name")
+	retentionDays := flag.Int("retention-days", 0, "purge hatchets analyzed more than days ago")
+	retentionSize := flag.String("retention-size", "", "purge oldest hatchets beyond a total size, i.e. 20GB")
 	s3 := flag.Bool("s3", false, "files from AWS S3")
 	user := flag.String("user", "", "HTTP Auth (username:password)")
 	ver := flag.Bool("version", false, "print version number")
@@ -46,8 +52,13 @@ func Run(fullVersion string) {
 		log.Println(fullVersion)
 	}
 
+	if *dburi != "" && !IsMongoURI(*dburi) {
+		log.Fatalln("invalid MongoDB connection string", *dburi)
+	}
 	if *inMem {
-		if *dbfile != SQLITE3_FILE {
+		if *dburi != "" {
+			log.Fatalln("cannot use -db and -in-memory together")
+		} else if *dbfile != SQLITE3_FILE {
 			log.Fatalln("cannot use -dbfile and -in-memory together")
 		} else if len(flag.Args()) == 0 {
 			log.Fatalln("cannot use -in-memory without a log file")
@@ -66,18 +77,38 @@ func Run(fullVersion string) {
 				return conn.RegisterFunc("regexp", regex, true)
 			},
 		})
-	logv2 := Logv2{version: fullVersion, dbfile: *dbfile, verbose: *verbose, legacy: *legacy, user: *user, isDigest: *digest}
-	if *s3 {
+	opts := Options{DBFile: *dbfile, DBURI: *dburi, Verbose: *verbose, Version: fullVersion}
+	opts.Retention.MaxAge = time.Duration(*retentionDays) * 24 * time.Hour
+	if *retentionSize != "" {
 		var err error
-		if logv2.s3client, err = NewS3Client(*profile, *endpoint); err != nil {
+		if opts.Retention.MaxSize, err = ParseBytes(*retentionSize); err != nil {
 			log.Fatal(err)
 		}
 	}
-	instance = &logv2
+	defer CloseSQLite3Pools() // checkpoints write-ahead logs into data files on returns
+	CloseSQLite3PoolsOnSignal()
+	var s3client *S3Client
+	if *s3 {
+		var err error
+		if s3client, err = NewS3Client(*profile, *endpoint); err != nil {
+			fatal(err)
+		}
+	}
+	if *cmd != "" {
+		if err := RunHatchetCommand(opts, *cmd, flag.Args(), os.Stdout); err != nil {
+			fatal(err)
+		}
+		return
+	}
+	if _, err := PurgeHatchets(opts, true); err != nil {
+		fatal(err)
+	}
 	for _, filename := range flag.Args() {
+		logv2 := NewLogv2(opts)
+		logv2.legacy, logv2.user, logv2.isDigest, logv2.s3client = *legacy, *user, *digest, s3client
 		err := logv2.Analyze(filename)
 		if err != nil {
-			log.Fatal(err)
+			fatal(err)
 		}
 	}
 	if *legacy || !*web {
@@ -87,29 +118,46 @@ func Run(fullVersion string) {
 		return
 	}
 
+	if opts.Retention.IsEnabled() {
+		go PurgeHatchetsPeriodically(opts, RETENTION_INTERVAL)
+	}
+	server := NewServer(opts)
 	router := httprouter.New()
-	router.GET("/", Handler)
+	router.GET("/", server.Handler)
 	router.GET("/favicon.ico", FaviconHandler)
 
-	router.GET("/api/hatchet/v1.0/hatchets/:hatchet/:category/:attr", APIHandler)
+	router.GET("/api/hatchet/v1.0/hatchets", server.HatchetsHandler)
+	router.GET("/api/hatchet/v1.0/hatchets/:hatchet", server.HatchetsHandler)
+	router.DELETE("/api/hatchet/v1.0/hatchets/:hatchet", server.HatchetsHandler)
+	router.PATCH("/api/hatchet/v1.0/hatchets/:hatchet", server.HatchetsHandler)
+	router.POST("/api/hatchet/v1.0/vacuum", server.HatchetsHandler)
+	router.GET("/api/hatchet/v1.0/hatchets/:hatchet/:category/:attr", server.APIHandler)
 
-	router.GET("/hatchets/:hatchet/charts/:attr", ChartsHandler)
-	router.GET("/hatchets/:hatchet/logs/:attr", LogsHandler)
-	router.GET("/hatchets/:hatchet/stats/:attr", StatsHandler)
+	router.GET("/hatchets/:hatchet/charts/:attr", server.ChartsHandler)
+	router.GET("/hatchets/:hatchet/logs/:attr", server.LogsHandler)
+	router.GET("/hatchets/:hatchet/stats/:attr", server.StatsHandler)
 
 	addr := fmt.Sprintf(":%d", *port)
 	if listener, err := net.Listen("tcp", addr); err != nil {
-		log.Fatal(err)
+		fatal(err)
 	} else {
 		listener.Close()
-		if !*inMem {
+		if *dburi != "" {
+			log.Println("using MongoDB database")
+		} else if !*inMem {
 			log.Println("using data file", *dbfile)
 		}
 		log.Println("starting web server at", addr)
-		log.Fatal(http.ListenAndServe(addr, router))
+		fatal(http.ListenAndServe(addr, router))
 	}
 }
 
+// fatal closes all pools to checkpoint write-ahead logs, as log.Fatal exits without running deferred calls
+func fatal(v ...interface{}) {
+	CloseSQLite3Pools()
+	log.Fatal(v...)
+}
+
 func FaviconHandler(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
 	r.Close = true
 	r.Header.Set("Connection", "close")
@@ -0,0 +1,220 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * hatchets.go
+ */
+
+package hatchet
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+const (
+	CMD_DELETE = "delete"
+	CMD_INFO   = "info"
+	CMD_LIST   = "list"
+	CMD_PIN    = "pin"
+	CMD_PURGE  = "purge"
+	CMD_RENAME = "rename"
+	CMD_UNPIN  = "unpin"
+	CMD_VACUUM = "vacuum"
+)
+
+// HatchetUsage is the summary and the disk usage of a hatchet
+type HatchetUsage struct {
+	Name     string
+	Start    string
+	End      string
+	Version  string
+	Size     int64
+	Status   string
+	Summary  string
+	Analyzed string
+	Pinned   bool
+}
+
+// GetHatchetUsages returns summaries and disk usages of all hatchets
+func GetHatchetUsages(opts Options) ([]HatchetUsage, error) {
+	usages := []HatchetUsage{}
+	dbase, err := GetDatabase(opts, "")
+	if err != nil {
+		return usages, err
+	}
+	defer dbase.Close()
+	names, err := dbase.GetHatchetNames()
+	if err != nil {
+		return usages, err
+	}
+	statuses, err := dbase.GetHatchetStatuses()
+	if err != nil {
+		return usages, err
+	}
+	for _, name := range names {
+		usage, err := getHatchetUsage(opts, name, statuses[name])
+		if err != nil {
+			return usages, err
+		}
+		usages = append(usages, usage)
+	}
+	return usages, err
+}
+
+// GetHatchetUsage returns the summary and the disk usage of a hatchet
+func GetHatchetUsage(opts Options, hatchetName string) (HatchetUsage, error) {
+	if err := ValidateHatchetName(hatchetName); err != nil {
+		return HatchetUsage{Name: hatchetName}, err
+	}
+	dbase, err := GetDatabase(opts, hatchetName)
+	if err != nil {
+		return HatchetUsage{Name: hatchetName}, err
+	}
+	defer dbase.Close()
+	statuses, err := dbase.GetHatchetStatuses()
+	if err != nil {
+		return HatchetUsage{Name: hatchetName}, err
+	}
+	if _, ok := statuses[hatchetName]; !ok {
+		return HatchetUsage{Name: hatchetName}, NewInvalidInputError("hatchet %v not found", hatchetName)
+	}
+	return getHatchetUsage(opts, hatchetName, statuses[hatchetName])
+}
+
+func getHatchetUsage(opts Options, hatchetName string, status string) (HatchetUsage, error) {
+	usage := HatchetUsage{Name: hatchetName, Status: status}
+	dbase, err := GetDatabase(opts, hatchetName)
+	if err != nil {
+		return usage, err
+	}
+	defer dbase.Close()
+	info := dbase.GetHatchetInfo()
+	usage.Start, usage.End, usage.Version = info.Start, info.End, info.Version
+	usage.Analyzed, usage.Pinned = info.Analyzed, info.Pinned
+	usage.Summary = GetHatchetSummary(info)
+	usage.Size, err = dbase.GetHatchetSize()
+	return usage, err
+}
+
+// DeleteHatchet drops all tables of a hatchet
+func DeleteHatchet(opts Options, hatchetName string) error {
+	if err := ValidateHatchetName(hatchetName); err != nil {
+		return err
+	}
+	dbase, err := GetDatabase(opts, hatchetName)
+	if err != nil {
+		return err
+	}
+	defer dbase.Close()
+	return dbase.DeleteHatchet()
+}
+
+// RenameHatchet renames all tables of a hatchet
+func RenameHatchet(opts Options, hatchetName string, name string) error {
+	if err := ValidateHatchetName(hatchetName); err != nil {
+		return err
+	}
+	dbase, err := GetDatabase(opts, hatchetName)
+	if err != nil {
+		return err
+	}
+	defer dbase.Close()
+	return dbase.RenameHatchet(name)
+}
+
+// PinHatchet sets whether a hatchet is kept regardless of the retention policy
+func PinHatchet(opts Options, hatchetName string, pinned bool) error {
+	if err := ValidateHatchetName(hatchetName); err != nil {
+		return err
+	}
+	dbase, err := GetDatabase(opts, hatchetName)
+	if err != nil {
+		return err
+	}
+	defer dbase.Close()
+	return dbase.PinHatchet(pinned)
+}
+
+// Vacuum reclaims disk space of deleted hatchets
+func Vacuum(opts Options) error {
+	dbase, err := GetDatabase(opts, "")
+	if err != nil {
+		return err
+	}
+	defer dbase.Close()
+	return dbase.Vacuum()
+}
+
+// RunHatchetCommand runs a command, i.e. list, delete {hatchet}..., rename {hatchet} {name}, info {hatchet},
+// pin {hatchet}..., unpin {hatchet}..., purge or vacuum, and prints results
+func RunHatchetCommand(opts Options, cmd string, args []string, out io.Writer) error {
+	if cmd == CMD_LIST {
+		usages, err := GetHatchetUsages(opts)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tSTART\tEND\tVERSION\tSIZE\tANALYZED\tPINNED\tSTATUS")
+		for _, usage := range usages {
+			fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\n", usage.Name, usage.Start, usage.End, usage.Version,
+				FormatBytes(usage.Size), usage.Analyzed, usage.Pinned, usage.Status)
+		}
+		return w.Flush()
+	} else if cmd == CMD_INFO && len(args) == 1 {
+		usage, err := GetHatchetUsage(opts, args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, usage.Summary)
+		fmt.Fprintln(out, "period:", usage.Start, "-", usage.End)
+		fmt.Fprintln(out, "size:", FormatBytes(usage.Size))
+		fmt.Fprintln(out, "analyzed:", usage.Analyzed)
+		if usage.Pinned {
+			fmt.Fprintln(out, "pinned: true")
+		}
+		if usage.Status != "" {
+			fmt.Fprintln(out, "status:", usage.Status)
+		}
+		return nil
+	} else if cmd == CMD_DELETE && len(args) > 0 {
+		for _, hatchetName := range args {
+			if err := DeleteHatchet(opts, hatchetName); err != nil {
+				return err
+			}
+			fmt.Fprintln(out, "hatchet", hatchetName, "deleted")
+		}
+		return nil
+	} else if cmd == CMD_RENAME && len(args) == 2 {
+		if err := RenameHatchet(opts, args[0], args[1]); err != nil {
+			return err
+		}
+		fmt.Fprintln(out, "hatchet", args[0], "renamed to", args[1])
+		return nil
+	} else if (cmd == CMD_PIN || cmd == CMD_UNPIN) && len(args) > 0 {
+		for _, hatchetName := range args {
+			if err := PinHatchet(opts, hatchetName, cmd == CMD_PIN); err != nil {
+				return err
+			}
+			fmt.Fprintln(out, "hatchet", hatchetName, cmd+"ned")
+		}
+		return nil
+	} else if cmd == CMD_PURGE {
+		if !opts.Retention.IsEnabled() {
+			return NewInvalidInputError("retention policy not set, use -retention-days or -retention-size")
+		}
+		purged, err := PurgeHatchets(opts, true)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, len(purged), "hatchet(s) purged")
+		return nil
+	} else if cmd == CMD_VACUUM {
+		if err := Vacuum(opts); err != nil {
+			return err
+		}
+		fmt.Fprintln(out, "database vacuumed")
+		return nil
+	}
+	return NewInvalidInputError("usage: hatchet -cmd [list | info {hatchet} | delete {hatchet}... | " +
+		"rename {hatchet} {name} | pin {hatchet}... | unpin {hatchet}... | purge | vacuum]")
+}
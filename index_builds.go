@@ -0,0 +1,198 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * index_builds.go
+ */
+
+package hatchet
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	INDEX_BUILD_ABORTED     = "aborted"
+	INDEX_BUILD_COMMITTED   = "committed"
+	INDEX_BUILD_IN_PROGRESS = "in progress"
+	INDEX_BUILD_RESTARTED   = "restarted"
+
+	PHASE_BULK_LOAD     = "bulk load"
+	PHASE_COLLSCAN      = "collection scan"
+	PHASE_COMMIT_QUORUM = "commit quorum"
+	PHASE_DRAIN         = "drain side writes"
+)
+
+// IndexBuild stores an index build from start to commit or abort
+type IndexBuild struct {
+	BuildUUID    string         `json:"build_uuid"`
+	CommitQuorum string         `json:"commit_quorum"`
+	End          string         `json:"end"`
+	Error        string         `json:"error"`
+	Indexes      string         `json:"indexes"`
+	Keys         string         `json:"keys"`
+	Method       string         `json:"method"`
+	Milli        int            `json:"duration_ms"`
+	Namespace    string         `json:"ns"`
+	Phases       map[string]int `json:"phases"` // milliseconds of each phase
+	Start        string         `json:"start"`
+	Status       string         `json:"status"`
+
+	index   int       // log line of index build started
+	start   time.Time // time of index build started
+	waiting time.Time // time of waiting for commit quorum
+}
+
+// IndexBuildTracker links index build logs by build UUID
+type IndexBuildTracker struct {
+	builds map[string]*IndexBuild
+}
+
+// NewIndexBuildTracker returns an IndexBuildTracker
+func NewIndexBuildTracker() *IndexBuildTracker {
+	return &IndexBuildTracker{builds: map[string]*IndexBuild{}}
+}
+
+// Analyze tracks a log and returns index builds committed or aborted by it
+func (ptr *IndexBuildTracker) Analyze(index int, doc *Logv2Info) []*IndexBuild {
+	if doc.Component == "CONTROL" && doc.Msg == "MongoDB starting" {
+		return ptr.Close(getDateTimeStr(doc.Timestamp), INDEX_BUILD_RESTARTED)
+	}
+	if doc.Component != "INDEX" || !strings.HasPrefix(doc.Msg, "Index build: ") {
+		return nil
+	}
+	attrMap := doc.Attr.Map()
+	uuid := ""
+	if buildUUID, ok := attrMap["buildUUID"].(bson.D); ok {
+		uuid = getUUIDString(buildUUID.Map()["uuid"])
+	}
+	if uuid == "" { // some phases only log namespace and index name
+		uuid = ptr.findBuildUUID(attrMap)
+	}
+	if uuid == "" {
+		return nil
+	}
+	msg := doc.Msg[len("Index build: "):]
+	ended := strings.HasPrefix(msg, "completed") || strings.HasPrefix(msg, "failed") || strings.HasPrefix(msg, "aborted")
+	build, ok := ptr.builds[uuid]
+	if !ok && ended { // already ended or started before the log
+		return nil
+	} else if !ok {
+		build = &IndexBuild{BuildUUID: uuid, Start: getDateTimeStr(doc.Timestamp), Status: INDEX_BUILD_IN_PROGRESS,
+			Phases: map[string]int{}, index: index, start: doc.Timestamp}
+		ptr.builds[uuid] = build
+	}
+	if ns, ok := attrMap["namespace"].(string); ok && build.Namespace == "" {
+		build.Namespace = ns
+	}
+	milli := ToInt(attrMap["durationMillis"])
+	if strings.HasPrefix(msg, "registering") {
+		if command, ok := attrMap["command"].(bson.D); ok && command.Map()["commitQuorum"] != nil {
+			build.CommitQuorum = fmt.Sprintf("%v", command.Map()["commitQuorum"])
+		}
+	} else if strings.HasPrefix(msg, "starting") {
+		build.Method, _ = attrMap["method"].(string)
+		if properties, ok := attrMap["properties"].(bson.D); ok {
+			propMap := properties.Map()
+			build.Indexes = appendString(build.Indexes, fmt.Sprintf("%v", propMap["name"]), ", ")
+			if key, ok := propMap["key"].(bson.D); ok {
+				build.Keys = appendString(build.Keys, getKeyString(key), "; ")
+			}
+		}
+	} else if strings.HasPrefix(msg, "collection scan done") {
+		build.Phases[PHASE_COLLSCAN] += milli
+	} else if strings.HasPrefix(msg, "inserted keys from external sorter") {
+		build.Phases[PHASE_BULK_LOAD] += milli
+	} else if strings.HasPrefix(msg, "drain") {
+		build.Phases[PHASE_DRAIN] += milli
+	} else if strings.HasPrefix(msg, "waiting for next action") || strings.HasPrefix(msg, "waiting for commit quorum") {
+		build.waiting = doc.Timestamp
+	} else if strings.HasPrefix(msg, "received signal") || strings.HasPrefix(msg, "commit quorum satisfied") {
+		if !build.waiting.IsZero() {
+			build.Phases[PHASE_COMMIT_QUORUM] += int(doc.Timestamp.Sub(build.waiting).Milliseconds())
+			build.waiting = time.Time{}
+		}
+	} else if strings.HasPrefix(msg, "done building") {
+		if name, ok := attrMap["index"].(string); ok && !hasIndexName(build.Indexes, name) {
+			build.Indexes = appendString(build.Indexes, name, ", ")
+		}
+	} else if ended {
+		build.Status = INDEX_BUILD_COMMITTED
+		if !strings.HasPrefix(msg, "completed") {
+			build.Status = INDEX_BUILD_ABORTED
+			build.Error = getErrorString(attrMap["error"])
+		}
+		build.End = getDateTimeStr(doc.Timestamp)
+		build.Milli = int(doc.Timestamp.Sub(build.start).Milliseconds())
+		delete(ptr.builds, uuid)
+		return []*IndexBuild{build}
+	}
+	return nil
+}
+
+// Close returns all index builds not yet completed with a status and elapsed time
+func (ptr *IndexBuildTracker) Close(date string, status string) []*IndexBuild {
+	builds := []*IndexBuild{}
+	for _, build := range ptr.builds {
+		build.Status = status
+		build.Milli = getMilliseconds(build.Start, date) // elapsed so far if still in progress
+		if status != INDEX_BUILD_IN_PROGRESS {
+			build.End = date
+		}
+		builds = append(builds, build)
+	}
+	sort.Slice(builds, func(i, j int) bool {
+		return builds[i].index < builds[j].index
+	})
+	ptr.builds = map[string]*IndexBuild{}
+	return builds
+}
+
+// findBuildUUID returns the build UUID of an index build in progress by namespace and index name
+func (ptr *IndexBuildTracker) findBuildUUID(attrMap map[string]interface{}) string {
+	ns, _ := attrMap["namespace"].(string)
+	name, _ := attrMap["index"].(string)
+	for uuid, build := range ptr.builds {
+		if build.Namespace == ns && hasIndexName(build.Indexes, name) {
+			return uuid
+		}
+	}
+	return ""
+}
+
+// getKeyString returns an index key in relaxed extended JSON, e.g. {"a":1}
+func getKeyString(key bson.D) string {
+	b, err := bson.MarshalExtJSON(key, false, false)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// hasIndexName returns true if a name is in a comma separated index names
+func hasIndexName(indexes string, name string) bool {
+	return name != "" && strings.Contains(", "+indexes+", ", ", "+name+", ")
+}
+
+func appendString(str string, value string, sep string) string {
+	if str == "" {
+		return value
+	}
+	return str + sep + value
+}
+
+// getIndexBuildOpCounts returns index builds as bubbles at their start times to overlay on ops charts
+func getIndexBuildOpCounts(builds []IndexBuild) []OpCount {
+	docs := []OpCount{}
+	for _, build := range builds {
+		if len(build.Start) < 19 {
+			continue
+		}
+		docs = append(docs, OpCount{Date: build.Start[:19], Count: 1, Milli: float64(build.Milli),
+			Op: "index build", Namespace: fmt.Sprintf("%v %v (%v)", build.Namespace, build.Indexes, build.Status)})
+	}
+	return docs
+}
@@ -0,0 +1,86 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * index_builds_template.go
+ */
+
+package hatchet
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+)
+
+// GetIndexBuildsTemplate returns HTML
+func GetIndexBuildsTemplate() (*template.Template, error) {
+	html := getContentHTML()
+	html += `{{$name := .Hatchet}}{{$end := .End}}
+<script>
+	document.getElementById('nextReport').selectedIndex = {{.Report.Index}};
+</script>
+	<div style='clear: left; margin: 10px 10px;'>
+		<button class='button' onClick="javascript:location.href='/hatchets/{{$name}}/charts/ops?type=stats'; return false;">
+			<i class='fa fa-area-chart'></i> Average Operation Time with Index Builds</button>
+	</div>
+{{if .IndexBuilds}}
+	<table style='float: left; margin: 10px 10px; clear: left;'>
+		<caption><span style="font-size: 16px; padding: 5px 5px;"><i class="fa fa-wrench"></i></span>Index Builds</caption>
+		<tr><th></th><th>Namespace</th><th>Indexes</th><th>Started</th><th>Duration</th><th>Status</th>
+			<th>Commit Quorum</th><th>Phases</th></tr>
+	{{range $n, $val := .IndexBuilds}}
+		<tr><td align=right>{{add $n 1}}</td>
+			<td><button class='btn' onClick="javascript:location.href='/hatchets/{{$name}}/logs/all?component=INDEX&duration={{getBuildDuration $val $end}}'; return false;">
+				<i class='fa fa-search'></i></button>{{$val.Namespace}}</td>
+			<td>{{$val.Indexes}}<br/><span style='font-size: 0.9em;'>{{$val.Keys}}</span></td>
+			<td>{{formatDateTime $val.Start}}</td>
+			<td align=right>{{getDurationFromMillis $val.Milli}}</td>
+		{{if eq $val.Status "committed"}}
+			<td>{{$val.Status}}</td>
+		{{else if eq $val.Status "aborted"}}
+			<td><span style='color: red;'>{{$val.Status}}</span><br/><span style='font-size: 0.9em;'>{{$val.Error}}</span></td>
+		{{else}}
+			<td><span style='color: orange;'>{{$val.Status}}</span></td>
+		{{end}}
+			<td>{{$val.CommitQuorum}}</td>
+			<td>{{getPhases $val.Phases}}</td>
+		</tr>
+	{{end}}
+	</table>
+{{else}}
+	<div style='clear: left;' align='center' class='btn'><span style='color: red'>no index builds found</span></div>
+{{end}}
+	<div style='clear: left;' align='center'><hr/><p/>@simagix</div>
+</body></html>`
+	return template.New("hatchet").Funcs(template.FuncMap{
+		"add": func(a int, b int) int {
+			return a + b
+		},
+		"getDurationFromMillis": func(milli int) string {
+			if milli < 1000 {
+				return fmt.Sprintf("%d ms", milli)
+			} else if milli < 60000 {
+				return fmt.Sprintf("%.1f s", float64(milli)/1000)
+			} else if milli < 3600000 {
+				return fmt.Sprintf("%.1f m", float64(milli)/60000)
+			}
+			return fmt.Sprintf("%.1f h", float64(milli)/3600000)
+		},
+		"getBuildDuration": func(build IndexBuild, end string) string {
+			if build.End != "" {
+				end = build.End
+			}
+			return build.Start + "," + end
+		},
+		"getPhases": func(phases map[string]int) template.HTML {
+			strs := []string{}
+			for _, phase := range []string{PHASE_COLLSCAN, PHASE_BULK_LOAD, PHASE_DRAIN, PHASE_COMMIT_QUORUM} {
+				if milli, ok := phases[phase]; ok {
+					strs = append(strs, fmt.Sprintf("%v: %d ms", phase, milli))
+				}
+			}
+			return template.HTML(strings.Join(strs, "<br/>"))
+		},
+		"formatDateTime": func(str string) string {
+			return strings.Replace(str, "T", " ", 1)
+		}}).Parse(html)
+}
@@ -0,0 +1,57 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * index_builds_test.go
+ */
+
+package hatchet
+
+import (
+	"testing"
+
+	"github.com/simagix/gox"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestIndexBuildTracker(t *testing.T) {
+	strs := []string{
+		`{"t":{"$date":"2023-03-01T11:32:00.000+00:00"},"s":"I",  "c":"INDEX",    "id":20438,   "ctx":"conn12","msg":"Index build: registering","attr":{"buildUUID":{"uuid":{"$uuid":"0a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d"}},"namespace":"shop.orders","collectionUUID":{"uuid":{"$uuid":"aaaaaaaa-2222-4333-8444-555555555555"}},"indexes":1,"firstIndex":{"name":"status_1_date_-1"},"command":{"createIndexes":"orders","indexes":[{"key":{"status":1,"date":-1},"name":"status_1_date_-1"}],"commitQuorum":"votingMembers"}}}`,
+		`{"t":{"$date":"2023-03-01T11:32:00.010+00:00"},"s":"I",  "c":"INDEX",    "id":20384,   "ctx":"IndexBuildsCoordinatorMongod-0","msg":"Index build: starting","attr":{"buildUUID":{"uuid":{"$uuid":"0a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d"}},"collectionUUID":{"uuid":{"$uuid":"aaaaaaaa-2222-4333-8444-555555555555"}},"namespace":"shop.orders","properties":{"v":2,"key":{"status":1,"date":-1},"name":"status_1_date_-1"},"specIndex":0,"numSpecs":1,"method":"Hybrid","ident":"index-1","collectionIdent":"collection-1","maxTemporaryMemoryUsageMB":200}}`,
+		`{"t":{"$date":"2023-03-01T11:32:00.020+00:00"},"s":"I",  "c":"INDEX",    "id":20440,   "ctx":"conn12","msg":"Index build: waiting for index build to complete","attr":{"buildUUID":{"uuid":{"$uuid":"0a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d"}},"deadline":{"$date":{"$numberLong":"9223372036854775807"}}}}`,
+		`{"t":{"$date":"2023-03-01T11:32:40.000+00:00"},"s":"I",  "c":"INDEX",    "id":20391,   "ctx":"IndexBuildsCoordinatorMongod-0","msg":"Index build: collection scan done","attr":{"buildUUID":{"uuid":{"$uuid":"0a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d"}},"collectionUUID":{"uuid":{"$uuid":"aaaaaaaa-2222-4333-8444-555555555555"}},"namespace":"shop.orders","totalRecords":5000000,"readSource":"kMajorityCommitted","durationMillis":39980}}`,
+		`{"t":{"$date":"2023-03-01T11:32:52.000+00:00"},"s":"I",  "c":"INDEX",    "id":20685,   "ctx":"IndexBuildsCoordinatorMongod-0","msg":"Index build: inserted keys from external sorter into index","attr":{"namespace":"shop.orders","index":"status_1_date_-1","keysInserted":5000000,"durationMillis":12000}}`,
+		`{"t":{"$date":"2023-03-01T11:32:52.100+00:00"},"s":"I",  "c":"INDEX",    "id":20689,   "ctx":"IndexBuildsCoordinatorMongod-0","msg":"Index build: drain applied 120 side writes","attr":{"buildUUID":{"uuid":{"$uuid":"0a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d"}},"namespace":"shop.orders","index":"status_1_date_-1","numApplied":120,"totalInserted":120,"totalDeleted":0,"durationMillis":100}}`,
+		`{"t":{"$date":"2023-03-01T11:32:52.200+00:00"},"s":"I",  "c":"INDEX",    "id":3856203,   "ctx":"IndexBuildsCoordinatorMongod-0","msg":"Index build: waiting for next action before completing final phase","attr":{"buildUUID":{"uuid":{"$uuid":"0a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d"}}}}`,
+		`{"t":{"$date":"2023-03-01T11:32:55.200+00:00"},"s":"I",  "c":"INDEX",    "id":3856204,   "ctx":"IndexBuildsCoordinatorMongod-0","msg":"Index build: received signal","attr":{"buildUUID":{"uuid":{"$uuid":"0a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d"}},"action":"Commit quorum Satisfied"}}`,
+		`{"t":{"$date":"2023-03-01T11:32:55.300+00:00"},"s":"I",  "c":"INDEX",    "id":20345,   "ctx":"IndexBuildsCoordinatorMongod-0","msg":"Index build: done building","attr":{"buildUUID":{"uuid":{"$uuid":"0a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d"}},"collectionUUID":{"uuid":{"$uuid":"aaaaaaaa-2222-4333-8444-555555555555"}},"namespace":"shop.orders","index":"status_1_date_-1","ident":"index-1","collectionIdent":"collection-1","commitTimestamp":{"$timestamp":{"t":1677670375,"i":1}}}}`,
+		`{"t":{"$date":"2023-03-01T11:32:55.400+00:00"},"s":"I",  "c":"INDEX",    "id":20663,   "ctx":"IndexBuildsCoordinatorMongod-0","msg":"Index build: completed successfully","attr":{"buildUUID":{"uuid":{"$uuid":"0a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d"}},"collectionUUID":{"uuid":{"$uuid":"aaaaaaaa-2222-4333-8444-555555555555"}},"namespace":"shop.orders","indexesBuilt":["status_1_date_-1"],"numIndexesBefore":2,"numIndexesAfter":3}}`,
+		`{"t":{"$date":"2023-03-01T11:33:00.000+00:00"},"s":"I",  "c":"INDEX",    "id":20384,   "ctx":"IndexBuildsCoordinatorMongod-0","msg":"Index build: starting","attr":{"buildUUID":{"uuid":{"$uuid":"11111111-2222-4333-8444-555555555555"}},"collectionUUID":{"uuid":{"$uuid":"bbbbbbbb-2222-4333-8444-555555555555"}},"namespace":"shop.users","properties":{"v":2,"key":{"email":1},"name":"email_1","unique":true},"specIndex":0,"numSpecs":1,"method":"Hybrid"}}`,
+		`{"t":{"$date":"2023-03-01T11:33:05.000+00:00"},"s":"I",  "c":"INDEX",    "id":20649,   "ctx":"IndexBuildsCoordinatorMongod-0","msg":"Index build: failed","attr":{"buildUUID":{"uuid":{"$uuid":"11111111-2222-4333-8444-555555555555"}},"collectionUUID":{"uuid":{"$uuid":"bbbbbbbb-2222-4333-8444-555555555555"}},"namespace":"shop.users","error":{"code":11000,"codeName":"DuplicateKey","errmsg":"E11000 duplicate key error collection: shop.users index: email_1 dup key: { email: \"a@b.c\" }"}}}`,
+	}
+	tracker := NewIndexBuildTracker()
+	builds := []*IndexBuild{}
+	for i, str := range strs {
+		doc := Logv2Info{}
+		if err := bson.UnmarshalExtJSON([]byte(str), false, &doc); err != nil {
+			t.Fatalf("bson unmarshal error %v", err)
+		}
+		builds = append(builds, tracker.Analyze(i+1, &doc)...)
+	}
+	if len(builds) != 2 {
+		t.Fatal("expected", 2, "but got", len(builds))
+	}
+	build := builds[0]
+	if build.Namespace != "shop.orders" || build.Indexes != "status_1_date_-1" || build.Status != INDEX_BUILD_COMMITTED ||
+		build.CommitQuorum != "votingMembers" || build.Milli != 55400 {
+		t.Fatal("unexpected", gox.Stringify(build))
+	}
+	if build.Phases[PHASE_COLLSCAN] != 39980 || build.Phases[PHASE_BULK_LOAD] != 12000 ||
+		build.Phases[PHASE_COMMIT_QUORUM] != 3000 {
+		t.Fatal("unexpected phases", gox.Stringify(build.Phases))
+	}
+	if builds[1].Status != INDEX_BUILD_ABORTED || builds[1].Error == "" {
+		t.Fatal("unexpected", gox.Stringify(builds[1]))
+	}
+	if open := tracker.Close("2023-03-01T11:40:00.000-0000", INDEX_BUILD_IN_PROGRESS); len(open) != 0 {
+		t.Fatal("unexpected", gox.Stringify(open))
+	}
+}
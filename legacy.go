@@ -76,6 +76,9 @@ func AddLegacyString(doc *Logv2Info) error {
 				if doc.Msg == "client metadata" {
 					data, ok := attr.Value.(bson.D)
 					if ok {
+						if application, ok := data.Map()["application"].(bson.D); ok {
+							remote.AppName, _ = application.Map()["name"].(string)
+						}
 						driver, ok := data.Map()["driver"].(bson.D)
 						if ok {
 							remote.Driver, _ = driver.Map()["name"].(string)
@@ -104,6 +107,9 @@ func AddLegacyString(doc *Logv2Info) error {
 				remote := RemoteClient{}
 				_client, ok := attr.Value.(bson.D).Map()["$client"].(bson.D)
 				if ok {
+					if application, ok := _client.Map()["application"].(bson.D); ok {
+						remote.AppName, _ = application.Map()["name"].(string)
+					}
 					driver, ok := _client.Map()["driver"].(bson.D)
 					if ok {
 						remote.Driver, _ = driver.Map()["name"].(string)
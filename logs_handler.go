@@ -7,20 +7,25 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 	"strings"
 
 	"github.com/julienschmidt/httprouter"
 )
 
 // LogsHandler responds to charts API calls
-func LogsHandler(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+func (ptr *Server) LogsHandler(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
 	/** APIs
 	 * /hatchets/{hatchet}/logs/all
 	 * /hatchets/{hatchet}/logs/slowops
 	 */
 	hatchetName := params.ByName("hatchet")
 	attr := params.ByName("attr")
-	dbase, err := GetDatabase(hatchetName)
+	if err := validateRequest(hatchetName, r); err != nil {
+		writeError(w, err)
+		return
+	}
+	dbase, err := GetDatabase(ptr.opts, hatchetName)
 	if err != nil {
 		json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
 		return
@@ -38,16 +43,30 @@ func LogsHandler(w http.ResponseWriter, r *http.Request, params httprouter.Param
 		component := r.URL.Query().Get("component")
 		context := r.URL.Query().Get("context")
 		severity := r.URL.Query().Get("severity")
+		txn := r.URL.Query().Get("txn")
+		errcode := r.URL.Query().Get("errcode")
 		limit := r.URL.Query().Get("limit")
 		if limit == "" {
 			limit = fmt.Sprintf("%v", LIMIT)
 		}
 		offset, nlimit := GetOffsetLimit(limit)
-		logs, err := dbase.GetLogs(fmt.Sprintf("component=%v", component), fmt.Sprintf("limit=%v", limit),
+		filters, err := GetAttrFilters(r.URL.RawQuery)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		opts := []string{fmt.Sprintf("component=%v", component), fmt.Sprintf("limit=%v", limit),
 			fmt.Sprintf("context=%v", context), fmt.Sprintf("severity=%v", severity),
-			fmt.Sprintf("duration=%v", duration))
+			fmt.Sprintf("duration=%v", duration), fmt.Sprintf("txn=%v", txn),
+			fmt.Sprintf("errcode=%v", errcode)}
+		attrQuery := ""
+		for _, filter := range filters {
+			opts = append(opts, "attr="+filter)
+			attrQuery += "&" + url.QueryEscape(filter)
+		}
+		logs, err := dbase.GetLogs(opts...)
 		if err != nil {
-			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			writeError(w, err)
 			return
 		}
 		templ, err := GetLogTableTemplate(attr)
@@ -65,11 +84,13 @@ func LogsHandler(w http.ResponseWriter, r *http.Request, params httprouter.Param
 			logs = logs[:len(logs)-1]
 		}
 		limit = fmt.Sprintf("%v,%v", offset+nlimit, nlimit)
-		url := fmt.Sprintf("%v?component=%v&context=%v&severity=%v&duration=%v&limit=%v", r.URL.Path,
-			component, context, severity, duration, limit)
+		query := url.Values{"component": {component}, "context": {context}, "severity": {severity},
+			"duration": {duration}, "txn": {txn}, "errcode": {errcode}, "limit": {limit}}
+		next := r.URL.Path + "?" + query.Encode() + attrQuery
 		doc := map[string]interface{}{"Hatchet": hatchetName, "Logs": logs, "Seq": seq,
 			"Summary": summary, "Context": context, "Component": component, "Severity": severity,
-			"HasMore": hasMore, "URL": url}
+			"HasMore": hasMore, "URL": next, "Filters": filters, "AttrQuery": attrQuery,
+			"Notice": getSearchNotice(dbase, context)}
 		if err = templ.Execute(w, doc); err != nil {
 			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
 			return
@@ -98,3 +119,15 @@ func LogsHandler(w http.ResponseWriter, r *http.Request, params httprouter.Param
 		return
 	}
 }
+
+// getSearchNotice returns a notice if messages are searched by substrings instead of the full-text index,
+// i.e. built without the sqlite_fts5 tag, analyzed by older versions, or stored in MongoDB
+func getSearchNotice(dbase Database, context string) string {
+	if context == "" {
+		return ""
+	}
+	if indexed, err := dbase.HasFullTextIndex(); err != nil || indexed {
+		return ""
+	}
+	return "full-text search not available, messages are matched as substrings"
+}
@@ -2,6 +2,8 @@
 package hatchet
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"html/template"
 	"regexp"
@@ -54,6 +56,13 @@ func GetLogTableTemplate(attr string) (*template.Template, error) {
 		"highlightLog": func(log string, params ...string) template.HTML {
 			return template.HTML(highlightLog(log, params...))
 		},
+		"formatJSON": func(data json.RawMessage) string {
+			var buf bytes.Buffer
+			if err := json.Indent(&buf, data, "", "  "); err != nil {
+				return string(data)
+			}
+			return buf.String()
+		},
 		"formatDateTime": func(str string) string {
 			return strings.Replace(str, "T", " ", 1)
 		}}).Parse(html)
@@ -69,14 +78,30 @@ func highlightLog(log string, params ...string) string {
 	re = regexp.MustCompile(`(?i)("?(errMsg)"?:\s?"(.*?)"|planSummary:\s?"?COLLSCAN"?)`)
 	log = re.ReplaceAllString(log, "<span style='color: red; font-weight: bold;'>$1</span>")
 	for _, param := range params {
-		if param != "" {
-			re = regexp.MustCompile("(?i)(" + param + `(:\s?\".*?\")?)`)
-			log = re.ReplaceAllString(log, "<mark>$1</mark>")
+		if param == "" {
+			continue
+		}
+		for _, re = range GetSearchRegexps(param) {
+			log = markOutsideTags(log, re)
 		}
 	}
 	return log
 }
 
+// markOutsideTags marks matches of a pattern, leaving HTML tags added by highlighting untouched
+func markOutsideTags(log string, re *regexp.Regexp) string {
+	tags := regexp.MustCompile(`<[^>]*>`)
+	var buf strings.Builder
+	last := 0
+	for _, loc := range tags.FindAllStringIndex(log, -1) {
+		buf.WriteString(re.ReplaceAllString(log[last:loc[0]], "<mark>$0</mark>"))
+		buf.WriteString(log[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	buf.WriteString(re.ReplaceAllString(log[last:], "<mark>$0</mark>"))
+	return buf.String()
+}
+
 func getSlowOpsLogsTable() string {
 	template := ` 
 <p/>
@@ -127,9 +152,21 @@ func getLegacyLogsTable() string {
 
   <div style="float: left; margin-right: 20px;">
 	<label><i class="fa fa-search"></i></label>
-	<input id='context' type='text' value='{{.Context}}' size='30'/>
+	<input id='context' type='text' value='{{.Context}}' size='30'
+		title='context, or full-text search of terms, "phrases", prefix*, AND, OR, and NOT'/>
 	<button id="find" onClick="findLogs()" class="button" style="float: right;">Find</button>
   </div>
+{{if .Notice}}
+  <div style="float: left; margin-right: 20px;">
+	<i class="fa fa-info-circle"></i> {{.Notice}}
+  </div>
+{{end}}
+{{if .Filters}}
+  <div style="float: left; margin-right: 20px;">
+	<label>filters</label>
+	{{range .Filters}}<code>{{.}}</code> {{end}}
+  </div>
+{{end}}
 
 <p/>
 <div>
@@ -156,7 +193,11 @@ func getLegacyLogsTable() string {
 			<td>{{ $value.Severity }}</td>
 			<td>{{ $value.Component }}</td>
 			<td>{{ $value.Context }}</td>
-			<td>{{ highlightLog $value.Message $search }}</td>
+			<td>{{ highlightLog $value.Message $search }}
+			{{ if $value.Attr }}
+				<a href='#' onClick="toggleAttr('attr{{$n}}'); return false;" title='view raw JSON'><i class="fa fa-code"></i></a>
+				<pre id='attr{{$n}}' style='display: none;'>{{ formatJSON $value.Attr }}</pre>
+			{{ end }}</td>
 		</tr>
 	{{end}}
 	</table>
@@ -182,7 +223,12 @@ func getLegacyLogsTable() string {
 		sel = document.getElementById('severity')
 		var severity = sel.options[sel.selectedIndex].value;
 		var context = document.getElementById('context').value
-		window.location.href = '/hatchets/{{.Hatchet}}/logs/all?component='+component+'&severity='+severity+'&context='+context;
+		window.location.href = '/hatchets/{{.Hatchet}}/logs/all?component='+component+'&severity='+severity+'&context='+encodeURIComponent(context)+{{.AttrQuery}};
+	}
+
+	function toggleAttr(id) {
+		var pre = document.getElementById(id);
+		pre.style.display = (pre.style.display == 'none') ? 'block' : 'none';
 	}
 </script>
 `
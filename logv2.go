@@ -8,6 +8,7 @@ package hatchet
 import (
 	"bufio"
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
@@ -28,30 +29,23 @@ const (
 	TOP_N      = 23
 )
 
-var instance *Logv2
-
-// GetLogv2 returns Logv2 instance
-func GetLogv2() *Logv2 {
-	if instance == nil {
-		instance = &Logv2{dbfile: SQLITE3_FILE}
-	}
-	return instance
-}
-
-// Logv2 keeps Logv2 object
+// Logv2 keeps Logv2 object, of an analysis of a log file
 type Logv2 struct {
 	buildInfo   map[string]interface{}
-	dbfile      string
 	filename    string
 	legacy      bool
 	hatchetName string
 	isDigest    bool
+	opts        Options
 	s3client    *S3Client
 	testing     bool //test mode
 	totalLines  int
 	user        string
-	verbose     bool
-	version     string
+}
+
+// NewLogv2 returns a Logv2 of database options
+func NewLogv2(opts Options) *Logv2 {
+	return &Logv2{opts: opts}
 }
 
 // Logv2Info stores logv2 struct
@@ -62,6 +56,7 @@ type Logv2Info struct {
 	ID        int       `json:"id" bson:"id"`
 	Msg       string    `json:"msg" bson:"msg"`
 	Severity  string    `json:"s" bson:"s"`
+	Tags      []string  `json:"tags" bson:"tags"`
 	Timestamp time.Time `json:"t" bson:"t"`
 
 	Attributes Attributes
@@ -87,40 +82,51 @@ type RemoteClient struct {
 	IP       string `json:"value"`
 	Port     string `json:"port"`
 
+	AppName string // application name
 	Driver  string // driver name
 	Version string // driver version
 }
 
 // OpStat stores performance data
 type OpStat struct {
-	AvgMilli     float64 `json:"avg_ms"`        // max millisecond
-	Count        int     `json:"count"`         // number of ops
-	Index        string  `json:"index"`         // index used
-	MaxMilli     int     `json:"max_ms"`        // max millisecond
-	Namespace    string  `json:"ns"`            // database.collectin
-	Op           string  `json:"op"`            // count, delete, find, remove, and update
-	QueryPattern string  `json:"query_pattern"` // query pattern
-	Reslen       int     `json:"total_reslen"`  // total reslen
-	TotalMilli   int     `json:"total_ms"`      // total milliseconds
+	AppName      string   `json:"app_name"`           // application name
+	AvgMilli     float64  `json:"avg_ms"`             // max millisecond
+	Count        int      `json:"count"`              // number of ops
+	Driver       string   `json:"driver"`             // driver name and version
+	Findings     []string `json:"findings,omitempty"` // anti-pattern rules found
+	Index        string   `json:"index"`              // index used
+	MaxMilli     int      `json:"max_ms"`             // max millisecond
+	Namespace    string   `json:"ns"`                 // database.collectin
+	Op           string   `json:"op"`                 // count, delete, find, remove, and update
+	QueryPattern string   `json:"query_pattern"`      // query pattern
+	ReadConcern  string   `json:"read_concern"`       // read concern level
+	ReadPref     string   `json:"read_pref"`          // read preference mode
+	Reslen       int      `json:"total_reslen"`       // total reslen
+	TotalMilli   int      `json:"total_ms"`           // total milliseconds
+	WriteConcern string   `json:"write_concern"`      // write concern w and j
 }
 
 type LegacyLog struct {
-	Timestamp string `json:"date"`
-	Severity  string `json:"severity"`
-	Component string `json:"component"`
-	Context   string `json:"context"`
-	Message   string `json:"message"` // remaining legacy message
+	Timestamp string          `json:"date"`
+	Severity  string          `json:"severity"`
+	Component string          `json:"component"`
+	Context   string          `json:"context"`
+	Message   string          `json:"message"` // remaining legacy message
+	Attr      json.RawMessage `json:"attr,omitempty" bson:"-"`
 }
 
 type HatchetInfo struct {
-	Arch    string
-	End     string
-	Module  string
-	Name    string
-	OS      string
-	Start   string
-	Version string
+	Analyzed string // time analyzed in RFC3339
+	Arch     string
+	End      string
+	Module   string
+	Name     string
+	OS       string
+	Pinned   bool // kept regardless of the retention policy
+	Start    string
+	Version  string
 
+	Config   ServerConfig
 	Drivers  []map[string]string
 	Provider string
 	Region   string
@@ -172,7 +178,7 @@ func (ptr *Logv2) Analyze(filename string) error {
 			}
 		}
 	} else {
-		dirname := filepath.Dir(ptr.dbfile)
+		dirname := filepath.Dir(ptr.opts.DBFile)
 		os.Mkdir(dirname, 0755)
 		if file, err = os.Open(filename); err != nil {
 			return err
@@ -210,11 +216,17 @@ func (ptr *Logv2) Analyze(filename string) error {
 	var isPrefix bool
 	var stat *OpStat
 	index := 0
-	var start, end string
+	var start, end, node string
 	var dbase Database
+	tracker := NewConnectionTracker()
+	builds := NewIndexBuildTracker()
+	uptime := NewUptimeTracker()
+	cursors := NewCursorTracker()
+	detector := NewAntiPatternDetector()
+	config := NewServerConfig()
 
 	if !ptr.legacy {
-		if dbase, err = GetDatabase(ptr.hatchetName); err != nil {
+		if dbase, err = GetDatabase(ptr.opts, ptr.hatchetName); err != nil {
 			return err
 		}
 		defer dbase.Close()
@@ -264,11 +276,66 @@ func (ptr *Logv2) Analyze(filename string) error {
 			continue
 		}
 		stat, _ = AnalyzeSlowOp(&doc)
+		if stat.Op != "" && (stat.AppName == "" || stat.Driver == "") { // attribute to the connection's client
+			appName, driver := tracker.GetClient(doc.Context)
+			if stat.AppName == "" {
+				stat.AppName = appName
+			}
+			if stat.Driver == "" {
+				stat.Driver = driver
+			}
+		}
 		end = getDateTimeStr(doc.Timestamp)
 		if start == "" {
 			start = end
 		}
-		dbase.InsertLog(index, end, &doc, stat)
+		if err := dbase.InsertLog(index, end, &doc, stat); err != nil {
+			return err
+		}
+		if lsid, txnNumber, ok := GetTxnKey(&doc); ok {
+			dbase.InsertTxnLog(index, lsid, txnNumber)
+		}
+		if txn, err := AnalyzeTransaction(&doc); err == nil {
+			dbase.InsertTransaction(index, txn)
+		}
+		if doc.Component == "CONTROL" && doc.Msg == "MongoDB starting" {
+			attrMap := doc.Attr.Map()
+			node = fmt.Sprintf("%v:%v", attrMap["host"], attrMap["port"])
+		}
+		if event, err := AnalyzeReplEvent(&doc); err == nil {
+			if event.Node == SELF_NODE && node != "" {
+				event.Node = node
+			}
+			dbase.InsertReplEvent(index, event)
+		}
+		if event, err := AnalyzeShardingEvent(&doc); err == nil {
+			dbase.InsertShardingEvent(index, event)
+		}
+		for _, conn := range tracker.Analyze(index, &doc) {
+			dbase.InsertConnection(conn.index, conn)
+		}
+		if event, err := AnalyzeAuthEvent(&doc); err == nil {
+			dbase.InsertAuthEvent(index, event)
+		}
+		for _, build := range builds.Analyze(index, &doc) {
+			dbase.InsertIndexBuild(build.index, build)
+		}
+		if event, err := AnalyzeStorageEvent(&doc); err == nil {
+			dbase.InsertStorageEvent(index, event)
+		}
+		for _, cursor := range cursors.Analyze(index, &doc, stat) {
+			dbase.InsertCursor(cursor.index, cursor)
+		}
+		for _, finding := range detector.Analyze(index, &doc, stat) {
+			dbase.InsertFinding(finding.index, finding)
+		}
+		if opError, err := AnalyzeOpError(&doc); err == nil {
+			dbase.InsertOpError(index, opError)
+		}
+		for _, segment := range uptime.Analyze(index, &doc) {
+			dbase.InsertUptimeSegment(segment.ID, segment)
+		}
+		config.Analyze(&doc)
 		if doc.Client != nil {
 			if (doc.Client.Accepted + doc.Client.Ended) > 0 { // record connections
 				dbase.InsertClientConn(index, &doc)
@@ -282,10 +349,25 @@ func (ptr *Logv2) Analyze(filename string) error {
 	if ptr.legacy {
 		return nil
 	}
+	for _, conn := range tracker.Close(end, CONN_OPEN) {
+		dbase.InsertConnection(conn.index, conn)
+	}
+	for _, build := range builds.Close(end, INDEX_BUILD_IN_PROGRESS) {
+		dbase.InsertIndexBuild(build.index, build)
+	}
+	for _, cursor := range cursors.Close(end, CURSOR_OPEN) {
+		dbase.InsertCursor(cursor.index, cursor)
+	}
+	for _, finding := range detector.Close() {
+		dbase.InsertFinding(finding.index, finding)
+	}
+	for _, segment := range uptime.Close() {
+		dbase.InsertUptimeSegment(segment.ID, segment)
+	}
 	if err = dbase.Commit(); err != nil {
 		return err
 	}
-	info := HatchetInfo{Start: start, End: end}
+	info := HatchetInfo{Start: start, End: end, Config: *config}
 	if ptr.buildInfo != nil {
 		if ptr.buildInfo["environment"] != nil {
 			env := ptr.buildInfo["environment"].(bson.D).Map()
@@ -299,10 +381,11 @@ func (ptr *Logv2) Analyze(filename string) error {
 		}
 		info.Version, _ = ptr.buildInfo["version"].(string)
 	}
-	if err = dbase.UpdateHatchetInfo(info); err != nil {
+	if err = dbase.CreateMetaData(); err != nil {
 		return err
 	}
-	if err = dbase.CreateMetaData(); err != nil {
+	info.Analyzed = time.Now().UTC().Format(time.RFC3339)
+	if err = dbase.UpdateHatchetInfo(info); err != nil { // listed and purgeable only after metadata is created
 		return err
 	}
 	if !ptr.testing && !ptr.legacy {
@@ -312,7 +395,7 @@ func (ptr *Logv2) Analyze(filename string) error {
 }
 
 func (ptr *Logv2) PrintSummary() error {
-	dbase, err := GetDatabase(ptr.hatchetName)
+	dbase, err := GetDatabase(ptr.opts, ptr.hatchetName)
 	if err != nil {
 		return err
 	}
@@ -323,7 +406,7 @@ func (ptr *Logv2) PrintSummary() error {
 	buffer.WriteString(fmt.Sprintf("| Command  |COLLSCAN|avg ms| max ms | Count| %-32s| %-60s |\n", "Namespace", "Query Pattern"))
 	buffer.WriteString("|----------+--------+------+--------+------+---------------------------------+--------------------------------------------------------------|\n")
 	var ops []OpStat
-	if ops, err = dbase.GetSlowOps("avg_ms", "DESC", false); err != nil {
+	if ops, err = dbase.GetSlowOps("avg_ms", "DESC", false, ""); err != nil {
 		return err
 	}
 	for count, value := range ops {
@@ -7,13 +7,24 @@ package hatchet
 
 import (
 	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
 	"regexp"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/mattn/go-sqlite3"
 )
 
-func TestAnalyze(t *testing.T) {
+// registerSQLite3Extended registers the sqlite3_extended driver once for all tests
+func registerSQLite3Extended() {
+	for _, driver := range sql.Drivers() {
+		if driver == "sqlite3_extended" {
+			return
+		}
+	}
 	regex := func(re, s string) (bool, error) {
 		return regexp.MatchString(re, s)
 	}
@@ -23,8 +34,12 @@ func TestAnalyze(t *testing.T) {
 				return conn.RegisterFunc("regexp", regex, true)
 			},
 		})
+}
+
+func TestAnalyze(t *testing.T) {
+	registerSQLite3Extended()
 	filename := "testdata/mongod_ops.log.gz"
-	logv2 := &Logv2{testing: true, dbfile: SQLITE3_FILE}
+	logv2 := &Logv2{testing: true, opts: Options{DBFile: SQLITE3_FILE}}
 	err := logv2.Analyze(filename)
 	if err != nil {
 		t.Fatal(err)
@@ -39,3 +54,65 @@ func TestAnalyzeLegacy(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestAnalyzeConcurrently(t *testing.T) {
+	registerSQLite3Extended()
+	defer func(timeout int) { sqlite3BusyTimeout = timeout }(sqlite3BusyTimeout)
+	sqlite3BusyTimeout = 100 // milliseconds, shorter than an analysis holds the write lock
+	dir := t.TempDir()
+	var logs strings.Builder
+	logs.WriteString(`{"t":{"$date":"2023-03-01T10:00:00.000+00:00"},"s":"I","c":"CONTROL","id":4615611,"ctx":"initandlisten","msg":"MongoDB starting","attr":{"pid":1234,"port":27017,"dbPath":"/data/db","architecture":"64-bit","host":"host1"}}
+`)
+	for i := 0; i < 3*SQLITE3_BATCH_SIZE; i++ { // in batches
+		fmt.Fprintf(&logs, `{"t":{"$date":"2023-03-01T10:%02d:%02d.000+00:00"},"s":"I","c":"COMMAND","id":51803,"ctx":"conn1","msg":"Slow query","attr":{"type":"command","ns":"test.orders","command":{"find":"orders","filter":{"a":%d}},"planSummary":"COLLSCAN","durationMillis":120}}
+`, i/60%60, i%60, i)
+	}
+	filenames := []string{filepath.Join(dir, "mongod0.log"), filepath.Join(dir, "mongod1.log")}
+	for _, filename := range filenames {
+		if err := os.WriteFile(filename, []byte(logs.String()), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	opts := Options{DBFile: filepath.Join(dir, "hatchet.db")}
+	var wg sync.WaitGroup
+	errs := make([]error, 4)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			logv2 := NewLogv2(opts)
+			logv2.testing = true
+			errs[i] = logv2.Analyze(filenames[i%2])
+		}(i)
+	}
+	done := make(chan struct{})
+	var rerr error
+	go func() { // reads as a web server does while logs are analyzed
+		defer close(done)
+		for i := 0; i < 20 && rerr == nil; i++ {
+			_, rerr = GetHatchetUsages(opts)
+		}
+	}()
+	wg.Wait()
+	<-done
+	if rerr != nil {
+		t.Fatal(rerr)
+	}
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	usages, err := GetHatchetUsages(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(usages) != len(errs) {
+		t.Fatal("expected", len(errs), "but got", len(usages))
+	}
+	for _, usage := range usages {
+		if !strings.HasPrefix(usage.Name, "mongod0_") && !strings.HasPrefix(usage.Name, "mongod1_") || usage.Start == "" {
+			t.Fatal("expected a hatchet of mongod0_ or mongod1_ but got", usage)
+		}
+	}
+}
@@ -0,0 +1,453 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * mongodb.go
+ */
+
+package hatchet
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/connstring"
+)
+
+const (
+	MONGODB_BATCH_SIZE = 1000      // number of documents of each bulk insert
+	MONGODB_DATABASE   = "hatchet" // default database name
+)
+
+var mongoClients = map[string]*mongo.Client{}
+var mongoMutex sync.Mutex
+
+type MongoDB struct {
+	buffers     map[string][]interface{} // documents to insert by collections
+	db          *mongo.Database
+	hatchetName string
+	verbose     bool
+}
+
+// IsMongoURI returns true if a database URI is a MongoDB connection string
+func IsMongoURI(uri string) bool {
+	return strings.HasPrefix(uri, "mongodb://") || strings.HasPrefix(uri, "mongodb+srv://")
+}
+
+func GetMongoDB(opts Options, hatchetName string) (Database, error) {
+	var dbase Database
+	var err error
+	if dbase, err = NewMongoDB(opts.DBURI, hatchetName); err != nil {
+		return dbase, err
+	}
+	dbase.SetVerbose(opts.Verbose)
+	return dbase, err
+}
+
+// NewMongoDB returns a MongoDB of a connection string, the database name is from the
+// connection string or hatchet by default.  Clients are shared by connection strings
+func NewMongoDB(uri string, hatchetName string) (*MongoDB, error) {
+	var err error
+	mongodb := &MongoDB{buffers: map[string][]interface{}{}, hatchetName: hatchetName}
+	cs, err := connstring.ParseAndValidate(uri)
+	if err != nil {
+		return mongodb, err
+	}
+	dbName := cs.Database
+	if dbName == "" {
+		dbName = MONGODB_DATABASE
+	}
+	mongoMutex.Lock()
+	defer mongoMutex.Unlock()
+	client := mongoClients[uri]
+	if client == nil {
+		ctx := context.Background()
+		if client, err = mongo.Connect(ctx, options.Client().ApplyURI(uri)); err != nil {
+			return mongodb, err
+		}
+		if err = client.Ping(ctx, nil); err != nil {
+			client.Disconnect(ctx)
+			return mongodb, err
+		}
+		mongoClients[uri] = client
+	}
+	mongodb.db = client.Database(dbName)
+	return mongodb, err
+}
+
+func (ptr *MongoDB) GetVerbose() bool {
+	return ptr.verbose
+}
+
+func (ptr *MongoDB) SetVerbose(b bool) {
+	ptr.verbose = b
+}
+
+func (ptr *MongoDB) Begin() error {
+	var err error
+	ctx := context.Background()
+	log.Println("creating hatchet", ptr.hatchetName)
+	for _, suffix := range HATCHET_TABLES {
+		if err = ptr.collection(suffix).Drop(ctx); err != nil {
+			return err
+		}
+	}
+	// comma separated fields of indexes, descending if prefixed with -
+	indexes := map[string][]string{
+		"":              {"component", "context,date", "severity", "op,ns,filter", "date", "-milli"},
+		"_auth":         {"id", "result,ip"},
+		"_clients":      {"id", "context,ip"},
+		"_conns":        {"id", "ip,app", "conn_id,-id"},
+		"_cursors":      {"id", "status"},
+		"_drivers":      {"id"},
+		"_errors":       {"id", "code,name"},
+		"_findings":     {"id", "rule"},
+		"_index_builds": {"id", "ns,start"},
+		"_repl":         {"id", "type"},
+		"_sharding":     {"id", "type,ns"},
+		"_storage":      {"id", "type"},
+		"_txn_logs":     {"id", "lsid,txn_number"},
+		"_txns":         {"id", "lsid,txn_number"},
+		"_uptime":       {"id"},
+	}
+	for suffix, specs := range indexes {
+		models := []mongo.IndexModel{}
+		for _, spec := range specs {
+			keys := bson.D{}
+			for _, field := range strings.Split(spec, ",") {
+				if strings.HasPrefix(field, "-") {
+					keys = append(keys, bson.E{Key: field[1:], Value: -1})
+				} else {
+					keys = append(keys, bson.E{Key: field, Value: 1})
+				}
+			}
+			models = append(models, mongo.IndexModel{Keys: keys})
+		}
+		if _, err = ptr.collection(suffix).Indexes().CreateMany(ctx, models); err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// Commit inserts buffered documents
+func (ptr *MongoDB) Commit() error {
+	for suffix := range ptr.buffers {
+		if err := ptr.flush(suffix); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close does not disconnect the client shared by connection strings
+func (ptr *MongoDB) Close() error {
+	return nil
+}
+
+// collection returns a collection of the hatchet, i.e. {hatchet}_ops of _ops
+func (ptr *MongoDB) collection(suffix string) *mongo.Collection {
+	return ptr.db.Collection(ptr.hatchetName + suffix)
+}
+
+// insert buffers a document and inserts buffered documents of a batch size
+func (ptr *MongoDB) insert(suffix string, doc bson.M) error {
+	ptr.buffers[suffix] = append(ptr.buffers[suffix], doc)
+	if len(ptr.buffers[suffix]) < MONGODB_BATCH_SIZE {
+		return nil
+	}
+	return ptr.flush(suffix)
+}
+
+// flush inserts buffered documents of a collection
+func (ptr *MongoDB) flush(suffix string) error {
+	if len(ptr.buffers[suffix]) == 0 {
+		return nil
+	}
+	_, err := ptr.collection(suffix).InsertMany(context.Background(), ptr.buffers[suffix],
+		options.InsertMany().SetOrdered(false))
+	ptr.buffers[suffix] = nil
+	return err
+}
+
+// aggregate runs a pipeline on a collection of the hatchet and decodes results
+func (ptr *MongoDB) aggregate(suffix string, pipeline bson.A, results interface{}) error {
+	ctx := context.Background()
+	if ptr.verbose {
+		data, _ := bson.MarshalExtJSON(bson.D{{Key: "aggregate", Value: ptr.hatchetName + suffix},
+			{Key: "pipeline", Value: pipeline}}, false, false)
+		log.Println(string(data))
+	}
+	cursor, err := ptr.collection(suffix).Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return err
+	}
+	return cursor.All(ctx, results)
+}
+
+// merge runs a pipeline on a collection of the hatchet and inserts results into another collection
+func (ptr *MongoDB) merge(suffix string, pipeline bson.A, into string) error {
+	pipeline = append(pipeline, bson.M{"$project": bson.M{"_id": 0}},
+		bson.M{"$merge": bson.M{"into": ptr.hatchetName + into, "whenNotMatched": "insert"}})
+	results := []bson.M{}
+	return ptr.aggregate(suffix, pipeline, &results)
+}
+
+func (ptr *MongoDB) InsertLog(index int, end string, doc *Logv2Info, stat *OpStat) error {
+	row := bson.M{"_id": index, "date": end, "severity": doc.Severity,
+		"component": doc.Component, "context": doc.Context, "msg": doc.Msg, "plan": doc.Attributes.PlanSummary,
+		"type": doc.Attr.Map()["type"], "ns": doc.Attributes.NS, "message": doc.Message, "op": stat.Op,
+		"filter": stat.QueryPattern, "_index": stat.Index, "milli": doc.Attributes.Milli,
+		"reslen": doc.Attributes.Reslen, "app": stat.AppName, "driver": stat.Driver,
+		"read_concern": stat.ReadConcern, "write_concern": stat.WriteConcern, "read_pref": stat.ReadPref}
+	if len(doc.Attr) > 0 {
+		row["attr"] = doc.Attr
+	}
+	return ptr.insert("", row)
+}
+
+func (ptr *MongoDB) InsertClientConn(index int, doc *Logv2Info) error {
+	client := doc.Client
+	return ptr.insert("_clients", bson.M{"id": index, "ip": client.IP, "port": client.Port,
+		"conns": client.Conns, "accepted": client.Accepted, "ended": client.Ended, "context": doc.Context})
+}
+
+func (ptr *MongoDB) InsertConnection(index int, conn *ConnectionLifetime) error {
+	return ptr.insert("_conns", bson.M{"id": index, "conn_id": conn.ConnID, "ip": conn.IP, "port": conn.Port,
+		"app": conn.AppName, "accepted": conn.Accepted, "ended": conn.Ended, "milli": conn.Milli,
+		"status": conn.Status})
+}
+
+func (ptr *MongoDB) InsertCursor(index int, cursor *Cursor) error {
+	return ptr.insert("_cursors", bson.M{"id": index, "cursor_id": cursor.CursorID, "ns": cursor.Namespace,
+		"op": cursor.Op, "filter": cursor.QueryPattern, "batches": cursor.Batches, "nreturned": cursor.NReturned,
+		"milli": cursor.Milli, "start": cursor.Start, "end": cursor.End, "lifetime": cursor.Lifetime,
+		"status": cursor.Status})
+}
+
+func (ptr *MongoDB) InsertFinding(index int, finding *Finding) error {
+	return ptr.insert("_findings", bson.M{"id": index, "rule": finding.Rule, "severity": finding.Severity,
+		"ns": finding.Namespace, "op": finding.Op, "filter": finding.QueryPattern, "detail": finding.Detail})
+}
+
+func (ptr *MongoDB) InsertDriver(index int, doc *Logv2Info) error {
+	client := doc.Client
+	return ptr.insert("_drivers", bson.M{"id": index, "ip": client.IP, "driver": client.Driver,
+		"version": client.Version, "app": client.AppName})
+}
+
+func (ptr *MongoDB) InsertTransaction(index int, txn *Transaction) error {
+	return ptr.insert("_txns", bson.M{"id": index, "lsid": txn.LSID, "txn_number": txn.TxnNumber,
+		"termination": txn.Termination, "abort_cause": txn.AbortCause, "milli": txn.Milli,
+		"time_active": txn.TimeActive, "time_inactive": txn.TimeInactive, "num_yields": txn.NumYields,
+		"keys_examined": txn.KeysExamined, "docs_examined": txn.DocsExamined, "nreturned": txn.NReturned,
+		"ninserted": txn.NInserted, "nmodified": txn.NModified, "ndeleted": txn.NDeleted,
+		"read_concern": txn.ReadConcern, "was_prepared": txn.WasPrepared})
+}
+
+func (ptr *MongoDB) InsertTxnLog(index int, lsid string, txnNumber int) error {
+	return ptr.insert("_txn_logs", bson.M{"id": index, "lsid": lsid, "txn_number": txnNumber})
+}
+
+func (ptr *MongoDB) InsertReplEvent(index int, event *ReplEvent) error {
+	return ptr.insert("_repl", bson.M{"id": index, "type": event.Type, "node": event.Node, "name": event.Name,
+		"detail": event.Detail, "milli": event.Milli})
+}
+
+func (ptr *MongoDB) InsertShardingEvent(index int, event *ShardingEvent) error {
+	return ptr.insert("_sharding", bson.M{"id": index, "type": event.Type, "name": event.Name,
+		"ns": event.Namespace, "from_shard": event.From, "to_shard": event.To, "milli": event.Milli,
+		"result": event.Result, "metrics": getMetricsDoc(event.Metrics), "detail": event.Detail})
+}
+
+func (ptr *MongoDB) InsertAuthEvent(index int, event *AuthEvent) error {
+	return ptr.insert("_auth", bson.M{"id": index, "result": event.Result, "principal": event.Principal,
+		"db": event.Database, "mechanism": event.Mechanism, "ip": event.IP, "err_code": event.ErrCode,
+		"err_name": event.ErrName})
+}
+
+func (ptr *MongoDB) InsertIndexBuild(index int, build *IndexBuild) error {
+	return ptr.insert("_index_builds", bson.M{"id": index, "build_uuid": build.BuildUUID, "ns": build.Namespace,
+		"indexes": build.Indexes, "keys": build.Keys, "method": build.Method,
+		"commit_quorum": build.CommitQuorum, "start": build.Start, "end": build.End, "milli": build.Milli,
+		"status": build.Status, "phases": getMetricsDoc(build.Phases), "error": build.Error})
+}
+
+func (ptr *MongoDB) InsertStorageEvent(index int, event *StorageEvent) error {
+	return ptr.insert("_storage", bson.M{"id": index, "type": event.Type, "category": event.Category,
+		"milli": event.Milli, "detail": event.Detail})
+}
+
+func (ptr *MongoDB) InsertOpError(index int, opError *OpError) error {
+	return ptr.insert("_errors", bson.M{"id": index, "code": opError.Code, "name": opError.Name,
+		"ns": opError.Namespace, "op": opError.Op, "message": opError.Message})
+}
+
+func (ptr *MongoDB) InsertUptimeSegment(index int, segment *UptimeSegment) error {
+	return ptr.insert("_uptime", bson.M{"id": index, "pid": segment.PID, "host": segment.Host,
+		"port": segment.Port, "start": segment.Start, "end": segment.End, "end_id": segment.EndID,
+		"status": segment.Status, "crash_id": segment.CrashID, "reason": segment.Reason})
+}
+
+func (ptr *MongoDB) UpdateHatchetInfo(info HatchetInfo) error {
+	var err error
+	ctx := context.Background()
+	// pinned is kept on re-analysis
+	doc := bson.M{"version": info.Version, "module": info.Module, "arch": info.Arch,
+		"os": info.OS, "start": info.Start, "end": info.End, "schema_version": SCHEMA_VERSION, "status": "",
+		"analyzed": info.Analyzed}
+	if _, err = ptr.db.Collection("hatchet").UpdateOne(ctx, bson.M{"_id": ptr.hatchetName}, bson.M{"$set": doc},
+		options.Update().SetUpsert(true)); err != nil {
+		return err
+	}
+	config := info.Config
+	warnings := config.Warnings
+	if warnings == nil {
+		warnings = []string{}
+	}
+	_, err = ptr.collection("_config").InsertOne(ctx, bson.M{"host": config.Host, "port": config.Port,
+		"repl_set_name": config.ReplSetName, "storage_engine": config.StorageEngine,
+		"cache_size_gb": config.CacheSizeGB, "slowms": config.SlowMS, "profile": config.Profile,
+		"options": config.Options, "warnings": warnings})
+	return err
+}
+
+func (ptr *MongoDB) CreateMetaData() error {
+	var err error
+	log.Printf("insert into %v_ops\n", ptr.hatchetName)
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{"op": bson.M{"$ne": ""}}},
+		bson.M{"$group": bson.M{
+			"_id":   bson.M{"op": "$op", "ns": "$ns", "filter": "$filter", "_index": "$_index", "app": "$app"},
+			"count": bson.M{"$sum": 1}, "avg_ms": bson.M{"$avg": "$milli"}, "max_ms": bson.M{"$max": "$milli"},
+			"total_ms": bson.M{"$sum": "$milli"}, "reslen": bson.M{"$sum": "$reslen"}}},
+		bson.M{"$project": bson.M{"op": "$_id.op", "count": 1, "avg_ms": bson.M{"$round": bson.A{"$avg_ms", 1}},
+			"max_ms": 1, "total_ms": 1, "ns": "$_id.ns", "_index": "$_id._index", "reslen": 1,
+			"filter": "$_id.filter", "app": "$_id.app"}},
+	}
+	if err = ptr.merge("", pipeline, "_ops"); err != nil {
+		return err
+	}
+
+	log.Printf("insert exception into %v_audit\n", ptr.hatchetName)
+	pipeline = bson.A{
+		bson.M{"$match": bson.M{"severity": bson.M{"$in": bson.A{"W", "E", "F"}}}},
+		getAuditGroupStage("exception", "$severity", 1),
+	}
+	if err = ptr.merge("", pipeline, "_audit"); err != nil {
+		return err
+	}
+
+	log.Printf("insert failed into %v_audit\n", ptr.hatchetName)
+	pipeline = bson.A{
+		bson.M{"$match": bson.M{"message": bson.M{"$regex": `\w\sfailed\s`}}},
+		getAuditGroupStage("failed", bson.M{"$substrBytes": bson.A{"$message", 0,
+			bson.M{"$add": bson.A{bson.M{"$indexOfBytes": bson.A{"$message", "failed"}}, 6}}}}, 1),
+	}
+	if err = ptr.merge("", pipeline, "_audit"); err != nil {
+		return err
+	}
+
+	log.Printf("insert errcode into %v_audit\n", ptr.hatchetName)
+	pipeline = bson.A{
+		getAuditGroupStage("errcode", bson.M{"$concat": bson.A{"$name", " (", bson.M{"$toString": "$code"}, ")"}}, 1),
+	}
+	if err = ptr.merge("_errors", pipeline, "_audit"); err != nil {
+		return err
+	}
+
+	log.Printf("insert op into %v_audit\n", ptr.hatchetName)
+	pipeline = bson.A{
+		bson.M{"$match": bson.M{"op": bson.M{"$ne": ""}}},
+		getAuditGroupStage("op", "$op", 1),
+	}
+	if err = ptr.merge("", pipeline, "_audit"); err != nil {
+		return err
+	}
+
+	log.Printf("insert ip into %v_audit\n", ptr.hatchetName)
+	pipeline = bson.A{getAuditGroupStage("ip", "$ip", "$accepted")}
+	if err = ptr.merge("_clients", pipeline, "_audit"); err != nil {
+		return err
+	}
+
+	log.Printf("insert reslen-ip into %v_audit\n", ptr.hatchetName)
+	pipeline = bson.A{
+		bson.M{"$match": bson.M{"op": bson.M{"$ne": ""}, "reslen": bson.M{"$gt": 0}}},
+		bson.M{"$lookup": bson.M{"from": ptr.hatchetName + "_clients", "localField": "context",
+			"foreignField": "context", "as": "client"}},
+		bson.M{"$unwind": "$client"},
+		getAuditGroupStage("reslen-ip", "$client.ip", "$reslen"),
+	}
+	if err = ptr.merge("", pipeline, "_audit"); err != nil {
+		return err
+	}
+
+	log.Printf("insert ns into %v_audit\n", ptr.hatchetName)
+	pipeline = bson.A{
+		bson.M{"$match": bson.M{"op": bson.M{"$ne": ""}}},
+		getAuditGroupStage("ns", "$ns", 1),
+	}
+	if err = ptr.merge("", pipeline, "_audit"); err != nil {
+		return err
+	}
+
+	log.Printf("insert reslen-ns into %v_audit\n", ptr.hatchetName)
+	pipeline = bson.A{
+		bson.M{"$match": bson.M{"ns": bson.M{"$ne": ""}, "reslen": bson.M{"$gt": 0}}},
+		getAuditGroupStage("reslen-ns", "$ns", "$reslen"),
+	}
+	if err = ptr.merge("", pipeline, "_audit"); err != nil {
+		return err
+	}
+
+	log.Printf("insert txn into %v_audit\n", ptr.hatchetName)
+	pipeline = bson.A{getAuditGroupStage("txn", "$termination", 1)}
+	if err = ptr.merge("_txns", pipeline, "_audit"); err != nil {
+		return err
+	}
+
+	log.Printf("insert txn-abort into %v_audit\n", ptr.hatchetName)
+	pipeline = bson.A{
+		bson.M{"$match": bson.M{"termination": "aborted"}},
+		getAuditGroupStage("txn-abort", "$abort_cause", 1),
+	}
+	return ptr.merge("_txns", pipeline, "_audit")
+}
+
+// getAuditGroupStage returns a $group stage of audit documents, i.e. { type, name, value }, of a
+// name expression and the value to sum
+func getAuditGroupStage(auditType string, name interface{}, value interface{}) bson.M {
+	return bson.M{"$group": bson.M{"_id": name, "type": bson.M{"$first": auditType},
+		"name": bson.M{"$first": name}, "value": bson.M{"$sum": value}}}
+}
+
+// getMetricsDoc returns a document of metrics, nil if there is no metric
+func getMetricsDoc(metrics map[string]int) bson.M {
+	if len(metrics) == 0 {
+		return nil
+	}
+	doc := bson.M{}
+	for k, v := range metrics {
+		doc[k] = v
+	}
+	return doc
+}
+
+// GetHatchetInitStmt returns an empty statement, collections are created by Begin
+func (ptr *MongoDB) GetHatchetInitStmt() string {
+	return ""
+}
+
+// GetHatchetPreparedStmt returns an empty statement, documents are inserted in batches
+func (ptr *MongoDB) GetHatchetPreparedStmt() string {
+	return ""
+}
+
+// GetClientPreparedStmt returns an empty statement, documents are inserted in batches
+func (ptr *MongoDB) GetClientPreparedStmt() string {
+	return ""
+}
@@ -0,0 +1,99 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * mongodb_audit.go
+ */
+
+package hatchet
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func (ptr *MongoDB) GetAuditData() (map[string][]NameValues, error) {
+	var err error
+	data := map[string][]NameValues{}
+	// get max connection counts
+	category := "stats"
+	conns := []NameValue{}
+	pipeline := bson.A{bson.M{"$group": bson.M{"_id": "maxConns", "value": bson.M{"$max": "$conns"}}},
+		bson.M{"$project": bson.M{"_id": 0, "name": "$_id", "value": 1}}}
+	if err = ptr.aggregate("_clients", pipeline, &conns); err == nil && len(conns) > 0 && conns[0].Value > 0 {
+		data[category] = append(data[category], NameValues{conns[0].Name, []int{conns[0].Value}})
+	}
+
+	// get max operation time
+	totals := []struct {
+		Count      int `bson:"count"`
+		MaxMilli   int `bson:"max_ms"`
+		TotalMilli int `bson:"total_ms"`
+	}{}
+	group := bson.M{"$group": bson.M{"_id": nil, "max_ms": bson.M{"$max": "$max_ms"},
+		"count": bson.M{"$sum": "$count"}, "total_ms": bson.M{"$sum": "$total_ms"}}}
+	if err = ptr.aggregate("_ops", bson.A{group}, &totals); err != nil {
+		return data, err
+	}
+	if len(totals) > 0 && totals[0].Count > 0 {
+		doc := totals[0]
+		data[category] = append(data[category], NameValues{"maxMilli", []int{doc.MaxMilli}})
+		data[category] = append(data[category], NameValues{"avgMilli", []int{doc.TotalMilli / doc.Count}})
+		data[category] = append(data[category], NameValues{"totalMilli", []int{doc.TotalMilli}})
+	}
+
+	// get max operation time
+	category = "collscan"
+	pipeline = bson.A{bson.M{"$match": bson.M{"_index": COLLSCAN}}, group}
+	if err = ptr.aggregate("_ops", pipeline, &totals); err != nil {
+		return data, err
+	}
+	if len(totals) > 0 && totals[0].Count > 0 {
+		doc := totals[0]
+		data[category] = append(data[category], NameValues{"count", []int{doc.Count}})
+		data[category] = append(data[category], NameValues{"maxMilli", []int{doc.MaxMilli}})
+		data[category] = append(data[category], NameValues{"totalMilli", []int{doc.TotalMilli}})
+	}
+
+	// get audit data
+	audits := []struct {
+		Name  string `bson:"name"`
+		Type  string `bson:"type"`
+		Value int    `bson:"value"`
+	}{}
+	pipeline = bson.A{
+		bson.M{"$match": bson.M{"type": bson.M{"$in": bson.A{"exception", "failed", "errcode", "op", "duration"}}}},
+		bson.M{"$sort": bson.D{{Key: "type", Value: 1}, {Key: "value", Value: -1}}}}
+	if err = ptr.aggregate("_audit", pipeline, &audits); err != nil {
+		return data, err
+	}
+	for _, audit := range audits {
+		doc := NameValues{audit.Name, []int{audit.Value}}
+		if audit.Type == "exception" {
+			if doc.Name == "E" {
+				doc.Name = "Error"
+			} else if doc.Name == "F" {
+				doc.Name = "Fatal"
+			} else if doc.Name == "W" {
+				doc.Name = "Warn"
+			}
+		}
+		data[audit.Type] = append(data[audit.Type], doc)
+	}
+
+	for _, category = range []string{"ip", "ns"} {
+		docs := []NameValues{}
+		pipeline = bson.A{
+			bson.M{"$match": bson.M{"type": category}},
+			bson.M{"$lookup": bson.M{"from": ptr.hatchetName + "_audit", "localField": "name",
+				"foreignField": "name", "as": "reslen"}},
+			bson.M{"$unwind": "$reslen"},
+			bson.M{"$match": bson.M{"reslen.type": "reslen-" + category}},
+			bson.M{"$sort": bson.M{"reslen.value": -1}},
+			bson.M{"$project": bson.M{"_id": 0, "name": 1, "values": bson.A{"$value", "$reslen.value"}}}}
+		if err = ptr.aggregate("_audit", pipeline, &docs); err != nil {
+			return data, err
+		}
+		if len(docs) > 0 {
+			data[category] = docs
+		}
+	}
+	return data, err
+}
@@ -0,0 +1,82 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * mongodb_auth.go
+ */
+
+package hatchet
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// userExpr is an expression of principal@db, or principal if db is empty
+var userExpr = bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$db", ""}}, "$principal",
+	bson.M{"$concat": bson.A{"$principal", "@", "$db"}}}}
+
+// GetAuthStats returns authentication mechanisms, failures by users and IPs, error codes
+// and possible brute-force attempts
+func (ptr *MongoDB) GetAuthStats() (AuthStats, error) {
+	var err error
+	stats := AuthStats{Bursts: []AuthBurst{}, Mechanisms: []NameValues{}, FailuresByUser: []NameValues{},
+		FailuresByIP: []NameValues{}, Errors: []NameValue{}}
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{"mechanism": bson.M{"$ne": ""}}},
+		bson.M{"$group": bson.M{"_id": "$mechanism",
+			"succeeded": getCountIfExpr("$result", AUTH_SUCCEEDED),
+			"failed":    getCountIfExpr("$result", AUTH_FAILED)}},
+		bson.M{"$sort": bson.M{"_id": 1}},
+		bson.M{"$project": bson.M{"_id": 0, "name": "$_id", "values": bson.A{"$succeeded", "$failed"}}}}
+	if err = ptr.aggregate("_auth", pipeline, &stats.Mechanisms); err != nil {
+		return stats, err
+	}
+	pipeline = bson.A{
+		bson.M{"$match": bson.M{"result": bson.M{"$ne": AUTH_SUCCEEDED}, "principal": bson.M{"$ne": ""}}},
+		bson.M{"$group": bson.M{"_id": userExpr, "count": bson.M{"$sum": 1}, "ips": bson.M{"$addToSet": "$ip"}}},
+		bson.M{"$sort": bson.M{"count": -1}},
+		bson.M{"$project": bson.M{"_id": 0, "name": "$_id", "values": bson.A{"$count", bson.M{"$size": "$ips"}}}}}
+	if err = ptr.aggregate("_auth", pipeline, &stats.FailuresByUser); err != nil {
+		return stats, err
+	}
+	pipeline = bson.A{
+		bson.M{"$match": bson.M{"result": bson.M{"$ne": AUTH_SUCCEEDED}, "ip": bson.M{"$ne": ""}}},
+		bson.M{"$group": bson.M{"_id": "$ip", "count": bson.M{"$sum": 1},
+			"users": bson.M{"$addToSet": bson.M{"$concat": bson.A{"$principal", "@", "$db"}}}}},
+		bson.M{"$sort": bson.M{"count": -1}},
+		bson.M{"$project": bson.M{"_id": 0, "name": "$_id", "values": bson.A{"$count", bson.M{"$size": "$users"}}}}}
+	if err = ptr.aggregate("_auth", pipeline, &stats.FailuresByIP); err != nil {
+		return stats, err
+	}
+	pipeline = append(bson.A{bson.M{"$match": bson.M{"result": bson.M{"$ne": AUTH_SUCCEEDED}}}},
+		getNameValueStages(bson.M{"$concat": bson.A{"$err_name", " (", bson.M{"$toString": "$err_code"}, ")"}}, 1)...)
+	if err = ptr.aggregate("_auth", pipeline, &stats.Errors); err != nil {
+		return stats, err
+	}
+
+	events := []AuthEvent{}
+	pipeline, _ = ptr.getLogLookupStages("")
+	pipeline = append(bson.A{bson.M{"$match": bson.M{"result": AUTH_FAILED}}}, pipeline...)
+	pipeline = append(pipeline, bson.M{"$sort": bson.M{"id": 1}},
+		bson.M{"$project": bson.M{"_id": 0, "date": "$log.date", "principal": 1, "database": "$db", "ip": 1}})
+	if err = ptr.aggregate("_auth", pipeline, &events); err != nil {
+		return stats, err
+	}
+	stats.Bursts = DetectBruteForce(events)
+	return stats, err
+}
+
+// GetAuthFailures returns counts of authentication failures by users and IPs over a period of time
+func (ptr *MongoDB) GetAuthFailures(duration string) ([]OpCount, error) {
+	docs := []OpCount{}
+	stages, err := ptr.getLogLookupStages(duration)
+	if err != nil {
+		return docs, err
+	}
+	pipeline := append(bson.A{bson.M{"$match": bson.M{"result": bson.M{"$ne": AUTH_SUCCEEDED}}}}, stages...)
+	pipeline = append(pipeline,
+		bson.M{"$group": bson.M{"_id": bson.M{"date": ptr.getDateBucketExpr("$log.date", duration), "user": userExpr, "ip": "$ip"},
+			"count": bson.M{"$sum": 1}}},
+		bson.M{"$sort": bson.M{"_id.date": 1}},
+		getOpCountProjection("$_id.user", "$_id.ip", emptyString))
+	err = ptr.aggregate("_auth", pipeline, &docs)
+	return docs, err
+}
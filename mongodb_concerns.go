@@ -0,0 +1,77 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * mongodb_concerns.go
+ */
+
+package hatchet
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// GetConcernStats returns read and write concerns and read preferences of slow ops
+func (ptr *MongoDB) GetConcernStats(topN int) (ConcernStats, error) {
+	var err error
+	stats := ConcernStats{ReadConcerns: []NameValues{}, ReadPreferences: []NameValues{}, WriteConcerns: []NameValues{},
+		Usages: []ConcernUsage{}}
+	if stats.ReadConcerns, err = ptr.getConcernCounts("$read_concern", READ_OPS); err != nil {
+		return stats, err
+	}
+	if stats.ReadPreferences, err = ptr.getConcernCounts("$read_pref", READ_OPS); err != nil {
+		return stats, err
+	}
+	if stats.WriteConcerns, err = ptr.getConcernCounts("$write_concern", WRITE_OPS); err != nil {
+		return stats, err
+	}
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{"op": bson.M{"$in": append(append([]string{}, READ_OPS...), WRITE_OPS...)},
+			"$or": bson.A{bson.M{"read_concern": bson.M{"$ne": ""}}, bson.M{"write_concern": bson.M{"$ne": ""}},
+				bson.M{"read_pref": bson.M{"$ne": ""}}}}},
+		bson.M{"$group": bson.M{"_id": bson.M{"ns": "$ns", "app": "$app", "read_concern": "$read_concern",
+			"write_concern": "$write_concern", "read_pref": "$read_pref"},
+			"count": bson.M{"$sum": 1}, "avg_ms": bson.M{"$avg": "$milli"}, "max_ms": bson.M{"$max": "$milli"}}},
+		bson.M{"$sort": bson.M{"count": -1}},
+		bson.M{"$limit": topN},
+		bson.M{"$project": bson.M{"_id": 0, "namespace": "$_id.ns", "appname": "$_id.app",
+			"readconcern": "$_id.read_concern", "writeconcern": "$_id.write_concern", "readpreference": "$_id.read_pref",
+			"count": 1, "avgmilli": bson.M{"$round": bson.A{"$avg_ms", 1}}, "maxmilli": "$max_ms"}}}
+	err = ptr.aggregate("", pipeline, &stats.Usages)
+	return stats, err
+}
+
+// GetWriteConcernLatency returns average milliseconds of write ops by write concerns over a period of time
+func (ptr *MongoDB) GetWriteConcernLatency(duration string) ([]OpCount, error) {
+	docs := []OpCount{}
+	match, err := getDurationMatch("date", duration)
+	if err != nil {
+		return docs, err
+	}
+	match = append(match, bson.E{Key: "op", Value: bson.M{"$in": WRITE_OPS}})
+	pipeline := bson.A{bson.M{"$match": match},
+		bson.M{"$group": bson.M{"_id": bson.M{"date": ptr.getDateBucketExpr("$date", duration),
+			"wc": getConcernExpr("$write_concern"), "ns": "$ns"},
+			"milli": bson.M{"$avg": "$milli"}, "count": bson.M{"$sum": 1}}},
+		bson.M{"$sort": bson.M{"_id.date": 1}},
+		getOpCountProjection("$_id.wc", "$_id.ns", emptyString)}
+	err = ptr.aggregate("", pipeline, &docs)
+	return docs, err
+}
+
+// getConcernCounts returns counts and average milliseconds of ops by a concern field
+func (ptr *MongoDB) getConcernCounts(field string, ops []string) ([]NameValues, error) {
+	docs := []NameValues{}
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{"op": bson.M{"$in": ops}}},
+		bson.M{"$group": bson.M{"_id": getConcernExpr(field), "count": bson.M{"$sum": 1},
+			"avg_ms": bson.M{"$avg": "$milli"}}},
+		bson.M{"$sort": bson.M{"count": -1}},
+		bson.M{"$project": bson.M{"_id": 0, "name": "$_id",
+			"values": bson.A{"$count", bson.M{"$toInt": bson.M{"$round": bson.A{"$avg_ms", 0}}}}}}}
+	err := ptr.aggregate("", pipeline, &docs)
+	return docs, err
+}
+
+// getConcernExpr returns an expression of a concern field, or default if not specified
+func getConcernExpr(field string) bson.M {
+	return bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{field, ""}}, CONCERN_DEFAULT, field}}
+}
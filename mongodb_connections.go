@@ -0,0 +1,84 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * mongodb_connections.go
+ */
+
+package hatchet
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// GetAcceptedRate returns peak accepted connections per second of each time bucket and spikes
+func (ptr *MongoDB) GetAcceptedRate(duration string) (AcceptedRate, error) {
+	rate := AcceptedRate{Rates: []ConnectionRate{}, Spikes: []NameValue{}}
+	stages, err := ptr.getLogLookupStages(duration)
+	if err != nil {
+		return rate, err
+	}
+	start, end := ptr.getDurationDates(duration)
+	pipeline := append(bson.A{bson.M{"$match": bson.M{"accepted": 1}}}, stages...)
+	pipeline = append(pipeline,
+		bson.M{"$group": bson.M{"_id": bson.M{"$substrBytes": bson.A{"$log.date", 0, 19}},
+			"bucket": bson.M{"$first": GetDateBucketExpr("$log.date", start, end)},
+			"value":  bson.M{"$sum": "$accepted"}}},
+		bson.M{"$sort": bson.M{"_id": 1}})
+	docs := []struct {
+		Bucket string `bson:"bucket"`
+		Second string `bson:"_id"`
+		Value  int    `bson:"value"`
+	}{}
+	if err = ptr.aggregate("_clients", pipeline, &docs); err != nil {
+		return rate, err
+	}
+	buckets := []string{}
+	seconds := []NameValue{}
+	for _, doc := range docs {
+		buckets = append(buckets, doc.Bucket)
+		seconds = append(seconds, NameValue{doc.Second, doc.Value})
+	}
+	return getAcceptedRate(buckets, seconds, getDurationSeconds(start, end)), err
+}
+
+// GetConnectionDistribution returns counts of ended connections by lifetimes
+func (ptr *MongoDB) GetConnectionDistribution(duration string) ([]NameValue, error) {
+	docs := []NameValue{}
+	for _, bucket := range CONN_BUCKETS {
+		docs = append(docs, NameValue{bucket.Name, 0})
+	}
+	match, err := getDurationMatch("accepted", duration)
+	if err != nil {
+		return docs, err
+	}
+	match = append(match, bson.E{Key: "status", Value: CONN_ENDED})
+	counts := []struct {
+		Bucket int `bson:"_id"`
+		Count  int `bson:"count"`
+	}{}
+	pipeline := bson.A{bson.M{"$match": match},
+		bson.M{"$group": bson.M{"_id": getBucketSwitchExpr("$milli", CONN_BUCKETS), "count": bson.M{"$sum": 1}}}}
+	if err = ptr.aggregate("_conns", pipeline, &counts); err != nil {
+		return docs, err
+	}
+	for _, count := range counts {
+		docs[count.Bucket].Value = count.Count
+	}
+	return docs, err
+}
+
+// GetConnectionLifetimes returns connection lifetimes by IPs and applications,
+// sorted by open connections
+func (ptr *MongoDB) GetConnectionLifetimes(duration string) ([]ConnectionStat, error) {
+	match, err := getDurationMatch("accepted", duration)
+	if err != nil {
+		return []ConnectionStat{}, err
+	}
+	pipeline := bson.A{bson.M{"$match": match}, bson.M{"$sort": bson.M{"id": 1}},
+		bson.M{"$project": bson.M{"_id": 0, "ip": 1, "appname": "$app", "accepted": 1, "ended": 1,
+			"milli": 1, "status": 1}}}
+	conns := []ConnectionLifetime{}
+	if err = ptr.aggregate("_conns", pipeline, &conns); err != nil {
+		return []ConnectionStat{}, err
+	}
+	return summarizeConnections(conns), err
+}
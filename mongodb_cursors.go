@@ -0,0 +1,43 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * mongodb_cursors.go
+ */
+
+package hatchet
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// GetCursorStats returns counts of cursors by statuses, and cursors of the longest lifetime and of
+// the longest total execution time
+func (ptr *MongoDB) GetCursorStats(topN int) (CursorStats, error) {
+	var err error
+	stats := CursorStats{Statuses: []NameValues{}}
+	pipeline := bson.A{
+		bson.M{"$group": bson.M{"_id": "$status", "count": bson.M{"$sum": 1},
+			"batches": bson.M{"$sum": "$batches"}, "nreturned": bson.M{"$sum": "$nreturned"}}},
+		bson.M{"$sort": bson.M{"count": -1}},
+		bson.M{"$project": bson.M{"_id": 0, "name": "$_id", "values": bson.A{"$count", "$batches", "$nreturned"}}}}
+	if err = ptr.aggregate("_cursors", pipeline, &stats.Statuses); err != nil {
+		return stats, err
+	}
+	if stats.LongLived, err = ptr.getCursors("lifetime", topN); err != nil {
+		return stats, err
+	}
+	stats.Heaviest, err = ptr.getCursors("milli", topN)
+	return stats, err
+}
+
+// getCursors returns top N cursors sorted by a field
+func (ptr *MongoDB) getCursors(orderBy string, topN int) ([]Cursor, error) {
+	cursors := []Cursor{}
+	pipeline := bson.A{
+		bson.M{"$sort": bson.M{orderBy: -1}},
+		bson.M{"$limit": topN},
+		bson.M{"$project": bson.M{"_id": 0, "cursorid": "$cursor_id", "namespace": "$ns", "op": 1,
+			"querypattern": "$filter", "batches": 1, "nreturned": 1, "milli": 1, "start": 1, "end": 1,
+			"lifetime": 1, "status": 1}}}
+	err := ptr.aggregate("_cursors", pipeline, &cursors)
+	return cursors, err
+}
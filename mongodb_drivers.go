@@ -0,0 +1,39 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * mongodb_drivers.go
+ */
+
+package hatchet
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// GetDriverUsages returns driver versions and client IPs and applications using them
+func (ptr *MongoDB) GetDriverUsages() ([]DriverUsage, error) {
+	usages := []DriverUsage{}
+	pipeline := bson.A{
+		bson.M{"$group": bson.M{"_id": bson.M{"driver": "$driver", "version": "$version"}, "count": bson.M{"$sum": 1}}},
+		bson.M{"$sort": bson.D{{Key: "_id.driver", Value: 1}, {Key: "_id.version", Value: 1}}},
+		bson.M{"$project": bson.M{"_id": 0, "driver": "$_id.driver", "version": "$_id.version", "count": 1}}}
+	if err := ptr.aggregate("_drivers", pipeline, &usages); err != nil {
+		return usages, err
+	}
+	for i, usage := range usages {
+		for _, field := range []string{"ip", "app"} {
+			docs := []NameValue{}
+			pipeline = append(bson.A{bson.M{"$match": bson.M{"driver": usage.Driver, "version": usage.Version,
+				field: bson.M{"$ne": ""}}}}, getNameValueStages("$"+field, 1)...)
+			pipeline = append(pipeline, bson.M{"$limit": TOP_N})
+			if err := ptr.aggregate("_drivers", pipeline, &docs); err != nil {
+				return usages, err
+			}
+			if field == "ip" {
+				usages[i].IPs = docs
+			} else {
+				usages[i].AppNames = docs
+			}
+		}
+	}
+	return usages, nil
+}
@@ -0,0 +1,87 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * mongodb_errors.go
+ */
+
+package hatchet
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// errorExpr is an expression of an error name and code, i.e. name (code)
+var errorExpr = bson.M{"$concat": bson.A{"$name", " (", bson.M{"$toString": "$code"}, ")"}}
+
+// GetErrorCodes returns counts of error codes by namespaces, ops and client IPs
+func (ptr *MongoDB) GetErrorCodes() ([]ErrorCode, error) {
+	codes := []ErrorCode{}
+	pipeline := bson.A{
+		bson.M{"$group": bson.M{"_id": bson.M{"code": "$code", "name": "$name"}, "count": bson.M{"$sum": 1},
+			"message": bson.M{"$max": "$message"}}},
+		bson.M{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id.code", Value: 1}}},
+		bson.M{"$project": bson.M{"_id": 0, "code": "$_id.code", "name": "$_id.name", "count": 1, "message": 1}}}
+	if err := ptr.aggregate("_errors", pipeline, &codes); err != nil {
+		return codes, err
+	}
+	for i, code := range codes {
+		for _, field := range []string{"ns", "op"} {
+			docs := []NameValue{}
+			match := bson.M{"code": code.Code, "name": code.Name, field: bson.M{"$ne": ""}}
+			pipeline = append(bson.A{bson.M{"$match": match}},
+				getNameValueStages("$"+field, 1)...)
+			pipeline = append(pipeline, bson.M{"$limit": TOP_N})
+			if err := ptr.aggregate("_errors", pipeline, &docs); err != nil {
+				return codes, err
+			}
+			if field == "ns" {
+				codes[i].Namespaces = docs
+			} else {
+				codes[i].Ops = docs
+			}
+		}
+		// client IP of the last accepted connection of the context, e.g. conn12, before the error
+		stages, _ := ptr.getLogLookupStages("")
+		pipeline = append(bson.A{bson.M{"$match": bson.M{"code": code.Code, "name": code.Name}}}, stages...)
+		pipeline = append(pipeline,
+			bson.M{"$match": bson.M{"log.context": bson.M{"$regex": "^conn"}}},
+			bson.M{"$lookup": bson.M{"from": ptr.hatchetName + "_conns",
+				"let": bson.M{"conn_id": bson.M{"$convert": bson.M{"input": bson.M{"$substrBytes": bson.A{"$log.context", 4, -1}},
+					"to": "int", "onError": nil, "onNull": nil}}, "id": "$id"},
+				"pipeline": bson.A{
+					bson.M{"$match": bson.M{"$expr": bson.M{"$and": bson.A{bson.M{"$eq": bson.A{"$conn_id", "$$conn_id"}},
+						bson.M{"$lt": bson.A{"$id", "$$id"}}}}}},
+					bson.M{"$sort": bson.M{"id": -1}},
+					bson.M{"$limit": 1}},
+				"as": "conn"}},
+			bson.M{"$unwind": "$conn"})
+		pipeline = append(pipeline, getNameValueStages("$conn.ip", 1)...)
+		pipeline = append(pipeline, bson.M{"$limit": TOP_N})
+		codes[i].Clients = []NameValue{}
+		if err := ptr.aggregate("_errors", pipeline, &codes[i].Clients); err != nil {
+			return codes, err
+		}
+	}
+	return codes, nil
+}
+
+// GetErrorCounts returns counts of errors by codes and namespaces over a period of time, all codes
+// are returned if code is 0
+func (ptr *MongoDB) GetErrorCounts(code int, duration string) ([]OpCount, error) {
+	docs := []OpCount{}
+	match := bson.D{}
+	if code != 0 {
+		match = append(match, bson.E{Key: "code", Value: code})
+	}
+	stages, err := ptr.getLogLookupStages(duration)
+	if err != nil {
+		return docs, err
+	}
+	pipeline := append(bson.A{bson.M{"$match": match}}, stages...)
+	pipeline = append(pipeline,
+		bson.M{"$group": bson.M{"_id": bson.M{"date": ptr.getDateBucketExpr("$log.date", duration), "error": errorExpr, "ns": "$ns"},
+			"count": bson.M{"$sum": 1}}},
+		bson.M{"$sort": bson.M{"_id.date": 1}},
+		getOpCountProjection("$_id.error", "$_id.ns", emptyString))
+	err = ptr.aggregate("_errors", pipeline, &docs)
+	return docs, err
+}
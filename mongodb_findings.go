@@ -0,0 +1,50 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * mongodb_findings.go
+ */
+
+package hatchet
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// GetFindings returns anti-patterns found, ordered by severities and counts
+func (ptr *MongoDB) GetFindings() ([]Finding, error) {
+	findings := []Finding{}
+	pipeline := bson.A{
+		bson.M{"$group": bson.M{"_id": bson.M{"rule": "$rule", "severity": "$severity", "ns": "$ns", "op": "$op",
+			"filter": "$filter"}, "detail": bson.M{"$min": "$detail"}, "count": bson.M{"$sum": 1}}},
+		bson.M{"$addFields": bson.M{"rank": bson.M{"$switch": bson.M{"branches": bson.A{
+			bson.M{"case": bson.M{"$eq": bson.A{"$_id.severity", SEVERITY_HIGH}}, "then": 1},
+			bson.M{"case": bson.M{"$eq": bson.A{"$_id.severity", SEVERITY_MEDIUM}}, "then": 2}},
+			"default": 3}}}},
+		bson.M{"$sort": bson.D{{Key: "rank", Value: 1}, {Key: "count", Value: -1}}},
+		bson.M{"$project": bson.M{"_id": 0, "rule": "$_id.rule", "severity": "$_id.severity", "namespace": "$_id.ns",
+			"op": "$_id.op", "querypattern": "$_id.filter", "detail": 1, "count": 1}}}
+	err := ptr.aggregate("_findings", pipeline, &findings)
+	return findings, err
+}
+
+// getFindingRules returns anti-pattern rules found by op, namespace, and query pattern
+func (ptr *MongoDB) getFindingRules() (map[string][]string, error) {
+	rules := map[string][]string{}
+	docs := []struct {
+		Filter string `bson:"filter"`
+		NS     string `bson:"ns"`
+		Op     string `bson:"op"`
+		Rule   string `bson:"rule"`
+	}{}
+	pipeline := bson.A{
+		bson.M{"$group": bson.M{"_id": bson.M{"op": "$op", "ns": "$ns", "filter": "$filter", "rule": "$rule"}}},
+		bson.M{"$replaceRoot": bson.M{"newRoot": "$_id"}},
+		bson.M{"$sort": bson.M{"rule": 1}}}
+	if err := ptr.aggregate("_findings", pipeline, &docs); err != nil {
+		return rules, err
+	}
+	for _, doc := range docs {
+		key := getFindingKey(doc.Op, doc.NS, doc.Filter)
+		rules[key] = append(rules[key], doc.Rule)
+	}
+	return rules, nil
+}
@@ -0,0 +1,120 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * mongodb_hatchets.go
+ */
+
+package hatchet
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// DeleteHatchet drops all collections of the hatchet and removes it from the hatchet collection
+func (ptr *MongoDB) DeleteHatchet() error {
+	ctx := context.Background()
+	collections, err := ptr.getHatchetCollections()
+	if err != nil {
+		return err
+	}
+	for _, collection := range collections {
+		if err = ptr.db.Collection(collection).Drop(ctx); err != nil {
+			return err
+		}
+	}
+	_, err = ptr.db.Collection("hatchet").DeleteOne(ctx, bson.M{"_id": ptr.hatchetName})
+	return err
+}
+
+// RenameHatchet renames all collections of the hatchet and its document of the hatchet collection
+func (ptr *MongoDB) RenameHatchet(name string) error {
+	ctx := context.Background()
+	if err := ValidateHatchetName(name); err != nil {
+		return err
+	}
+	collections, err := ptr.getHatchetCollections()
+	if err != nil {
+		return err
+	}
+	if count, err := ptr.db.Collection("hatchet").CountDocuments(ctx, bson.M{"_id": name}); err != nil {
+		return err
+	} else if count > 0 {
+		return NewInvalidInputError("hatchet %v already exists", name)
+	}
+	var doc bson.M
+	if err = ptr.db.Collection("hatchet").FindOne(ctx, bson.M{"_id": ptr.hatchetName}).Decode(&doc); err != nil {
+		return err
+	}
+	dbName := ptr.db.Name()
+	for _, collection := range collections {
+		cmd := bson.D{{Key: "renameCollection", Value: dbName + "." + collection},
+			{Key: "to", Value: dbName + "." + name + collection[len(ptr.hatchetName):]}}
+		if err = ptr.db.Client().Database("admin").RunCommand(ctx, cmd).Err(); err != nil {
+			return err
+		}
+	}
+	doc["_id"] = name
+	if _, err = ptr.db.Collection("hatchet").InsertOne(ctx, doc); err != nil {
+		return err
+	}
+	if _, err = ptr.db.Collection("hatchet").DeleteOne(ctx, bson.M{"_id": ptr.hatchetName}); err != nil {
+		return err
+	}
+	ptr.hatchetName = name
+	return err
+}
+
+// PinHatchet sets whether the hatchet is kept regardless of the retention policy
+func (ptr *MongoDB) PinHatchet(pinned bool) error {
+	result, err := ptr.db.Collection("hatchet").UpdateOne(context.Background(), bson.M{"_id": ptr.hatchetName},
+		bson.M{"$set": bson.M{"pinned": pinned}})
+	if err != nil {
+		return err
+	} else if result.MatchedCount == 0 {
+		return NewInvalidInputError("hatchet %v not found", ptr.hatchetName)
+	}
+	return err
+}
+
+// GetHatchetSize returns the storage size, in bytes, of all collections and indexes of the hatchet
+func (ptr *MongoDB) GetHatchetSize() (int64, error) {
+	var total int64
+	ctx := context.Background()
+	collections, err := ptr.getHatchetCollections()
+	if err != nil {
+		return total, err
+	}
+	for _, collection := range collections {
+		var stats struct {
+			StorageSize    int64 `bson:"storageSize"`
+			TotalIndexSize int64 `bson:"totalIndexSize"`
+		}
+		cmd := bson.D{{Key: "collStats", Value: collection}}
+		if err = ptr.db.RunCommand(ctx, cmd).Decode(&stats); err != nil {
+			return total, err
+		}
+		total += stats.StorageSize + stats.TotalIndexSize
+	}
+	return total, err
+}
+
+// Vacuum is not required, WiredTiger reuses space of dropped collections
+func (ptr *MongoDB) Vacuum() error {
+	return nil
+}
+
+// getHatchetCollections returns existing collections of the hatchet, an error if the hatchet doesn't exist
+func (ptr *MongoDB) getHatchetCollections() ([]string, error) {
+	ctx := context.Background()
+	if count, err := ptr.db.Collection("hatchet").CountDocuments(ctx, bson.M{"_id": ptr.hatchetName}); err != nil {
+		return []string{}, err
+	} else if count == 0 {
+		return []string{}, NewInvalidInputError("hatchet %v not found", ptr.hatchetName)
+	}
+	names := bson.A{}
+	for _, suffix := range HATCHET_TABLES {
+		names = append(names, ptr.hatchetName+suffix)
+	}
+	return ptr.db.ListCollectionNames(ctx, bson.M{"name": bson.M{"$in": names}})
+}
@@ -0,0 +1,32 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * mongodb_index_builds.go
+ */
+
+package hatchet
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// GetIndexBuilds returns index builds started within a period of time, sorted by start time
+func (ptr *MongoDB) GetIndexBuilds(duration string) ([]IndexBuild, error) {
+	docs := []IndexBuild{}
+	match, err := getDurationMatch("start", duration)
+	if err != nil {
+		return docs, err
+	}
+	pipeline := bson.A{bson.M{"$match": match}, bson.M{"$sort": bson.M{"start": 1}},
+		bson.M{"$project": bson.M{"_id": 0, "builduuid": "$build_uuid", "namespace": "$ns", "indexes": 1,
+			"keys": 1, "method": 1, "commitquorum": "$commit_quorum", "start": 1, "end": 1, "milli": 1,
+			"status": 1, "phases": 1, "error": 1}}}
+	if err = ptr.aggregate("_index_builds", pipeline, &docs); err != nil {
+		return docs, err
+	}
+	for i := range docs {
+		if docs[i].Phases == nil {
+			docs[i].Phases = map[string]int{}
+		}
+	}
+	return docs, err
+}
@@ -0,0 +1,566 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * mongodb_query.go
+ */
+
+package hatchet
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// opStatProjection projects ops stats to fields of OpStat
+var opStatProjection = bson.M{"$project": bson.M{"_id": 0, "op": 1, "count": 1, "avgmilli": "$avg_ms",
+	"maxmilli": "$max_ms", "totalmilli": "$total_ms", "namespace": "$ns", "index": "$_index",
+	"reslen": 1, "querypattern": "$filter", "appname": "$app"}}
+
+// emptyString is an expression of an empty string
+var emptyString = bson.M{"$literal": ""}
+
+// legacyLogProjection projects logs to fields of LegacyLog
+var legacyLogProjection = bson.M{"$project": bson.M{"_id": 0, "timestamp": "$date", "severity": 1,
+	"component": 1, "context": 1, "message": 1}}
+
+// GetSlowOps returns ops stats of all applications combined, of each application if app is "all",
+// or of an application
+func (ptr *MongoDB) GetSlowOps(orderBy string, order string, collscan bool, app string) ([]OpStat, error) {
+	ops := []OpStat{}
+	sortStage, err := getSortStage(orderBy, order, SLOWOPS_SORT_KEYS)
+	if err != nil {
+		return ops, err
+	}
+	match := bson.D{}
+	if collscan {
+		match = append(match, bson.E{Key: "_index", Value: COLLSCAN})
+	}
+	if app != "" && app != APP_ALL {
+		match = append(match, bson.E{Key: "app", Value: app})
+	}
+	pipeline := bson.A{bson.M{"$match": match}}
+	if app == "" {
+		pipeline = append(pipeline,
+			bson.M{"$group": bson.M{"_id": bson.M{"op": "$op", "ns": "$ns", "_index": "$_index", "filter": "$filter"},
+				"count": bson.M{"$sum": "$count"}, "max_ms": bson.M{"$max": "$max_ms"},
+				"total_ms": bson.M{"$sum": "$total_ms"}, "reslen": bson.M{"$sum": "$reslen"}}},
+			bson.M{"$project": bson.M{"op": "$_id.op", "count": 1,
+				"avg_ms": bson.M{"$round": bson.A{bson.M{"$divide": bson.A{"$total_ms", "$count"}}, 1}},
+				"max_ms": 1, "total_ms": 1, "ns": "$_id.ns", "_index": "$_id._index", "reslen": 1,
+				"filter": "$_id.filter", "app": emptyString}})
+	}
+	pipeline = append(pipeline, sortStage, opStatProjection)
+	if err = ptr.aggregate("_ops", pipeline, &ops); err != nil {
+		return ops, err
+	}
+	rules, err := ptr.getFindingRules()
+	if err != nil {
+		return ops, err
+	}
+	for i, op := range ops {
+		ops[i].Findings = rules[getFindingKey(op.Op, op.Namespace, op.QueryPattern)]
+	}
+	return ops, err
+}
+
+// GetAppCounts returns counts of slow ops by application names
+func (ptr *MongoDB) GetAppCounts(duration string) ([]NameValue, error) {
+	docs := []NameValue{}
+	match, err := getDurationMatch("date", duration)
+	if err != nil {
+		return docs, err
+	}
+	match = append(match, bson.E{Key: "op", Value: bson.M{"$ne": ""}}, bson.E{Key: "app", Value: bson.M{"$ne": ""}})
+	pipeline := append(bson.A{bson.M{"$match": match}}, getNameValueStages("$app", 1)...)
+	err = ptr.aggregate("", pipeline, &docs)
+	return docs, err
+}
+
+func (ptr *MongoDB) GetLogs(opts ...string) ([]LegacyLog, error) {
+	docs := []LegacyLog{}
+	match, offset, qlimit, err := ptr.getLogsMatch(false, opts...)
+	if err != nil {
+		return docs, err
+	}
+	search := ""
+	for _, opt := range opts {
+		if toks := strings.SplitN(opt, "=", 2); len(toks) == 2 && toks[0] == "context" {
+			search = toks[1]
+		}
+	}
+	if docs, err = ptr.queryLogs(match, offset, qlimit); err != nil {
+		return docs, err
+	}
+	if len(docs) == 0 && search != "" { // no context found, perform message search
+		return ptr.SearchLogs(opts...)
+	}
+	return docs, err
+}
+
+// HasFullTextIndex returns false, messages are matched as case-insensitive substrings
+func (ptr *MongoDB) HasFullTextIndex() (bool, error) {
+	return false, nil
+}
+
+func (ptr *MongoDB) SearchLogs(opts ...string) ([]LegacyLog, error) {
+	match, offset, qlimit, err := ptr.getLogsMatch(true, opts...)
+	if err != nil {
+		return []LegacyLog{}, err
+	}
+	return ptr.queryLogs(match, offset, qlimit)
+}
+
+// queryLogs returns logs, with raw attributes, of conditions from an offset
+func (ptr *MongoDB) queryLogs(match bson.D, offset int, qlimit int) ([]LegacyLog, error) {
+	docs := []LegacyLog{}
+	projection := bson.M{"_id": 0, "timestamp": "$date", "severity": 1, "component": 1, "context": 1,
+		"message": 1, "attr": 1}
+	pipeline := bson.A{bson.M{"$match": match}, bson.M{"$sort": bson.M{"_id": 1}},
+		bson.M{"$skip": offset}, bson.M{"$limit": qlimit}, bson.M{"$project": projection}}
+	var rows []struct {
+		LegacyLog `bson:",inline"`
+		Attr      bson.Raw `bson:"attr"`
+	}
+	if err := ptr.aggregate("", pipeline, &rows); err != nil {
+		return docs, err
+	}
+	for _, row := range rows {
+		if len(row.Attr) > 0 {
+			data, err := bson.MarshalExtJSON(row.Attr, false, false)
+			if err != nil {
+				return docs, err
+			}
+			row.LegacyLog.Attr = data
+		}
+		docs = append(docs, row.LegacyLog)
+	}
+	return docs, nil
+}
+
+// getAttrMatch returns a condition of a dotted path of the attr field
+func getAttrMatch(filter *AttrFilter) bson.E {
+	path := "attr." + strings.Join(filter.Path, ".")
+	return bson.E{Key: path, Value: bson.M{ATTR_FILTER_OPS[filter.Op]: filter.Value}}
+}
+
+// getFullTextMatch returns a match of a full-text search node of case-insensitive patterns of a field
+func getFullTextMatch(node *ftsNode, field string) bson.M {
+	if node.op == "" {
+		return bson.M{field: bson.M{"$regex": regexp.QuoteMeta(node.text), "$options": "i"}}
+	} else if node.op == FTS_NOT {
+		return bson.M{"$and": bson.A{getFullTextMatch(node.children[0], field),
+			bson.M{"$nor": bson.A{getFullTextMatch(node.children[1], field)}}}}
+	}
+	conds := bson.A{}
+	for _, child := range node.children {
+		conds = append(conds, getFullTextMatch(child, field))
+	}
+	return bson.M{"$" + strings.ToLower(node.op): conds}
+}
+
+// getLogsMatch returns conditions, offset, and limit of logs from options, i.e. {name}={value} or
+// attr={filter} of ParseAttrFilter.  Context is a full-text search of messages, matched by case-insensitive
+// substrings, if search is true
+func (ptr *MongoDB) getLogsMatch(search bool, opts ...string) (bson.D, int, int, error) {
+	match := bson.D{}
+	qlimit := LIMIT + 1
+	var offset, nlimit int
+	for _, opt := range opts {
+		toks := strings.SplitN(opt, "=", 2)
+		if len(toks) < 2 || toks[1] == "" {
+			continue
+		}
+		if toks[0] == "duration" {
+			cond, err := getDurationMatch("date", toks[1])
+			if err != nil {
+				return match, offset, qlimit, err
+			}
+			match = append(match, cond...)
+		} else if toks[0] == "limit" {
+			offset, nlimit = GetOffsetLimit(toks[1])
+			qlimit = nlimit + 1
+		} else if toks[0] == "severity" {
+			if _, ok := SEVERITY_M[toks[1]]; !ok {
+				return match, offset, qlimit, NewInvalidInputError("invalid severity %v", toks[1])
+			}
+			severities := []string{}
+			for _, v := range SEVERITIES {
+				severities = append(severities, v)
+				if v == toks[1] {
+					break
+				}
+			}
+			match = append(match, bson.E{Key: "severity", Value: bson.M{"$in": severities}})
+		} else if toks[0] == "txn" {
+			lsid, txnNumber, err := ParseTxnKey(toks[1])
+			if err != nil {
+				return match, offset, qlimit, err
+			}
+			cond, err := ptr.getTxnMatch(lsid, txnNumber)
+			if err != nil {
+				return match, offset, qlimit, err
+			}
+			match = append(match, cond)
+		} else if toks[0] == "errcode" {
+			ids, err := ptr.collection("_errors").Distinct(context.Background(), "id",
+				bson.M{"code": ToInt(toks[1])})
+			if err != nil {
+				return match, offset, qlimit, err
+			}
+			match = append(match, bson.E{Key: "_id", Value: bson.M{"$in": ids}})
+		} else if toks[0] == "attr" {
+			filter, err := ParseAttrFilter(toks[1])
+			if err != nil {
+				return match, offset, qlimit, err
+			}
+			match = append(match, getAttrMatch(filter))
+		} else if toks[0] == "context" && search {
+			query, err := ParseFullTextQuery(toks[1])
+			if err != nil {
+				return match, offset, qlimit, err
+			}
+			match = append(match, bson.E{Key: "$and", Value: bson.A{getFullTextMatch(query.root, "message")}})
+		} else if !LOG_FILTERS[toks[0]] {
+			return match, offset, qlimit, NewInvalidInputError("invalid filter %v", toks[0])
+		} else {
+			match = append(match, bson.E{Key: toks[0], Value: toks[1]})
+		}
+	}
+	return match, offset, qlimit, nil
+}
+
+// getTxnMatch returns the condition of logs of a transaction, linked when analyzed, or matched by messages
+// for hatchets analyzed before logs were linked
+func (ptr *MongoDB) getTxnMatch(lsid string, txnNumber int) (bson.E, error) {
+	ctx := context.Background()
+	names, err := ptr.db.ListCollectionNames(ctx, bson.M{"name": ptr.hatchetName + "_txn_logs"})
+	if err != nil {
+		return bson.E{}, err
+	} else if len(names) == 0 {
+		pattern := fmt.Sprintf("%v.*txnNumber:%v[, ]", regexp.QuoteMeta(lsid), txnNumber)
+		return bson.E{Key: "message", Value: bson.M{"$regex": pattern}}, nil
+	}
+	ids, err := ptr.collection("_txn_logs").Distinct(ctx, "id", bson.M{"lsid": lsid, "txn_number": txnNumber})
+	return bson.E{Key: "_id", Value: bson.M{"$in": ids}}, err
+}
+
+func (ptr *MongoDB) GetSlowestLogs(topN int) ([]LegacyLog, error) {
+	docs := []LegacyLog{}
+	pipeline := bson.A{bson.M{"$match": bson.M{"op": bson.M{"$ne": ""}}},
+		bson.M{"$sort": bson.M{"milli": -1}}, bson.M{"$limit": topN}, legacyLogProjection}
+	err := ptr.aggregate("", pipeline, &docs)
+	return docs, err
+}
+
+func (ptr *MongoDB) GetAverageOpTime(op string, duration string, app string) ([]OpCount, error) {
+	docs := []OpCount{}
+	match, err := getDurationMatch("date", duration)
+	if err != nil {
+		return docs, err
+	}
+	if op != "" {
+		match = append(match, bson.E{Key: "op", Value: op})
+	} else {
+		match = append(match, bson.E{Key: "op", Value: bson.M{"$ne": ""}})
+	}
+	if app != "" {
+		match = append(match, bson.E{Key: "app", Value: app})
+	}
+	pipeline := bson.A{bson.M{"$match": match},
+		bson.M{"$group": bson.M{
+			"_id":   bson.M{"date": ptr.getDateBucketExpr("$date", duration), "op": "$op", "ns": "$ns", "filter": "$filter"},
+			"milli": bson.M{"$avg": "$milli"}, "count": bson.M{"$sum": 1}}},
+		bson.M{"$sort": bson.M{"_id": 1}},
+		getOpCountProjection("$_id.op", "$_id.ns", "$_id.filter")}
+	err = ptr.aggregate("", pipeline, &docs)
+	return docs, err
+}
+
+func (ptr *MongoDB) GetHatchetInfo() HatchetInfo {
+	var info HatchetInfo
+	ctx := context.Background()
+	var doc struct {
+		Name     string `bson:"_id"`
+		Version  string `bson:"version"`
+		Module   string `bson:"module"`
+		OS       string `bson:"os"`
+		Arch     string `bson:"arch"`
+		Start    string `bson:"start"`
+		End      string `bson:"end"`
+		Analyzed string `bson:"analyzed"`
+		Pinned   bool   `bson:"pinned"`
+	}
+	if err := ptr.db.Collection("hatchet").FindOne(ctx, bson.M{"_id": ptr.hatchetName}).Decode(&doc); err != nil {
+		return info
+	}
+	info = HatchetInfo{Name: doc.Name, Version: doc.Version, Module: doc.Module, OS: doc.OS, Arch: doc.Arch,
+		Start: doc.Start, End: doc.End, Analyzed: doc.Analyzed, Pinned: doc.Pinned}
+
+	pipeline := bson.A{bson.M{"$match": bson.M{"component": "CONTROL", "message": bson.M{"$regex": "provider:.*region:"}}},
+		bson.M{"$limit": 1}, legacyLogProjection}
+	logs := []LegacyLog{}
+	if err := ptr.aggregate("", pipeline, &logs); err == nil && len(logs) > 0 {
+		re := regexp.MustCompile(`.*(provider: "(\w+)", region: "(\w+)",).*`)
+		if matches := re.FindStringSubmatch(logs[0].Message); len(matches) > 3 {
+			info.Provider = matches[2]
+			info.Region = matches[3]
+		}
+	}
+
+	drivers := []struct {
+		Driver  string `bson:"driver"`
+		Version string `bson:"version"`
+	}{}
+	pipeline = bson.A{bson.M{"$group": bson.M{"_id": bson.M{"driver": "$driver", "version": "$version"}}},
+		bson.M{"$project": bson.M{"_id": 0, "driver": "$_id.driver", "version": "$_id.version"}}}
+	if err := ptr.aggregate("_drivers", pipeline, &drivers); err == nil {
+		for _, driver := range drivers {
+			info.Drivers = append(info.Drivers, map[string]string{driver.Driver: driver.Version})
+		}
+	}
+
+	info.Config = ServerConfig{Warnings: []string{}}
+	var config struct {
+		Host          string   `bson:"host"`
+		Port          int      `bson:"port"`
+		ReplSetName   string   `bson:"repl_set_name"`
+		StorageEngine string   `bson:"storage_engine"`
+		CacheSizeGB   float64  `bson:"cache_size_gb"`
+		SlowMS        int      `bson:"slowms"`
+		Profile       string   `bson:"profile"`
+		Options       string   `bson:"options"`
+		Warnings      []string `bson:"warnings"`
+	}
+	if err := ptr.collection("_config").FindOne(ctx, bson.D{}).Decode(&config); err == nil {
+		info.Config = ServerConfig{Host: config.Host, Port: config.Port, ReplSetName: config.ReplSetName,
+			StorageEngine: config.StorageEngine, CacheSizeGB: config.CacheSizeGB, SlowMS: config.SlowMS,
+			Profile: config.Profile, Options: config.Options, Warnings: config.Warnings}
+		if info.Config.Warnings == nil {
+			info.Config.Warnings = []string{}
+		}
+	}
+	return info
+}
+
+// GetHatchetStatuses returns statuses of hatchets, i.e. REANALYZE_REQUIRED, empty if up to date.  Collections
+// are schemaless and upgraded in place, only hatchets analyzed by newer versions require re-analyzing
+func (ptr *MongoDB) GetHatchetStatuses() (map[string]string, error) {
+	statuses := map[string]string{}
+	docs := []struct {
+		Name    string `bson:"_id"`
+		Status  string `bson:"status"`
+		Version int    `bson:"schema_version"`
+	}{}
+	ctx := context.Background()
+	cursor, err := ptr.db.Collection("hatchet").Find(ctx, bson.D{},
+		options.Find().SetProjection(bson.M{"status": 1, "schema_version": 1}))
+	if err != nil {
+		return statuses, err
+	}
+	if err = cursor.All(ctx, &docs); err != nil {
+		return statuses, err
+	}
+	for _, doc := range docs {
+		if doc.Version == 0 {
+			doc.Version = SCHEMA_VERSION
+		}
+		statuses[doc.Name] = GetSchemaStatus(doc.Version, doc.Status)
+	}
+	return statuses, err
+}
+
+func (ptr *MongoDB) GetHatchetNames() ([]string, error) {
+	hatchets := []string{}
+	ctx := context.Background()
+	cursor, err := ptr.db.Collection("hatchet").Find(ctx, bson.D{},
+		options.Find().SetProjection(bson.M{"_id": 1}).SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return hatchets, err
+	}
+	defer cursor.Close(ctx)
+	for cursor.Next(ctx) {
+		var doc struct {
+			Name string `bson:"_id"`
+		}
+		if err = cursor.Decode(&doc); err != nil {
+			return hatchets, err
+		}
+		hatchets = append(hatchets, doc.Name)
+	}
+	return hatchets, cursor.Err()
+}
+
+// GetAcceptedConnsCounts returns opened connection counts
+func (ptr *MongoDB) GetAcceptedConnsCounts(duration string) ([]NameValue, error) {
+	docs := []NameValue{}
+	stages, err := ptr.getLogLookupStages(duration)
+	if err != nil {
+		return docs, err
+	}
+	pipeline := append(bson.A{bson.M{"$match": bson.M{"accepted": 1}}}, stages...)
+	pipeline = append(pipeline, getNameValueStages("$ip", "$accepted")...)
+	err = ptr.aggregate("_clients", pipeline, &docs)
+	return docs, err
+}
+
+// GetConnectionStats returns stats data of accepted and ended
+func (ptr *MongoDB) GetConnectionStats(chartType string, duration string) ([]RemoteClient, error) {
+	docs := []RemoteClient{}
+	pipeline, err := ptr.getLogLookupStages(duration)
+	if err != nil {
+		return docs, err
+	}
+	group := bson.M{"accepted": bson.M{"$sum": "$accepted"}, "ended": bson.M{"$sum": "$ended"}}
+	if chartType == "time" {
+		group["_id"] = ptr.getDateBucketExpr("$log.date", duration)
+		pipeline = append(pipeline, bson.M{"$group": group},
+			bson.M{"$sort": bson.M{"_id": 1}})
+	} else if chartType == "total" {
+		group["_id"] = "$ip"
+		pipeline = append(pipeline, bson.M{"$group": group},
+			bson.M{"$sort": bson.M{"accepted": -1}})
+	} else {
+		return docs, err
+	}
+	pipeline = append(pipeline, bson.M{"$project": bson.M{"_id": 0, "ip": "$_id", "accepted": 1, "ended": 1}})
+	err = ptr.aggregate("_clients", pipeline, &docs)
+	return docs, err
+}
+
+// GetOpsCounts returns opened connection counts
+func (ptr *MongoDB) GetOpsCounts(duration string, app string) ([]NameValue, error) {
+	docs := []NameValue{}
+	match, err := getDurationMatch("date", duration)
+	if err != nil {
+		return docs, err
+	}
+	match = append(match, bson.E{Key: "op", Value: bson.M{"$ne": ""}})
+	if app != "" {
+		match = append(match, bson.E{Key: "app", Value: app})
+	}
+	pipeline := append(bson.A{bson.M{"$match": match}}, getNameValueStages("$op", 1)...)
+	err = ptr.aggregate("", pipeline, &docs)
+	return docs, err
+}
+
+// GetReslenByIP returns total response length by ip
+func (ptr *MongoDB) GetReslenByIP(ip string, duration string) ([]NameValue, error) {
+	docs := []NameValue{}
+	match, err := getDurationMatch("date", duration)
+	if err != nil {
+		return docs, err
+	}
+	match = append(match, bson.E{Key: "reslen", Value: bson.M{"$gt": 0}})
+	pipeline := bson.A{bson.M{"$match": match},
+		bson.M{"$lookup": bson.M{"from": ptr.hatchetName + "_clients", "localField": "context",
+			"foreignField": "context", "as": "client"}},
+		bson.M{"$unwind": "$client"}}
+	if ip != "" {
+		pipeline = append(pipeline, bson.M{"$match": bson.M{"client.ip": ip}})
+		pipeline = append(pipeline, getNameValueStages("$context", "$reslen")...)
+	} else {
+		pipeline = append(pipeline, getNameValueStages("$client.ip", "$reslen")...)
+	}
+	err = ptr.aggregate("", pipeline, &docs)
+	return docs, err
+}
+
+// GetReslenByNamespace returns total response length by ns
+func (ptr *MongoDB) GetReslenByNamespace(ns string, duration string) ([]NameValue, error) {
+	docs := []NameValue{}
+	match, err := getDurationMatch("date", duration)
+	if err != nil {
+		return docs, err
+	}
+	match = append(match, bson.E{Key: "op", Value: bson.M{"$ne": ""}}, bson.E{Key: "reslen", Value: bson.M{"$gt": 0}})
+	if ns != "" {
+		match = append(match, bson.E{Key: "ns", Value: ns})
+	}
+	pipeline := append(bson.A{bson.M{"$match": match}}, getNameValueStages("$ns", "$reslen")...)
+	err = ptr.aggregate("", pipeline, &docs)
+	return docs, err
+}
+
+// getLogLookupStages returns stages to join logs of the same line, i.e. log.date, and to match
+// dates of a duration
+func (ptr *MongoDB) getLogLookupStages(duration string) (bson.A, error) {
+	stages := bson.A{
+		bson.M{"$lookup": bson.M{"from": ptr.hatchetName, "localField": "id", "foreignField": "_id", "as": "log"}},
+		bson.M{"$unwind": "$log"}}
+	match, err := getDurationMatch("log.date", duration)
+	if err != nil || len(match) == 0 {
+		return stages, err
+	}
+	return append(stages, bson.M{"$match": match}), err
+}
+
+// getDateBucketExpr returns an expression of time buckets of a date field of a duration, or of
+// the hatchet if duration is empty
+func (ptr *MongoDB) getDateBucketExpr(field string, duration string) bson.M {
+	start, end := ptr.getDurationDates(duration)
+	return GetDateBucketExpr(field, start, end)
+}
+
+// getDurationDates returns start and end dates of a duration, or of the hatchet if the duration is empty.
+// Durations are validated by getDurationMatch of callers, of which invalid ones fall back to the hatchet
+func (ptr *MongoDB) getDurationDates(duration string) (string, string) {
+	if toks, err := ParseDuration(duration); err == nil {
+		return toks[0], toks[1]
+	}
+	info := ptr.GetHatchetInfo()
+	return info.Start, info.End
+}
+
+// GetDateBucketExpr returns an aggregation expression of time buckets of a date field, equivalent
+// to GetDateSubString
+func GetDateBucketExpr(field string, start string, end string) bson.M {
+	length, suffix := GetDateBucket(start, end)
+	return bson.M{"$concat": bson.A{bson.M{"$substrBytes": bson.A{field, 0, length}}, suffix}}
+}
+
+// getDurationMatch returns a condition of a date field between dates of a duration, i.e. {date},{date}
+func getDurationMatch(field string, duration string) (bson.D, error) {
+	if duration == "" {
+		return bson.D{}, nil
+	}
+	toks, err := ParseDuration(duration)
+	if err != nil {
+		return bson.D{}, err
+	}
+	return bson.D{{Key: field, Value: bson.M{"$gte": toks[0], "$lte": toks[1]}}}, nil
+}
+
+// getNameValueStages returns stages of name and value pairs, i.e. NameValue, grouped by a name
+// expression and sorted by the sum of a value in descending order
+func getNameValueStages(name interface{}, value interface{}) bson.A {
+	return bson.A{
+		bson.M{"$group": bson.M{"_id": name, "value": bson.M{"$sum": value}}},
+		bson.M{"$sort": bson.D{{Key: "value", Value: -1}, {Key: "_id", Value: 1}}},
+		bson.M{"$project": bson.M{"_id": 0, "name": "$_id", "value": 1}}}
+}
+
+// getOpCountProjection returns a stage projecting { _id.date, milli, count } and op, namespace and
+// filter expressions to fields of OpCount
+func getOpCountProjection(op interface{}, ns interface{}, filter interface{}) bson.M {
+	return bson.M{"$project": bson.M{"_id": 0, "date": "$_id.date", "milli": 1, "count": 1,
+		"op": op, "namespace": ns, "filter": filter}}
+}
+
+// getSortStage returns a $sort stage of a whitelisted sort key and ASC or DESC
+func getSortStage(orderBy string, order string, keys map[string]string) (bson.M, error) {
+	field, ok := keys[orderBy]
+	if !ok {
+		return nil, NewInvalidInputError("invalid sort key %v", orderBy)
+	}
+	order = strings.ToUpper(order)
+	if order == "" || order == "DESC" {
+		return bson.M{"$sort": bson.M{field: -1}}, nil
+	} else if order == "ASC" {
+		return bson.M{"$sort": bson.M{field: 1}}, nil
+	}
+	return nil, NewInvalidInputError("invalid sort order %v", order)
+}
@@ -0,0 +1,46 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * mongodb_replication.go
+ */
+
+package hatchet
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// GetReplEvents returns replication events of a type, all types if empty
+func (ptr *MongoDB) GetReplEvents(eventType string, duration string) ([]ReplEvent, error) {
+	docs := []ReplEvent{}
+	match := bson.D{}
+	if eventType != "" {
+		match = append(match, bson.E{Key: "type", Value: eventType})
+	}
+	stages, err := ptr.getLogLookupStages(duration)
+	if err != nil {
+		return docs, err
+	}
+	pipeline := append(bson.A{bson.M{"$match": match}}, stages...)
+	pipeline = append(pipeline, bson.M{"$sort": bson.M{"id": 1}},
+		bson.M{"$project": bson.M{"_id": 0, "date": "$log.date", "type": 1, "node": 1, "name": 1,
+			"detail": 1, "milli": 1}})
+	err = ptr.aggregate("_repl", pipeline, &docs)
+	return docs, err
+}
+
+// GetOplogApplicationTime returns average oplog application time over a period of time
+func (ptr *MongoDB) GetOplogApplicationTime(duration string) ([]OpCount, error) {
+	docs := []OpCount{}
+	stages, err := ptr.getLogLookupStages(duration)
+	if err != nil {
+		return docs, err
+	}
+	pipeline := append(bson.A{bson.M{"$match": bson.M{"type": REPL_OPLOG}}}, stages...)
+	pipeline = append(pipeline,
+		bson.M{"$group": bson.M{"_id": bson.M{"date": ptr.getDateBucketExpr("$log.date", duration), "name": "$name",
+			"detail": "$detail"}, "milli": bson.M{"$avg": "$milli"}, "count": bson.M{"$sum": 1}}},
+		bson.M{"$sort": bson.M{"_id.date": 1}},
+		getOpCountProjection("$_id.name", "$_id.detail", emptyString))
+	err = ptr.aggregate("_repl", pipeline, &docs)
+	return docs, err
+}
@@ -0,0 +1,151 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * mongodb_sharding.go
+ */
+
+package hatchet
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// GetShardingStats returns migrations, step durations, failures, balancer rounds, splits,
+// range deletions and StaleConfig errors summaries
+func (ptr *MongoDB) GetShardingStats() (ShardingStats, error) {
+	var err error
+	stats := ShardingStats{}
+	if stats.Migrations, err = ptr.getMigrationStats(); err != nil {
+		return stats, err
+	}
+	stats.Failures = []NameValue{}
+	pipeline := append(bson.A{bson.M{"$match": bson.M{"type": SHARD_MIGRATION,
+		"result": bson.M{"$nin": bson.A{"", "success"}}}}},
+		getNameValueStages(bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$detail", ""}}, "$result", "$detail"}}, 1)...)
+	if err = ptr.aggregate("_sharding", pipeline, &stats.Failures); err != nil {
+		return stats, err
+	}
+	if stats.Steps, err = ptr.getMigrationSteps(); err != nil {
+		return stats, err
+	}
+	if stats.Balancer, err = ptr.getBalancerStats(); err != nil {
+		return stats, err
+	}
+	stats.Splits = []NameValue{}
+	pipeline = append(bson.A{bson.M{"$match": bson.M{"type": SHARD_SPLIT}}}, getNameValueStages("$ns", 1)...)
+	if err = ptr.aggregate("_sharding", pipeline, &stats.Splits); err != nil {
+		return stats, err
+	}
+	stats.StaleConfig = []NameValue{}
+	pipeline = append(bson.A{bson.M{"$match": bson.M{"type": SHARD_STALE_CONFIG}}}, getNameValueStages("$ns", 1)...)
+	if err = ptr.aggregate("_sharding", pipeline, &stats.StaleConfig); err != nil {
+		return stats, err
+	}
+
+	stats.RangeDeletions = []NameValues{}
+	docs := []struct {
+		Deleted   int    `bson:"deleted"`
+		NS        string `bson:"_id"`
+		Scheduled int    `bson:"scheduled"`
+	}{}
+	pipeline = bson.A{
+		bson.M{"$match": bson.M{"type": SHARD_RANGE_DELETION}},
+		bson.M{"$group": bson.M{"_id": "$ns", "scheduled": getCountIfExpr("$name", RANGE_SCHEDULED),
+			"deleted": getCountIfExpr("$name", RANGE_DELETED)}},
+		bson.M{"$sort": bson.M{"_id": 1}}}
+	if err = ptr.aggregate("_sharding", pipeline, &docs); err != nil {
+		return stats, err
+	}
+	for _, doc := range docs {
+		backlog := doc.Scheduled - doc.Deleted
+		if backlog < 0 {
+			backlog = 0
+		}
+		stats.RangeDeletions = append(stats.RangeDeletions, NameValues{doc.NS, []int{doc.Scheduled, doc.Deleted, backlog}})
+	}
+	return stats, err
+}
+
+// GetShardingTimeSeries returns counts and average durations of a type of sharding events over a period of time
+func (ptr *MongoDB) GetShardingTimeSeries(eventType string, duration string) ([]OpCount, error) {
+	docs := []OpCount{}
+	stages, err := ptr.getLogLookupStages(duration)
+	if err != nil {
+		return docs, err
+	}
+	var op, ns interface{} = "$name", "$ns"
+	if eventType == SHARD_MIGRATION {
+		op = bson.M{"$concat": bson.A{"$name", " ", "$result"}}
+		ns = bson.M{"$concat": bson.A{"$ns", " ", "$from_shard", " -> ", "$to_shard"}}
+	}
+	pipeline := append(bson.A{bson.M{"$match": bson.M{"type": eventType}}}, stages...)
+	pipeline = append(pipeline,
+		bson.M{"$group": bson.M{"_id": bson.M{"date": ptr.getDateBucketExpr("$log.date", duration), "event": op, "target": ns},
+			"milli": bson.M{"$avg": "$milli"}, "count": bson.M{"$sum": 1}}},
+		bson.M{"$sort": bson.M{"_id.date": 1}},
+		getOpCountProjection("$_id.event", "$_id.target", emptyString))
+	if err = ptr.aggregate("_sharding", pipeline, &docs); err != nil {
+		return docs, err
+	}
+	if eventType == SHARD_RANGE_DELETION {
+		docs = append(docs, getRangeDeleterBacklog(docs)...)
+	}
+	return docs, err
+}
+
+func (ptr *MongoDB) getMigrationStats() ([]MigrationStat, error) {
+	docs := []MigrationStat{}
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{"type": SHARD_MIGRATION}},
+		bson.M{"$group": bson.M{"_id": bson.M{"ns": "$ns", "from": "$from_shard", "to": "$to_shard", "name": "$name"},
+			"total": bson.M{"$sum": 1}, "succeeded": getCountIfExpr("$result", "success"),
+			"avg_ms": bson.M{"$avg": "$milli"}, "max_ms": bson.M{"$max": "$milli"}}},
+		bson.M{"$sort": bson.M{"total": -1}},
+		bson.M{"$project": bson.M{"_id": 0, "namespace": "$_id.ns", "from": "$_id.from", "to": "$_id.to",
+			"role": "$_id.name", "total": 1, "succeeded": 1, "failed": bson.M{"$subtract": bson.A{"$total", "$succeeded"}},
+			"avgmilli": bson.M{"$toInt": "$avg_ms"}, "maxmilli": "$max_ms"}}}
+	err := ptr.aggregate("_sharding", pipeline, &docs)
+	return docs, err
+}
+
+// getMigrationSteps returns count, average and max milliseconds of each donor and recipient step
+func (ptr *MongoDB) getMigrationSteps() ([]NameValues, error) {
+	docs := []NameValues{}
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{"type": SHARD_MIGRATION, "metrics": bson.M{"$type": "object"}}},
+		bson.M{"$project": bson.M{"name": 1, "step": bson.M{"$objectToArray": "$metrics"}}},
+		bson.M{"$unwind": "$step"},
+		bson.M{"$group": bson.M{"_id": bson.M{"$concat": bson.A{"$name", " ", "$step.k"}},
+			"count": bson.M{"$sum": 1}, "total": bson.M{"$sum": "$step.v"}, "max": bson.M{"$max": "$step.v"}}},
+		bson.M{"$sort": bson.M{"_id": 1}},
+		bson.M{"$project": bson.M{"_id": 0, "name": "$_id", "values": bson.A{"$count",
+			bson.M{"$toInt": bson.M{"$trunc": bson.M{"$divide": bson.A{"$total", "$count"}}}}, "$max"}}}}
+	err := ptr.aggregate("_sharding", pipeline, &docs)
+	return docs, err
+}
+
+// getBalancerStats returns totals of balancer rounds, errors, chunks moved and round durations
+func (ptr *MongoDB) getBalancerStats() ([]NameValue, error) {
+	docs := []struct {
+		Errors int `bson:"errors"`
+		Max    int `bson:"max"`
+		Moved  int `bson:"moved"`
+		Rounds int `bson:"rounds"`
+		Total  int `bson:"total"`
+	}{}
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{"type": SHARD_BALANCER}},
+		bson.M{"$group": bson.M{"_id": nil, "rounds": bson.M{"$sum": 1}, "errors": getCountIfExpr("$result", "error"),
+			"moved": bson.M{"$sum": bson.M{"$ifNull": bson.A{"$metrics.chunksMoved", 0}}},
+			"total": bson.M{"$sum": "$milli"}, "max": bson.M{"$max": "$milli"}}}}
+	if err := ptr.aggregate("_sharding", pipeline, &docs); err != nil || len(docs) == 0 || docs[0].Rounds == 0 {
+		return []NameValue{}, err
+	}
+	doc := docs[0]
+	return []NameValue{{"rounds", doc.Rounds}, {"errors", doc.Errors}, {"chunks moved", doc.Moved},
+		{"avg round (ms)", doc.Total / doc.Rounds}, {"max round (ms)", doc.Max}}, nil
+}
+
+// getCountIfExpr returns an accumulator counting documents of which a field equals a value
+func getCountIfExpr(field string, value interface{}) bson.M {
+	return bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{field, value}}, 1, 0}}}
+}
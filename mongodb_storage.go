@@ -0,0 +1,66 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * mongodb_storage.go
+ */
+
+package hatchet
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// GetStorageStats returns checkpoint durations, counts of storage events, and cache pressure,
+// journal and error messages
+func (ptr *MongoDB) GetStorageStats() (StorageStats, error) {
+	var err error
+	stats := StorageStats{Checkpoints: []NameValue{}, Events: []NameValue{}, Warnings: []StorageWarning{}}
+	if err = ptr.aggregate("_storage", getNameValueStages("$type", 1), &stats.Events); err != nil {
+		return stats, err
+	}
+
+	checkpoints := []struct {
+		Avg   int `bson:"avg"`
+		Count int `bson:"count"`
+		Max   int `bson:"max"`
+	}{}
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{"type": STORAGE_CHECKPOINT}},
+		bson.M{"$group": bson.M{"_id": nil, "count": bson.M{"$sum": 1}, "avg": bson.M{"$avg": "$milli"},
+			"max": bson.M{"$max": "$milli"}}},
+		bson.M{"$project": bson.M{"count": 1, "avg": bson.M{"$toInt": "$avg"}, "max": 1}}}
+	if err = ptr.aggregate("_storage", pipeline, &checkpoints); err != nil {
+		return stats, err
+	}
+	if len(checkpoints) > 0 && checkpoints[0].Count > 0 {
+		doc := checkpoints[0]
+		stats.Checkpoints = []NameValue{{"checkpoints", doc.Count}, {"avg (ms)", doc.Avg}, {"max (ms)", doc.Max}}
+	}
+
+	events := []StorageEvent{}
+	pipeline = bson.A{
+		bson.M{"$match": bson.M{"type": bson.M{"$in": bson.A{STORAGE_ERROR, STORAGE_EVICTION, STORAGE_JOURNAL}}}},
+		bson.M{"$sort": bson.M{"id": 1}},
+		bson.M{"$project": bson.M{"_id": 0, "type": 1, "detail": 1}}}
+	if err = ptr.aggregate("_storage", pipeline, &events); err != nil {
+		return stats, err
+	}
+	stats.Warnings = getStorageWarnings(events)
+	return stats, err
+}
+
+// GetCheckpointDurations returns counts and average durations of checkpoints over a period of time
+func (ptr *MongoDB) GetCheckpointDurations(duration string) ([]OpCount, error) {
+	docs := []OpCount{}
+	stages, err := ptr.getLogLookupStages(duration)
+	if err != nil {
+		return docs, err
+	}
+	pipeline := append(bson.A{bson.M{"$match": bson.M{"type": STORAGE_CHECKPOINT}}}, stages...)
+	pipeline = append(pipeline,
+		bson.M{"$group": bson.M{"_id": bson.M{"date": ptr.getDateBucketExpr("$log.date", duration),
+			"component": "$log.component"}, "milli": bson.M{"$avg": "$milli"}, "count": bson.M{"$sum": 1}}},
+		bson.M{"$sort": bson.M{"_id.date": 1}},
+		getOpCountProjection(bson.M{"$literal": STORAGE_CHECKPOINT}, "$_id.component", emptyString))
+	err = ptr.aggregate("_storage", pipeline, &docs)
+	return docs, err
+}
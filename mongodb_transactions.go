@@ -0,0 +1,73 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * mongodb_transactions.go
+ */
+
+package hatchet
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// GetTransactionStats returns counts of terminations, abort causes and time distributions
+func (ptr *MongoDB) GetTransactionStats() (map[string][]NameValues, error) {
+	data := map[string][]NameValues{}
+	audits := []struct {
+		Name  string `bson:"name"`
+		Type  string `bson:"type"`
+		Value int    `bson:"value"`
+	}{}
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{"type": bson.M{"$in": bson.A{"txn", "txn-abort"}}}},
+		bson.M{"$sort": bson.D{{Key: "type", Value: 1}, {Key: "value", Value: -1}}}}
+	if err := ptr.aggregate("_audit", pipeline, &audits); err != nil {
+		return data, err
+	}
+	for _, audit := range audits {
+		data[audit.Type] = append(data[audit.Type], NameValues{audit.Name, []int{audit.Value}})
+	}
+
+	category := "distribution"
+	for _, bucket := range TXN_BUCKETS {
+		data[category] = append(data[category], NameValues{bucket.Name, []int{0, 0}})
+	}
+	for n, field := range []string{"$time_active", "$time_inactive"} {
+		counts := []struct {
+			Bucket int `bson:"_id"`
+			Count  int `bson:"count"`
+		}{}
+		pipeline = bson.A{bson.M{"$group": bson.M{"_id": getBucketSwitchExpr(field, TXN_BUCKETS),
+			"count": bson.M{"$sum": 1}}}}
+		if err := ptr.aggregate("_txns", pipeline, &counts); err != nil {
+			return data, err
+		}
+		for _, count := range counts {
+			data[category][count.Bucket].Values[n] = count.Count
+		}
+	}
+	return data, nil
+}
+
+// GetLongestTransactions returns the topN longest transactions
+func (ptr *MongoDB) GetLongestTransactions(topN int) ([]Transaction, error) {
+	docs := []Transaction{}
+	stages, _ := ptr.getLogLookupStages("")
+	pipeline := append(bson.A{bson.M{"$sort": bson.M{"milli": -1}}, bson.M{"$limit": topN}}, stages...)
+	pipeline = append(pipeline, bson.M{"$project": bson.M{"_id": 0, "date": "$log.date", "lsid": 1,
+		"txnnumber": "$txn_number", "termination": 1, "abortcause": "$abort_cause", "milli": 1,
+		"timeactive": "$time_active", "timeinactive": "$time_inactive", "numyields": "$num_yields",
+		"keysexamined": "$keys_examined", "docsexamined": "$docs_examined", "nreturned": 1, "ninserted": 1,
+		"nmodified": 1, "ndeleted": 1, "readconcern": "$read_concern", "wasprepared": "$was_prepared"}})
+	err := ptr.aggregate("_txns", pipeline, &docs)
+	return docs, err
+}
+
+// getBucketSwitchExpr returns a $switch expression mapping a field to the index of its bucket,
+// the last bucket takes everything greater than the previous upper bound
+func getBucketSwitchExpr(field string, buckets []NameValue) bson.M {
+	branches := bson.A{}
+	for i, bucket := range buckets[:len(buckets)-1] {
+		branches = append(branches, bson.M{"case": bson.M{"$lt": bson.A{field, bucket.Value}}, "then": i})
+	}
+	return bson.M{"$switch": bson.M{"branches": branches, "default": len(buckets) - 1}}
+}
@@ -0,0 +1,57 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * mongodb_uptime.go
+ */
+
+package hatchet
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// uptimeProjection projects uptime segments to fields of UptimeSegment
+var uptimeProjection = bson.M{"$project": bson.M{"_id": 0, "id": 1, "pid": 1, "host": 1, "port": 1,
+	"start": 1, "end": 1, "endid": "$end_id", "status": 1, "crashid": "$crash_id", "reason": 1}}
+
+// GetUptimeSegments returns process lifetimes split by restarts
+func (ptr *MongoDB) GetUptimeSegments() ([]UptimeSegment, error) {
+	segments := []UptimeSegment{}
+	pipeline := bson.A{bson.M{"$sort": bson.M{"id": 1}}, uptimeProjection}
+	err := ptr.aggregate("_uptime", pipeline, &segments)
+	return segments, err
+}
+
+// GetCrashDetail returns logs preceding a crash and the stack trace of an uptime segment, logs
+// before the last line are returned for an unclean shutdown
+func (ptr *MongoDB) GetCrashDetail(id int) (CrashDetail, error) {
+	detail := CrashDetail{Preceding: []LegacyLog{}, StackTrace: []LegacyLog{}}
+	segments := []UptimeSegment{}
+	pipeline := bson.A{bson.M{"$match": bson.M{"id": id}}, bson.M{"$limit": 1}, uptimeProjection}
+	err := ptr.aggregate("_uptime", pipeline, &segments)
+	if err != nil {
+		return detail, err
+	} else if len(segments) == 0 {
+		return detail, mongo.ErrNoDocuments
+	}
+	detail.Segment = segments[0]
+	segment := &detail.Segment
+	last := segment.EndID + 1
+	if segment.CrashID > 0 {
+		last = segment.CrashID
+		if detail.StackTrace, err = ptr.getLogsByRange(segment.CrashID, segment.EndID, CRASH_TRACE_LINES); err != nil {
+			return detail, err
+		}
+	}
+	detail.Preceding, err = ptr.getLogsByRange(last-CRASH_PRECEDING_LINES, last-1, CRASH_PRECEDING_LINES)
+	return detail, err
+}
+
+// getLogsByRange returns logs between two line numbers
+func (ptr *MongoDB) getLogsByRange(from int, to int, limit int) ([]LegacyLog, error) {
+	docs := []LegacyLog{}
+	pipeline := bson.A{bson.M{"$match": bson.M{"_id": bson.M{"$gte": from, "$lte": to}}},
+		bson.M{"$sort": bson.M{"_id": 1}}, bson.M{"$limit": limit}, legacyLogProjection}
+	err := ptr.aggregate("", pipeline, &docs)
+	return docs, err
+}
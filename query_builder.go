@@ -0,0 +1,229 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * query_builder.go
+ */
+
+package hatchet
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// LOG_FILTERS are columns of which logs can be filtered by
+var LOG_FILTERS = map[string]bool{"app": true, "component": true, "context": true, "driver": true,
+	"msg": true, "ns": true, "op": true, "plan": true}
+
+// SLOWOPS_SORT_KEYS maps sort keys of slow ops stats to columns
+var SLOWOPS_SORT_KEYS = map[string]string{"app": "app", "avg_ms": "avg_ms", "count": "count",
+	"index": "_index", "_index": "_index", "max_ms": "max_ms", "ns": "ns", "op": "op",
+	"reslen": "reslen", "total_ms": "total_ms"}
+
+// ATTR_FILTER_OPS are comparison operators of JSON path filters of attributes
+var ATTR_FILTER_OPS = map[string]string{"=": "$eq", "!=": "$ne", ">": "$gt", ">=": "$gte", "<": "$lt", "<=": "$lte"}
+
+// InvalidInputError is an error of invalid request parameters
+type InvalidInputError struct {
+	message string
+}
+
+func (e *InvalidInputError) Error() string {
+	return e.message
+}
+
+// NewInvalidInputError returns an InvalidInputError
+func NewInvalidInputError(format string, a ...interface{}) error {
+	return &InvalidInputError{message: fmt.Sprintf(format, a...)}
+}
+
+// IsInvalidInput returns true if an error is caused by invalid request parameters
+func IsInvalidInput(err error) bool {
+	var e *InvalidInputError
+	return errors.As(err, &e)
+}
+
+// QueryBuilder builds conditions of a WHERE clause with values bound as parameters
+type QueryBuilder struct {
+	args   []interface{}
+	wheres []string
+}
+
+// NewQueryBuilder returns a QueryBuilder
+func NewQueryBuilder() *QueryBuilder {
+	return &QueryBuilder{args: []interface{}{}, wheres: []string{}}
+}
+
+// Where adds a condition with ? placeholders and their values
+func (ptr *QueryBuilder) Where(cond string, args ...interface{}) *QueryBuilder {
+	ptr.wheres = append(ptr.wheres, cond)
+	ptr.args = append(ptr.args, args...)
+	return ptr
+}
+
+// WhereEqual adds a condition of a column equal to a value, the column must be one of the filters
+func (ptr *QueryBuilder) WhereEqual(column string, value interface{}, filters map[string]bool) error {
+	if !filters[column] {
+		return NewInvalidInputError("invalid filter %v", column)
+	}
+	ptr.Where(column+" = ?", value)
+	return nil
+}
+
+// WhereIn adds a condition of a column in a list of values
+func (ptr *QueryBuilder) WhereIn(column string, values []string) *QueryBuilder {
+	if len(values) == 0 {
+		return ptr.Where("0")
+	}
+	args := []interface{}{}
+	for _, value := range values {
+		args = append(args, value)
+	}
+	marks := strings.TrimSuffix(strings.Repeat("?,", len(values)), ",")
+	return ptr.Where(fmt.Sprintf("%v IN (%v)", column, marks), args...)
+}
+
+// WhereDuration adds a condition of a date column between dates of a duration, i.e. {date},{date}
+func (ptr *QueryBuilder) WhereDuration(column string, duration string) error {
+	if duration == "" {
+		return nil
+	}
+	toks, err := ParseDuration(duration)
+	if err != nil {
+		return err
+	}
+	ptr.Where(column+" BETWEEN ? AND ?", toks[0], toks[1])
+	return nil
+}
+
+// WhereAttr adds a condition of a JSON path of the attr column
+func (ptr *QueryBuilder) WhereAttr(filter *AttrFilter) *QueryBuilder {
+	return ptr.Where(fmt.Sprintf("json_extract(attr, ?) %v ?", filter.Op), filter.JSONPath(), filter.Value)
+}
+
+// WhereDurationMinutes adds a condition of a column of minutes, i.e. yyyy-mm-ddThh:mm, within a duration
+// of whole minutes, see IsWholeMinutes
+func (ptr *QueryBuilder) WhereDurationMinutes(column string, duration string) error {
+	if duration == "" {
+		return nil
+	}
+	toks, err := ParseDuration(duration)
+	if err != nil {
+		return err
+	}
+	ptr.Where(column+" >= SUBSTR(?, 1, 16) AND "+column+" < SUBSTR(?, 1, 16)", toks[0], toks[1])
+	return nil
+}
+
+// IsWholeMinutes returns true if dates of a duration are at minutes, i.e. yyyy-mm-ddThh:mm[:00[.000]], of
+// which logs between them are all logs of minutes from the start minute until before the end minute
+func IsWholeMinutes(start string, end string) bool {
+	for _, date := range []string{start, end} {
+		if len(date) < 16 {
+			return false
+		}
+		if rest := date[16:]; rest != "" && rest != ":00" && rest != ":00.000" {
+			return false
+		}
+	}
+	return true
+}
+
+// Args returns values of conditions
+func (ptr *QueryBuilder) Args() []interface{} {
+	return ptr.args
+}
+
+// Conditions returns conditions joined by AND
+func (ptr *QueryBuilder) Conditions() string {
+	return strings.Join(ptr.wheres, " AND ")
+}
+
+// Clause returns a WHERE clause, or an empty string if there is no condition
+func (ptr *QueryBuilder) Clause() string {
+	if len(ptr.wheres) == 0 {
+		return ""
+	}
+	return "WHERE " + ptr.Conditions()
+}
+
+// GetOrderByClause returns an ORDER BY clause of a whitelisted sort key and ASC or DESC
+func GetOrderByClause(orderBy string, order string, keys map[string]string) (string, error) {
+	column, ok := keys[orderBy]
+	if !ok {
+		return "", NewInvalidInputError("invalid sort key %v", orderBy)
+	}
+	order = strings.ToUpper(order)
+	if order == "" {
+		order = "DESC"
+	} else if order != "ASC" && order != "DESC" {
+		return "", NewInvalidInputError("invalid sort order %v", order)
+	}
+	return fmt.Sprintf("ORDER BY %v %v", column, order), nil
+}
+
+// ParseDuration returns start and end dates of a duration, i.e. {date},{date}
+func ParseDuration(duration string) ([]string, error) {
+	toks := strings.Split(duration, ",")
+	if len(toks) != 2 || toks[0] == "" || toks[1] == "" {
+		return nil, NewInvalidInputError("invalid duration %v", duration)
+	}
+	return toks, nil
+}
+
+// ValidateHatchetName returns an error if a hatchet name is not a valid table name
+func ValidateHatchetName(hatchetName string) error {
+	re := regexp.MustCompile(`^[\p{L}_][\p{L}\p{N}_]*$`)
+	if !re.MatchString(hatchetName) {
+		return NewInvalidInputError("invalid hatchet name %v", hatchetName)
+	}
+	return nil
+}
+
+// AttrFilter is a comparison of a value at a path of attributes, e.g. attr.command.find=orders
+type AttrFilter struct {
+	Path  []string
+	Op    string
+	Value interface{}
+}
+
+// ParseAttrFilter parses attr.{path}{op}{value}, of which op is one of ATTR_FILTER_OPS.  Values are
+// compared as numbers or booleans if they can be parsed, or as strings otherwise
+func ParseAttrFilter(expr string) (*AttrFilter, error) {
+	re := regexp.MustCompile(`^attr((?:\.[A-Za-z_][A-Za-z0-9_]*)+)(!=|>=|<=|=|>|<)(.*)$`)
+	matches := re.FindStringSubmatch(expr)
+	if matches == nil {
+		return nil, NewInvalidInputError("invalid attr filter %v", expr)
+	}
+	filter := &AttrFilter{Path: strings.Split(matches[1][1:], "."), Op: matches[2], Value: matches[3]}
+	if value, err := strconv.ParseFloat(matches[3], 64); err == nil {
+		filter.Value = value
+	} else if value, err := strconv.ParseBool(matches[3]); err == nil && (matches[3] == "true" || matches[3] == "false") {
+		filter.Value = value
+	}
+	return filter, nil
+}
+
+// JSONPath returns the path of SQLite JSON functions, e.g. $."command"."find"
+func (ptr *AttrFilter) JSONPath() string {
+	return `$."` + strings.Join(ptr.Path, `"."`) + `"`
+}
+
+// GetAttrFilters returns JSON path filters of attributes, e.g. attr.durationMillis>500, of a raw URL
+// query, of which = and > are parsed, unescaped, as parts of names
+func GetAttrFilters(rawQuery string) ([]string, error) {
+	filters := []string{}
+	for _, part := range strings.Split(rawQuery, "&") {
+		expr, err := url.QueryUnescape(part)
+		if err != nil {
+			return filters, NewInvalidInputError("invalid query %v", part)
+		}
+		if strings.HasPrefix(expr, "attr.") {
+			filters = append(filters, expr)
+		}
+	}
+	return filters, nil
+}
@@ -0,0 +1,199 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * query_builder_test.go
+ */
+
+package hatchet
+
+import (
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestQueryBuilder(t *testing.T) {
+	builder := NewQueryBuilder()
+	if builder.Clause() != "" {
+		t.Fatal("expected empty clause but got", builder.Clause())
+	}
+	builder.Where("op != ''").WhereIn("severity", []string{"F", "E"})
+	if err := builder.WhereDuration("date", "2023-03-01T10:00:00,2023-03-01T11:00:00"); err != nil {
+		t.Fatal(err)
+	}
+	if err := builder.WhereEqual("ns", `x" OR 1=1 --`, LOG_FILTERS); err != nil {
+		t.Fatal(err)
+	}
+	expected := "WHERE op != '' AND severity IN (?,?) AND date BETWEEN ? AND ? AND ns = ?"
+	if builder.Clause() != expected {
+		t.Fatal("expected", expected, "but got", builder.Clause())
+	}
+	if len(builder.Args()) != 5 || builder.Args()[4] != `x" OR 1=1 --` {
+		t.Fatal("unexpected", builder.Args())
+	}
+	if err := builder.WhereEqual("1=1 OR message", "x", LOG_FILTERS); !IsInvalidInput(err) {
+		t.Fatal("expected invalid input but got", err)
+	}
+	if err := builder.WhereDuration("date", "2023-03-01T10:00:00"); !IsInvalidInput(err) {
+		t.Fatal("expected invalid input but got", err)
+	}
+	builder = NewQueryBuilder()
+	if err := builder.WhereDurationMinutes("date", "2023-03-01T10:00:00,2023-03-01T11:00:00"); err != nil {
+		t.Fatal(err)
+	}
+	if builder.Clause() != "WHERE date >= SUBSTR(?, 1, 16) AND date < SUBSTR(?, 1, 16)" {
+		t.Fatal("unexpected", builder.Clause())
+	}
+}
+
+func TestIsWholeMinutes(t *testing.T) {
+	for _, toks := range [][]string{{"2023-03-01T10:00", "2023-03-01T11:00"},
+		{"2023-03-01T10:00:00", "2023-03-01T11:00:00.000"}} {
+		if !IsWholeMinutes(toks[0], toks[1]) {
+			t.Fatal("expected", true, "but got", false, toks)
+		}
+	}
+	for _, toks := range [][]string{{"2023-03-01T10:00:30", "2023-03-01T11:00"},
+		{"2023-03-01T10:00", "2023-03-01T11:00:59"}, {"2023-03-01", "2023-03-02"},
+		{"2023-03-01T10:00:00.000-0000", "2023-03-01T11:00:00.000-0000"}} {
+		if IsWholeMinutes(toks[0], toks[1]) {
+			t.Fatal("expected", false, "but got", true, toks)
+		}
+	}
+}
+
+func TestGetOrderByClause(t *testing.T) {
+	tests := []struct {
+		orderBy, order, clause string
+		valid                  bool
+	}{
+		{"avg_ms", "", "ORDER BY avg_ms DESC", true},
+		{"index", "asc", "ORDER BY _index ASC", true},
+		{"ns", "ASC", "ORDER BY ns ASC", true},
+		{"milli; DROP TABLE hatchet", "", "", false},
+		{"count", "DESC, 1", "", false},
+	}
+	for _, test := range tests {
+		clause, err := GetOrderByClause(test.orderBy, test.order, SLOWOPS_SORT_KEYS)
+		if test.valid && (err != nil || clause != test.clause) {
+			t.Fatal("expected", test.clause, "but got", clause, err)
+		} else if !test.valid && !IsInvalidInput(err) {
+			t.Fatal("expected invalid input but got", err)
+		}
+	}
+}
+
+func TestGetLogsQueryBuilder(t *testing.T) {
+	sqlite := &SQLite3DB{hatchetName: "test"}
+	builder, offset, qlimit, err := sqlite.getLogsQueryBuilder(false, "component=COMMAND", "limit=100,50",
+		"severity=W", "errcode=11000", "context=", "txn=")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "WHERE component = ? AND severity IN (?,?,?) AND id IN (SELECT id FROM test_errors WHERE code = ?)"
+	if builder.Clause() != expected || offset != 100 || qlimit != 51 {
+		t.Fatal("unexpected", builder.Clause(), offset, qlimit)
+	}
+	if builder, _, _, err = sqlite.getLogsQueryBuilder(true, "context=Slow"); err != nil {
+		t.Fatal(err)
+	}
+	if builder.Clause() != `WHERE message LIKE ? ESCAPE '\'` || builder.Args()[0] != "%Slow%" {
+		t.Fatal("unexpected", builder.Clause(), builder.Args())
+	}
+	for _, opt := range []string{"severity=X", "message=x", "duration=x", "txn=x", "txn=9a1f813b,7"} {
+		if _, _, _, err = sqlite.getLogsQueryBuilder(false, opt); !IsInvalidInput(err) {
+			t.Fatal("expected invalid input of", opt, "but got", err)
+		}
+	}
+}
+
+func TestParseAttrFilter(t *testing.T) {
+	tests := map[string]AttrFilter{
+		"attr.command.find=orders":      {[]string{"command", "find"}, "=", "orders"},
+		"attr.durationMillis>500":       {[]string{"durationMillis"}, ">", float64(500)},
+		"attr.ns!=test.users":           {[]string{"ns"}, "!=", "test.users"},
+		"attr.command.autocommit=false": {[]string{"command", "autocommit"}, "=", false},
+		"attr.reslen<=1.5e3":            {[]string{"reslen"}, "<=", 1500.0},
+	}
+	for expr, expected := range tests {
+		filter, err := ParseAttrFilter(expr)
+		if err != nil {
+			t.Fatal(expr, err)
+		}
+		if !reflect.DeepEqual(*filter, expected) {
+			t.Fatal("expected", expected, "but got", *filter)
+		}
+	}
+	for _, expr := range []string{"attr", "attr.=x", "attr.a", "attr.$db=x", "attr.a'--=x", "command.find=orders"} {
+		if _, err := ParseAttrFilter(expr); !IsInvalidInput(err) {
+			t.Fatal("expected invalid input of", expr, "but got", err)
+		}
+	}
+	filter, _ := ParseAttrFilter("attr.command.find=orders")
+	builder := NewQueryBuilder().WhereAttr(filter)
+	if builder.Clause() != "WHERE json_extract(attr, ?) = ?" || builder.Args()[0] != `$."command"."find"` {
+		t.Fatal("unexpected", builder.Clause(), builder.Args())
+	}
+}
+
+func TestGetAttrFilters(t *testing.T) {
+	filters, err := GetAttrFilters("component=COMMAND&attr.command.find=orders&attr.durationMillis%3E%3D500&context=x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"attr.command.find=orders", "attr.durationMillis>=500"}
+	if !reflect.DeepEqual(filters, expected) {
+		t.Fatal("expected", expected, "but got", filters)
+	}
+	if _, err = GetAttrFilters("attr.x=%zz"); !IsInvalidInput(err) {
+		t.Fatal("expected invalid input but got", err)
+	}
+}
+
+func TestValidateHatchetName(t *testing.T) {
+	for _, name := range []string{"mongod_b28d", "_2023_03_01_mongod", "日志_a1"} {
+		if err := ValidateHatchetName(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"", "1mongod", "x; DROP TABLE hatchet", "x'--"} {
+		if err := ValidateHatchetName(name); !IsInvalidInput(err) {
+			t.Fatal("expected invalid input of", name, "but got", err)
+		}
+	}
+}
+
+func TestDurationsOfQueries(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "hatchet.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	sqlite := &SQLite3DB{db: newSQLite3Pool(db), hatchetName: "mongod_1b3d5f"}
+	if _, err = db.Exec(sqlite.GetHatchetInitStmt()); err != nil {
+		t.Fatal(err)
+	}
+	queries := map[string]func(duration string) error{
+		"GetAcceptedRate":           func(d string) error { _, err := sqlite.GetAcceptedRate(d); return err },
+		"GetAuthFailures":           func(d string) error { _, err := sqlite.GetAuthFailures(d); return err },
+		"GetCheckpointDurations":    func(d string) error { _, err := sqlite.GetCheckpointDurations(d); return err },
+		"GetConnectionDistribution": func(d string) error { _, err := sqlite.GetConnectionDistribution(d); return err },
+		"GetConnectionLifetimes":    func(d string) error { _, err := sqlite.GetConnectionLifetimes(d); return err },
+		"GetErrorCounts":            func(d string) error { _, err := sqlite.GetErrorCounts(0, d); return err },
+		"GetIndexBuilds":            func(d string) error { _, err := sqlite.GetIndexBuilds(d); return err },
+		"GetOplogApplicationTime":   func(d string) error { _, err := sqlite.GetOplogApplicationTime(d); return err },
+		"GetReplEvents":             func(d string) error { _, err := sqlite.GetReplEvents("", d); return err },
+		"GetShardingTimeSeries":     func(d string) error { _, err := sqlite.GetShardingTimeSeries(SHARD_MIGRATION, d); return err },
+		"GetWriteConcernLatency":    func(d string) error { _, err := sqlite.GetWriteConcernLatency(d); return err },
+	}
+	for name, query := range queries {
+		if err = query("2023-03-01T10:00:00,2023-03-01T11:00:00"); err != nil {
+			t.Fatal(name, err)
+		}
+		for _, duration := range []string{"2023-03-01T10:00:00", "2023-03-01T10:00:00,", "a,b,c"} {
+			if err = query(duration); !IsInvalidInput(err) {
+				t.Fatal("expected invalid input of", name, duration, "but got", err)
+			}
+		}
+	}
+}
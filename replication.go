@@ -0,0 +1,244 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * replication.go
+ */
+
+package hatchet
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	REPL_ELECTION = "election"
+	REPL_OPLOG    = "oplog"
+	REPL_ROLLBACK = "rollback"
+	REPL_STATE    = "state"
+	REPL_SYNC     = "sync"
+
+	ELECTION_LOST  = "lost"
+	ELECTION_START = "start"
+	ELECTION_WON   = "won"
+
+	SELF_NODE = "self"
+)
+
+// ReplEvent stores a replication event
+type ReplEvent struct {
+	Date   string `json:"date"`
+	Detail string `json:"detail"`
+	Milli  int    `json:"duration_ms"`
+	Name   string `json:"name"`
+	Node   string `json:"node"`
+	Type   string `json:"type"`
+}
+
+// Election stores an election from its start to its result
+type Election struct {
+	End    string `json:"end"`
+	Milli  int    `json:"duration_ms"`
+	Node   string `json:"node"`
+	Reason string `json:"reason"`
+	Result string `json:"result"`
+	Start  string `json:"start"`
+	Term   string `json:"term"`
+}
+
+// StateSegment stores a period of a replica set member state
+type StateSegment struct {
+	End   string `json:"end"`
+	Node  string `json:"node"`
+	Start string `json:"start"`
+	State string `json:"state"`
+}
+
+// ReplicationData stores elections, member states, sync source changes and rollbacks
+type ReplicationData struct {
+	Elections   []Election     `json:"elections"`
+	Rollbacks   []ReplEvent    `json:"rollbacks"`
+	States      []StateSegment `json:"states"`
+	SyncSources []ReplEvent    `json:"sync_sources"`
+}
+
+// AnalyzeReplEvent analyzes elections, state transitions, oplog application, sync source and rollback logs
+func AnalyzeReplEvent(doc *Logv2Info) (*ReplEvent, error) {
+	c := doc.Component
+	if c != "REPL" && c != "ELECTION" && c != "ROLLBACK" && c != "REPL_HB" {
+		return nil, errors.New("not a replication event")
+	}
+	attrMap := doc.Attr.Map()
+	msg := doc.Msg
+	event := &ReplEvent{}
+	if strings.HasPrefix(msg, "Starting an election") {
+		event.Type = REPL_ELECTION
+		event.Name = ELECTION_START
+		event.Detail = getElectionReason(msg, attrMap)
+	} else if strings.HasPrefix(msg, "Election succeeded") {
+		event.Type = REPL_ELECTION
+		event.Name = ELECTION_WON
+		if attrMap["term"] != nil {
+			event.Detail = fmt.Sprintf("%v", attrMap["term"])
+		}
+	} else if strings.HasPrefix(msg, "Not becoming primary") || strings.HasPrefix(msg, "Lost election") ||
+		strings.HasPrefix(msg, "Election failed") || strings.HasPrefix(msg, "Not running for primary") {
+		event.Type = REPL_ELECTION
+		event.Name = ELECTION_LOST
+		event.Detail = msg
+		if reason, ok := attrMap["reason"].(string); ok {
+			event.Detail = reason
+		}
+	} else if msg == "Replica set state transition" {
+		event.Type = REPL_STATE
+		event.Name, _ = attrMap["newState"].(string)
+		event.Detail, _ = attrMap["oldState"].(string)
+	} else if msg == "Member is in new state" || msg == "Member is now in state" {
+		event.Type = REPL_STATE
+		event.Node, _ = attrMap["hostAndPort"].(string)
+		event.Name, _ = attrMap["newState"].(string)
+		if event.Node == "" {
+			return nil, errors.New("no member found")
+		}
+	} else if msg == "Applied op" || strings.Contains(strings.ToLower(msg), "oplog application") {
+		event.Type = REPL_OPLOG
+		event.Milli = ToInt(attrMap["durationMillis"])
+		event.Name = "oplog application"
+		if command, ok := attrMap["command"].(bson.D); ok {
+			event.Detail, _ = command.Map()["ns"].(string)
+		}
+		if event.Milli == 0 {
+			return nil, errors.New("no duration found")
+		}
+	} else if strings.Contains(strings.ToLower(msg), "sync source") &&
+		(attrMap["syncSource"] != nil || attrMap["newSyncSource"] != nil) {
+		event.Type = REPL_SYNC
+		if attrMap["newSyncSource"] != nil {
+			event.Name = fmt.Sprintf("%v", attrMap["newSyncSource"])
+		} else {
+			event.Name = fmt.Sprintf("%v", attrMap["syncSource"])
+		}
+		event.Detail = msg
+		if attrMap["oldSyncSource"] != nil {
+			event.Detail = fmt.Sprintf("%v (previous %v)", msg, attrMap["oldSyncSource"])
+		}
+	} else if c == "ROLLBACK" && (strings.HasPrefix(msg, "Starting rollback") || strings.HasPrefix(msg, "Rollback") ||
+		doc.Severity == "W" || doc.Severity == "E" || doc.Severity == "F") {
+		event.Type = REPL_ROLLBACK
+		event.Name = msg
+		event.Milli = ToInt(attrMap["durationMillis"])
+		event.Detail = doc.Message
+		if len(event.Detail) > 256 {
+			event.Detail = event.Detail[:256] + "..."
+		}
+	} else {
+		return nil, errors.New("not a replication event")
+	}
+	if event.Node == "" {
+		event.Node = SELF_NODE
+	}
+	return event, nil
+}
+
+func getElectionReason(msg string, attrMap map[string]interface{}) string {
+	if reason, ok := attrMap["reason"].(string); ok {
+		return reason
+	}
+	lower := strings.ToLower(msg)
+	reasons := []string{"election timeout", "step up request", "priority takeover", "catchup takeover"}
+	for _, reason := range reasons {
+		if strings.Contains(lower, reason) {
+			return reason
+		}
+	}
+	return msg
+}
+
+// GetElections pairs election starts with their results, events are sorted by date
+func GetElections(events []ReplEvent) []Election {
+	elections := []Election{}
+	var election *Election
+	for _, event := range events {
+		if event.Type != REPL_ELECTION {
+			continue
+		}
+		if event.Name == ELECTION_START {
+			if election != nil { // no result found
+				elections = append(elections, *election)
+			}
+			election = &Election{Start: event.Date, Node: event.Node, Reason: event.Detail}
+			continue
+		}
+		if election == nil { // result without a start
+			election = &Election{Start: event.Date, Node: event.Node}
+		}
+		election.End = event.Date
+		election.Result = event.Name
+		if event.Name == ELECTION_WON {
+			election.Term = event.Detail
+		} else if election.Reason == "" {
+			election.Reason = event.Detail
+		}
+		election.Milli = getMilliseconds(election.Start, election.End)
+		elections = append(elections, *election)
+		election = nil
+	}
+	if election != nil {
+		elections = append(elections, *election)
+	}
+	return elections
+}
+
+// GetReplicationData groups replication events, events are sorted by date
+func GetReplicationData(events []ReplEvent, end string) ReplicationData {
+	data := ReplicationData{Elections: GetElections(events), Rollbacks: []ReplEvent{},
+		States: GetStateTimeline(events, end), SyncSources: []ReplEvent{}}
+	for _, event := range events {
+		if event.Type == REPL_ROLLBACK {
+			data.Rollbacks = append(data.Rollbacks, event)
+		} else if event.Type == REPL_SYNC {
+			data.SyncSources = append(data.SyncSources, event)
+		}
+	}
+	return data
+}
+
+// GetStateTimeline returns state periods of each node, events are sorted by date
+func GetStateTimeline(events []ReplEvent, end string) []StateSegment {
+	segments := []StateSegment{}
+	last := map[string]int{}
+	for _, event := range events {
+		if event.Type != REPL_STATE || event.Name == "" {
+			continue
+		}
+		if i, ok := last[event.Node]; ok {
+			if segments[i].State == event.Name {
+				continue
+			}
+			segments[i].End = event.Date
+		}
+		segments = append(segments, StateSegment{Node: event.Node, State: event.Name, Start: event.Date})
+		last[event.Node] = len(segments) - 1
+	}
+	for _, i := range last {
+		segments[i].End = end
+	}
+	return segments
+}
+
+// getMilliseconds returns milliseconds between two log date strings
+func getMilliseconds(start string, end string) int {
+	layout := "2006-01-02T15:04:05.000-0700"
+	var err error
+	var stime, etime time.Time
+	if stime, err = time.Parse(layout, start); err != nil {
+		return 0
+	}
+	if etime, err = time.Parse(layout, end); err != nil {
+		return 0
+	}
+	return int(etime.Sub(stime).Milliseconds())
+}
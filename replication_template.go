@@ -0,0 +1,95 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * replication_template.go
+ */
+
+package hatchet
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+)
+
+// GetReplicationTemplate returns HTML
+func GetReplicationTemplate() (*template.Template, error) {
+	html := getContentHTML()
+	html += `{{$name := .Hatchet}}
+<script>
+	document.getElementById('nextReport').selectedIndex = {{.Report.Index}};
+</script>
+	<div style='clear: left; margin: 10px 10px;'>
+		<button class='button' onClick="javascript:location.href='/hatchets/{{$name}}/charts/replication?type=states'; return false;">
+			<i class='fa fa-bar-chart'></i> Member States</button>
+		<button class='button' onClick="javascript:location.href='/hatchets/{{$name}}/charts/replication?type=oplog'; return false;">
+			<i class='fa fa-line-chart'></i> Oplog Application Latency</button>
+	</div>
+{{if .Data.Elections}}
+	<table style='float: left; margin: 10px 10px; clear: left;'>
+		<caption><span style="font-size: 16px; padding: 5px 5px;"><i class="fa fa-flag"></i></span>Elections</caption>
+		<tr><th></th><th>start</th><th>node</th><th>reason</th><th>result</th><th>term</th><th>duration</th></tr>
+	{{range $n, $val := .Data.Elections}}
+		<tr><td align=right>{{add $n 1}}</td><td>{{formatDateTime $val.Start}}</td><td>{{$val.Node}}</td>
+			<td>{{$val.Reason}}</td>
+		{{if eq $val.Result "won"}}
+			<td>{{$val.Result}}</td>
+		{{else}}
+			<td><span style='color: red;'>{{$val.Result}}</span></td>
+		{{end}}
+			<td align=right>{{$val.Term}}</td><td align=right>{{getDurationFromMillis $val.Milli}}</td></tr>
+	{{end}}
+	</table>
+{{end}}
+
+{{if .Data.States}}
+	<table style='float: left; margin: 10px 10px; clear: left;'>
+		<caption><span style="font-size: 16px; padding: 5px 5px;"><i class="fa fa-exchange"></i></span>Member States</caption>
+		<tr><th></th><th>node</th><th>state</th><th>from</th><th>to</th></tr>
+	{{range $n, $val := .Data.States}}
+		<tr><td align=right>{{add $n 1}}</td><td>{{$val.Node}}</td><td>{{$val.State}}</td>
+			<td>{{formatDateTime $val.Start}}</td><td>{{formatDateTime $val.End}}</td></tr>
+	{{end}}
+	</table>
+{{end}}
+
+{{if .Data.SyncSources}}
+	<table style='float: left; margin: 10px 10px; clear: left;'>
+		<caption><span style="font-size: 16px; padding: 5px 5px;"><i class="fa fa-refresh"></i></span>Sync Source Changes</caption>
+		<tr><th></th><th>date</th><th>node</th><th>sync source</th><th>detail</th></tr>
+	{{range $n, $val := .Data.SyncSources}}
+		<tr><td align=right>{{add $n 1}}</td><td>{{formatDateTime $val.Date}}</td><td>{{$val.Node}}</td>
+			<td>{{$val.Name}}</td><td>{{$val.Detail}}</td></tr>
+	{{end}}
+	</table>
+{{end}}
+
+{{if .Data.Rollbacks}}
+	<table style='float: left; margin: 10px 10px; clear: left;'>
+		<caption><span style="font-size: 16px; padding: 5px 5px;"><i class="fa fa-undo"></i></span>Rollbacks</caption>
+		<tr><th></th><th>date</th><th>node</th><th>message</th></tr>
+	{{range $n, $val := .Data.Rollbacks}}
+		<tr><td align=right>{{add $n 1}}</td><td>{{formatDateTime $val.Date}}</td><td>{{$val.Node}}</td>
+			<td class='break'>{{$val.Detail}}</td></tr>
+	{{end}}
+	</table>
+{{end}}
+
+{{if not (or .Data.Elections .Data.States .Data.SyncSources .Data.Rollbacks)}}
+	<div style='clear: left;' align='center' class='btn'><span style='color: red'>no replication events found</span></div>
+{{end}}
+	<div style='clear: left;' align='center'><hr/><p/>@simagix</div>
+</body></html>`
+	return template.New("hatchet").Funcs(template.FuncMap{
+		"add": func(a int, b int) int {
+			return a + b
+		},
+		"getDurationFromMillis": func(milli int) string {
+			if milli < 1000 {
+				return fmt.Sprintf("%d ms", milli)
+			}
+			return fmt.Sprintf("%.1f s", float64(milli)/1000)
+		},
+		"formatDateTime": func(str string) string {
+			return strings.Replace(str, "T", " ", 1)
+		}}).Parse(html)
+}
@@ -0,0 +1,80 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * replication_test.go
+ */
+
+package hatchet
+
+import (
+	"testing"
+
+	"github.com/simagix/gox"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestAnalyzeReplEvent(t *testing.T) {
+	strs := []string{
+		`{"t":{"$date":"2023-03-01T10:00:00.000+00:00"},"s":"I",  "c":"ELECTION", "id":21438,   "ctx":"ReplCoord-1","msg":"Starting an election, since we've seen no PRIMARY in election timeout period","attr":{"electionTimeoutPeriodMillis":10000}}`,
+		`{"t":{"$date":"2023-03-01T10:00:01.500+00:00"},"s":"I",  "c":"ELECTION", "id":21450,   "ctx":"ReplCoord-2","msg":"Election succeeded, assuming primary role","attr":{"term":5}}`,
+		`{"t":{"$date":"2023-03-01T10:00:01.600+00:00"},"s":"I",  "c":"REPL",     "id":21358,   "ctx":"ReplCoord-2","msg":"Replica set state transition","attr":{"newState":"PRIMARY","oldState":"SECONDARY"}}`,
+		`{"t":{"$date":"2023-03-01T10:00:02.000+00:00"},"s":"I",  "c":"REPL",     "id":21215,   "ctx":"ReplCoord-3","msg":"Member is in new state","attr":{"hostAndPort":"host2:27017","newState":"SECONDARY"}}`,
+	}
+	expected := []ReplEvent{{Type: REPL_ELECTION, Name: ELECTION_START, Node: SELF_NODE, Detail: "election timeout"},
+		{Type: REPL_ELECTION, Name: ELECTION_WON, Node: SELF_NODE, Detail: "5"},
+		{Type: REPL_STATE, Name: "PRIMARY", Node: SELF_NODE, Detail: "SECONDARY"},
+		{Type: REPL_STATE, Name: "SECONDARY", Node: "host2:27017"}}
+	for i, str := range strs {
+		doc := Logv2Info{}
+		err := bson.UnmarshalExtJSON([]byte(str), false, &doc)
+		if err != nil {
+			t.Fatalf("bson unmarshal error %v", err)
+		}
+		event, err := AnalyzeReplEvent(&doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if event.Type != expected[i].Type || event.Name != expected[i].Name ||
+			event.Node != expected[i].Node || event.Detail != expected[i].Detail {
+			t.Fatal("expected", expected[i], "but got", *event)
+		}
+		t.Log(gox.Stringify(event, "", "  "))
+	}
+}
+
+func TestGetElections(t *testing.T) {
+	events := []ReplEvent{
+		{Date: "2023-03-01T10:00:00.000-0000", Type: REPL_ELECTION, Name: ELECTION_START, Node: SELF_NODE, Detail: "election timeout"},
+		{Date: "2023-03-01T10:00:01.500-0000", Type: REPL_ELECTION, Name: ELECTION_WON, Node: SELF_NODE, Detail: "5"},
+		{Date: "2023-03-01T11:00:00.000-0000", Type: REPL_ELECTION, Name: ELECTION_START, Node: SELF_NODE, Detail: "step up request"},
+	}
+	elections := GetElections(events)
+	if len(elections) != 2 {
+		t.Fatal("expected", 2, "but got", len(elections))
+	}
+	if elections[0].Result != ELECTION_WON || elections[0].Term != "5" || elections[0].Milli != 1500 {
+		t.Fatal("expected", ELECTION_WON, "5", 1500, "but got", elections[0].Result, elections[0].Term, elections[0].Milli)
+	}
+	if elections[1].Result != "" || elections[1].Reason != "step up request" {
+		t.Fatal("unexpected", gox.Stringify(elections[1]))
+	}
+}
+
+func TestGetStateTimeline(t *testing.T) {
+	end := "2023-03-01T12:00:00.000-0000"
+	events := []ReplEvent{
+		{Date: "2023-03-01T10:00:00.000-0000", Type: REPL_STATE, Name: "SECONDARY", Node: SELF_NODE},
+		{Date: "2023-03-01T10:00:01.000-0000", Type: REPL_STATE, Name: "SECONDARY", Node: "host2:27017"},
+		{Date: "2023-03-01T10:00:02.000-0000", Type: REPL_STATE, Name: "PRIMARY", Node: SELF_NODE},
+		{Date: "2023-03-01T10:00:03.000-0000", Type: REPL_STATE, Name: "SECONDARY", Node: "host2:27017"},
+	}
+	segments := GetStateTimeline(events, end)
+	if len(segments) != 3 {
+		t.Fatal("expected", 3, "but got", len(segments))
+	}
+	if segments[0].End != "2023-03-01T10:00:02.000-0000" || segments[2].State != "PRIMARY" || segments[2].End != end {
+		t.Fatal("unexpected", gox.Stringify(segments))
+	}
+	if segments[1].End != end {
+		t.Fatal("expected", end, "but got", segments[1].End)
+	}
+}
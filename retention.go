@@ -0,0 +1,88 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * retention.go
+ */
+
+package hatchet
+
+import (
+	"log"
+	"sort"
+	"time"
+)
+
+const RETENTION_INTERVAL = time.Hour // interval of purging hatchets beyond the retention policy by a web server
+
+// RetentionPolicy limits hatchets by the age since analyzed and by the total size, zero values are unlimited.
+// Pinned hatchets are never purged but count toward the total size
+type RetentionPolicy struct {
+	MaxAge  time.Duration
+	MaxSize int64 // disk usage in bytes recorded when hatchets are analyzed, see GetHatchetSize
+}
+
+// IsEnabled returns true if the policy limits ages or the total size
+func (ptr RetentionPolicy) IsEnabled() bool {
+	return ptr.MaxAge > 0 || ptr.MaxSize > 0
+}
+
+// GetPurgeableHatchets returns names of unpinned hatchets beyond the policy, oldest first.  Hatchets of unknown
+// analysis times are the oldest and only purged by the total size
+func (ptr RetentionPolicy) GetPurgeableHatchets(usages []HatchetUsage, now time.Time) []string {
+	names := []string{}
+	sorted := append([]HatchetUsage{}, usages...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Analyzed < sorted[j].Analyzed // RFC3339 of UTC
+	})
+	var total int64
+	for _, usage := range sorted {
+		total += usage.Size
+	}
+	for _, usage := range sorted {
+		if usage.Pinned {
+			continue
+		}
+		expired := ptr.MaxSize > 0 && total > ptr.MaxSize
+		if analyzed, err := time.Parse(time.RFC3339, usage.Analyzed); err == nil && ptr.MaxAge > 0 {
+			expired = expired || now.Sub(analyzed) > ptr.MaxAge
+		}
+		if expired {
+			names = append(names, usage.Name)
+			total -= usage.Size
+		}
+	}
+	return names
+}
+
+// PurgeHatchets deletes hatchets beyond the retention policy of options and reclaims disk space if vacuum is
+// true, returns names of purged hatchets
+func PurgeHatchets(opts Options, vacuum bool) ([]string, error) {
+	purged := []string{}
+	if !opts.Retention.IsEnabled() {
+		return purged, nil
+	}
+	usages, err := GetHatchetUsages(opts)
+	if err != nil {
+		return purged, err
+	}
+	for _, name := range opts.Retention.GetPurgeableHatchets(usages, time.Now()) {
+		if err = DeleteHatchet(opts, name); err != nil {
+			return purged, err
+		}
+		log.Println("hatchet", name, "purged by the retention policy")
+		purged = append(purged, name)
+	}
+	if vacuum && len(purged) > 0 {
+		err = Vacuum(opts)
+	}
+	return purged, err
+}
+
+// PurgeHatchetsPeriodically purges hatchets beyond the retention policy at intervals, it never returns.  Data
+// files are not vacuumed, which would hold the write lock longer than requests wait, freed pages are reused
+func PurgeHatchetsPeriodically(opts Options, interval time.Duration) {
+	for range time.Tick(interval) {
+		if _, err := PurgeHatchets(opts, false); err != nil {
+			log.Println("failed to purge hatchets:", err)
+		}
+	}
+}
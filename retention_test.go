@@ -0,0 +1,101 @@
+// Copyright 2022-present Kuei-chun Chen. All rights reserved.
+
+package hatchet
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGetPurgeableHatchets(t *testing.T) {
+	now := time.Date(2023, 3, 31, 0, 0, 0, 0, time.UTC)
+	usages := []HatchetUsage{
+		{Name: "mongod_new", Analyzed: "2023-03-30T00:00:00Z", Size: 100},
+		{Name: "mongod_old", Analyzed: "2023-03-01T00:00:00Z", Size: 300},
+		{Name: "mongod_pinned", Analyzed: "2023-02-01T00:00:00Z", Size: 200, Pinned: true},
+		{Name: "mongod_mid", Analyzed: "2023-03-20T00:00:00Z", Size: 100},
+		{Name: "mongod_unknown", Size: 100},
+	}
+	if names := (RetentionPolicy{}).GetPurgeableHatchets(usages, now); len(names) != 0 {
+		t.Fatal("expected", []string{}, "but got", names)
+	}
+	policy := RetentionPolicy{MaxAge: 15 * 24 * time.Hour}
+	expected := []string{"mongod_old"}
+	if names := policy.GetPurgeableHatchets(usages, now); !reflect.DeepEqual(names, expected) {
+		t.Fatal("expected", expected, "but got", names)
+	}
+	policy = RetentionPolicy{MaxSize: 450}
+	expected = []string{"mongod_unknown", "mongod_old"}
+	if names := policy.GetPurgeableHatchets(usages, now); !reflect.DeepEqual(names, expected) {
+		t.Fatal("expected", expected, "but got", names)
+	}
+	policy = RetentionPolicy{MaxAge: 5 * 24 * time.Hour, MaxSize: 100}
+	expected = []string{"mongod_unknown", "mongod_old", "mongod_mid", "mongod_new"}
+	if names := policy.GetPurgeableHatchets(usages, now); !reflect.DeepEqual(names, expected) {
+		t.Fatal("expected", expected, "but got", names)
+	}
+}
+
+func TestPurgeHatchets(t *testing.T) {
+	registerSQLite3Extended()
+	opts := Options{DBFile: filepath.Join(t.TempDir(), "hatchet.db")}
+	pool, err := GetSQLite3Pool(opts.DBFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	analyzed := time.Now().UTC().Add(-48 * time.Hour).Format(time.RFC3339)
+	stmts := `
+		INSERT INTO hatchet (name, version, module, arch, os, start, end, analyzed) VALUES
+			('mongod_old', '6.0.4', '', '', '', '', '', ?), ('mongod_kept', '6.0.4', '', '', '', '', '', ?),
+			('mongod_new', '6.0.4', '', '', '', '', '', ?);
+		CREATE TABLE mongod_old (id integer not null primary key, message text);
+		CREATE TABLE mongod_kept (id integer not null primary key, message text);
+		CREATE TABLE mongod_new (id integer not null primary key, message text);`
+	if _, err = pool.Exec(stmts, analyzed, analyzed, time.Now().UTC().Format(time.RFC3339)); err != nil {
+		t.Fatal(err)
+	}
+	if err = PinHatchet(opts, "mongod_kept", true); err != nil {
+		t.Fatal(err)
+	}
+	if err = PinHatchet(opts, "mongod_gone", true); err == nil {
+		t.Fatal("expected", "an error", "but got", nil)
+	}
+	if purged, err := PurgeHatchets(opts, true); err != nil || len(purged) != 0 {
+		t.Fatal("expected", []string{}, "but got", purged, err)
+	}
+	opts.Retention = RetentionPolicy{MaxAge: 24 * time.Hour}
+	purged, err := PurgeHatchets(opts, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(purged, []string{"mongod_old"}) {
+		t.Fatal("expected", []string{"mongod_old"}, "but got", purged)
+	}
+	var freelist int // pages of dropped tables are kept without vacuum
+	if err = pool.QueryRow("PRAGMA freelist_count").Scan(&freelist); err != nil {
+		t.Fatal(err)
+	}
+	if freelist == 0 {
+		t.Fatal("expected", "free pages", "but got", freelist)
+	}
+	usages, err := GetHatchetUsages(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(usages) != 2 || usages[0].Name != "mongod_kept" || !usages[0].Pinned || usages[1].Pinned {
+		t.Fatal("expected", "mongod_kept pinned and mongod_new", "but got", usages)
+	}
+	var out bytes.Buffer
+	if err = RunHatchetCommand(opts, CMD_UNPIN, []string{"mongod_kept"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "hatchet mongod_kept unpinned\n" {
+		t.Fatal("expected", "hatchet mongod_kept unpinned", "but got", out.String())
+	}
+	if err = RunHatchetCommand(opts, "mongod.log", nil, &out); !IsInvalidInput(err) {
+		t.Fatal("expected usage but got", err)
+	}
+}
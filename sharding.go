@@ -0,0 +1,215 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * sharding.go
+ */
+
+package hatchet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	SHARD_BALANCER       = "balancer"
+	SHARD_MIGRATION      = "migration"
+	SHARD_RANGE_DELETION = "range-deletion"
+	SHARD_SPLIT          = "split"
+	SHARD_STALE_CONFIG   = "stale-config"
+
+	MIGRATION_DONOR     = "donor"
+	MIGRATION_ERROR     = "error"
+	MIGRATION_RECIPIENT = "recipient"
+
+	RANGE_DELETED   = "deleted"
+	RANGE_SCHEDULED = "scheduled"
+
+	STALE_CONFIG_CODE = 13388
+)
+
+// ShardingEvent stores a chunk migration, balancer round, split, range deletion or StaleConfig error
+type ShardingEvent struct {
+	Date      string         `json:"date"`
+	Detail    string         `json:"detail"`
+	From      string         `json:"from"`
+	Metrics   map[string]int `json:"metrics"`
+	Milli     int            `json:"duration_ms"`
+	Name      string         `json:"name"`
+	Namespace string         `json:"ns"`
+	Result    string         `json:"result"`
+	To        string         `json:"to"`
+	Type      string         `json:"type"`
+}
+
+// MigrationStat stores chunk migrations stats of a namespace between two shards
+type MigrationStat struct {
+	AvgMilli  int    `json:"avg_ms"`
+	Failed    int    `json:"failed"`
+	From      string `json:"from"`
+	MaxMilli  int    `json:"max_ms"`
+	Namespace string `json:"ns"`
+	Role      string `json:"role"`
+	Succeeded int    `json:"succeeded"`
+	To        string `json:"to"`
+	Total     int    `json:"total"`
+}
+
+// ShardingStats stores sharding and balancer summaries
+type ShardingStats struct {
+	Balancer       []NameValue     `json:"balancer"`
+	Failures       []NameValue     `json:"failures"`
+	Migrations     []MigrationStat `json:"migrations"`
+	RangeDeletions []NameValues    `json:"range_deletions"` // [scheduled, deleted, backlog]
+	Splits         []NameValue     `json:"splits"`
+	StaleConfig    []NameValue     `json:"stale_config"`
+	Steps          []NameValues    `json:"steps"` // [count, avg ms, max ms]
+}
+
+// AnalyzeShardingEvent analyzes changelog events, migration failures, range deletions and StaleConfig errors
+func AnalyzeShardingEvent(doc *Logv2Info) (*ShardingEvent, error) {
+	attrMap := doc.Attr.Map()
+	msg := doc.Msg
+	if msg == "about to log metadata event" {
+		if event, ok := attrMap["event"].(bson.D); ok {
+			return analyzeChangelogEvent(event.Map())
+		}
+		return nil, errors.New("no metadata event found")
+	}
+	c := doc.Component
+	if c == "SHARDING" || c == "MIGRATION" || c == "RANGE_DELETER" {
+		if strings.HasPrefix(msg, "Migration failed") || strings.HasPrefix(msg, "Error while doing moveChunk") {
+			event := &ShardingEvent{Type: SHARD_MIGRATION, Name: MIGRATION_ERROR, Result: "failed"}
+			event.Namespace = getShardingNamespace(attrMap)
+			event.Detail = getErrorString(attrMap["error"])
+			if event.Detail == "" {
+				event.Detail = msg
+			}
+			return event, nil
+		}
+		lower := strings.ToLower(msg)
+		if strings.Contains(lower, "range") && strings.Contains(lower, "delet") {
+			event := &ShardingEvent{Type: SHARD_RANGE_DELETION, Namespace: getShardingNamespace(attrMap)}
+			if strings.HasPrefix(msg, "Scheduling deletion") || strings.HasPrefix(msg, "Submitting range deletion") ||
+				strings.HasPrefix(msg, "Scheduled range deletion") {
+				event.Name = RANGE_SCHEDULED
+			} else if strings.HasPrefix(msg, "Deleted documents in range") || strings.HasPrefix(msg, "Finished deleting") {
+				event.Name = RANGE_DELETED
+				event.Milli = ToInt(attrMap["durationMillis"])
+			} else {
+				return nil, errors.New("not a range deletion event")
+			}
+			if attrMap["range"] != nil {
+				event.Detail = fmt.Sprintf("%v", attrMap["range"])
+			}
+			return event, nil
+		}
+	}
+	if isStaleConfig(doc, attrMap) {
+		return &ShardingEvent{Type: SHARD_STALE_CONFIG, Name: "StaleConfig", Namespace: getShardingNamespace(attrMap)}, nil
+	}
+	return nil, errors.New("not a sharding event")
+}
+
+// analyzeChangelogEvent analyzes a config.changelog document
+func analyzeChangelogEvent(event map[string]interface{}) (*ShardingEvent, error) {
+	what, _ := event["what"].(string)
+	ns, _ := event["ns"].(string)
+	details := map[string]interface{}{}
+	if doc, ok := event["details"].(bson.D); ok {
+		details = doc.Map()
+	}
+	sevent := &ShardingEvent{Namespace: ns, Metrics: map[string]int{}}
+	switch what {
+	case "moveChunk.from", "moveChunk.to":
+		sevent.Type = SHARD_MIGRATION
+		sevent.Name = MIGRATION_DONOR
+		if what == "moveChunk.to" {
+			sevent.Name = MIGRATION_RECIPIENT
+		}
+		for k, v := range details {
+			if strings.HasPrefix(k, "step ") {
+				sevent.Metrics[k] = ToInt(v)
+				sevent.Milli += ToInt(v)
+			}
+		}
+		sevent.Result, _ = details["note"].(string)
+		sevent.Detail = getErrorString(details["errmsg"])
+	case "moveChunk.error":
+		sevent.Type = SHARD_MIGRATION
+		sevent.Name = MIGRATION_ERROR
+		sevent.Result = "failed"
+		sevent.Detail = getErrorString(details["errmsg"])
+	case "split", "multi-split":
+		sevent.Type = SHARD_SPLIT
+		sevent.Name = what
+	case "balancer.round":
+		sevent.Type = SHARD_BALANCER
+		sevent.Name = "round"
+		sevent.Milli = ToInt(details["executionTimeMillis"])
+		sevent.Metrics["candidateChunks"] = ToInt(details["candidateChunks"])
+		sevent.Metrics["chunksMoved"] = ToInt(details["chunksMoved"])
+		sevent.Result = "ok"
+		if errorOccurred, ok := details["errorOccured"].(bool); ok && errorOccurred {
+			sevent.Result = "error"
+			sevent.Detail = getErrorString(details["errmsg"])
+		}
+	default:
+		return nil, errors.New("not a sharding changelog event")
+	}
+	sevent.From, _ = details["from"].(string)
+	sevent.To, _ = details["to"].(string)
+	return sevent, nil
+}
+
+func getShardingNamespace(attrMap map[string]interface{}) string {
+	for _, key := range []string{"namespace", "ns", "nss"} {
+		if ns, ok := attrMap[key].(string); ok {
+			return ns
+		}
+	}
+	return ""
+}
+
+// getErrorString returns an error message from a string or an error document
+func getErrorString(o interface{}) string {
+	if o == nil {
+		return ""
+	}
+	if str, ok := o.(string); ok {
+		return str
+	}
+	if doc, ok := o.(bson.D); ok {
+		m := doc.Map()
+		if m["errmsg"] != nil {
+			return fmt.Sprintf("%v", m["errmsg"])
+		}
+		if m["codeName"] != nil {
+			return fmt.Sprintf("%v", m["codeName"])
+		}
+	}
+	return fmt.Sprintf("%v", o)
+}
+
+func isStaleConfig(doc *Logv2Info, attrMap map[string]interface{}) bool {
+	if attrMap["errName"] == "StaleConfig" || attrMap["codeName"] == "StaleConfig" ||
+		(attrMap["errCode"] != nil && ToInt(attrMap["errCode"]) == STALE_CONFIG_CODE) {
+		return true
+	}
+	return doc.Severity != "I" && strings.Contains(doc.Message, "StaleConfig")
+}
+
+// getMetricsString returns metrics as a JSON string
+func getMetricsString(metrics map[string]int) string {
+	if len(metrics) == 0 {
+		return ""
+	}
+	b, err := json.Marshal(metrics)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
@@ -0,0 +1,130 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * sharding_template.go
+ */
+
+package hatchet
+
+import (
+	"html/template"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// GetShardingTemplate returns HTML
+func GetShardingTemplate() (*template.Template, error) {
+	html := getContentHTML()
+	html += `{{$name := .Hatchet}}
+<script>
+	document.getElementById('nextReport').selectedIndex = {{.Report.Index}};
+</script>
+	<div style='clear: left; margin: 10px 10px;'>
+		<button class='button' onClick="javascript:location.href='/hatchets/{{$name}}/charts/sharding?type=migrations'; return false;">
+			<i class='fa fa-line-chart'></i> Chunk Migrations</button>
+		<button class='button' onClick="javascript:location.href='/hatchets/{{$name}}/charts/sharding?type=range-deleter'; return false;">
+			<i class='fa fa-line-chart'></i> Range Deleter Backlog</button>
+		<button class='button' onClick="javascript:location.href='/hatchets/{{$name}}/charts/sharding?type=stale'; return false;">
+			<i class='fa fa-line-chart'></i> StaleConfig Errors</button>
+	</div>
+{{if .Data.Migrations}}
+	<table style='float: left; margin: 10px 10px; clear: left;'>
+		<caption><span style="font-size: 16px; padding: 5px 5px;"><i class="fa fa-truck"></i></span>Chunk Migrations</caption>
+		<tr><th></th><th>namespace</th><th>from</th><th>to</th><th>role</th><th>total</th>
+			<th>succeeded</th><th>failed</th><th>avg ms</th><th>max ms</th></tr>
+	{{range $n, $val := .Data.Migrations}}
+		<tr><td align=right>{{add $n 1}}</td><td>{{$val.Namespace}}</td><td>{{$val.From}}</td><td>{{$val.To}}</td>
+			<td>{{$val.Role}}</td><td align=right>{{numPrinter $val.Total}}</td>
+			<td align=right>{{numPrinter $val.Succeeded}}</td>
+		{{if gt $val.Failed 0}}
+			<td align=right><span style='color: red;'>{{numPrinter $val.Failed}}</span></td>
+		{{else}}
+			<td align=right>0</td>
+		{{end}}
+			<td align=right>{{numPrinter $val.AvgMilli}}</td><td align=right>{{numPrinter $val.MaxMilli}}</td></tr>
+	{{end}}
+	</table>
+{{end}}
+
+{{if .Data.Steps}}
+	<table style='float: left; margin: 10px 10px;'>
+		<caption><span style="font-size: 16px; padding: 5px 5px;"><i class="fa fa-clock-o"></i></span>Migration Steps</caption>
+		<tr><th></th><th>step</th><th>count</th><th>avg ms</th><th>max ms</th></tr>
+	{{range $n, $val := .Data.Steps}}
+		<tr><td align=right>{{add $n 1}}</td><td>{{$val.Name}}</td>
+			<td align=right>{{getFormattedNumber $val.Values 0}}</td><td align=right>{{getFormattedNumber $val.Values 1}}</td>
+			<td align=right>{{getFormattedNumber $val.Values 2}}</td></tr>
+	{{end}}
+	</table>
+{{end}}
+
+{{if .Data.Failures}}
+	<table style='float: left; margin: 10px 10px;'>
+		<caption><span style="font-size: 16px; padding: 5px 5px;"><i class="fa fa-ban"></i></span>Migration Failures</caption>
+		<tr><th></th><th>reason</th><th>total</th></tr>
+	{{range $n, $val := .Data.Failures}}
+		<tr><td align=right>{{add $n 1}}</td><td class='break'>{{$val.Name}}</td><td align=right>{{numPrinter $val.Value}}</td></tr>
+	{{end}}
+	</table>
+{{end}}
+
+{{if .Data.Balancer}}
+	<table style='float: left; margin: 10px 10px; clear: left;'>
+		<caption><span style="font-size: 16px; padding: 5px 5px;"><i class="fa fa-balance-scale"></i></span>Balancer Rounds</caption>
+		<tr><th></th><th>metric</th><th>value</th></tr>
+	{{range $n, $val := .Data.Balancer}}
+		<tr><td align=right>{{add $n 1}}</td><td>{{$val.Name}}</td><td align=right>{{numPrinter $val.Value}}</td></tr>
+	{{end}}
+	</table>
+{{end}}
+
+{{if .Data.Splits}}
+	<table style='float: left; margin: 10px 10px;'>
+		<caption><span style="font-size: 16px; padding: 5px 5px;"><i class="fa fa-scissors"></i></span>Chunk Splits</caption>
+		<tr><th></th><th>namespace</th><th>total</th></tr>
+	{{range $n, $val := .Data.Splits}}
+		<tr><td align=right>{{add $n 1}}</td><td>{{$val.Name}}</td><td align=right>{{numPrinter $val.Value}}</td></tr>
+	{{end}}
+	</table>
+{{end}}
+
+{{if .Data.RangeDeletions}}
+	<table style='float: left; margin: 10px 10px;'>
+		<caption><span style="font-size: 16px; padding: 5px 5px;"><i class="fa fa-trash"></i></span>Range Deletions</caption>
+		<tr><th></th><th>namespace</th><th>scheduled</th><th>deleted</th><th>backlog</th></tr>
+	{{range $n, $val := .Data.RangeDeletions}}
+		<tr><td align=right>{{add $n 1}}</td><td>{{$val.Name}}</td>
+			<td align=right>{{getFormattedNumber $val.Values 0}}</td><td align=right>{{getFormattedNumber $val.Values 1}}</td>
+			<td align=right>{{getFormattedNumber $val.Values 2}}</td></tr>
+	{{end}}
+	</table>
+{{end}}
+
+{{if .Data.StaleConfig}}
+	<table style='float: left; margin: 10px 10px;'>
+		<caption><span style="font-size: 16px; padding: 5px 5px;"><i class="fa fa-exclamation-triangle"></i></span>StaleConfig Errors</caption>
+		<tr><th></th><th>namespace</th><th>total</th></tr>
+	{{range $n, $val := .Data.StaleConfig}}
+		<tr><td align=right>{{add $n 1}}</td><td>{{$val.Name}}</td><td align=right>{{numPrinter $val.Value}}</td></tr>
+	{{end}}
+	</table>
+{{end}}
+
+{{if not (or .Data.Migrations .Data.Balancer .Data.Splits .Data.RangeDeletions .Data.StaleConfig)}}
+	<div style='clear: left;' align='center' class='btn'><span style='color: red'>no sharding events found</span></div>
+{{end}}
+	<div style='clear: left;' align='center'><hr/><p/>@simagix</div>
+</body></html>`
+	return template.New("hatchet").Funcs(template.FuncMap{
+		"add": func(a int, b int) int {
+			return a + b
+		},
+		"numPrinter": func(n interface{}) string {
+			printer := message.NewPrinter(language.English)
+			return printer.Sprintf("%v", ToInt(n))
+		},
+		"getFormattedNumber": func(numbers []int, i int) string {
+			printer := message.NewPrinter(language.English)
+			return printer.Sprintf("%v", numbers[i])
+		}}).Parse(html)
+}
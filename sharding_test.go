@@ -0,0 +1,62 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * sharding_test.go
+ */
+
+package hatchet
+
+import (
+	"testing"
+
+	"github.com/simagix/gox"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestAnalyzeShardingEventMigration(t *testing.T) {
+	str := `{"t":{"$date":"2023-03-01T10:10:00.000+00:00"},"s":"I",  "c":"SHARDING", "id":22080,   "ctx":"MoveChunk","msg":"about to log metadata event","attr":{"namespace":"changelog","event":{"_id":"host1:27018-2023-03-01T10:10:00.000+00:00-63ff2a58","server":"host1:27018","shard":"shard01","clientAddr":"","time":{"$date":"2023-03-01T10:10:00.000Z"},"what":"moveChunk.from","ns":"test.foo","details":{"min":{"_id":0},"max":{"_id":100},"step 1 of 6":0,"step 2 of 6":5,"step 3 of 6":120,"step 4 of 6":3000,"step 5 of 6":15,"step 6 of 6":40,"to":"shard02","from":"shard01","note":"success"}}}}`
+	doc := Logv2Info{}
+	err := bson.UnmarshalExtJSON([]byte(str), false, &doc)
+	if err != nil {
+		t.Fatalf("bson unmarshal error %v", err)
+	}
+	event, err := AnalyzeShardingEvent(&doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Type != SHARD_MIGRATION || event.Name != MIGRATION_DONOR || event.Result != "success" {
+		t.Fatal("expected", SHARD_MIGRATION, MIGRATION_DONOR, "success", "but got", event.Type, event.Name, event.Result)
+	}
+	if event.Namespace != "test.foo" || event.From != "shard01" || event.To != "shard02" {
+		t.Fatal("expected", "test.foo", "shard01", "shard02", "but got", event.Namespace, event.From, event.To)
+	}
+	if event.Milli != 3180 || event.Metrics["step 4 of 6"] != 3000 {
+		t.Fatal("expected", 3180, 3000, "but got", event.Milli, event.Metrics["step 4 of 6"])
+	}
+	t.Log(gox.Stringify(event, "", "  "))
+}
+
+func TestAnalyzeShardingEventStaleConfig(t *testing.T) {
+	str := `{"t":{"$date":"2023-03-01T10:11:00.000+00:00"},"s":"I",  "c":"COMMAND",  "id":51803,   "ctx":"conn12","msg":"Slow query","attr":{"type":"command","ns":"test.foo","command":{"find":"foo","filter":{"a":1}},"ok":0,"errMsg":"version mismatch detected for test.foo","errName":"StaleConfig","errCode":13388,"durationMillis":120}}`
+	doc := Logv2Info{}
+	err := bson.UnmarshalExtJSON([]byte(str), false, &doc)
+	if err != nil {
+		t.Fatalf("bson unmarshal error %v", err)
+	}
+	event, err := AnalyzeShardingEvent(&doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Type != SHARD_STALE_CONFIG || event.Namespace != "test.foo" {
+		t.Fatal("expected", SHARD_STALE_CONFIG, "test.foo", "but got", event.Type, event.Namespace)
+	}
+}
+
+func TestGetRangeDeleterBacklog(t *testing.T) {
+	docs := []OpCount{{Date: "2023-03-01T10:00", Count: 3, Op: RANGE_SCHEDULED},
+		{Date: "2023-03-01T10:00", Count: 1, Op: RANGE_DELETED},
+		{Date: "2023-03-01T10:01", Count: 2, Op: RANGE_DELETED}}
+	backlogs := getRangeDeleterBacklog(docs)
+	if len(backlogs) != 2 || backlogs[0].Count != 2 || backlogs[1].Count != 0 {
+		t.Fatal("unexpected", gox.Stringify(backlogs))
+	}
+}
@@ -49,6 +49,8 @@ func AnalyzeSlowOp(doc *Logv2Info) (*OpStat, error) {
 	}
 	b, _ := bson.Marshal(doc.Attr)
 	bson.Unmarshal(b, &doc.Attributes)
+	stat.AppName, stat.Driver = getClientInfo(doc)
+	stat.ReadConcern, stat.WriteConcern, stat.ReadPref = getConcerns(doc)
 	stat.TotalMilli = doc.Attributes.Milli
 	stat.Namespace = doc.Attributes.NS
 	if stat.Namespace == "" {
@@ -208,6 +210,26 @@ func AnalyzeSlowOp(doc *Logv2Info) (*OpStat, error) {
 	return stat, nil
 }
 
+// getClientInfo returns application name and driver from attr.appName and attr.command.$client
+func getClientInfo(doc *Logv2Info) (string, string) {
+	var appName, driver string
+	attrMap := doc.Attr.Map()
+	appName, _ = attrMap["appName"].(string)
+	command, ok := attrMap["command"].(bson.D)
+	if !ok {
+		return appName, driver
+	}
+	client, ok := command.Map()["$client"].(bson.D)
+	if !ok {
+		return appName, driver
+	}
+	if application, ok := client.Map()["application"].(bson.D); ok && appName == "" {
+		appName, _ = application.Map()["name"].(string)
+	}
+	driver = getDriverFromDoc(client)
+	return appName, driver
+}
+
 func isRegex(doc map[string]interface{}) bool {
 	if buf, err := json.Marshal(doc); err != nil {
 		return false
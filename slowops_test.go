@@ -24,9 +24,23 @@ func TestAnalyzeLog(t *testing.T) {
 	if stat.QueryPattern != expected {
 		t.Fatal("expected", expected, "but got", stat.QueryPattern)
 	}
+	if stat.AppName != "Keyhole Lib" {
+		t.Fatal("expected", "Keyhole Lib", "but got", stat.AppName)
+	}
 	t.Log(gox.Stringify(stat, "", "  "))
 }
 
+func TestAnalyzeLogClient(t *testing.T) {
+	str := `{"t":{"$date":"2023-03-01T10:00:05.000+00:00"},"s":"I", "c":"COMMAND", "id":51803, "ctx":"conn12","msg":"Slow query","attr":{"type":"command","ns":"shop.orders","command":{"find":"orders","filter":{"status":"A"},"$client":{"application":{"name":"orders-svc"},"driver":{"name":"PyMongo","version":"4.3.3"},"mongos":{"host":"mongos1:27017","client":"10.0.0.9:50001","version":"6.0.4"}},"$db":"shop"},"planSummary":"COLLSCAN","nreturned":1,"reslen":230,"durationMillis":150}}`
+	stat, err := AnalyzeLog(str)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stat.AppName != "orders-svc" || stat.Driver != "PyMongo 4.3.3" {
+		t.Fatal("unexpected", gox.Stringify(stat))
+	}
+}
+
 func TestAnalyzeSlowOp(t *testing.T) {
 	str := `{"t":{"$date":"2021-07-25T09:38:57.078+00:00"},"s":"I", "c":"COMMAND", "id":51803, "ctx":"conn541","msg":"Slow query","attr":{"type":"command","ns":"demo.hatchet","appName":"Keyhole Lib","command":{"aggregate":"hatchet","allowDiskUse":true,"pipeline":[{"$match":{"status":{"$in":["completed","split","splitting"]}}},{"$group":{"_id":{"replica_set":"$replica_set","namespace":"$query_filter.namespace"},"inserted":{"$sum":"$inserted"},"source_counts":{"$sum":"$source_counts"}}},{"$sort":{"status":1,"_id":-1}},{"$project":{"_id":0,"replica":"$_id.replica_set","ns":"$_id.namespace","inserted":1,"source_counts":1}}],"cursor":{},"lsid":{"id":{"$uuid":"86cf813b-463a-4e7b-b8f8-c587441a9575"}},"$clusterTime":{"clusterTime":{"$timestamp":{"t":1627205936,"i":4}},"signature":{"hash":{"$binary":{"base64":"Plz//gyzhsJMGIeEd6BdCIbgHSQ=","subType":"0"}},"keyId":6988792980442185732}},"$db":"_mongopush","$readPreference":{"mode":"primary"}},"planSummary":"IXSCAN { status: 1 }","keysExamined":218,"docsExamined":217,"hasSortStage":true,"cursorExhausted":true,"numYields":6,"nreturned":53,"queryHash":"6C0186CD","planCacheKey":"6EB1F22F","reslen":6117,"locks":{"ReplicationStateTransition":{"acquireCount":{"w":8}},"Global":{"acquireCount":{"r":8}},"Database":{"acquireCount":{"r":8}},"Collection":{"acquireCount":{"r":8}},"Mutex":{"acquireCount":{"r":2}}},"storage":{"data":{"bytesRead":4248700,"timeReadingMicros":527302}},"protocol":"op_msg","durationMillis":530}}`
 	t.Log(str)
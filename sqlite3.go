@@ -7,41 +7,55 @@ package hatchet
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
 )
 
 type SQLite3DB struct {
+	authStmt    *sql.Stmt // {hatchet}_auth
 	clientStmt  *sql.Stmt // {hatchet}_clients
+	connStmt    *sql.Stmt // {hatchet}_conns
+	cursorStmt  *sql.Stmt // {hatchet}_cursors
 	driverStmt  *sql.Stmt // {hatchet}_drivers
-	db          *sql.DB
+	errorStmt   *sql.Stmt // {hatchet}_errors
+	findingStmt *sql.Stmt // {hatchet}_findings
+	db          *SQLite3Pool
 	dbfile      string
 	hatchetName string
+	indexStmt   *sql.Stmt // {hatchet}_index_builds
 	tx          *sql.Tx
 	pstmt       *sql.Stmt // {hatchet}
+	replStmt    *sql.Stmt // {hatchet}_repl
+	rows        int       // logs inserted in the transaction
+	shardStmt   *sql.Stmt // {hatchet}_sharding
+	storageStmt *sql.Stmt // {hatchet}_storage
+	txnLogStmt  *sql.Stmt // {hatchet}_txn_logs
+	txnStmt     *sql.Stmt // {hatchet}_txns
+	uptimeStmt  *sql.Stmt // {hatchet}_uptime
 	verbose     bool
 }
 
-func GetSQLite3DB(hatchetName string) (Database, error) {
+func GetSQLite3DB(opts Options, hatchetName string) (Database, error) {
 	var dbase Database
 	var err error
-	logv2 := GetLogv2()
-	if logv2.verbose {
-		log.Println("dbfile", logv2.dbfile, "hatchet name", hatchetName)
+	if opts.Verbose {
+		log.Println("dbfile", opts.DBFile, "hatchet name", hatchetName)
 	}
-	if dbase, err = NewSQLite3DB(logv2.dbfile, hatchetName); err != nil {
+	if dbase, err = NewSQLite3DB(opts.DBFile, hatchetName); err != nil {
 		return dbase, err
 	}
-	dbase.SetVerbose(logv2.verbose)
+	dbase.SetVerbose(opts.Verbose)
 	return dbase, err
 }
 
+// NewSQLite3DB returns a SQLite3DB of a hatchet, borrowing the pool of the database file
 func NewSQLite3DB(dbfile string, hatchetName string) (*SQLite3DB, error) {
 	var err error
 	sqlite := &SQLite3DB{dbfile: dbfile, hatchetName: hatchetName}
-	if sqlite.db, err = sql.Open("sqlite3_extended", dbfile); err != nil {
-		return sqlite, err
-	}
+	sqlite.db, err = GetSQLite3Pool(dbfile)
 	return sqlite, err
 }
 
@@ -54,15 +68,24 @@ func (ptr *SQLite3DB) SetVerbose(b bool) {
 }
 
 func (ptr *SQLite3DB) Begin() error {
-	var err error
 	log.Println("creating hatchet", ptr.hatchetName)
 	stmts := ptr.GetHatchetInitStmt()
-	if _, err = ptr.db.Exec(stmts); err != nil {
+	if _, err := ptr.db.Exec(stmts); err != nil {
 		return err
 	}
-	if ptr.tx, err = ptr.db.Begin(); err != nil {
+	return ptr.beginBatch()
+}
+
+// beginBatch begins a transaction of a batch of logs holding the writer lock, and prepares statements
+// of it
+func (ptr *SQLite3DB) beginBatch() error {
+	var err error
+	ptr.db.writer.Lock()
+	if ptr.tx, err = ptr.db.DB.Begin(); err != nil {
+		ptr.db.writer.Unlock()
 		return err
 	}
+	ptr.rows = 0
 	if ptr.pstmt, err = ptr.tx.Prepare(ptr.GetHatchetPreparedStmt()); err != nil {
 		return err
 	}
@@ -72,11 +95,51 @@ func (ptr *SQLite3DB) Begin() error {
 	if ptr.driverStmt, err = ptr.tx.Prepare(ptr.GetDriverPreparedStmt()); err != nil {
 		return err
 	}
+	if ptr.txnStmt, err = ptr.tx.Prepare(ptr.GetTransactionPreparedStmt()); err != nil {
+		return err
+	}
+	if ptr.txnLogStmt, err = ptr.tx.Prepare(ptr.GetTxnLogPreparedStmt()); err != nil {
+		return err
+	}
+	if ptr.replStmt, err = ptr.tx.Prepare(ptr.GetReplPreparedStmt()); err != nil {
+		return err
+	}
+	if ptr.shardStmt, err = ptr.tx.Prepare(ptr.GetShardingPreparedStmt()); err != nil {
+		return err
+	}
+	if ptr.connStmt, err = ptr.tx.Prepare(ptr.GetConnectionPreparedStmt()); err != nil {
+		return err
+	}
+	if ptr.authStmt, err = ptr.tx.Prepare(ptr.GetAuthPreparedStmt()); err != nil {
+		return err
+	}
+	if ptr.indexStmt, err = ptr.tx.Prepare(ptr.GetIndexBuildPreparedStmt()); err != nil {
+		return err
+	}
+	if ptr.storageStmt, err = ptr.tx.Prepare(ptr.GetStoragePreparedStmt()); err != nil {
+		return err
+	}
+	if ptr.uptimeStmt, err = ptr.tx.Prepare(ptr.GetUptimePreparedStmt()); err != nil {
+		return err
+	}
+	if ptr.errorStmt, err = ptr.tx.Prepare(ptr.GetErrorPreparedStmt()); err != nil {
+		return err
+	}
+	if ptr.cursorStmt, err = ptr.tx.Prepare(ptr.GetCursorPreparedStmt()); err != nil {
+		return err
+	}
+	if ptr.findingStmt, err = ptr.tx.Prepare(ptr.GetFindingPreparedStmt()); err != nil {
+		return err
+	}
 	return err
 }
 
+// Commit commits the transaction of the last batch and releases the writer lock
 func (ptr *SQLite3DB) Commit() error {
-	return ptr.tx.Commit()
+	err := ptr.tx.Commit()
+	ptr.tx = nil
+	ptr.db.writer.Unlock()
+	return err
 }
 
 func (ptr *SQLite3DB) Close() error {
@@ -91,15 +154,100 @@ func (ptr *SQLite3DB) Close() error {
 			return err
 		}
 	}
-	defer ptr.db.Close()
-	return err
+	if ptr.txnStmt != nil {
+		if err = ptr.txnStmt.Close(); err != nil {
+			return err
+		}
+	}
+	if ptr.txnLogStmt != nil {
+		if err = ptr.txnLogStmt.Close(); err != nil {
+			return err
+		}
+	}
+	if ptr.replStmt != nil {
+		if err = ptr.replStmt.Close(); err != nil {
+			return err
+		}
+	}
+	if ptr.shardStmt != nil {
+		if err = ptr.shardStmt.Close(); err != nil {
+			return err
+		}
+	}
+	if ptr.connStmt != nil {
+		if err = ptr.connStmt.Close(); err != nil {
+			return err
+		}
+	}
+	if ptr.authStmt != nil {
+		if err = ptr.authStmt.Close(); err != nil {
+			return err
+		}
+	}
+	if ptr.indexStmt != nil {
+		if err = ptr.indexStmt.Close(); err != nil {
+			return err
+		}
+	}
+	if ptr.storageStmt != nil {
+		if err = ptr.storageStmt.Close(); err != nil {
+			return err
+		}
+	}
+	if ptr.uptimeStmt != nil {
+		if err = ptr.uptimeStmt.Close(); err != nil {
+			return err
+		}
+	}
+	if ptr.errorStmt != nil {
+		if err = ptr.errorStmt.Close(); err != nil {
+			return err
+		}
+	}
+	if ptr.cursorStmt != nil {
+		if err = ptr.cursorStmt.Close(); err != nil {
+			return err
+		}
+	}
+	if ptr.findingStmt != nil {
+		if err = ptr.findingStmt.Close(); err != nil {
+			return err
+		}
+	}
+	if ptr.tx != nil { // releases the write lock of an unfinished analysis
+		err = ptr.tx.Rollback()
+		ptr.tx = nil
+		ptr.db.writer.Unlock()
+		if err != nil && err != sql.ErrTxDone {
+			return err
+		}
+	}
+	return nil // the pool is shared and stays open
 }
 
 func (ptr *SQLite3DB) InsertLog(index int, end string, doc *Logv2Info, stat *OpStat) error {
 	var err error
+	if ptr.rows == SQLITE3_BATCH_SIZE { // other inserts of a log follow it in the same batch
+		if err = ptr.Commit(); err != nil {
+			return err
+		}
+		if err = ptr.beginBatch(); err != nil {
+			return err
+		}
+	}
+	ptr.rows++
+	var attr interface{} // NULL if no attributes, e.g. legacy logs
+	if len(doc.Attr) > 0 {
+		data, err := bson.MarshalExtJSON(doc.Attr, false, false)
+		if err != nil {
+			return err
+		}
+		attr = string(data)
+	}
 	_, err = ptr.pstmt.Exec(index, end, doc.Severity, doc.Component, doc.Context,
 		doc.Msg, doc.Attributes.PlanSummary, doc.Attr.Map()["type"], doc.Attributes.NS, doc.Message,
-		stat.Op, stat.QueryPattern, stat.Index, doc.Attributes.Milli, doc.Attributes.Reslen)
+		stat.Op, stat.QueryPattern, stat.Index, doc.Attributes.Milli, doc.Attributes.Reslen,
+		stat.AppName, stat.Driver, stat.ReadConcern, stat.WriteConcern, stat.ReadPref, attr)
 	return err
 }
 
@@ -110,26 +258,134 @@ func (ptr *SQLite3DB) InsertClientConn(index int, doc *Logv2Info) error {
 	return err
 }
 
+func (ptr *SQLite3DB) InsertConnection(index int, conn *ConnectionLifetime) error {
+	var err error
+	_, err = ptr.connStmt.Exec(index, conn.ConnID, conn.IP, conn.Port, conn.AppName,
+		conn.Accepted, conn.Ended, conn.Milli, conn.Status)
+	return err
+}
+
+func (ptr *SQLite3DB) InsertCursor(index int, cursor *Cursor) error {
+	var err error
+	_, err = ptr.cursorStmt.Exec(index, cursor.CursorID, cursor.Namespace, cursor.Op, cursor.QueryPattern,
+		cursor.Batches, cursor.NReturned, cursor.Milli, cursor.Start, cursor.End, cursor.Lifetime, cursor.Status)
+	return err
+}
+
+func (ptr *SQLite3DB) InsertFinding(index int, finding *Finding) error {
+	var err error
+	_, err = ptr.findingStmt.Exec(index, finding.Rule, finding.Severity, finding.Namespace, finding.Op,
+		finding.QueryPattern, finding.Detail)
+	return err
+}
+
 func (ptr *SQLite3DB) InsertDriver(index int, doc *Logv2Info) error {
 	var err error
 	client := doc.Client
-	_, err = ptr.driverStmt.Exec(index, client.IP, client.Driver, client.Version)
+	_, err = ptr.driverStmt.Exec(index, client.IP, client.Driver, client.Version, client.AppName)
+	return err
+}
+
+func (ptr *SQLite3DB) InsertTransaction(index int, txn *Transaction) error {
+	var err error
+	_, err = ptr.txnStmt.Exec(index, txn.LSID, txn.TxnNumber, txn.Termination, txn.AbortCause, txn.Milli,
+		txn.TimeActive, txn.TimeInactive, txn.NumYields, txn.KeysExamined, txn.DocsExamined, txn.NReturned,
+		txn.NInserted, txn.NModified, txn.NDeleted, txn.ReadConcern, txn.WasPrepared)
+	return err
+}
+
+func (ptr *SQLite3DB) InsertTxnLog(index int, lsid string, txnNumber int) error {
+	var err error
+	_, err = ptr.txnLogStmt.Exec(index, lsid, txnNumber)
+	return err
+}
+
+func (ptr *SQLite3DB) InsertReplEvent(index int, event *ReplEvent) error {
+	var err error
+	_, err = ptr.replStmt.Exec(index, event.Type, event.Node, event.Name, event.Detail, event.Milli)
+	return err
+}
+
+func (ptr *SQLite3DB) InsertShardingEvent(index int, event *ShardingEvent) error {
+	var err error
+	_, err = ptr.shardStmt.Exec(index, event.Type, event.Name, event.Namespace, event.From, event.To,
+		event.Milli, event.Result, getMetricsString(event.Metrics), event.Detail)
+	return err
+}
+
+func (ptr *SQLite3DB) InsertAuthEvent(index int, event *AuthEvent) error {
+	var err error
+	_, err = ptr.authStmt.Exec(index, event.Result, event.Principal, event.Database, event.Mechanism,
+		event.IP, event.ErrCode, event.ErrName)
+	return err
+}
+
+func (ptr *SQLite3DB) InsertIndexBuild(index int, build *IndexBuild) error {
+	var err error
+	_, err = ptr.indexStmt.Exec(index, build.BuildUUID, build.Namespace, build.Indexes, build.Keys, build.Method,
+		build.CommitQuorum, build.Start, build.End, build.Milli, build.Status, getMetricsString(build.Phases), build.Error)
+	return err
+}
+
+func (ptr *SQLite3DB) InsertStorageEvent(index int, event *StorageEvent) error {
+	var err error
+	_, err = ptr.storageStmt.Exec(index, event.Type, event.Category, event.Milli, event.Detail)
+	return err
+}
+
+func (ptr *SQLite3DB) InsertOpError(index int, opError *OpError) error {
+	var err error
+	_, err = ptr.errorStmt.Exec(index, opError.Code, opError.Name, opError.Namespace, opError.Op, opError.Message)
+	return err
+}
+
+func (ptr *SQLite3DB) InsertUptimeSegment(index int, segment *UptimeSegment) error {
+	var err error
+	_, err = ptr.uptimeStmt.Exec(index, segment.PID, segment.Host, segment.Port, segment.Start, segment.End,
+		segment.EndID, segment.Status, segment.CrashID, segment.Reason)
 	return err
 }
 
 func (ptr *SQLite3DB) UpdateHatchetInfo(info HatchetInfo) error {
-	istmt := fmt.Sprintf(`INSERT OR REPLACE INTO hatchet (name, version, module, arch, os, start, end)
-		VALUES ('%v', '%v', '%v', '%v', '%v', '%v', '%v');`, ptr.hatchetName, info.Version, info.Module, info.Arch, info.OS, info.Start, info.End)
-	_, err := ptr.db.Exec(istmt)
+	// pinned and size are kept on re-analysis; size is re-measured below
+	istmt := `INSERT INTO hatchet (name, version, module, arch, os, start, end, schema_version, status, analyzed)
+		VALUES (?,?,?,?,?, ?,?,?,'',?)
+		ON CONFLICT(name) DO UPDATE SET version = excluded.version, module = excluded.module,
+			arch = excluded.arch, os = excluded.os, start = excluded.start, end = excluded.end,
+			schema_version = excluded.schema_version, status = excluded.status, analyzed = excluded.analyzed;`
+	if _, err := ptr.db.Exec(istmt, ptr.hatchetName, info.Version, info.Module, info.Arch, info.OS,
+		info.Start, info.End, SCHEMA_VERSION, info.Analyzed); err != nil {
+		return err
+	}
+	config := info.Config
+	warnings, err := json.Marshal(config.Warnings)
+	if err != nil {
+		return err
+	}
+	istmt = fmt.Sprintf(`INSERT INTO %v_config (host, port, repl_set_name, storage_engine, cache_size_gb, slowms, profile, options, warnings)
+		VALUES(?,?,?,?,?, ?,?,?,?)`, ptr.hatchetName)
+	_, err = ptr.db.Exec(istmt, config.Host, config.Port, config.ReplSetName, config.StorageEngine, config.CacheSizeGB,
+		config.SlowMS, config.Profile, config.Options, string(warnings))
+	if err != nil {
+		return err
+	}
+	_, err = ptr.updateHatchetSize()
 	return err
 }
 
 func (ptr *SQLite3DB) CreateMetaData() error {
 	var err error
+	if err = ptr.createFullTextIndex(); err != nil {
+		return err
+	}
+	if err = createRollups(ptr.db, ptr.hatchetName); err != nil {
+		return err
+	}
+
 	log.Printf("insert into %v_ops\n", ptr.hatchetName)
 	istmt := fmt.Sprintf(`INSERT INTO %v_ops
-			SELECT op, COUNT(*), ROUND(AVG(milli),1), MAX(milli), SUM(milli), ns, _index, SUM(reslen), filter
-				FROM %v WHERE op != "" GROUP BY op, ns, filter, _index`, ptr.hatchetName, ptr.hatchetName)
+			SELECT op, COUNT(*), ROUND(AVG(milli),1), MAX(milli), SUM(milli), ns, _index, SUM(reslen), filter, app
+				FROM %v WHERE op != "" GROUP BY op, ns, filter, _index, app`, ptr.hatchetName, ptr.hatchetName)
 	if _, err = ptr.db.Exec(istmt); err != nil {
 		return err
 	}
@@ -150,6 +406,14 @@ func (ptr *SQLite3DB) CreateMetaData() error {
 		return err
 	}
 
+	log.Printf("insert errcode into %v_audit\n", ptr.hatchetName)
+	istmt = fmt.Sprintf(`INSERT INTO %v_audit
+		SELECT 'errcode', name||' ('||code||')', COUNT(*) count FROM %v_errors GROUP by code, name`,
+		ptr.hatchetName, ptr.hatchetName)
+	if _, err = ptr.db.Exec(istmt); err != nil {
+		return err
+	}
+
 	log.Printf("insert op into %v_audit\n", ptr.hatchetName)
 	istmt = fmt.Sprintf(`INSERT INTO %v_audit
 		SELECT 'op', op, COUNT(*) count FROM %v WHERE op != '' GROUP by op`, ptr.hatchetName, ptr.hatchetName)
@@ -186,6 +450,21 @@ func (ptr *SQLite3DB) CreateMetaData() error {
 		return err
 	}
 
+	log.Printf("insert txn into %v_audit\n", ptr.hatchetName)
+	istmt = fmt.Sprintf(`INSERT INTO %v_audit
+		SELECT 'txn', termination, COUNT(*) count FROM %v_txns GROUP by termination`, ptr.hatchetName, ptr.hatchetName)
+	if _, err = ptr.db.Exec(istmt); err != nil {
+		return err
+	}
+
+	log.Printf("insert txn-abort into %v_audit\n", ptr.hatchetName)
+	istmt = fmt.Sprintf(`INSERT INTO %v_audit
+		SELECT 'txn-abort', abort_cause, COUNT(*) count FROM %v_txns WHERE termination = 'aborted' GROUP by abort_cause`,
+		ptr.hatchetName, ptr.hatchetName)
+	if _, err = ptr.db.Exec(istmt); err != nil {
+		return err
+	}
+
 	/* logs don't present trusted data from context, ignored
 	log.Printf("insert duration into %v_audit\n", ptr.hatchetName)
 	istmt = fmt.Sprintf(`INSERT INTO %v_audit
@@ -199,21 +478,39 @@ func (ptr *SQLite3DB) CreateMetaData() error {
 	return err
 }
 
+// createFullTextIndex indexes messages in a contentless FTS5 table, skipped if FTS5 is not compiled in,
+// i.e. built without the sqlite_fts5 tag
+func (ptr *SQLite3DB) createFullTextIndex() error {
+	if !ptr.db.fts5 { // reported when the pool is opened
+		return nil
+	}
+	log.Printf("insert into %v_fts\n", ptr.hatchetName)
+	istmt := fmt.Sprintf(`DROP TABLE IF EXISTS %v_fts;
+		CREATE VIRTUAL TABLE %v_fts USING fts5(message, msg, content='');
+		INSERT INTO %v_fts (rowid, message, msg) SELECT id, message, msg FROM %v;`,
+		ptr.hatchetName, ptr.hatchetName, ptr.hatchetName, ptr.hatchetName)
+	_, err := ptr.db.Exec(istmt)
+	return err
+}
+
 // GetHatchetInitStmt returns init statement
 func (ptr *SQLite3DB) GetHatchetInitStmt() string {
 	hatchetName := ptr.hatchetName
 	return fmt.Sprintf(`
 			CREATE TABLE IF NOT EXISTS hatchet ( name text not null primary key,
-				version text, module text, arch text, os text, start text, end text);
+				version text, module text, arch text, os text, start text, end text,
+				schema_version integer default 1, status text default '', analyzed text default '',
+				pinned integer default 0, size integer);
 
 			DROP TABLE IF EXISTS %v;
 			CREATE TABLE %v (
 				id integer not null primary key, date text, severity text, component text, context text,
 				msg text, plan text, type text, ns text, message text,
-				op text, filter text, _index text, milli integer, reslen integer);
+				op text, filter text, _index text, milli integer, reslen integer, app text, driver text,
+				read_concern text, write_concern text, read_pref text, attr text);
 
 			DROP TABLE IF EXISTS %v_ops;
-			CREATE TABLE %v_ops ( op, count, avg_ms, max_ms, total_ms, ns, _index, reslen, filter);
+			CREATE TABLE %v_ops ( op, count, avg_ms, max_ms, total_ms, ns, _index, reslen, filter, app);
 
 			DROP TABLE IF EXISTS %v_audit;
 			CREATE TABLE %v_audit ( type, name, value);
@@ -225,22 +522,106 @@ func (ptr *SQLite3DB) GetHatchetInitStmt() string {
 
 			DROP TABLE IF EXISTS %v_drivers;
 			CREATE TABLE %v_drivers (
-				id integer not null primary key, ip text, driver text, version text);
+				id integer not null primary key, ip text, driver text, version text, app text);
 
 			DROP TABLE IF EXISTS %v_clients;
 			CREATE TABLE %v_clients(
 				id integer not null primary key, ip text, port text, conns integer, accepted integer, ended integer, context string);
 			CREATE INDEX IF NOT EXISTS %v_clients_idx_context ON %v_clients (context,ip);`,
 		hatchetName, hatchetName, hatchetName, hatchetName, hatchetName, hatchetName, hatchetName, hatchetName, hatchetName, hatchetName,
-		hatchetName, hatchetName, hatchetName, hatchetName, hatchetName, hatchetName, hatchetName, hatchetName, hatchetName, hatchetName)
-
+		hatchetName, hatchetName, hatchetName, hatchetName, hatchetName, hatchetName, hatchetName, hatchetName, hatchetName, hatchetName) +
+		fmt.Sprintf(`
+			DROP TABLE IF EXISTS %v_txns;
+			CREATE TABLE %v_txns (
+				id integer not null primary key, lsid text, txn_number integer, termination text, abort_cause text,
+				milli integer, time_active integer, time_inactive integer, num_yields integer,
+				keys_examined integer, docs_examined integer, nreturned integer,
+				ninserted integer, nmodified integer, ndeleted integer, read_concern text, was_prepared integer);
+			CREATE INDEX IF NOT EXISTS %v_txns_idx_lsid ON %v_txns (lsid,txn_number);
+
+			DROP TABLE IF EXISTS %v_txn_logs;
+			CREATE TABLE %v_txn_logs (id integer not null primary key, lsid text, txn_number integer);
+			CREATE INDEX IF NOT EXISTS %v_txn_logs_idx_lsid ON %v_txn_logs (lsid,txn_number);
+
+			DROP TABLE IF EXISTS %v_repl;
+			CREATE TABLE %v_repl (
+				id integer not null primary key, type text, node text, name text, detail text, milli integer);
+			CREATE INDEX IF NOT EXISTS %v_repl_idx_type ON %v_repl (type);
+
+			DROP TABLE IF EXISTS %v_sharding;
+			CREATE TABLE %v_sharding (
+				id integer not null primary key, type text, name text, ns text, from_shard text, to_shard text,
+				milli integer, result text, metrics text, detail text);
+			CREATE INDEX IF NOT EXISTS %v_sharding_idx_type ON %v_sharding (type,ns);
+
+			DROP TABLE IF EXISTS %v_conns;
+			CREATE TABLE %v_conns (
+				id integer not null primary key, conn_id integer, ip text, port text, app text,
+				accepted text, ended text, milli integer, status text);
+			CREATE INDEX IF NOT EXISTS %v_conns_idx_ip ON %v_conns (ip,app);
+
+			DROP TABLE IF EXISTS %v_auth;
+			CREATE TABLE %v_auth (
+				id integer not null primary key, result text, principal text, db text, mechanism text,
+				ip text, err_code integer, err_name text);
+			CREATE INDEX IF NOT EXISTS %v_auth_idx_result ON %v_auth (result,ip);
+
+			DROP TABLE IF EXISTS %v_index_builds;
+			CREATE TABLE %v_index_builds (
+				id integer not null primary key, build_uuid text, ns text, indexes text, keys text, method text,
+				commit_quorum text, start text, end text, milli integer, status text, phases text, error text);
+			CREATE INDEX IF NOT EXISTS %v_index_builds_idx_ns ON %v_index_builds (ns,start);
+
+			DROP TABLE IF EXISTS %v_storage;
+			CREATE TABLE %v_storage (
+				id integer not null primary key, type text, category text, milli integer, detail text);
+			CREATE INDEX IF NOT EXISTS %v_storage_idx_type ON %v_storage (type);
+
+			DROP TABLE IF EXISTS %v_config;
+			CREATE TABLE %v_config (
+				host text, port integer, repl_set_name text, storage_engine text, cache_size_gb real,
+				slowms integer, profile text, options text, warnings text);
+
+			DROP TABLE IF EXISTS %v_uptime;
+			CREATE TABLE %v_uptime (
+				id integer not null primary key, pid integer, host text, port integer, start text, end text,
+				end_id integer, status text, crash_id integer, reason text);
+
+			DROP TABLE IF EXISTS %v_errors;
+			CREATE TABLE %v_errors (
+				id integer not null primary key, code integer, name text, ns text, op text, message text);
+			CREATE INDEX IF NOT EXISTS %v_errors_idx_code ON %v_errors (code,name);
+
+			DROP TABLE IF EXISTS %v_cursors;
+			CREATE TABLE %v_cursors (
+				id integer not null primary key, cursor_id integer, ns text, op text, filter text,
+				batches integer, nreturned integer, milli integer, start text, end text, lifetime integer, status text);
+			CREATE INDEX IF NOT EXISTS %v_cursors_idx_status ON %v_cursors (status);
+
+			DROP TABLE IF EXISTS %v_findings;
+			CREATE TABLE %v_findings (
+				id integer, rule text, severity text, ns text, op text, filter text, detail text);
+			CREATE INDEX IF NOT EXISTS %v_findings_idx_rule ON %v_findings (rule);`,
+			hatchetName, hatchetName, hatchetName, hatchetName, hatchetName, hatchetName, hatchetName, hatchetName,
+			hatchetName, hatchetName, hatchetName, hatchetName, hatchetName, hatchetName, hatchetName, hatchetName,
+			hatchetName, hatchetName, hatchetName, hatchetName, hatchetName, hatchetName, hatchetName, hatchetName,
+			hatchetName, hatchetName, hatchetName, hatchetName, hatchetName, hatchetName, hatchetName, hatchetName,
+			hatchetName, hatchetName, hatchetName, hatchetName, hatchetName, hatchetName, hatchetName, hatchetName,
+			hatchetName, hatchetName, hatchetName, hatchetName, hatchetName, hatchetName, hatchetName, hatchetName)
 }
 
 // GetHatchetPreparedStmt returns prepared statement of the hatchet table
 func (ptr *SQLite3DB) GetHatchetPreparedStmt() string {
 	return fmt.Sprintf(`INSERT INTO %v (id, date, severity, component, context,
-		msg, plan, type, ns, message, op, filter, _index, milli, reslen)
-		VALUES(?,?,?,?,?, ?,?,?,?,?, ?,?,?,?,?)`, ptr.hatchetName)
+		msg, plan, type, ns, message, op, filter, _index, milli, reslen, app, driver,
+		read_concern, write_concern, read_pref, attr)
+		VALUES(?,?,?,?,?, ?,?,?,?,?, ?,?,?,?,?, ?,?,?,?,?, ?)`, ptr.hatchetName)
+}
+
+// GetAuthPreparedStmt returns prepared statement of authentication events table
+func (ptr *SQLite3DB) GetAuthPreparedStmt() string {
+	return fmt.Sprintf(`INSERT INTO %v_auth (id, result, principal, db, mechanism, ip, err_code, err_name)
+		VALUES(?,?,?,?,?, ?,?,?)`, ptr.hatchetName)
 }
 
 // GetClientPreparedStmt returns prepared statement of clients table
@@ -249,8 +630,77 @@ func (ptr *SQLite3DB) GetClientPreparedStmt() string {
 		VALUES(?,?,?,?,?, ?,?)`, ptr.hatchetName)
 }
 
+// GetConnectionPreparedStmt returns prepared statement of connection lifetimes table
+func (ptr *SQLite3DB) GetConnectionPreparedStmt() string {
+	return fmt.Sprintf(`INSERT INTO %v_conns (id, conn_id, ip, port, app, accepted, ended, milli, status)
+		VALUES(?,?,?,?,?, ?,?,?,?)`, ptr.hatchetName)
+}
+
+// GetCursorPreparedStmt returns prepared statement of cursors table
+func (ptr *SQLite3DB) GetCursorPreparedStmt() string {
+	return fmt.Sprintf(`INSERT INTO %v_cursors (id, cursor_id, ns, op, filter, batches, nreturned, milli,
+		start, end, lifetime, status)
+		VALUES(?,?,?,?,?, ?,?,?,?,?, ?,?)`, ptr.hatchetName)
+}
+
+// GetFindingPreparedStmt returns prepared statement of anti-pattern findings table
+func (ptr *SQLite3DB) GetFindingPreparedStmt() string {
+	return fmt.Sprintf(`INSERT INTO %v_findings (id, rule, severity, ns, op, filter, detail)
+		VALUES(?,?,?,?,?, ?,?)`, ptr.hatchetName)
+}
+
 // GetDriverPreparedStmt returns prepared statement of drivers table
 func (ptr *SQLite3DB) GetDriverPreparedStmt() string {
-	return fmt.Sprintf(`INSERT INTO %v_drivers (id, ip, driver, version)
-		VALUES(?,?,?,?)`, ptr.hatchetName)
+	return fmt.Sprintf(`INSERT INTO %v_drivers (id, ip, driver, version, app)
+		VALUES(?,?,?,?,?)`, ptr.hatchetName)
+}
+
+// GetStoragePreparedStmt returns prepared statement of storage events table
+func (ptr *SQLite3DB) GetStoragePreparedStmt() string {
+	return fmt.Sprintf(`INSERT INTO %v_storage (id, type, category, milli, detail)
+		VALUES(?,?,?,?,?)`, ptr.hatchetName)
+}
+
+// GetTransactionPreparedStmt returns prepared statement of transactions table
+func (ptr *SQLite3DB) GetTransactionPreparedStmt() string {
+	return fmt.Sprintf(`INSERT INTO %v_txns (id, lsid, txn_number, termination, abort_cause,
+		milli, time_active, time_inactive, num_yields, keys_examined, docs_examined, nreturned,
+		ninserted, nmodified, ndeleted, read_concern, was_prepared)
+		VALUES(?,?,?,?,?, ?,?,?,?,?, ?,?,?,?,?, ?,?)`, ptr.hatchetName)
+}
+
+// GetTxnLogPreparedStmt returns prepared statement of logs of transactions table
+func (ptr *SQLite3DB) GetTxnLogPreparedStmt() string {
+	return fmt.Sprintf(`INSERT INTO %v_txn_logs (id, lsid, txn_number) VALUES(?,?,?)`, ptr.hatchetName)
+}
+
+// GetUptimePreparedStmt returns prepared statement of uptime segments table
+func (ptr *SQLite3DB) GetUptimePreparedStmt() string {
+	return fmt.Sprintf(`INSERT INTO %v_uptime (id, pid, host, port, start, end, end_id, status, crash_id, reason)
+		VALUES(?,?,?,?,?, ?,?,?,?,?)`, ptr.hatchetName)
+}
+
+// GetErrorPreparedStmt returns prepared statement of error codes table
+func (ptr *SQLite3DB) GetErrorPreparedStmt() string {
+	return fmt.Sprintf(`INSERT INTO %v_errors (id, code, name, ns, op, message)
+		VALUES(?,?,?,?,?, ?)`, ptr.hatchetName)
+}
+
+// GetIndexBuildPreparedStmt returns prepared statement of index builds table
+func (ptr *SQLite3DB) GetIndexBuildPreparedStmt() string {
+	return fmt.Sprintf(`INSERT INTO %v_index_builds (id, build_uuid, ns, indexes, keys, method,
+		commit_quorum, start, end, milli, status, phases, error)
+		VALUES(?,?,?,?,?, ?,?,?,?,?, ?,?,?)`, ptr.hatchetName)
+}
+
+// GetReplPreparedStmt returns prepared statement of replication events table
+func (ptr *SQLite3DB) GetReplPreparedStmt() string {
+	return fmt.Sprintf(`INSERT INTO %v_repl (id, type, node, name, detail, milli)
+		VALUES(?,?,?,?,?, ?)`, ptr.hatchetName)
+}
+
+// GetShardingPreparedStmt returns prepared statement of sharding events table
+func (ptr *SQLite3DB) GetShardingPreparedStmt() string {
+	return fmt.Sprintf(`INSERT INTO %v_sharding (id, type, name, ns, from_shard, to_shard, milli, result, metrics, detail)
+		VALUES(?,?,?,?,?, ?,?,?,?,?)`, ptr.hatchetName)
 }
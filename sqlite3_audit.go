@@ -74,7 +74,7 @@ func (ptr *SQLite3DB) GetAuditData() (map[string][]NameValues, error) {
 
 	// get audit data
 	query = fmt.Sprintf(`SELECT type, name, value FROM %v_audit
-		WHERE type IN ('exception', 'failed', 'op', 'duration') ORDER BY type, value DESC;`, ptr.hatchetName)
+		WHERE type IN ('exception', 'failed', 'errcode', 'op', 'duration') ORDER BY type, value DESC;`, ptr.hatchetName)
 	if ptr.verbose {
 		log.Println(query)
 	}
@@ -103,12 +103,12 @@ func (ptr *SQLite3DB) GetAuditData() (map[string][]NameValues, error) {
 	}
 
 	category = "ip"
-	query = fmt.Sprintf(`SELECT a.name ip, a.value count, b.value reslen FROM %v_audit a, %v_audit b WHERE a.type == '%v' AND b.type = 'reslen-ip' AND a.name = b.name ORDER BY reslen DESC;`,
-		ptr.hatchetName, ptr.hatchetName, category)
+	query = fmt.Sprintf(`SELECT a.name ip, a.value count, b.value reslen FROM %v_audit a, %v_audit b WHERE a.type == ? AND b.type = 'reslen-ip' AND a.name = b.name ORDER BY reslen DESC;`,
+		ptr.hatchetName, ptr.hatchetName)
 	if ptr.verbose {
-		log.Println(query)
+		log.Println(query, category)
 	}
-	rows, err = db.Query(query)
+	rows, err = db.Query(query, category)
 	if err != nil {
 		return data, err
 	}
@@ -127,12 +127,12 @@ func (ptr *SQLite3DB) GetAuditData() (map[string][]NameValues, error) {
 	}
 
 	category = "ns"
-	query = fmt.Sprintf(`SELECT a.name ns, a.value count, b.value reslen FROM %v_audit a, %v_audit b WHERE a.type == '%v' AND b.type = 'reslen-ns' AND a.name = b.name ORDER BY reslen DESC;`,
-		ptr.hatchetName, ptr.hatchetName, category)
+	query = fmt.Sprintf(`SELECT a.name ns, a.value count, b.value reslen FROM %v_audit a, %v_audit b WHERE a.type == ? AND b.type = 'reslen-ns' AND a.name = b.name ORDER BY reslen DESC;`,
+		ptr.hatchetName, ptr.hatchetName)
 	if ptr.verbose {
-		log.Println(query)
+		log.Println(query, category)
 	}
-	rows, err = db.Query(query)
+	rows, err = db.Query(query, category)
 	if err != nil {
 		return data, err
 	}
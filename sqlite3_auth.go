@@ -0,0 +1,115 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * sqlite3_auth.go
+ */
+
+package hatchet
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// GetAuthStats returns authentication mechanisms, failures by users and IPs, error codes
+// and possible brute-force attempts
+func (ptr *SQLite3DB) GetAuthStats() (AuthStats, error) {
+	var err error
+	hatchetName := ptr.hatchetName
+	stats := AuthStats{Bursts: []AuthBurst{}}
+	query := fmt.Sprintf(`SELECT mechanism, SUM(CASE WHEN result = ? THEN 1 ELSE 0 END),
+			SUM(CASE WHEN result = ? THEN 1 ELSE 0 END) FROM %v_auth
+		WHERE mechanism != '' GROUP BY mechanism ORDER BY mechanism;`, hatchetName)
+	if stats.Mechanisms, err = ptr.getNamePairs(query, AUTH_SUCCEEDED, AUTH_FAILED); err != nil {
+		return stats, err
+	}
+	query = fmt.Sprintf(`SELECT CASE WHEN db = '' THEN principal ELSE principal||'@'||db END user, COUNT(*), COUNT(DISTINCT ip)
+		FROM %v_auth WHERE result != ? AND principal != '' GROUP BY user ORDER BY COUNT(*) DESC;`, hatchetName)
+	if stats.FailuresByUser, err = ptr.getNamePairs(query, AUTH_SUCCEEDED); err != nil {
+		return stats, err
+	}
+	query = fmt.Sprintf(`SELECT ip, COUNT(*), COUNT(DISTINCT principal||'@'||db) FROM %v_auth
+		WHERE result != ? AND ip != '' GROUP BY ip ORDER BY COUNT(*) DESC;`, hatchetName)
+	if stats.FailuresByIP, err = ptr.getNamePairs(query, AUTH_SUCCEEDED); err != nil {
+		return stats, err
+	}
+	query = fmt.Sprintf(`SELECT err_name||' ('||err_code||')' error, COUNT(*) FROM %v_auth
+		WHERE result != ? GROUP BY error ORDER BY COUNT(*) DESC;`, hatchetName)
+	if stats.Errors, err = ptr.getNameValues(query, AUTH_SUCCEEDED); err != nil {
+		return stats, err
+	}
+
+	query = fmt.Sprintf(`SELECT a.date, b.principal, b.db, b.ip FROM %v a, %v_auth b
+		WHERE a.id = b.id AND b.result = ? ORDER BY a.id;`, hatchetName, hatchetName)
+	if ptr.verbose {
+		log.Println(query)
+	}
+	rows, err := ptr.db.Query(query, AUTH_FAILED)
+	if err != nil {
+		return stats, err
+	}
+	defer rows.Close()
+	events := []AuthEvent{}
+	for rows.Next() {
+		var event AuthEvent
+		if err = rows.Scan(&event.Date, &event.Principal, &event.Database, &event.IP); err != nil {
+			return stats, err
+		}
+		events = append(events, event)
+	}
+	stats.Bursts = DetectBruteForce(events)
+	return stats, err
+}
+
+// GetAuthFailures returns counts of authentication failures by users and IPs over a period of time
+func (ptr *SQLite3DB) GetAuthFailures(duration string) ([]OpCount, error) {
+	hatchetName := ptr.hatchetName
+	docs := []OpCount{}
+	builder := NewQueryBuilder().Where("a.id = b.id").Where("b.result != ?", AUTH_SUCCEEDED)
+	start, end, err := ptr.whereDuration(builder, "a.date", duration)
+	if err != nil {
+		return docs, err
+	}
+	substr := strings.ReplaceAll(GetDateSubString(start, end), "date", "a.date")
+	query := fmt.Sprintf(`SELECT %v dt, COUNT(*), CASE WHEN b.db = '' THEN b.principal ELSE b.principal||'@'||b.db END user,
+			b.ip FROM %v a, %v_auth b %v GROUP by dt, user, b.ip ORDER BY dt;`,
+		substr, hatchetName, hatchetName, builder.Clause())
+	if ptr.verbose {
+		log.Println(query, builder.Args())
+	}
+	rows, err := ptr.db.Query(query, builder.Args()...)
+	if err != nil {
+		return docs, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var doc OpCount
+		if err = rows.Scan(&doc.Date, &doc.Count, &doc.Op, &doc.Namespace); err != nil {
+			return docs, err
+		}
+		docs = append(docs, doc)
+	}
+	return docs, err
+}
+
+// getNamePairs returns name and two counts of a query
+func (ptr *SQLite3DB) getNamePairs(query string, args ...interface{}) ([]NameValues, error) {
+	docs := []NameValues{}
+	if ptr.verbose {
+		log.Println(query, args)
+	}
+	rows, err := ptr.db.Query(query, args...)
+	if err != nil {
+		return docs, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		var first, second int
+		if err = rows.Scan(&name, &first, &second); err != nil {
+			return docs, err
+		}
+		docs = append(docs, NameValues{name, []int{first, second}})
+	}
+	return docs, err
+}
@@ -0,0 +1,99 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * sqlite3_concerns.go
+ */
+
+package hatchet
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// GetConcernStats returns read and write concerns and read preferences of slow ops
+func (ptr *SQLite3DB) GetConcernStats(topN int) (ConcernStats, error) {
+	var err error
+	hatchetName := ptr.hatchetName
+	stats := ConcernStats{}
+	reads := getOpsList(READ_OPS)
+	writes := getOpsList(WRITE_OPS)
+	query := fmt.Sprintf(`SELECT CASE WHEN read_concern = '' THEN '%v' ELSE read_concern END rc,
+			COUNT(*), CAST(ROUND(AVG(milli)) AS INTEGER) FROM %v WHERE op IN (%v)
+		GROUP BY rc ORDER BY COUNT(*) DESC;`, CONCERN_DEFAULT, hatchetName, reads)
+	if stats.ReadConcerns, err = ptr.getNamePairs(query); err != nil {
+		return stats, err
+	}
+	query = fmt.Sprintf(`SELECT CASE WHEN read_pref = '' THEN '%v' ELSE read_pref END rp,
+			COUNT(*), CAST(ROUND(AVG(milli)) AS INTEGER) FROM %v WHERE op IN (%v)
+		GROUP BY rp ORDER BY COUNT(*) DESC;`, CONCERN_DEFAULT, hatchetName, reads)
+	if stats.ReadPreferences, err = ptr.getNamePairs(query); err != nil {
+		return stats, err
+	}
+	query = fmt.Sprintf(`SELECT CASE WHEN write_concern = '' THEN '%v' ELSE write_concern END wc,
+			COUNT(*), CAST(ROUND(AVG(milli)) AS INTEGER) FROM %v WHERE op IN (%v)
+		GROUP BY wc ORDER BY COUNT(*) DESC;`, CONCERN_DEFAULT, hatchetName, writes)
+	if stats.WriteConcerns, err = ptr.getNamePairs(query); err != nil {
+		return stats, err
+	}
+	query = fmt.Sprintf(`SELECT ns, app, read_concern, write_concern, read_pref, COUNT(*), ROUND(AVG(milli),1), MAX(milli)
+		FROM %v WHERE op IN (%v, %v) AND (read_concern != '' OR write_concern != '' OR read_pref != '')
+		GROUP BY ns, app, read_concern, write_concern, read_pref ORDER BY COUNT(*) DESC LIMIT ?;`,
+		hatchetName, reads, writes)
+	if ptr.verbose {
+		log.Println(query, topN)
+	}
+	rows, err := ptr.db.Query(query, topN)
+	if err != nil {
+		return stats, err
+	}
+	defer rows.Close()
+	stats.Usages = []ConcernUsage{}
+	for rows.Next() {
+		var usage ConcernUsage
+		if err = rows.Scan(&usage.Namespace, &usage.AppName, &usage.ReadConcern, &usage.WriteConcern,
+			&usage.ReadPreference, &usage.Count, &usage.AvgMilli, &usage.MaxMilli); err != nil {
+			return stats, err
+		}
+		stats.Usages = append(stats.Usages, usage)
+	}
+	return stats, err
+}
+
+// GetWriteConcernLatency returns average milliseconds of write ops by write concerns over a period of time
+func (ptr *SQLite3DB) GetWriteConcernLatency(duration string) ([]OpCount, error) {
+	docs := []OpCount{}
+	builder := NewQueryBuilder().Where(fmt.Sprintf("op IN (%v)", getOpsList(WRITE_OPS)))
+	start, end, err := ptr.whereDuration(builder, "date", duration)
+	if err != nil {
+		return docs, err
+	}
+	query := fmt.Sprintf(`SELECT %v dt, AVG(milli), COUNT(*),
+			CASE WHEN write_concern = '' THEN '%v' ELSE write_concern END wc, ns FROM %v
+		%v GROUP BY dt, wc, ns ORDER BY dt;`, GetDateSubString(start, end), CONCERN_DEFAULT, ptr.hatchetName, builder.Clause())
+	if ptr.verbose {
+		log.Println(query, builder.Args())
+	}
+	rows, err := ptr.db.Query(query, builder.Args()...)
+	if err != nil {
+		return docs, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var doc OpCount
+		if err = rows.Scan(&doc.Date, &doc.Milli, &doc.Count, &doc.Op, &doc.Namespace); err != nil {
+			return docs, err
+		}
+		docs = append(docs, doc)
+	}
+	return docs, err
+}
+
+// getOpsList returns a quoted list of ops for an IN clause
+func getOpsList(ops []string) string {
+	strs := []string{}
+	for _, op := range ops {
+		strs = append(strs, fmt.Sprintf("'%v'", op))
+	}
+	return strings.Join(strs, ",")
+}
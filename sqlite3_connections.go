@@ -0,0 +1,107 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * sqlite3_connections.go
+ */
+
+package hatchet
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// GetAcceptedRate returns peak accepted connections per second of each time bucket and spikes
+func (ptr *SQLite3DB) GetAcceptedRate(duration string) (AcceptedRate, error) {
+	hatchetName := ptr.hatchetName
+	rate := AcceptedRate{Rates: []ConnectionRate{}, Spikes: []NameValue{}}
+	builder := NewQueryBuilder().Where("a.id = b.id").Where("b.accepted = 1")
+	start, end, err := ptr.whereDuration(builder, "a.date", duration)
+	if err != nil {
+		return rate, err
+	}
+	substr := strings.ReplaceAll(GetDateSubString(start, end), "date", "a.date")
+	query := fmt.Sprintf(`SELECT %v dt, SUBSTR(a.date, 1, 19) sec, SUM(b.accepted)
+		FROM %v a, %v_clients b %v GROUP by sec ORDER BY sec;`,
+		substr, hatchetName, hatchetName, builder.Clause())
+	if ptr.verbose {
+		log.Println(query, builder.Args())
+	}
+	rows, err := ptr.db.Query(query, builder.Args()...)
+	if err != nil {
+		return rate, err
+	}
+	defer rows.Close()
+	buckets := []string{}
+	seconds := []NameValue{}
+	for rows.Next() {
+		var bucket string
+		var second NameValue
+		if err = rows.Scan(&bucket, &second.Name, &second.Value); err != nil {
+			return rate, err
+		}
+		buckets = append(buckets, bucket)
+		seconds = append(seconds, second)
+	}
+	return getAcceptedRate(buckets, seconds, getDurationSeconds(start, end)), err
+}
+
+// GetConnectionDistribution returns counts of ended connections by lifetimes
+func (ptr *SQLite3DB) GetConnectionDistribution(duration string) ([]NameValue, error) {
+	docs := []NameValue{}
+	for _, bucket := range CONN_BUCKETS {
+		docs = append(docs, NameValue{bucket.Name, 0})
+	}
+	builder := NewQueryBuilder().Where("status = ?", CONN_ENDED)
+	if err := builder.WhereDuration("accepted", duration); err != nil {
+		return docs, err
+	}
+	query := fmt.Sprintf(`SELECT %v bucket, COUNT(*) FROM %v_conns %v GROUP BY bucket ORDER BY bucket;`,
+		getBucketCaseExpr("milli", CONN_BUCKETS), ptr.hatchetName, builder.Clause())
+	if ptr.verbose {
+		log.Println(query, builder.Args())
+	}
+	rows, err := ptr.db.Query(query, builder.Args()...)
+	if err != nil {
+		return docs, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var bucket, count int
+		if err = rows.Scan(&bucket, &count); err != nil {
+			return docs, err
+		}
+		docs[bucket].Value = count
+	}
+	return docs, err
+}
+
+// GetConnectionLifetimes returns connection lifetimes by IPs and applications,
+// sorted by open connections
+func (ptr *SQLite3DB) GetConnectionLifetimes(duration string) ([]ConnectionStat, error) {
+	docs := []ConnectionStat{}
+	builder := NewQueryBuilder()
+	if err := builder.WhereDuration("accepted", duration); err != nil {
+		return docs, err
+	}
+	query := fmt.Sprintf(`SELECT ip, app, accepted, ended, milli, status FROM %v_conns %v ORDER BY id;`,
+		ptr.hatchetName, builder.Clause())
+	if ptr.verbose {
+		log.Println(query, builder.Args())
+	}
+	rows, err := ptr.db.Query(query, builder.Args()...)
+	if err != nil {
+		return docs, err
+	}
+	defer rows.Close()
+	conns := []ConnectionLifetime{}
+	for rows.Next() {
+		var conn ConnectionLifetime
+		if err = rows.Scan(&conn.IP, &conn.AppName, &conn.Accepted, &conn.Ended, &conn.Milli, &conn.Status); err != nil {
+			return docs, err
+		}
+		conns = append(conns, conn)
+	}
+	docs = summarizeConnections(conns)
+	return docs, err
+}
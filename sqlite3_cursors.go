@@ -0,0 +1,67 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * sqlite3_cursors.go
+ */
+
+package hatchet
+
+import (
+	"fmt"
+	"log"
+)
+
+// GetCursorStats returns counts of cursors by statuses, and cursors of the longest lifetime and of
+// the longest total execution time
+func (ptr *SQLite3DB) GetCursorStats(topN int) (CursorStats, error) {
+	var err error
+	hatchetName := ptr.hatchetName
+	stats := CursorStats{}
+	query := fmt.Sprintf(`SELECT status, COUNT(*), SUM(batches), SUM(nreturned) FROM %v_cursors
+		GROUP BY status ORDER BY COUNT(*) DESC;`, hatchetName)
+	if ptr.verbose {
+		log.Println(query)
+	}
+	rows, err := ptr.db.Query(query)
+	if err != nil {
+		return stats, err
+	}
+	defer rows.Close()
+	stats.Statuses = []NameValues{}
+	for rows.Next() {
+		var status string
+		var count, batches, docs int
+		if err = rows.Scan(&status, &count, &batches, &docs); err != nil {
+			return stats, err
+		}
+		stats.Statuses = append(stats.Statuses, NameValues{status, []int{count, batches, docs}})
+	}
+	if stats.LongLived, err = ptr.getCursors("lifetime", topN); err != nil {
+		return stats, err
+	}
+	stats.Heaviest, err = ptr.getCursors("milli", topN)
+	return stats, err
+}
+
+// getCursors returns top N cursors sorted by a column
+func (ptr *SQLite3DB) getCursors(orderBy string, topN int) ([]Cursor, error) {
+	cursors := []Cursor{}
+	query := fmt.Sprintf(`SELECT cursor_id, ns, op, filter, batches, nreturned, milli, start, end, lifetime, status
+		FROM %v_cursors ORDER BY %v DESC LIMIT ?;`, ptr.hatchetName, orderBy)
+	if ptr.verbose {
+		log.Println(query, topN)
+	}
+	rows, err := ptr.db.Query(query, topN)
+	if err != nil {
+		return cursors, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var cursor Cursor
+		if err = rows.Scan(&cursor.CursorID, &cursor.Namespace, &cursor.Op, &cursor.QueryPattern, &cursor.Batches,
+			&cursor.NReturned, &cursor.Milli, &cursor.Start, &cursor.End, &cursor.Lifetime, &cursor.Status); err != nil {
+			return cursors, err
+		}
+		cursors = append(cursors, cursor)
+	}
+	return cursors, err
+}
@@ -0,0 +1,48 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * sqlite3_drivers.go
+ */
+
+package hatchet
+
+import (
+	"fmt"
+	"log"
+)
+
+// GetDriverUsages returns driver versions and client IPs and applications using them
+func (ptr *SQLite3DB) GetDriverUsages() ([]DriverUsage, error) {
+	hatchetName := ptr.hatchetName
+	usages := []DriverUsage{}
+	query := fmt.Sprintf(`SELECT driver, version, COUNT(*) FROM %v_drivers
+		GROUP BY driver, version ORDER BY driver, version;`, hatchetName)
+	if ptr.verbose {
+		log.Println(query)
+	}
+	rows, err := ptr.db.Query(query)
+	if err != nil {
+		return usages, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		usage := DriverUsage{}
+		if err = rows.Scan(&usage.Driver, &usage.Version, &usage.Count); err != nil {
+			return usages, err
+		}
+		usages = append(usages, usage)
+	}
+	rows.Close()
+	for i, usage := range usages {
+		query = fmt.Sprintf(`SELECT ip, COUNT(*) FROM %v_drivers WHERE driver = ? AND version = ? AND ip != ''
+			GROUP BY ip ORDER BY COUNT(*) DESC LIMIT ?;`, hatchetName)
+		if usages[i].IPs, err = ptr.getNameValues(query, usage.Driver, usage.Version, TOP_N); err != nil {
+			return usages, err
+		}
+		query = fmt.Sprintf(`SELECT app, COUNT(*) FROM %v_drivers WHERE driver = ? AND version = ? AND app != ''
+			GROUP BY app ORDER BY COUNT(*) DESC LIMIT ?;`, hatchetName)
+		if usages[i].AppNames, err = ptr.getNameValues(query, usage.Driver, usage.Version, TOP_N); err != nil {
+			return usages, err
+		}
+	}
+	return usages, err
+}
@@ -0,0 +1,93 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * sqlite3_errors.go
+ */
+
+package hatchet
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// GetErrorCodes returns counts of error codes by namespaces, ops and client IPs
+func (ptr *SQLite3DB) GetErrorCodes() ([]ErrorCode, error) {
+	hatchetName := ptr.hatchetName
+	codes := []ErrorCode{}
+	query := fmt.Sprintf(`SELECT code, name, COUNT(*), MAX(message) FROM %v_errors
+		GROUP BY code, name ORDER BY COUNT(*) DESC, code;`, hatchetName)
+	if ptr.verbose {
+		log.Println(query)
+	}
+	rows, err := ptr.db.Query(query)
+	if err != nil {
+		return codes, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		code := ErrorCode{}
+		if err = rows.Scan(&code.Code, &code.Name, &code.Count, &code.Message); err != nil {
+			return codes, err
+		}
+		codes = append(codes, code)
+	}
+	rows.Close()
+	for i, code := range codes {
+		query = fmt.Sprintf(`SELECT ns, COUNT(*) FROM %v_errors WHERE code = ? AND name = ? AND ns != ''
+			GROUP BY ns ORDER BY COUNT(*) DESC LIMIT ?;`, hatchetName)
+		if codes[i].Namespaces, err = ptr.getNameValues(query, code.Code, code.Name, TOP_N); err != nil {
+			return codes, err
+		}
+		query = fmt.Sprintf(`SELECT op, COUNT(*) FROM %v_errors WHERE code = ? AND name = ? AND op != ''
+			GROUP BY op ORDER BY COUNT(*) DESC LIMIT ?;`, hatchetName)
+		if codes[i].Ops, err = ptr.getNameValues(query, code.Code, code.Name, TOP_N); err != nil {
+			return codes, err
+		}
+		// client IP of the last accepted connection of the context, e.g. conn12, before the error
+		query = fmt.Sprintf(`SELECT ip, COUNT(*) FROM (
+				SELECT (SELECT c.ip FROM %v_conns c WHERE c.conn_id = CAST(SUBSTR(a.context, 5) AS INTEGER)
+					AND c.id < a.id ORDER BY c.id DESC LIMIT 1) ip
+				FROM %v a, %v_errors b WHERE a.id = b.id AND b.code = ? AND b.name = ? AND a.context LIKE 'conn%%')
+			WHERE ip IS NOT NULL GROUP BY ip ORDER BY COUNT(*) DESC LIMIT ?;`, hatchetName, hatchetName, hatchetName)
+		if codes[i].Clients, err = ptr.getNameValues(query, code.Code, code.Name, TOP_N); err != nil {
+			return codes, err
+		}
+	}
+	return codes, err
+}
+
+// GetErrorCounts returns counts of errors by codes and namespaces over a period of time, all codes
+// are returned if code is 0
+func (ptr *SQLite3DB) GetErrorCounts(code int, duration string) ([]OpCount, error) {
+	hatchetName := ptr.hatchetName
+	docs := []OpCount{}
+	builder := NewQueryBuilder().Where("a.id = b.id")
+	if code != 0 {
+		builder.Where("b.code = ?", code)
+	}
+	start, end, err := ptr.whereDuration(builder, "a.date", duration)
+	if err != nil {
+		return docs, err
+	}
+	substr := strings.ReplaceAll(GetDateSubString(start, end), "date", "a.date")
+	query := fmt.Sprintf(`SELECT %v dt, COUNT(*), b.name||' ('||b.code||')' error, b.ns FROM %v a, %v_errors b
+		%v GROUP by dt, error, b.ns ORDER BY dt;`,
+		substr, hatchetName, hatchetName, builder.Clause())
+	if ptr.verbose {
+		log.Println(query, builder.Args())
+	}
+	rows, err := ptr.db.Query(query, builder.Args()...)
+	if err != nil {
+		return docs, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var doc OpCount
+		if err = rows.Scan(&doc.Date, &doc.Count, &doc.Op, &doc.Namespace); err != nil {
+			return docs, err
+		}
+		docs = append(docs, doc)
+	}
+	return docs, err
+}
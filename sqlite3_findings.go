@@ -0,0 +1,66 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * sqlite3_findings.go
+ */
+
+package hatchet
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// GetFindings returns anti-patterns found, ordered by severities and counts
+func (ptr *SQLite3DB) GetFindings() ([]Finding, error) {
+	findings := []Finding{}
+	query := fmt.Sprintf(`SELECT rule, severity, ns, op, filter, MIN(detail), COUNT(*) FROM %v_findings
+		GROUP BY rule, severity, ns, op, filter
+		ORDER BY CASE severity WHEN '%v' THEN 1 WHEN '%v' THEN 2 ELSE 3 END, COUNT(*) DESC;`,
+		ptr.hatchetName, SEVERITY_HIGH, SEVERITY_MEDIUM)
+	if ptr.verbose {
+		log.Println(query)
+	}
+	rows, err := ptr.db.Query(query)
+	if err != nil {
+		return findings, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var finding Finding
+		if err = rows.Scan(&finding.Rule, &finding.Severity, &finding.Namespace, &finding.Op,
+			&finding.QueryPattern, &finding.Detail, &finding.Count); err != nil {
+			return findings, err
+		}
+		findings = append(findings, finding)
+	}
+	return findings, err
+}
+
+// getFindingRules returns anti-pattern rules found by op, namespace, and query pattern
+func (ptr *SQLite3DB) getFindingRules() (map[string][]string, error) {
+	rules := map[string][]string{}
+	query := fmt.Sprintf(`SELECT DISTINCT op, ns, filter, rule FROM %v_findings ORDER BY rule;`, ptr.hatchetName)
+	if ptr.verbose {
+		log.Println(query)
+	}
+	rows, err := ptr.db.Query(query)
+	if err != nil {
+		return rules, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var op, ns, filter, rule string
+		if err = rows.Scan(&op, &ns, &filter, &rule); err != nil {
+			return rules, err
+		}
+		key := getFindingKey(op, ns, filter)
+		rules[key] = append(rules[key], rule)
+	}
+	return rules, err
+}
+
+// getFindingKey returns a key of op, namespace, and query pattern
+func getFindingKey(op string, ns string, filter string) string {
+	return strings.Join([]string{op, ns, filter}, "|")
+}
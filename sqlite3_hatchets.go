@@ -0,0 +1,246 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * sqlite3_hatchets.go
+ */
+
+package hatchet
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// DeleteHatchet drops all tables of the hatchet and removes it from the hatchet table
+func (ptr *SQLite3DB) DeleteHatchet() error {
+	tables, err := ptr.getHatchetTables()
+	if err != nil {
+		return err
+	}
+	err = ptr.db.Write(func(tx *sql.Tx) error {
+		for _, table := range tables {
+			if ptr.verbose {
+				log.Println("drop table", table)
+			}
+			if _, err := tx.Exec(fmt.Sprintf("DROP TABLE IF EXISTS %v", table)); err != nil {
+				return err
+			}
+		}
+		_, err := tx.Exec("DELETE FROM hatchet WHERE name = ?", ptr.hatchetName)
+		return err
+	})
+	ptr.db.ClearStmts()
+	return err
+}
+
+// RenameHatchet renames all tables and indexes of the hatchet and its row of the hatchet table
+func (ptr *SQLite3DB) RenameHatchet(name string) error {
+	if err := ValidateHatchetName(name); err != nil {
+		return err
+	}
+	tables, err := ptr.getHatchetTables()
+	if err != nil {
+		return err
+	}
+	renamed := []interface{}{name}
+	for _, table := range tables {
+		renamed = append(renamed, name+strings.TrimPrefix(table, ptr.hatchetName))
+	}
+	placeholders := strings.Repeat(",?", len(renamed)-1)
+	var count int
+	query := fmt.Sprintf("SELECT COUNT(*) FROM sqlite_master WHERE name IN (?%v)", placeholders)
+	if err = ptr.db.QueryRow(query, renamed...).Scan(&count); err != nil {
+		return err
+	} else if count > 0 {
+		return NewInvalidInputError("hatchet %v already exists", name)
+	}
+	if err = ptr.db.QueryRow("SELECT COUNT(*) FROM hatchet WHERE name = ?", name).Scan(&count); err != nil {
+		return err
+	} else if count > 0 {
+		return NewInvalidInputError("hatchet %v already exists", name)
+	}
+	if err = ptr.db.Write(func(tx *sql.Tx) error {
+		for i, table := range tables {
+			if _, err := tx.Exec(fmt.Sprintf("ALTER TABLE %v RENAME TO %v", table, renamed[i+1])); err != nil {
+				return err
+			}
+		}
+		// indexes keep their names after tables are renamed, recreate them with names of the new hatchet
+		query := fmt.Sprintf(`SELECT name, sql FROM sqlite_master WHERE type = 'index' AND sql IS NOT NULL
+			AND tbl_name IN (?%v)`, placeholders)
+		rows, err := tx.Query(query, renamed...)
+		if err != nil {
+			return err
+		}
+		indexes := map[string]string{}
+		for rows.Next() {
+			var index, stmt string
+			if err = rows.Scan(&index, &stmt); err != nil {
+				rows.Close()
+				return err
+			}
+			indexes[index] = stmt
+		}
+		rows.Close()
+		for index, stmt := range indexes {
+			if !strings.HasPrefix(index, ptr.hatchetName+"_") {
+				continue
+			}
+			stmt = strings.Replace(stmt, index, name+strings.TrimPrefix(index, ptr.hatchetName), 1)
+			if _, err = tx.Exec(fmt.Sprintf("DROP INDEX %v; %v", index, stmt)); err != nil {
+				return err
+			}
+		}
+		_, err = tx.Exec("UPDATE hatchet SET name = ? WHERE name = ?", name, ptr.hatchetName)
+		return err
+	}); err != nil {
+		return err
+	}
+	ptr.db.ClearStmts()
+	ptr.hatchetName = name
+	return err
+}
+
+// PinHatchet sets whether the hatchet is kept regardless of the retention policy
+func (ptr *SQLite3DB) PinHatchet(pinned bool) error {
+	result, err := ptr.db.Exec("UPDATE hatchet SET pinned = ? WHERE name = ?", pinned, ptr.hatchetName)
+	if err != nil {
+		return err
+	}
+	if count, err := result.RowsAffected(); err != nil {
+		return err
+	} else if count == 0 {
+		return NewInvalidInputError("hatchet %v not found", ptr.hatchetName)
+	}
+	return err
+}
+
+// GetHatchetSize returns the size, in bytes, of the hatchet recorded after analyzed.  Sizes of hatchets
+// analyzed by earlier versions are measured once and recorded
+func (ptr *SQLite3DB) GetHatchetSize() (int64, error) {
+	var size sql.NullInt64
+	err := ptr.db.QueryRow("SELECT size FROM hatchet WHERE name = ?", ptr.hatchetName).Scan(&size)
+	if err == sql.ErrNoRows {
+		return 0, NewInvalidInputError("hatchet %v not found", ptr.hatchetName)
+	} else if err != nil {
+		return 0, err
+	} else if size.Valid {
+		return size.Int64, err
+	}
+	return ptr.updateHatchetSize()
+}
+
+// updateHatchetSize measures and records the size of the hatchet
+func (ptr *SQLite3DB) updateHatchetSize() (int64, error) {
+	size, err := ptr.measureHatchetSize()
+	if err != nil {
+		return size, err
+	}
+	_, err = ptr.db.Exec("UPDATE hatchet SET size = ? WHERE name = ?", size, ptr.hatchetName)
+	return size, err
+}
+
+// measureHatchetSize returns the disk usage of all tables and indexes of the hatchet from dbstat, or the
+// estimated data size if dbstat is not compiled in, i.e. built without SQLITE_ENABLE_DBSTAT_VTAB
+func (ptr *SQLite3DB) measureHatchetSize() (int64, error) {
+	var size int64
+	tables, err := ptr.getHatchetTables()
+	if err != nil {
+		return size, err
+	}
+	names := []interface{}{}
+	for _, table := range tables {
+		names = append(names, table)
+		if table == ptr.hatchetName+"_fts" { // shadow tables
+			for _, suffix := range []string{"_config", "_data", "_docsize", "_idx"} {
+				names = append(names, table+suffix)
+			}
+		}
+	}
+	query := fmt.Sprintf(`SELECT IFNULL(SUM(pgsize),0) FROM dbstat WHERE name IN (
+		SELECT name FROM sqlite_master WHERE tbl_name IN (?%v))`, strings.Repeat(",?", len(names)-1))
+	if ptr.verbose {
+		log.Println(query)
+	}
+	err = ptr.db.QueryRow(query, names...).Scan(&size)
+	if err != nil && strings.Contains(err.Error(), "no such table: dbstat") {
+		return ptr.estimateHatchetSize(tables)
+	}
+	return size, err
+}
+
+// estimateHatchetSize returns the sum of lengths of values of tables, excluding indexes and page overheads
+func (ptr *SQLite3DB) estimateHatchetSize(tables []string) (int64, error) {
+	var total int64
+	var err error
+	for _, table := range tables {
+		if table == ptr.hatchetName+"_fts" { // contentless, sizes of index blocks
+			var size int64
+			query := fmt.Sprintf("SELECT IFNULL(SUM(LENGTH(block)),0) FROM %v_data", table)
+			if err = ptr.db.QueryRow(query).Scan(&size); err != nil {
+				return total, err
+			}
+			total += size
+			continue
+		}
+		columns, err := getTableColumns(ptr.db, table)
+		if err != nil {
+			return total, err
+		}
+		lengths := []string{}
+		for column := range columns {
+			lengths = append(lengths, fmt.Sprintf(`IFNULL(LENGTH("%v"),0)`, column))
+		}
+		if len(lengths) == 0 {
+			continue
+		}
+		var size int64
+		query := fmt.Sprintf("SELECT IFNULL(SUM(%v),0) FROM %v", strings.Join(lengths, "+"), table)
+		if ptr.verbose {
+			log.Println(query)
+		}
+		if err = ptr.db.QueryRow(query).Scan(&size); err != nil {
+			return total, err
+		}
+		total += size
+	}
+	return total, err
+}
+
+// Vacuum rebuilds the database file to reclaim space of deleted hatchets
+func (ptr *SQLite3DB) Vacuum() error {
+	ptr.db.ClearStmts()
+	_, err := ptr.db.Exec("VACUUM")
+	return err
+}
+
+// getHatchetTables returns existing tables of the hatchet, an error if the hatchet doesn't exist
+func (ptr *SQLite3DB) getHatchetTables() ([]string, error) {
+	tables := []string{}
+	var count int
+	if err := ptr.db.QueryRow("SELECT COUNT(*) FROM hatchet WHERE name = ?", ptr.hatchetName).Scan(&count); err != nil {
+		return tables, err
+	} else if count == 0 {
+		return tables, NewInvalidInputError("hatchet %v not found", ptr.hatchetName)
+	}
+	names := []interface{}{}
+	for _, suffix := range HATCHET_TABLES {
+		names = append(names, ptr.hatchetName+suffix)
+	}
+	query := fmt.Sprintf("SELECT name FROM sqlite_master WHERE type = 'table' AND name IN (?%v)",
+		strings.Repeat(",?", len(names)-1))
+	rows, err := ptr.db.Query(query, names...)
+	if err != nil {
+		return tables, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var table string
+		if err = rows.Scan(&table); err != nil {
+			return tables, err
+		}
+		tables = append(tables, table)
+	}
+	return tables, err
+}
@@ -0,0 +1,83 @@
+// Copyright 2022-present Kuei-chun Chen. All rights reserved.
+
+package hatchet
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+func TestRenameDeleteHatchet(t *testing.T) {
+	dbfile := filepath.Join(t.TempDir(), "hatchet.db")
+	db, err := sql.Open("sqlite3", dbfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	sqlite := &SQLite3DB{db: newSQLite3Pool(db), hatchetName: "mongod_1b3d5f"}
+	if _, err = db.Exec(sqlite.GetHatchetInitStmt()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = db.Exec("INSERT INTO mongod_1b3d5f (id, message) VALUES (1, 'hello')"); err != nil {
+		t.Fatal(err)
+	}
+	if err = sqlite.createFullTextIndex(); err != nil {
+		t.Fatal(err)
+	}
+	if err = sqlite.UpdateHatchetInfo(HatchetInfo{Version: "6.0.4"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = sqlite.PinHatchet(true); err != nil {
+		t.Fatal(err)
+	}
+	if err = sqlite.UpdateHatchetInfo(HatchetInfo{Version: "6.0.5"}); err != nil {
+		t.Fatal(err)
+	}
+	if info := sqlite.GetHatchetInfo(); info.Version != "6.0.5" || !info.Pinned {
+		t.Fatal("expected", "pinned 6.0.5", "but got", info)
+	}
+	size, err := sqlite.GetHatchetSize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size == 0 {
+		t.Fatal("expected size greater than 0")
+	}
+	if _, err = db.Exec("INSERT INTO mongod_1b3d5f (id, message) VALUES (2, 'world')"); err != nil {
+		t.Fatal(err)
+	}
+	if recorded, err := sqlite.GetHatchetSize(); err != nil || recorded != size {
+		t.Fatal("expected", size, "but got", recorded, err)
+	}
+
+	if err = sqlite.RenameHatchet("mongod-2"); !IsInvalidInput(err) {
+		t.Fatal("expected invalid hatchet name but got", err)
+	}
+	if err = sqlite.RenameHatchet("mongod_2c4e6a"); err != nil {
+		t.Fatal(err)
+	}
+	var count int
+	if err = db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name LIKE 'mongod\_1b3d5f%' ESCAPE '\'`).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Fatal("expected", 0, "but got", count)
+	}
+	if info := sqlite.GetHatchetInfo(); info.Version != "6.0.5" {
+		t.Fatal("expected", "6.0.5", "but got", info.Version)
+	}
+
+	if err = sqlite.DeleteHatchet(); err != nil {
+		t.Fatal(err)
+	}
+	if err = db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name LIKE 'mongod\_2c4e6a%' ESCAPE '\'`).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Fatal("expected", 0, "but got", count)
+	}
+	if err = sqlite.DeleteHatchet(); !IsInvalidInput(err) {
+		t.Fatal("expected hatchet not found but got", err)
+	}
+}
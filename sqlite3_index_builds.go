@@ -0,0 +1,45 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * sqlite3_index_builds.go
+ */
+
+package hatchet
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+)
+
+// GetIndexBuilds returns index builds started within a period of time, sorted by start time
+func (ptr *SQLite3DB) GetIndexBuilds(duration string) ([]IndexBuild, error) {
+	docs := []IndexBuild{}
+	builder := NewQueryBuilder()
+	if err := builder.WhereDuration("start", duration); err != nil {
+		return docs, err
+	}
+	query := fmt.Sprintf(`SELECT build_uuid, ns, indexes, keys, method, commit_quorum, start, end, milli, status, phases, error
+		FROM %v_index_builds %v ORDER BY start;`, ptr.hatchetName, builder.Clause())
+	if ptr.verbose {
+		log.Println(query, builder.Args())
+	}
+	rows, err := ptr.db.Query(query, builder.Args()...)
+	if err != nil {
+		return docs, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var doc IndexBuild
+		var phases string
+		if err = rows.Scan(&doc.BuildUUID, &doc.Namespace, &doc.Indexes, &doc.Keys, &doc.Method, &doc.CommitQuorum,
+			&doc.Start, &doc.End, &doc.Milli, &doc.Status, &phases, &doc.Error); err != nil {
+			return docs, err
+		}
+		doc.Phases = map[string]int{}
+		if phases != "" {
+			json.Unmarshal([]byte(phases), &doc.Phases)
+		}
+		docs = append(docs, doc)
+	}
+	return docs, err
+}
@@ -0,0 +1,278 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * sqlite3_migrations.go
+ */
+
+package hatchet
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	REANALYZE_REQUIRED = "re-analyze required" // status of a hatchet that cannot be migrated
+	SCHEMA_VERSION     = 4                     // schema version of hatchet tables
+)
+
+// Migration upgrades tables of a hatchet from the previous version to Version.  Migrations are
+// frozen once released, a schema change adds a new migration instead of editing an old one
+type Migration struct {
+	Version int
+	Descr   string
+	Migrate func(tx *sql.Tx, hatchetName string) error
+}
+
+// SQLITE3_MIGRATIONS are migrations in ascending order of versions, version 1 is the original schema
+var SQLITE3_MIGRATIONS = []Migration{
+	{2, "add applications, drivers, concerns columns and event tables", migrateToV2},
+	{3, "add raw attributes column", migrateToV3},
+	{4, "require columns of per-minute rollup tables", migrateToV4},
+}
+
+var sqlite3Migrated = map[string]bool{}
+var sqlite3MigrationMutex sync.Mutex
+
+// MigrateSQLite3DB upgrades hatchets of a database file to SCHEMA_VERSION once per process.  Hatchets
+// failed to migrate are marked as REANALYZE_REQUIRED
+func MigrateSQLite3DB(db *sql.DB, dbfile string) error {
+	sqlite3MigrationMutex.Lock()
+	defer sqlite3MigrationMutex.Unlock()
+	if sqlite3Migrated[dbfile] {
+		return nil
+	}
+	if err := migrateHatchetTable(db); err != nil {
+		return err
+	}
+	query := "SELECT name, schema_version FROM hatchet WHERE schema_version < ? AND status = ''"
+	rows, err := db.Query(query, SCHEMA_VERSION)
+	if err != nil {
+		return err
+	}
+	versions := map[string]int{}
+	for rows.Next() {
+		var name string
+		var version int
+		if err = rows.Scan(&name, &version); err != nil {
+			rows.Close()
+			return err
+		}
+		versions[name] = version
+	}
+	rows.Close()
+	for name, version := range versions {
+		if err = migrateHatchet(db, name, version); err != nil {
+			log.Println("hatchet", name, REANALYZE_REQUIRED+":", err)
+			if _, err = db.Exec("UPDATE hatchet SET status = ? WHERE name = ?", REANALYZE_REQUIRED, name); err != nil {
+				return err
+			}
+		}
+	}
+	sqlite3Migrated[dbfile] = true
+	return nil
+}
+
+// GetSchemaStatus returns the status of a hatchet of a schema version, REANALYZE_REQUIRED if the
+// hatchet failed to migrate or was analyzed by a newer version
+func GetSchemaStatus(version int, status string) string {
+	if status == "" && version > SCHEMA_VERSION {
+		return REANALYZE_REQUIRED
+	}
+	return status
+}
+
+// migrateHatchetTable creates the hatchet table or adds version, status, analyzed, pinned and size columns to it.
+// Hatchets analyzed before analysis times were recorded are considered analyzed now
+func migrateHatchetTable(db *sql.DB) error {
+	stmt := `CREATE TABLE IF NOT EXISTS hatchet ( name text not null primary key,
+		version text, module text, arch text, os text, start text, end text,
+		schema_version integer default 1, status text default '', analyzed text default '',
+		pinned integer default 0, size integer);`
+	if _, err := db.Exec(stmt); err != nil {
+		return err
+	}
+	if err := addMissingColumns(db, "hatchet", "schema_version integer default 1", "status text default ''",
+		"analyzed text default ''", "pinned integer default 0", "size integer"); err != nil {
+		return err
+	}
+	_, err := db.Exec("UPDATE hatchet SET analyzed = ? WHERE analyzed = ''", time.Now().UTC().Format(time.RFC3339))
+	return err
+}
+
+// migrateHatchet applies migrations of versions greater than the version of a hatchet in a transaction
+func migrateHatchet(db *sql.DB, hatchetName string, version int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	for _, migration := range SQLITE3_MIGRATIONS {
+		if migration.Version <= version {
+			continue
+		}
+		log.Printf("migrating hatchet %v to version %v, %v\n", hatchetName, migration.Version, migration.Descr)
+		if err = migration.Migrate(tx, hatchetName); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if _, err = tx.Exec("UPDATE hatchet SET schema_version = ? WHERE name = ?", SCHEMA_VERSION, hatchetName); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// dbExecer is implemented by both *sql.DB and *sql.Tx
+type dbExecer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// getTableColumns returns column names of a table, empty if the table doesn't exist
+func getTableColumns(db dbExecer, table string) (map[string]bool, error) {
+	columns := map[string]bool{}
+	rows, err := db.Query("SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return columns, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			return columns, err
+		}
+		columns[name] = true
+	}
+	return columns, err
+}
+
+// addMissingColumns adds columns, i.e. {name} {type} [default {value}], not in a table
+func addMissingColumns(db dbExecer, table string, defs ...string) error {
+	columns, err := getTableColumns(db, table)
+	if err != nil {
+		return err
+	}
+	for _, def := range defs {
+		var name string
+		fmt.Sscan(def, &name)
+		if columns[name] {
+			continue
+		}
+		if _, err = db.Exec(fmt.Sprintf("ALTER TABLE %v ADD COLUMN %v", table, def)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// requireColumns returns an error if a table doesn't exist or misses any of columns
+func requireColumns(db dbExecer, table string, names ...string) error {
+	columns, err := getTableColumns(db, table)
+	if err != nil {
+		return err
+	}
+	if len(columns) == 0 {
+		return fmt.Errorf("table %v not found", table)
+	}
+	for _, name := range names {
+		if !columns[name] {
+			return fmt.Errorf("column %v not found in table %v", name, table)
+		}
+	}
+	return nil
+}
+
+// migrateToV2 adds columns of applications, drivers and concerns, which are empty for logs analyzed
+// before, and creates tables of events introduced since the original schema
+func migrateToV2(tx *sql.Tx, hatchetName string) error {
+	if err := requireColumns(tx, hatchetName, "id", "date", "severity", "component", "context", "msg", "plan",
+		"type", "ns", "message", "op", "filter", "_index", "milli", "reslen"); err != nil {
+		return err
+	}
+	if err := requireColumns(tx, hatchetName+"_ops", "op", "count", "avg_ms", "max_ms", "total_ms", "ns",
+		"_index", "reslen", "filter"); err != nil {
+		return err
+	}
+	if err := addMissingColumns(tx, hatchetName, "app text default ''", "driver text default ''",
+		"read_concern text default ''", "write_concern text default ''", "read_pref text default ''"); err != nil {
+		return err
+	}
+	if err := addMissingColumns(tx, hatchetName+"_ops", "app default ''"); err != nil {
+		return err
+	}
+	if err := addMissingColumns(tx, hatchetName+"_drivers", "app text default ''"); err != nil {
+		return err
+	}
+	stmts := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %[1]v_txns (
+			id integer not null primary key, lsid text, txn_number integer, termination text, abort_cause text,
+			milli integer, time_active integer, time_inactive integer, num_yields integer,
+			keys_examined integer, docs_examined integer, nreturned integer,
+			ninserted integer, nmodified integer, ndeleted integer, read_concern text, was_prepared integer);
+		CREATE INDEX IF NOT EXISTS %[1]v_txns_idx_lsid ON %[1]v_txns (lsid,txn_number);
+
+		CREATE TABLE IF NOT EXISTS %[1]v_repl (
+			id integer not null primary key, type text, node text, name text, detail text, milli integer);
+		CREATE INDEX IF NOT EXISTS %[1]v_repl_idx_type ON %[1]v_repl (type);
+
+		CREATE TABLE IF NOT EXISTS %[1]v_sharding (
+			id integer not null primary key, type text, name text, ns text, from_shard text, to_shard text,
+			milli integer, result text, metrics text, detail text);
+		CREATE INDEX IF NOT EXISTS %[1]v_sharding_idx_type ON %[1]v_sharding (type,ns);
+
+		CREATE TABLE IF NOT EXISTS %[1]v_conns (
+			id integer not null primary key, conn_id integer, ip text, port text, app text,
+			accepted text, ended text, milli integer, status text);
+		CREATE INDEX IF NOT EXISTS %[1]v_conns_idx_ip ON %[1]v_conns (ip,app);
+
+		CREATE TABLE IF NOT EXISTS %[1]v_auth (
+			id integer not null primary key, result text, principal text, db text, mechanism text,
+			ip text, err_code integer, err_name text);
+		CREATE INDEX IF NOT EXISTS %[1]v_auth_idx_result ON %[1]v_auth (result,ip);
+
+		CREATE TABLE IF NOT EXISTS %[1]v_index_builds (
+			id integer not null primary key, build_uuid text, ns text, indexes text, keys text, method text,
+			commit_quorum text, start text, end text, milli integer, status text, phases text, error text);
+		CREATE INDEX IF NOT EXISTS %[1]v_index_builds_idx_ns ON %[1]v_index_builds (ns,start);
+
+		CREATE TABLE IF NOT EXISTS %[1]v_storage (
+			id integer not null primary key, type text, category text, milli integer, detail text);
+		CREATE INDEX IF NOT EXISTS %[1]v_storage_idx_type ON %[1]v_storage (type);
+
+		CREATE TABLE IF NOT EXISTS %[1]v_config (
+			host text, port integer, repl_set_name text, storage_engine text, cache_size_gb real,
+			slowms integer, profile text, options text, warnings text);
+
+		CREATE TABLE IF NOT EXISTS %[1]v_uptime (
+			id integer not null primary key, pid integer, host text, port integer, start text, end text,
+			end_id integer, status text, crash_id integer, reason text);
+
+		CREATE TABLE IF NOT EXISTS %[1]v_errors (
+			id integer not null primary key, code integer, name text, ns text, op text, message text);
+		CREATE INDEX IF NOT EXISTS %[1]v_errors_idx_code ON %[1]v_errors (code,name);
+
+		CREATE TABLE IF NOT EXISTS %[1]v_cursors (
+			id integer not null primary key, cursor_id integer, ns text, op text, filter text,
+			batches integer, nreturned integer, milli integer, start text, end text, lifetime integer, status text);
+		CREATE INDEX IF NOT EXISTS %[1]v_cursors_idx_status ON %[1]v_cursors (status);
+
+		CREATE TABLE IF NOT EXISTS %[1]v_findings (
+			id integer, rule text, severity text, ns text, op text, filter text, detail text);
+		CREATE INDEX IF NOT EXISTS %[1]v_findings_idx_rule ON %[1]v_findings (rule);`, hatchetName)
+	_, err := tx.Exec(stmts)
+	return err
+}
+
+// migrateToV3 adds the column of raw attributes, which is NULL for logs analyzed before
+func migrateToV3(tx *sql.Tx, hatchetName string) error {
+	return addMissingColumns(tx, hatchetName, "attr text")
+}
+
+// migrateToV4 requires columns of which per-minute rollup tables are created, on first use of charts
+// instead of while the database file is opened
+func migrateToV4(tx *sql.Tx, hatchetName string) error {
+	return requireColumns(tx, hatchetName+"_clients", "id", "ip", "context", "accepted", "ended")
+}
@@ -0,0 +1,90 @@
+// Copyright 2022-present Kuei-chun Chen. All rights reserved.
+
+package hatchet
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestMigrateSQLite3DB(t *testing.T) {
+	dbfile := filepath.Join(t.TempDir(), "hatchet.db")
+	db, err := sql.Open("sqlite3", dbfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	// the original schema, of which mongod_v1 is analyzed and mongod_gone misses tables
+	stmts := `
+		CREATE TABLE hatchet ( name text not null primary key,
+			version text, module text, arch text, os text, start text, end text);
+		INSERT INTO hatchet (name) VALUES ('mongod_v1'), ('mongod_gone');
+		CREATE TABLE mongod_v1 (
+			id integer not null primary key, date text, severity text, component text, context text,
+			msg text, plan text, type text, ns text, message text,
+			op text, filter text, _index text, milli integer, reslen integer);
+		INSERT INTO mongod_v1 (id, op, ns) VALUES (1, 'find', 'test.users');
+		CREATE TABLE mongod_v1_ops ( op, count, avg_ms, max_ms, total_ms, ns, _index, reslen, filter);
+		CREATE TABLE mongod_v1_audit ( type, name, value);
+		CREATE TABLE mongod_v1_drivers (
+			id integer not null primary key, ip text, driver text, version text);
+		CREATE TABLE mongod_v1_clients(
+			id integer not null primary key, ip text, port text, conns integer, accepted integer, ended integer, context string);`
+	if _, err = db.Exec(stmts); err != nil {
+		t.Fatal(err)
+	}
+	if err = MigrateSQLite3DB(db, dbfile); err != nil {
+		t.Fatal(err)
+	}
+
+	sqlite := &SQLite3DB{db: newSQLite3Pool(db), hatchetName: "mongod_v1"}
+	statuses, err := sqlite.GetHatchetStatuses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statuses["mongod_v1"] != "" {
+		t.Fatal("expected", "", "but got", statuses["mongod_v1"])
+	}
+	if statuses["mongod_gone"] != REANALYZE_REQUIRED {
+		t.Fatal("expected", REANALYZE_REQUIRED, "but got", statuses["mongod_gone"])
+	}
+	var version int
+	var app string
+	if err = db.QueryRow("SELECT schema_version FROM hatchet WHERE name = 'mongod_v1'").Scan(&version); err != nil {
+		t.Fatal(err)
+	}
+	if version != SCHEMA_VERSION {
+		t.Fatal("expected", SCHEMA_VERSION, "but got", version)
+	}
+	if err = db.QueryRow("SELECT app FROM mongod_v1 WHERE id = 1").Scan(&app); err != nil || app != "" {
+		t.Fatal("expected", "", "but got", app, err)
+	}
+	var attr sql.NullString
+	if err = db.QueryRow("SELECT attr FROM mongod_v1 WHERE id = 1").Scan(&attr); err != nil || attr.Valid {
+		t.Fatal("expected", nil, "but got", attr, err)
+	}
+	var analyzed string
+	var pinned bool
+	if err = db.QueryRow("SELECT analyzed, pinned FROM hatchet WHERE name = 'mongod_v1'").Scan(&analyzed, &pinned); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = time.Parse(time.RFC3339, analyzed); err != nil || pinned {
+		t.Fatal("expected", "analyzed now and unpinned", "but got", analyzed, pinned, err)
+	}
+	if _, err = sqlite.GetLongestTransactions(TOP_N); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetSchemaStatus(t *testing.T) {
+	if status := GetSchemaStatus(SCHEMA_VERSION, ""); status != "" {
+		t.Fatal("expected", "", "but got", status)
+	}
+	if status := GetSchemaStatus(SCHEMA_VERSION+1, ""); status != REANALYZE_REQUIRED {
+		t.Fatal("expected", REANALYZE_REQUIRED, "but got", status)
+	}
+}
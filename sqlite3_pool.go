@@ -0,0 +1,215 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * sqlite3_pool.go
+ */
+
+package hatchet
+
+import (
+	"container/list"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+const (
+	SQLITE3_BATCH_SIZE      = 1000  // logs inserted in a transaction, other writers take turns between batches
+	SQLITE3_BUSY_TIMEOUT    = 10000 // milliseconds to wait for a lock before returning database is locked
+	SQLITE3_MAX_IDLE_CONNS  = 8     // idle connections kept open by a pool
+	SQLITE3_STMT_CACHE_SIZE = 256   // prepared statements cached by a pool, the least recently used is evicted beyond
+)
+
+var sqlite3BusyTimeout = SQLITE3_BUSY_TIMEOUT // shortened by tests
+var sqlite3Pools = map[string]*SQLite3Pool{}
+var sqlite3Mutex sync.Mutex
+
+// SQLite3Pool is a long-lived handle of a database file, shared by all hatchets and requests, of which
+// prepared statements of queries are cached and writes are serialized
+type SQLite3Pool struct {
+	*sql.DB
+	mutex  sync.Mutex
+	writer sync.Mutex // held by a write, others of the process wait for it instead of the busy timeout
+	lru    *list.List // of *cachedStmt, most recently used first
+	stmts  map[string]*list.Element
+	fts5   bool // messages can be indexed for full-text search, i.e. built with the sqlite_fts5 tag
+
+	rollups map[string]bool // hatchets of which rollup tables are being or have been built in the background
+}
+
+type cachedStmt struct {
+	query string
+	stmt  *sql.Stmt
+}
+
+// GetSQLite3Pool returns the pool of a database file, opened in WAL mode and migrated on first use
+func GetSQLite3Pool(dbfile string) (*SQLite3Pool, error) {
+	sqlite3Mutex.Lock()
+	defer sqlite3Mutex.Unlock()
+	if pool := sqlite3Pools[dbfile]; pool != nil {
+		return pool, nil
+	}
+	db, err := sql.Open("sqlite3_extended", GetSQLite3DSN(dbfile))
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxIdleConns(SQLITE3_MAX_IDLE_CONNS)
+	if err = MigrateSQLite3DB(db, dbfile); err != nil {
+		db.Close()
+		return nil, err
+	}
+	pool := newSQLite3Pool(db)
+	if !pool.fts5 {
+		log.Println("FTS5 not available, messages are searched by substrings, build with -tags sqlite_fts5 to index them")
+	}
+	sqlite3Pools[dbfile] = pool
+	return pool, err
+}
+
+// CloseSQLite3Pools closes all pools, e.g. before a process exits
+func CloseSQLite3Pools() error {
+	sqlite3Mutex.Lock()
+	defer sqlite3Mutex.Unlock()
+	var err error
+	for dbfile, pool := range sqlite3Pools {
+		if cerr := pool.Close(); cerr != nil {
+			err = cerr
+		}
+		delete(sqlite3Pools, dbfile)
+	}
+	return err
+}
+
+// CloseSQLite3PoolsOnSignal closes all pools and exits when a process is interrupted or terminated
+func CloseSQLite3PoolsOnSignal() {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-ch
+		log.Println("closing data files on", sig)
+		CloseSQLite3Pools()
+		os.Exit(1)
+	}()
+}
+
+// GetSQLite3DSN returns the data source name of a database file with the journal mode and the busy timeout,
+// in-memory databases have no write-ahead log
+func GetSQLite3DSN(dbfile string) string {
+	params := fmt.Sprintf("_busy_timeout=%d", sqlite3BusyTimeout)
+	if !strings.Contains(dbfile, ":memory:") && !strings.Contains(dbfile, "mode=memory") {
+		params += "&_journal_mode=WAL"
+	}
+	if strings.Contains(dbfile, "?") {
+		return dbfile + "&" + params
+	}
+	return dbfile + "?" + params
+}
+
+func newSQLite3Pool(db *sql.DB) *SQLite3Pool {
+	return &SQLite3Pool{DB: db, lru: list.New(), stmts: map[string]*list.Element{}, fts5: hasFTS5(db),
+		rollups: map[string]bool{}}
+}
+
+// hasFTS5 returns true if SQLite3 is compiled with the FTS5 extension
+func hasFTS5(db *sql.DB) bool {
+	var used bool
+	err := db.QueryRow("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&used)
+	return err == nil && used
+}
+
+// Exec executes a statement holding the writer lock
+func (ptr *SQLite3Pool) Exec(query string, args ...interface{}) (sql.Result, error) {
+	ptr.writer.Lock()
+	defer ptr.writer.Unlock()
+	return ptr.DB.Exec(query, args...)
+}
+
+// Write executes a function in a transaction holding the writer lock, committed unless the function
+// returns an error
+func (ptr *SQLite3Pool) Write(fn func(tx *sql.Tx) error) error {
+	ptr.writer.Lock()
+	defer ptr.writer.Unlock()
+	tx, err := ptr.DB.Begin()
+	if err != nil {
+		return err
+	}
+	if err = fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// IsBusy returns true if an error is caused by a lock held longer than the busy timeout, e.g. by another
+// process writing to the data file
+func IsBusy(err error) bool {
+	var e sqlite3.Error
+	return errors.As(err, &e) && (e.Code == sqlite3.ErrBusy || e.Code == sqlite3.ErrLocked)
+}
+
+// Query executes a query of a cached prepared statement, rows are read without holding the lock
+func (ptr *SQLite3Pool) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	ptr.mutex.Lock()
+	defer ptr.mutex.Unlock()
+	stmt, err := ptr.prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.Query(args...)
+}
+
+// QueryRow executes a query of a cached prepared statement that returns at most one row
+func (ptr *SQLite3Pool) QueryRow(query string, args ...interface{}) *sql.Row {
+	ptr.mutex.Lock()
+	defer ptr.mutex.Unlock()
+	stmt, err := ptr.prepare(query)
+	if err != nil { // the error is returned by Scan
+		return ptr.DB.QueryRow(query, args...)
+	}
+	return stmt.QueryRow(args...)
+}
+
+// ClearStmts closes cached statements, e.g. after tables are dropped or renamed
+func (ptr *SQLite3Pool) ClearStmts() {
+	ptr.mutex.Lock()
+	defer ptr.mutex.Unlock()
+	for elem := ptr.lru.Front(); elem != nil; elem = elem.Next() {
+		elem.Value.(*cachedStmt).stmt.Close()
+	}
+	ptr.lru.Init()
+	ptr.stmts = map[string]*list.Element{}
+}
+
+// Close closes cached statements and the database
+func (ptr *SQLite3Pool) Close() error {
+	ptr.ClearStmts()
+	return ptr.DB.Close()
+}
+
+// prepare returns a cached statement of a query and evicts the least recently used if the cache is full,
+// the caller holds the lock.  Rows of an evicted statement remain readable until closed
+func (ptr *SQLite3Pool) prepare(query string) (*sql.Stmt, error) {
+	if elem := ptr.stmts[query]; elem != nil {
+		ptr.lru.MoveToFront(elem)
+		return elem.Value.(*cachedStmt).stmt, nil
+	}
+	stmt, err := ptr.DB.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	for ptr.lru.Len() >= SQLITE3_STMT_CACHE_SIZE {
+		oldest := ptr.lru.Back()
+		cached := ptr.lru.Remove(oldest).(*cachedStmt)
+		delete(ptr.stmts, cached.query)
+		cached.stmt.Close()
+	}
+	ptr.stmts[query] = ptr.lru.PushFront(&cachedStmt{query: query, stmt: stmt})
+	return stmt, err
+}
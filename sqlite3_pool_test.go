@@ -0,0 +1,197 @@
+// Copyright 2022-present Kuei-chun Chen. All rights reserved.
+
+package hatchet
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGetSQLite3DSN(t *testing.T) {
+	expected := "./data/hatchet.db?_busy_timeout=10000&_journal_mode=WAL"
+	if dsn := GetSQLite3DSN("./data/hatchet.db"); dsn != expected {
+		t.Fatal("expected", expected, "but got", dsn)
+	}
+	expected = "file::memory:?cache=shared&_busy_timeout=10000"
+	if dsn := GetSQLite3DSN("file::memory:?cache=shared"); dsn != expected {
+		t.Fatal("expected", expected, "but got", dsn)
+	}
+}
+
+func TestGetSQLite3Pool(t *testing.T) {
+	registerSQLite3Extended()
+	dbfile := filepath.Join(t.TempDir(), "hatchet.db")
+	pool, err := GetSQLite3Pool(dbfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other, _ := GetSQLite3Pool(dbfile); other != pool {
+		t.Fatal("expected", pool, "but got", other)
+	}
+	var mode string
+	var timeout int
+	if err = pool.QueryRow("PRAGMA journal_mode").Scan(&mode); err != nil {
+		t.Fatal(err)
+	}
+	if mode != "wal" {
+		t.Fatal("expected", "wal", "but got", mode)
+	}
+	if err = pool.QueryRow("PRAGMA busy_timeout").Scan(&timeout); err != nil {
+		t.Fatal(err)
+	}
+	if timeout != SQLITE3_BUSY_TIMEOUT {
+		t.Fatal("expected", SQLITE3_BUSY_TIMEOUT, "but got", timeout)
+	}
+	if _, err = pool.Exec("CREATE TABLE logs (id integer not null primary key, msg text)"); err != nil {
+		t.Fatal(err)
+	}
+
+	// readers don't wait for the writer of another connection
+	tx, err := pool.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		if _, err = tx.Exec("INSERT INTO logs (msg) VALUES (?)", "Slow query"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var wg sync.WaitGroup
+	errs := make([]error, 8)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var count int
+			errs[i] = pool.QueryRow("SELECT COUNT(*) FROM logs WHERE msg = ?", "Slow query").Scan(&count)
+		}(i)
+	}
+	wg.Wait()
+	if err = tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	for _, err = range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if pool.lru.Len() != 3 {
+		t.Fatal("expected", 3, "but got", pool.lru.Len())
+	}
+	pool.ClearStmts()
+	if pool.lru.Len() != 0 {
+		t.Fatal("expected", 0, "but got", pool.lru.Len())
+	}
+	if err = CloseSQLite3Pools(); err != nil {
+		t.Fatal(err)
+	}
+	if other, _ := GetSQLite3Pool(dbfile); other == pool {
+		t.Fatal("expected a new pool but got", other)
+	}
+}
+
+func TestSQLite3PoolEviction(t *testing.T) {
+	registerSQLite3Extended()
+	pool, err := GetSQLite3Pool(filepath.Join(t.TempDir(), "hatchet.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer CloseSQLite3Pools()
+	first := "SELECT 0"
+	rows, err := pool.Query(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= SQLITE3_STMT_CACHE_SIZE; i++ {
+		var n int
+		if err = pool.QueryRow(fmt.Sprintf("SELECT %d", i)).Scan(&n); err != nil || n != i {
+			t.Fatal("expected", i, "but got", n, err)
+		}
+	}
+	if pool.lru.Len() != SQLITE3_STMT_CACHE_SIZE {
+		t.Fatal("expected", SQLITE3_STMT_CACHE_SIZE, "but got", pool.lru.Len())
+	}
+	if pool.stmts[first] != nil {
+		t.Fatal("expected", first, "evicted")
+	}
+	var n int
+	if !rows.Next() || rows.Scan(&n) != nil || n != 0 { // rows of an evicted statement are still readable
+		t.Fatal("expected", 0, "but got", n, rows.Err())
+	}
+	rows.Close()
+	// the recently used statement is kept while the least recently used is evicted
+	if err = pool.QueryRow("SELECT 1").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if err = pool.QueryRow("SELECT 0").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if pool.stmts["SELECT 1"] == nil || pool.stmts["SELECT 2"] != nil {
+		t.Fatal("expected", "SELECT 2 evicted", "but got", pool.stmts)
+	}
+}
+
+func TestSQLite3PoolWrites(t *testing.T) {
+	registerSQLite3Extended()
+	defer func(timeout int) { sqlite3BusyTimeout = timeout }(sqlite3BusyTimeout)
+	sqlite3BusyTimeout = 100 // milliseconds
+	dbfile := filepath.Join(t.TempDir(), "hatchet.db")
+	pool, err := GetSQLite3Pool(dbfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer CloseSQLite3Pools()
+	if _, err = pool.Exec("CREATE TABLE logs (id integer not null primary key, msg text)"); err != nil {
+		t.Fatal(err)
+	}
+
+	// writes of the process wait for the writer longer than the busy timeout
+	locked := make(chan struct{})
+	go pool.Write(func(tx *sql.Tx) error {
+		close(locked)
+		_, err := tx.Exec("INSERT INTO logs (msg) VALUES (?)", "first")
+		time.Sleep(3 * time.Duration(sqlite3BusyTimeout) * time.Millisecond)
+		return err
+	})
+	<-locked
+	if _, err = pool.Exec("INSERT INTO logs (msg) VALUES (?)", "second"); err != nil {
+		t.Fatal(err)
+	}
+	var msg string
+	if err = pool.QueryRow("SELECT msg FROM logs WHERE id = 1").Scan(&msg); err != nil || msg != "first" {
+		t.Fatal("expected", "first", "but got", msg, err)
+	}
+
+	// writes of another process fail after the busy timeout, and requests respond to retry
+	other, err := sql.Open("sqlite3", GetSQLite3DSN(dbfile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	tx, err := other.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	if _, err = tx.Exec("INSERT INTO logs (msg) VALUES (?)", "other"); err != nil {
+		t.Fatal(err)
+	}
+	_, err = pool.Exec("INSERT INTO logs (msg) VALUES (?)", "third")
+	if !IsBusy(err) {
+		t.Fatal("expected database is locked but got", err)
+	}
+	w := httptest.NewRecorder()
+	writeError(w, err)
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") == "" {
+		t.Fatal("expected", http.StatusServiceUnavailable, "but got", w.Code, w.Body.String())
+	}
+	if IsBusy(NewInvalidInputError("invalid hatchet name")) {
+		t.Fatal("expected", false, "but got", true)
+	}
+}
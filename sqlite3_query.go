@@ -6,12 +6,16 @@
 package hatchet
 
 import (
+	"database/sql"
+	"encoding/json"
 	"fmt"
 	"log"
 	"regexp"
 	"strings"
 )
 
+const APP_ALL = "all" // ops stats by applications
+
 type OpCount struct {
 	Date      string
 	Count     int
@@ -21,21 +25,34 @@ type OpCount struct {
 	Filter    string
 }
 
-func (ptr *SQLite3DB) GetSlowOps(orderBy string, order string, collscan bool) ([]OpStat, error) {
+// GetSlowOps returns ops stats of all applications combined, of each application if app is "all",
+// or of an application
+func (ptr *SQLite3DB) GetSlowOps(orderBy string, order string, collscan bool, app string) ([]OpStat, error) {
 	ops := []OpStat{}
 	db := ptr.db
-	query := fmt.Sprintf(`SELECT op, count, avg_ms, max_ms,
-			total_ms, ns, _index "index", reslen, filter "query pattern"
-			FROM %v_ops ORDER BY %v %v`, ptr.hatchetName, orderBy, order)
+	orderClause, err := GetOrderByClause(orderBy, order, SLOWOPS_SORT_KEYS)
+	if err != nil {
+		return ops, err
+	}
+	builder := NewQueryBuilder()
 	if collscan {
+		builder.Where("_index = ?", COLLSCAN)
+	}
+	if app != "" && app != APP_ALL {
+		builder.Where("app = ?", app)
+	}
+	query := fmt.Sprintf(`SELECT op, SUM(count) count, ROUND(SUM(total_ms)*1.0/SUM(count),1) avg_ms, MAX(max_ms) max_ms,
+			SUM(total_ms) total_ms, ns, _index "index", SUM(reslen) reslen, filter "query pattern", '' app
+			FROM %v_ops %v GROUP BY op, ns, _index, filter %v`, ptr.hatchetName, builder.Clause(), orderClause)
+	if app != "" {
 		query = fmt.Sprintf(`SELECT op, count, avg_ms, max_ms,
-				total_ms, ns, _index "index", reslen, filter "query pattern"
-				FROM %v_ops WHERE _index = "COLLSCAN" ORDER BY %v %v`, ptr.hatchetName, orderBy, order)
+				total_ms, ns, _index "index", reslen, filter "query pattern", app
+				FROM %v_ops %v %v`, ptr.hatchetName, builder.Clause(), orderClause)
 	}
 	if ptr.verbose {
-		log.Println(query)
+		log.Println(query, builder.Args())
 	}
-	rows, err := db.Query(query)
+	rows, err := db.Query(query, builder.Args()...)
 	if err != nil {
 		return ops, err
 	}
@@ -43,143 +60,253 @@ func (ptr *SQLite3DB) GetSlowOps(orderBy string, order string, collscan bool) ([
 	for rows.Next() {
 		var op OpStat
 		if err = rows.Scan(&op.Op, &op.Count, &op.AvgMilli, &op.MaxMilli, &op.TotalMilli,
-			&op.Namespace, &op.Index, &op.Reslen, &op.QueryPattern); err != nil {
+			&op.Namespace, &op.Index, &op.Reslen, &op.QueryPattern, &op.AppName); err != nil {
 			return ops, err
 		}
 		ops = append(ops, op)
 	}
+	rows.Close()
+	rules, err := ptr.getFindingRules()
+	if err != nil {
+		return ops, err
+	}
+	for i, op := range ops {
+		ops[i].Findings = rules[getFindingKey(op.Op, op.Namespace, op.QueryPattern)]
+	}
 	return ops, err
 }
 
+// GetAppCounts returns counts of slow ops by application names
+func (ptr *SQLite3DB) GetAppCounts(duration string) ([]NameValue, error) {
+	docs := []NameValue{}
+	builder := NewQueryBuilder().Where("op != ''").Where("app != ''")
+	if err := builder.WhereDuration("date", duration); err != nil {
+		return docs, err
+	}
+	query := fmt.Sprintf(`SELECT app, COUNT(op) counts
+		FROM %v %v GROUP by app ORDER BY counts DESC;`, ptr.hatchetName, builder.Clause())
+	if ptr.verbose {
+		log.Println(query, builder.Args())
+	}
+	rows, err := ptr.db.Query(query, builder.Args()...)
+	if err != nil {
+		return docs, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var doc NameValue
+		if err = rows.Scan(&doc.Name, &doc.Value); err != nil {
+			return docs, err
+		}
+		docs = append(docs, doc)
+	}
+	return docs, err
+}
+
 func (ptr *SQLite3DB) GetLogs(opts ...string) ([]LegacyLog, error) {
 	docs := []LegacyLog{}
-	qheader := fmt.Sprintf(`SELECT date, severity, component, context, message FROM %v`, ptr.hatchetName)
-	wheres := []string{}
+	builder, offset, qlimit, err := ptr.getLogsQueryBuilder(false, opts...)
+	if err != nil {
+		return docs, err
+	}
 	search := ""
-	qlimit := LIMIT + 1
-	var offset, nlimit int
+	for _, opt := range opts {
+		if toks := strings.SplitN(opt, "=", 2); len(toks) == 2 && toks[0] == "context" {
+			search = toks[1]
+		}
+	}
+	if docs, err = ptr.queryLogs(builder, offset, qlimit); err != nil {
+		return docs, err
+	}
+	if len(docs) == 0 && search != "" { // no context found, perform message search
+		return ptr.SearchLogs(opts...)
+	}
+	return docs, err
+}
 
-	if len(opts) > 0 {
-		for _, opt := range opts {
-			toks := strings.Split(opt, "=")
-			if len(toks) < 2 || toks[1] == "" {
-				continue
-			}
-			if toks[0] == "duration" {
-				dates := strings.Split(toks[1], ",")
-				wheres = append(wheres, fmt.Sprintf(" date BETWEEN '%v' and '%v'", dates[0], dates[1]))
-			} else if toks[0] == "limit" {
-				offset, nlimit = GetOffsetLimit(toks[1])
-				qlimit = ToInt(nlimit) + 1
-			} else if toks[0] == "severity" {
-				severities := []string{}
-				for _, v := range SEVERITIES {
-					severities = append(severities, fmt.Sprintf("'%v'", v))
-					if v == toks[1] {
-						break
-					}
-				}
-				wheres = append(wheres, " severity IN ("+strings.Join(severities, ",")+")")
-			} else {
-				wheres = append(wheres, fmt.Sprintf(` %v = "%v"`, toks[0], EscapeString(toks[1])))
-				if toks[0] == "context" {
-					search = toks[1]
-				}
-			}
+// SearchLogs returns logs of which messages match a full-text search in context, ranked by relevance if
+// messages are indexed
+func (ptr *SQLite3DB) SearchLogs(opts ...string) ([]LegacyLog, error) {
+	search := ""
+	others := []string{}
+	for _, opt := range opts {
+		if toks := strings.SplitN(opt, "=", 2); len(toks) == 2 && toks[0] == "context" {
+			search = toks[1]
+		} else {
+			others = append(others, opt)
 		}
 	}
-	wclause := ""
-	if len(wheres) > 0 {
-		wclause = " WHERE " + strings.Join(wheres, " AND")
+	indexed, err := ptr.HasFullTextIndex()
+	if err != nil {
+		return []LegacyLog{}, err
 	}
-	query := qheader + wclause + fmt.Sprintf(" LIMIT %v,%v", offset, qlimit)
-	db := ptr.db
+	if search == "" || !indexed {
+		builder, offset, qlimit, err := ptr.getLogsQueryBuilder(true, opts...)
+		if err != nil {
+			return []LegacyLog{}, err
+		}
+		return ptr.queryLogs(builder, offset, qlimit)
+	}
+	query, err := ParseFullTextQuery(search)
+	if err != nil {
+		return []LegacyLog{}, err
+	}
+	builder, offset, qlimit, err := ptr.getLogsQueryBuilder(false, others...)
+	if err != nil {
+		return []LegacyLog{}, err
+	}
+	stmt := fmt.Sprintf(`SELECT date, severity, component, context, message, attr FROM %v
+			JOIN (SELECT rowid fts_id, rank FROM %v_fts WHERE %v_fts MATCH ?) ON id = fts_id %v
+			ORDER BY rank, id LIMIT ?,?`, ptr.hatchetName, ptr.hatchetName, ptr.hatchetName, builder.Clause())
+	args := append([]interface{}{query.FTS5()}, builder.Args()...)
+	return ptr.fetchLogs(stmt, append(args, offset, qlimit)...)
+}
+
+// queryLogs returns logs of conditions from an offset
+func (ptr *SQLite3DB) queryLogs(builder *QueryBuilder, offset int, qlimit int) ([]LegacyLog, error) {
+	query := fmt.Sprintf(`SELECT date, severity, component, context, message, attr FROM %v %v LIMIT ?,?`,
+		ptr.hatchetName, builder.Clause())
+	return ptr.fetchLogs(query, append(builder.Args(), offset, qlimit)...)
+}
+
+// fetchLogs returns logs of a query selecting date, severity, component, context, message, and attr
+func (ptr *SQLite3DB) fetchLogs(query string, args ...interface{}) ([]LegacyLog, error) {
+	docs := []LegacyLog{}
 	if ptr.verbose {
-		log.Println(query)
+		log.Println(query, args)
 	}
-	rows, err := db.Query(query)
+	rows, err := ptr.db.Query(query, args...)
 	if err != nil {
 		return docs, err
 	}
 	defer rows.Close()
 	for rows.Next() {
 		var doc LegacyLog
-		if err = rows.Scan(&doc.Timestamp, &doc.Severity, &doc.Component, &doc.Context, &doc.Message); err != nil {
+		var attr sql.NullString
+		if err = rows.Scan(&doc.Timestamp, &doc.Severity, &doc.Component, &doc.Context, &doc.Message, &attr); err != nil {
 			return docs, err
 		}
+		if attr.Valid {
+			doc.Attr = json.RawMessage(attr.String)
+		}
 		docs = append(docs, doc)
 	}
-	if len(docs) == 0 && search != "" { // no context found, perform message search
-		return ptr.SearchLogs(opts...)
-	}
 	return docs, err
 }
 
-func (ptr *SQLite3DB) SearchLogs(opts ...string) ([]LegacyLog, error) {
-	qheader := fmt.Sprintf(`SELECT date, severity, component, context, message FROM %v`, ptr.hatchetName)
-	docs := []LegacyLog{}
-	wheres := []string{}
+// whereDuration adds a condition of a date column within a duration to a builder and returns start and end
+// dates of the duration, or of the hatchet if the duration is empty
+func (ptr *SQLite3DB) whereDuration(builder *QueryBuilder, column string, duration string) (string, string, error) {
+	if duration == "" {
+		info := ptr.GetHatchetInfo()
+		return info.Start, info.End, nil
+	}
+	toks, err := ParseDuration(duration)
+	if err != nil {
+		return "", "", err
+	}
+	return toks[0], toks[1], builder.WhereDuration(column, duration)
+}
+
+// HasFullTextIndex returns true if messages of the hatchet are indexed for full-text search and the index
+// is readable, i.e. built with the sqlite_fts5 tag
+func (ptr *SQLite3DB) HasFullTextIndex() (bool, error) {
+	if !ptr.db.fts5 {
+		return false, nil
+	}
+	return ptr.hasTable("_fts")
+}
+
+// hasTable returns true if a table of the hatchet exists, i.e. {hatchet}{suffix}
+func (ptr *SQLite3DB) hasTable(suffix string) (bool, error) {
+	var count int
+	err := ptr.db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?",
+		ptr.hatchetName+suffix).Scan(&count)
+	return count > 0, err
+}
+
+// getLogsQueryBuilder returns conditions, offset, and limit of logs from options, i.e. {name}={value} or
+// attr={filter} of ParseAttrFilter.  Context is a full-text search of messages, matched by substrings, if
+// search is true
+func (ptr *SQLite3DB) getLogsQueryBuilder(search bool, opts ...string) (*QueryBuilder, int, int, error) {
+	builder := NewQueryBuilder()
 	qlimit := LIMIT + 1
 	var offset, nlimit int
 	for _, opt := range opts {
-		toks := strings.Split(opt, "=")
+		toks := strings.SplitN(opt, "=", 2)
 		if len(toks) < 2 || toks[1] == "" {
 			continue
 		}
 		if toks[0] == "duration" {
-			dates := strings.Split(toks[1], ",")
-			wheres = append(wheres, fmt.Sprintf(" date BETWEEN '%v' and '%v'", dates[0], dates[1]))
+			if err := builder.WhereDuration("date", toks[1]); err != nil {
+				return builder, offset, qlimit, err
+			}
 		} else if toks[0] == "limit" {
 			offset, nlimit = GetOffsetLimit(toks[1])
-			qlimit = ToInt(nlimit) + 1
+			qlimit = nlimit + 1
 		} else if toks[0] == "severity" {
-			sevs := []string{}
+			if _, ok := SEVERITY_M[toks[1]]; !ok {
+				return builder, offset, qlimit, NewInvalidInputError("invalid severity %v", toks[1])
+			}
+			severities := []string{}
 			for _, v := range SEVERITIES {
-				sevs = append(sevs, fmt.Sprintf("'%v'", v))
+				severities = append(severities, v)
 				if v == toks[1] {
 					break
 				}
 			}
-			wheres = append(wheres, " severity IN ("+strings.Join(sevs, ",")+")")
-		} else if toks[0] == "context" {
-			wheres = append(wheres, fmt.Sprintf(` LOWER(message) LIKE "%%%v%%"`, EscapeString(toks[1])))
-		} else {
-			wheres = append(wheres, fmt.Sprintf(` %v = "%v"`, toks[0], EscapeString(toks[1])))
-		}
-	}
-	wclause := ""
-	if len(wheres) > 0 {
-		wclause = " WHERE " + strings.Join(wheres, " AND")
-	}
-	query := qheader + wclause + fmt.Sprintf(" LIMIT %v,%v", offset, qlimit)
-	if ptr.verbose {
-		log.Println(query)
-	}
-	db := ptr.db
-	rows, err := db.Query(query)
-	if err != nil {
-		return docs, err
-	}
-	defer rows.Close()
-	for rows.Next() {
-		var doc LegacyLog
-		if err = rows.Scan(&doc.Timestamp, &doc.Severity, &doc.Component, &doc.Context, &doc.Message); err != nil {
-			return docs, err
+			builder.WhereIn("severity", severities)
+		} else if toks[0] == "txn" {
+			lsid, txnNumber, err := ParseTxnKey(toks[1])
+			if err != nil {
+				return builder, offset, qlimit, err
+			}
+			linked, err := ptr.hasTable("_txn_logs")
+			if err != nil {
+				return builder, offset, qlimit, err
+			} else if linked {
+				builder.Where(fmt.Sprintf("id IN (SELECT id FROM %v_txn_logs WHERE lsid = ? AND txn_number = ?)",
+					ptr.hatchetName), lsid, txnNumber)
+			} else { // analyzed before logs were linked to transactions
+				builder.Where("(message LIKE ? OR message LIKE ?)", fmt.Sprintf("%%%v%%txnNumber:%v,%%", lsid, txnNumber),
+					fmt.Sprintf("%%%v%%txnNumber:%v %%", lsid, txnNumber))
+			}
+		} else if toks[0] == "errcode" {
+			builder.Where(getErrorCodeCond(ptr.hatchetName), ToInt(toks[1]))
+		} else if toks[0] == "attr" {
+			filter, err := ParseAttrFilter(toks[1])
+			if err != nil {
+				return builder, offset, qlimit, err
+			}
+			builder.WhereAttr(filter)
+		} else if toks[0] == "context" && search {
+			query, err := ParseFullTextQuery(toks[1])
+			if err != nil {
+				return builder, offset, qlimit, err
+			}
+			cond, args := query.LikeCondition("message")
+			builder.Where(cond, args...)
+		} else if err := builder.WhereEqual(toks[0], toks[1], LOG_FILTERS); err != nil {
+			return builder, offset, qlimit, err
 		}
-		docs = append(docs, doc)
 	}
-	return docs, err
+	return builder, offset, qlimit, nil
+}
+
+// getErrorCodeCond returns a condition of logs with an error code, bound with the code
+func getErrorCodeCond(hatchetName string) string {
+	return fmt.Sprintf("id IN (SELECT id FROM %v_errors WHERE code = ?)", hatchetName)
 }
 
 func (ptr *SQLite3DB) GetSlowestLogs(topN int) ([]LegacyLog, error) {
 	docs := []LegacyLog{}
 	query := fmt.Sprintf(`SELECT date, severity, component, context, message
-			FROM %v WHERE op != "" ORDER BY milli DESC LIMIT %v`, ptr.hatchetName, topN)
+			FROM %v WHERE op != "" ORDER BY milli DESC LIMIT ?`, ptr.hatchetName)
 	db := ptr.db
 	if ptr.verbose {
-		log.Println(query)
+		log.Println(query, topN)
 	}
-	rows, err := db.Query(query)
+	rows, err := db.Query(query, topN)
 	if err != nil {
 		return docs, err
 	}
@@ -194,29 +321,40 @@ func (ptr *SQLite3DB) GetSlowestLogs(topN int) ([]LegacyLog, error) {
 	return docs, err
 }
 
-func (ptr *SQLite3DB) GetAverageOpTime(op string, duration string) ([]OpCount, error) {
+// GetAverageOpTime returns average ops time by date buckets, read from per-minute rollups unless
+// buckets of the duration are shorter than a minute
+func (ptr *SQLite3DB) GetAverageOpTime(op string, duration string, app string) ([]OpCount, error) {
 	docs := []OpCount{}
 	db := ptr.db
-	durcond := ""
-	var substr string
-	opcond := "op != ''"
+	substr, rollup, err := ptr.getChartBuckets(duration)
+	if err != nil {
+		return docs, err
+	}
+	builder := NewQueryBuilder()
 	if op != "" {
-		opcond = fmt.Sprintf("op = '%v'", op)
+		builder.Where("op = ?", op)
+	} else {
+		builder.Where("op != ''")
+	}
+	if app != "" {
+		builder.Where("app = ?", app)
 	}
-	if duration != "" {
-		toks := strings.Split(duration, ",")
-		durcond = fmt.Sprintf("AND date BETWEEN '%v' AND '%v'", toks[0], toks[1])
-		substr = GetDateSubString(toks[0], toks[1])
+	table, avg, count := ptr.hatchetName, "AVG(milli)", "COUNT(*)"
+	if rollup {
+		table, avg, count = ptr.hatchetName+"_ops_minute", "SUM(total_ms)*1.0/SUM(count)", "SUM(count)"
+		err = builder.WhereDurationMinutes("date", duration)
 	} else {
-		info := ptr.GetHatchetInfo()
-		substr = GetDateSubString(info.Start, info.End)
+		err = builder.WhereDuration("date", duration)
+	}
+	if err != nil {
+		return docs, err
 	}
-	query := fmt.Sprintf(`SELECT %v, AVG(milli), COUNT(*), op, ns, filter FROM %v 
-		WHERE %v %v GROUP by %v, op, ns, filter;`, substr, ptr.hatchetName, opcond, durcond, substr)
+	query := fmt.Sprintf(`SELECT %v, %v, %v, op, ns, filter FROM %v
+		%v GROUP by %v, op, ns, filter;`, substr, avg, count, table, builder.Clause(), substr)
 	if ptr.verbose {
-		log.Println(query)
+		log.Println(query, builder.Args())
 	}
-	rows, err := db.Query(query)
+	rows, err := db.Query(query, builder.Args()...)
 	if err != nil {
 		return docs, err
 	}
@@ -233,16 +371,15 @@ func (ptr *SQLite3DB) GetAverageOpTime(op string, duration string) ([]OpCount, e
 
 func (ptr *SQLite3DB) GetHatchetInfo() HatchetInfo {
 	var info HatchetInfo
-	query := fmt.Sprintf("SELECT name, version, module, os, arch, start, end FROM hatchet WHERE name = '%v'",
-		ptr.hatchetName)
+	query := "SELECT name, version, module, os, arch, start, end, analyzed, pinned FROM hatchet WHERE name = ?"
 	db := ptr.db
-	rows, err := db.Query(query)
+	rows, err := db.Query(query, ptr.hatchetName)
 	if err != nil {
 		return info
 	}
 	if rows.Next() {
 		if err = rows.Scan(&info.Name, &info.Version, &info.Module, &info.OS, &info.Arch,
-			&info.Start, &info.End); err != nil {
+			&info.Start, &info.End, &info.Analyzed, &info.Pinned); err != nil {
 			return info
 		}
 	}
@@ -283,12 +420,54 @@ func (ptr *SQLite3DB) GetHatchetInfo() HatchetInfo {
 	if rows != nil {
 		rows.Close()
 	}
+
+	info.Config = ServerConfig{Warnings: []string{}}
+	query = fmt.Sprintf(`SELECT host, port, repl_set_name, storage_engine, cache_size_gb, slowms, profile, options, warnings
+		FROM %v_config;`, ptr.hatchetName)
+	if ptr.verbose {
+		log.Println(query)
+	}
+	rows, err = db.Query(query)
+	if err == nil && rows.Next() {
+		config := &info.Config
+		var warnings string
+		if err = rows.Scan(&config.Host, &config.Port, &config.ReplSetName, &config.StorageEngine, &config.CacheSizeGB,
+			&config.SlowMS, &config.Profile, &config.Options, &warnings); err == nil {
+			json.Unmarshal([]byte(warnings), &config.Warnings)
+		}
+	}
+	if rows != nil {
+		rows.Close()
+	}
 	return info
 }
 
+// GetHatchetStatuses returns statuses of hatchets, i.e. REANALYZE_REQUIRED, empty if up to date
+func (ptr *SQLite3DB) GetHatchetStatuses() (map[string]string, error) {
+	statuses := map[string]string{}
+	query := "SELECT name, schema_version, status FROM hatchet"
+	if ptr.verbose {
+		log.Println(query)
+	}
+	rows, err := ptr.db.Query(query)
+	if err != nil {
+		return statuses, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name, status string
+		var version int
+		if err = rows.Scan(&name, &version, &status); err != nil {
+			return statuses, err
+		}
+		statuses[name] = GetSchemaStatus(version, status)
+	}
+	return statuses, err
+}
+
 func (ptr *SQLite3DB) GetHatchetNames() ([]string, error) {
 	hatchets := []string{}
-	query := "SELECT name, version, module, os, arch FROM hatchet ORDER BY name"
+	query := "SELECT name FROM hatchet ORDER BY name"
 	db := ptr.db
 	if ptr.verbose {
 		log.Println(query)
@@ -299,8 +478,8 @@ func (ptr *SQLite3DB) GetHatchetNames() ([]string, error) {
 	}
 	defer rows.Close()
 	for rows.Next() {
-		var name, version, module, os, arch string
-		if err = rows.Scan(&name, &version, &module, &os, &arch); err != nil {
+		var name string
+		if err = rows.Scan(&name); err != nil {
 			return hatchets, err
 		}
 		hatchets = append(hatchets, name)
@@ -312,19 +491,18 @@ func (ptr *SQLite3DB) GetHatchetNames() ([]string, error) {
 func (ptr *SQLite3DB) GetAcceptedConnsCounts(duration string) ([]NameValue, error) {
 	hatchetName := ptr.hatchetName
 	docs := []NameValue{}
-	var durcond string
-	if duration != "" {
-		toks := strings.Split(duration, ",")
-		durcond = fmt.Sprintf("AND date BETWEEN '%v' AND '%v'", toks[0], toks[1])
+	builder := NewQueryBuilder().Where("a.id = b.id").Where("b.accepted = 1")
+	if err := builder.WhereDuration("date", duration); err != nil {
+		return docs, err
 	}
 	query := fmt.Sprintf(`SELECT b.ip, SUM(b.accepted)
-		FROM %v a, %v_clients b WHERE a.id = b.id AND b.accepted = 1 %v GROUP by ip ORDER BY accepted DESC;`,
-		hatchetName, hatchetName, durcond)
+		FROM %v a, %v_clients b %v GROUP by ip ORDER BY accepted DESC;`,
+		hatchetName, hatchetName, builder.Clause())
 	db := ptr.db
 	if ptr.verbose {
-		log.Println(query)
+		log.Println(query, builder.Args())
 	}
-	rows, err := db.Query(query)
+	rows, err := db.Query(query, builder.Args()...)
 	if err != nil {
 		return docs, err
 	}
@@ -341,33 +519,47 @@ func (ptr *SQLite3DB) GetAcceptedConnsCounts(duration string) ([]NameValue, erro
 	return docs, err
 }
 
-// GetConnectionStats returns stats data of accepted and ended
+// GetConnectionStats returns stats data of accepted and ended, read from per-minute rollups unless
+// buckets of the duration are shorter than a minute
 func (ptr *SQLite3DB) GetConnectionStats(chartType string, duration string) ([]RemoteClient, error) {
 	hatchetName := ptr.hatchetName
 	docs := []RemoteClient{}
-	var query, durcond string
-	var substr string
-	if duration != "" {
-		toks := strings.Split(duration, ",")
-		durcond = fmt.Sprintf("AND date BETWEEN '%v' AND '%v'", toks[0], toks[1])
-		substr = GetDateSubString(toks[0], toks[1])
-	} else {
-		info := ptr.GetHatchetInfo()
-		substr = GetDateSubString(info.Start, info.End)
+	var query string
+	substr, rollup, err := ptr.getChartBuckets(duration)
+	if err != nil {
+		return docs, err
 	}
-	if chartType == "time" {
-		query = fmt.Sprintf(`SELECT %v dt, SUM(b.accepted), SUM(b.ended)
-			FROM %v a, %v_clients b WHERE a.id = b.id %v GROUP by dt ORDER BY dt;`,
-			substr, hatchetName, hatchetName, durcond)
+	builder := NewQueryBuilder()
+	if !rollup {
+		builder.Where("a.id = b.id")
+		if err = builder.WhereDuration("date", duration); err != nil {
+			return docs, err
+		}
+		if chartType == "time" {
+			query = fmt.Sprintf(`SELECT %v dt, SUM(b.accepted), SUM(b.ended)
+				FROM %v a, %v_clients b %v GROUP by dt ORDER BY dt;`,
+				substr, hatchetName, hatchetName, builder.Clause())
+		} else if chartType == "total" {
+			query = fmt.Sprintf(`SELECT b.ip, SUM(b.accepted) total_accepted, SUM(b.ended)
+				FROM %v a, %v_clients b %v GROUP by b.ip ORDER BY total_accepted DESC;`,
+				hatchetName, hatchetName, builder.Clause())
+		}
+	} else if err = builder.WhereDurationMinutes("date", duration); err != nil {
+		return docs, err
+	} else if chartType == "time" {
+		query = fmt.Sprintf(`SELECT %v dt, SUM(accepted), SUM(ended) FROM %v_clients_minute %v
+			GROUP by dt HAVING SUM(accepted) > 0 OR SUM(ended) > 0 ORDER BY dt;`,
+			substr, hatchetName, builder.Clause())
 	} else if chartType == "total" {
-		query = fmt.Sprintf(`SELECT b.ip, SUM(b.accepted), SUM(b.ended)
-			FROM %v a, %v_clients b WHERE a.id = b.id %v GROUP by ip ORDER BY accepted DESC;`, hatchetName, hatchetName, durcond)
+		query = fmt.Sprintf(`SELECT ip, SUM(accepted) total_accepted, SUM(ended) FROM %v_clients_minute %v
+			GROUP by ip HAVING SUM(accepted) > 0 OR SUM(ended) > 0 ORDER BY total_accepted DESC;`,
+			hatchetName, builder.Clause())
 	}
 	db := ptr.db
 	if ptr.verbose {
-		log.Println(query)
+		log.Println(query, builder.Args())
 	}
-	rows, err := db.Query(query)
+	rows, err := db.Query(query, builder.Args()...)
 	if err != nil {
 		return docs, err
 	}
@@ -386,21 +578,35 @@ func (ptr *SQLite3DB) GetConnectionStats(chartType string, duration string) ([]R
 	return docs, err
 }
 
-// GetOpsCounts returns opened connection counts
-func (ptr *SQLite3DB) GetOpsCounts(duration string) ([]NameValue, error) {
+// GetOpsCounts returns counts of slow ops by op types from per-minute rollups
+func (ptr *SQLite3DB) GetOpsCounts(duration string, app string) ([]NameValue, error) {
 	docs := []NameValue{}
-	var durcond string
-	if duration != "" {
-		toks := strings.Split(duration, ",")
-		durcond = fmt.Sprintf("AND date BETWEEN '%v' AND '%v'", toks[0], toks[1])
+	_, rollup, err := ptr.getChartBuckets(duration)
+	if err != nil {
+		return docs, err
+	}
+	builder := NewQueryBuilder()
+	table, count := ptr.hatchetName, "COUNT(*)"
+	if rollup {
+		table, count = ptr.hatchetName+"_ops_minute", "SUM(count)"
+		err = builder.WhereDurationMinutes("date", duration)
+	} else {
+		builder.Where("op != ''")
+		err = builder.WhereDuration("date", duration)
+	}
+	if err != nil {
+		return docs, err
 	}
-	query := fmt.Sprintf(`SELECT op, COUNT(op) counts
-		FROM %v WHERE op != '' %v GROUP by op ORDER BY counts DESC;`, ptr.hatchetName, durcond)
+	if app != "" {
+		builder.Where("app = ?", app)
+	}
+	query := fmt.Sprintf(`SELECT op, %v counts
+		FROM %v %v GROUP by op ORDER BY counts DESC;`, count, table, builder.Clause())
 	db := ptr.db
 	if ptr.verbose {
-		log.Println(query)
+		log.Println(query, builder.Args())
 	}
-	rows, err := db.Query(query)
+	rows, err := db.Query(query, builder.Args()...)
 	if err != nil {
 		return docs, err
 	}
@@ -417,30 +623,44 @@ func (ptr *SQLite3DB) GetOpsCounts(duration string) ([]NameValue, error) {
 	return docs, err
 }
 
-// GetReslenByIP returns total response length by ip
+// GetReslenByIP returns total response length by ip, or by context of an ip, from per-minute rollups
 func (ptr *SQLite3DB) GetReslenByIP(ip string, duration string) ([]NameValue, error) {
 	hatchetName := ptr.hatchetName
 	docs := []NameValue{}
-	var query, durcond, ipcond string
-	if duration != "" {
-		toks := strings.Split(duration, ",")
-		durcond = fmt.Sprintf("AND a.date BETWEEN '%v' AND '%v'", toks[0], toks[1])
-	}
-	if ip != "" {
-		ipcond = fmt.Sprintf("AND b.ip = '%v'", ip)
-		query = fmt.Sprintf(`SELECT a.context, SUM(a.reslen) reslen FROM %v a, %v_clients b
-				WHERE reslen > 0 AND a.context = b.context %v %v GROUP by a.context ORDER BY reslen DESC;`,
-			hatchetName, hatchetName, ipcond, durcond)
+	var query string
+	_, rollup, err := ptr.getChartBuckets(duration)
+	if err != nil {
+		return docs, err
+	}
+	builder := NewQueryBuilder()
+	if !rollup {
+		builder.Where("a.reslen > 0").Where("a.context = b.context")
+		if err = builder.WhereDuration("a.date", duration); err != nil {
+			return docs, err
+		}
+		if ip != "" {
+			builder.Where("b.ip = ?", ip)
+			query = fmt.Sprintf(`SELECT a.context, SUM(a.reslen) total_reslen FROM %v a, %v_clients b
+				%v GROUP by a.context ORDER BY total_reslen DESC;`, hatchetName, hatchetName, builder.Clause())
+		} else {
+			query = fmt.Sprintf(`SELECT b.ip, SUM(a.reslen) total_reslen FROM %v a, %v_clients b
+				%v GROUP by b.ip ORDER BY total_reslen DESC;`, hatchetName, hatchetName, builder.Clause())
+		}
+	} else if err = builder.Where("reslen > 0").WhereDurationMinutes("date", duration); err != nil {
+		return docs, err
+	} else if ip != "" {
+		builder.Where("ip = ?", ip)
+		query = fmt.Sprintf(`SELECT context, SUM(reslen) total_reslen FROM %v_clients_minute
+				%v GROUP by context ORDER BY total_reslen DESC;`, hatchetName, builder.Clause())
 	} else {
-		query = fmt.Sprintf(`SELECT b.ip, SUM(a.reslen) reslen FROM %v a, %v_clients b
-				WHERE reslen > 0 AND a.context = b.context %v GROUP by b.ip ORDER BY reslen DESC;`,
-			hatchetName, hatchetName, durcond)
+		query = fmt.Sprintf(`SELECT ip, SUM(reslen) total_reslen FROM %v_clients_minute
+				%v GROUP by ip ORDER BY total_reslen DESC;`, hatchetName, builder.Clause())
 	}
 	db := ptr.db
 	if ptr.verbose {
-		log.Println(query)
+		log.Println(query, builder.Args())
 	}
-	rows, err := db.Query(query)
+	rows, err := db.Query(query, builder.Args()...)
 	if err != nil {
 		return docs, err
 	}
@@ -461,24 +681,20 @@ func (ptr *SQLite3DB) GetReslenByIP(ip string, duration string) ([]NameValue, er
 func (ptr *SQLite3DB) GetReslenByNamespace(ns string, duration string) ([]NameValue, error) {
 	hatchetName := ptr.hatchetName
 	docs := []NameValue{}
-	var query, durcond, nscond string
-	if duration != "" {
-		toks := strings.Split(duration, ",")
-		durcond = fmt.Sprintf("AND date BETWEEN '%v' AND '%v'", toks[0], toks[1])
+	builder := NewQueryBuilder().Where(`op != ""`).Where("reslen > 0")
+	if err := builder.WhereDuration("date", duration); err != nil {
+		return docs, err
 	}
 	if ns != "" {
-		nscond = fmt.Sprintf("AND ns = '%v'", ns)
-		query = fmt.Sprintf(`SELECT ns, SUM(reslen) reslen FROM %v WHERE op != "" AND reslen > 0 %v %v GROUP by ns ORDER BY reslen DESC;`,
-			hatchetName, nscond, durcond)
-	} else {
-		query = fmt.Sprintf(`SELECT ns, SUM(reslen) reslen FROM %v WHERE op != "" AND reslen > 0 %v GROUP by ns ORDER BY reslen DESC;`,
-			hatchetName, durcond)
+		builder.Where("ns = ?", ns)
 	}
+	query := fmt.Sprintf(`SELECT ns, SUM(reslen) reslen FROM %v %v GROUP by ns ORDER BY reslen DESC;`,
+		hatchetName, builder.Clause())
 	db := ptr.db
 	if ptr.verbose {
-		log.Println(query)
+		log.Println(query, builder.Args())
 	}
-	rows, err := db.Query(query)
+	rows, err := db.Query(query, builder.Args()...)
 	if err != nil {
 		return docs, err
 	}
@@ -0,0 +1,75 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * sqlite3_replication.go
+ */
+
+package hatchet
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// GetReplEvents returns replication events of a type, all types if empty
+func (ptr *SQLite3DB) GetReplEvents(eventType string, duration string) ([]ReplEvent, error) {
+	hatchetName := ptr.hatchetName
+	docs := []ReplEvent{}
+	builder := NewQueryBuilder().Where("a.id = b.id")
+	if eventType != "" {
+		builder.Where("b.type = ?", eventType)
+	}
+	if err := builder.WhereDuration("a.date", duration); err != nil {
+		return docs, err
+	}
+	query := fmt.Sprintf(`SELECT a.date, b.type, b.node, b.name, b.detail, b.milli
+		FROM %v a, %v_repl b %v ORDER BY b.id`, hatchetName, hatchetName, builder.Clause())
+	db := ptr.db
+	if ptr.verbose {
+		log.Println(query, builder.Args())
+	}
+	rows, err := db.Query(query, builder.Args()...)
+	if err != nil {
+		return docs, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var doc ReplEvent
+		if err = rows.Scan(&doc.Date, &doc.Type, &doc.Node, &doc.Name, &doc.Detail, &doc.Milli); err != nil {
+			return docs, err
+		}
+		docs = append(docs, doc)
+	}
+	return docs, err
+}
+
+// GetOplogApplicationTime returns average oplog application time over a period of time
+func (ptr *SQLite3DB) GetOplogApplicationTime(duration string) ([]OpCount, error) {
+	hatchetName := ptr.hatchetName
+	docs := []OpCount{}
+	builder := NewQueryBuilder().Where("a.id = b.id").Where("b.type = ?", REPL_OPLOG)
+	start, end, err := ptr.whereDuration(builder, "a.date", duration)
+	if err != nil {
+		return docs, err
+	}
+	substr := strings.ReplaceAll(GetDateSubString(start, end), "date", "a.date")
+	query := fmt.Sprintf(`SELECT %v dt, AVG(b.milli), COUNT(*), b.name, b.detail FROM %v a, %v_repl b
+		%v GROUP by dt, b.name, b.detail ORDER BY dt;`, substr, hatchetName, hatchetName, builder.Clause())
+	db := ptr.db
+	if ptr.verbose {
+		log.Println(query, builder.Args())
+	}
+	rows, err := db.Query(query, builder.Args()...)
+	if err != nil {
+		return docs, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var doc OpCount
+		if err = rows.Scan(&doc.Date, &doc.Milli, &doc.Count, &doc.Op, &doc.Namespace); err != nil {
+			return docs, err
+		}
+		docs = append(docs, doc)
+	}
+	return docs, err
+}
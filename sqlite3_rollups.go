@@ -0,0 +1,98 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * sqlite3_rollups.go
+ */
+
+package hatchet
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+)
+
+const ROLLUP_DATE_LENGTH = 16 // length of dates of per-minute rollups, i.e. yyyy-mm-ddThh:mm
+
+// createRollups creates and populates per-minute rollup tables of slow ops and clients, which charts
+// read and roll up to coarser buckets instead of scanning logs
+func createRollups(db dbExecer, hatchetName string) error {
+	log.Printf("insert into %v_ops_minute\n", hatchetName)
+	stmts := fmt.Sprintf(`
+		DROP TABLE IF EXISTS %[1]v_ops_minute;
+		CREATE TABLE %[1]v_ops_minute (
+			date text, op text, ns text, filter text, app text,
+			count integer, total_ms integer, max_ms integer, reslen integer);
+		CREATE INDEX %[1]v_ops_minute_idx_date ON %[1]v_ops_minute (date,op);
+
+		INSERT INTO %[1]v_ops_minute
+			SELECT SUBSTR(date, 1, %[2]v) dt, op, ns, filter, app, COUNT(*), SUM(milli), MAX(milli), SUM(reslen)
+			FROM %[1]v WHERE op != '' GROUP BY dt, op, ns, filter, app;`, hatchetName, ROLLUP_DATE_LENGTH)
+	if _, err := db.Exec(stmts); err != nil {
+		return err
+	}
+
+	log.Printf("insert into %v_clients_minute\n", hatchetName)
+	stmts = fmt.Sprintf(`
+		DROP TABLE IF EXISTS %[1]v_clients_minute;
+		CREATE TABLE %[1]v_clients_minute (
+			date text, ip text, context text, accepted integer, ended integer, reslen integer);
+		CREATE INDEX %[1]v_clients_minute_idx_date ON %[1]v_clients_minute (date,ip);
+
+		INSERT INTO %[1]v_clients_minute
+			SELECT dt, ip, context, SUM(accepted), SUM(ended), SUM(reslen) FROM (
+				SELECT SUBSTR(a.date, 1, %[2]v) dt, b.ip, b.context, b.accepted, b.ended, 0 reslen
+					FROM %[1]v a, %[1]v_clients b WHERE a.id = b.id
+				UNION ALL
+				SELECT SUBSTR(a.date, 1, %[2]v) dt, b.ip, a.context, 0, 0, a.reslen
+					FROM %[1]v a, %[1]v_clients b WHERE a.reslen > 0 AND a.context = b.context)
+			GROUP BY dt, ip, context;`, hatchetName, ROLLUP_DATE_LENGTH)
+	_, err := db.Exec(stmts)
+	return err
+}
+
+// buildRollups creates rollup tables of a hatchet in a transaction in the background, once per pool,
+// e.g. of hatchets migrated from versions without rollups
+func (ptr *SQLite3Pool) buildRollups(hatchetName string) {
+	ptr.mutex.Lock()
+	defer ptr.mutex.Unlock()
+	if ptr.rollups[hatchetName] {
+		return
+	}
+	ptr.rollups[hatchetName] = true
+	go func() {
+		if err := ptr.Write(func(tx *sql.Tx) error { return createRollups(tx, hatchetName) }); err != nil {
+			log.Println("rollups of", hatchetName, "not created:", err)
+		}
+	}()
+}
+
+// hasRollups returns true if rollup tables of the hatchet exist, otherwise they are built in the background
+// and charts scan logs until then
+func (ptr *SQLite3DB) hasRollups() bool {
+	ok, err := ptr.hasTable("_clients_minute") // created last
+	if err == nil && !ok {
+		ptr.db.buildRollups(ptr.hatchetName)
+	}
+	return ok
+}
+
+// getChartBuckets returns the expression of date buckets of a duration, or of the hatchet if the
+// duration is empty, and true if per-minute rollups exist, are fine enough for the buckets and count
+// exactly the logs of the duration, i.e. of the hatchet or of whole minutes
+func (ptr *SQLite3DB) getChartBuckets(duration string) (string, bool, error) {
+	var start, end string
+	exact := true
+	if duration != "" {
+		toks, err := ParseDuration(duration)
+		if err != nil {
+			return "", false, err
+		}
+		start, end = toks[0], toks[1]
+		exact = IsWholeMinutes(start, end)
+	} else {
+		info := ptr.GetHatchetInfo()
+		start, end = info.Start, info.End
+	}
+	length, _ := GetDateBucket(start, end)
+	return GetDateSubString(start, end), exact && length <= ROLLUP_DATE_LENGTH && ptr.hasRollups(), nil
+}
@@ -0,0 +1,115 @@
+// Copyright 2022-present Kuei-chun Chen. All rights reserved.
+
+package hatchet
+
+import (
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRollups(t *testing.T) {
+	dbfile := filepath.Join(t.TempDir(), "hatchet.db")
+	db, err := sql.Open("sqlite3", GetSQLite3DSN(dbfile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	sqlite := &SQLite3DB{db: newSQLite3Pool(db), hatchetName: "mongod_1b3d5f"}
+	if _, err = db.Exec(sqlite.GetHatchetInitStmt()); err != nil {
+		t.Fatal(err)
+	}
+	stmts := `INSERT INTO mongod_1b3d5f (id, date, context, op, ns, filter, milli, reslen, app) VALUES
+			(1, '2023-03-01T10:00:00.000-0000', 'listener', '', '', '', 0, 0, ''),
+			(2, '2023-03-01T10:00:01.000-0000', 'conn1', 'find', 'test.orders', '{ a: 1 }', 100, 1000, 'svc'),
+			(3, '2023-03-01T10:00:59.000-0000', 'conn1', 'find', 'test.orders', '{ a: 1 }', 300, 500, 'svc'),
+			(4, '2023-03-01T10:05:30.000-0000', 'conn1', 'insert', 'test.orders', '', 50, 0, 'svc'),
+			(5, '2023-03-01T10:40:00.000-0000', 'conn1', '', '', '', 0, 0, '');
+		INSERT INTO mongod_1b3d5f_clients (id, ip, port, conns, accepted, ended, context) VALUES
+			(1, '10.0.0.5', '50001', 1, 1, 0, 'conn1'), (5, '10.0.0.5', '50001', 0, 0, 1, 'conn1');`
+	if _, err = db.Exec(stmts); err != nil {
+		t.Fatal(err)
+	}
+
+	// logs are read until rollups are built in the background
+	duration := "2023-03-01T10:00:00,2023-03-01T11:00:00"
+	counts, err := sqlite.GetOpsCounts(duration, "svc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(counts, []NameValue{{"find", 2}, {"insert", 1}}) {
+		t.Fatal("unexpected", counts)
+	}
+	for i := 0; i < 100 && !sqlite.hasRollups(); i++ {
+		time.Sleep(50 * time.Millisecond)
+	}
+	if !sqlite.hasRollups() {
+		t.Fatal("expected", "rollups", "but got", "none")
+	}
+
+	ops, err := sqlite.GetAverageOpTime("find", duration, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []OpCount{{Date: "2023-03-01T10:09:59", Count: 2, Milli: 200, Op: "find", Namespace: "test.orders", Filter: "{ a: 1 }"}}
+	if !reflect.DeepEqual(ops, expected) {
+		t.Fatal("expected", expected, "but got", ops)
+	}
+	// buckets shorter than a minute are read from logs
+	if ops, err = sqlite.GetAverageOpTime("", "2023-03-01T10:00:00,2023-03-01T10:05:00", ""); err != nil {
+		t.Fatal(err)
+	}
+	if len(ops) != 2 || ops[0].Date != "2023-03-01T10:00:09" || ops[0].Count != 1 {
+		t.Fatal("unexpected", ops)
+	}
+	if counts, err = sqlite.GetOpsCounts(duration, "svc"); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(counts, []NameValue{{"find", 2}, {"insert", 1}}) {
+		t.Fatal("unexpected", counts)
+	}
+	clients, err := sqlite.GetConnectionStats("total", duration)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clients) != 1 || clients[0].IP != "10.0.0.5" || clients[0].Accepted != 1 || clients[0].Ended != 1 {
+		t.Fatal("unexpected", clients)
+	}
+	reslens, err := sqlite.GetReslenByIP("10.0.0.5", duration)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(reslens, []NameValue{{"conn1", 3000}}) {
+		t.Fatal("unexpected", reslens)
+	}
+
+	// partial minutes at edges are read from logs
+	duration = "2023-03-01T10:00:30,2023-03-01T10:05:00"
+	if counts, err = sqlite.GetOpsCounts(duration, ""); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(counts, []NameValue{{"find", 1}}) {
+		t.Fatal("unexpected", counts)
+	}
+	if clients, err = sqlite.GetConnectionStats("total", duration); err != nil {
+		t.Fatal(err)
+	}
+	if len(clients) != 0 {
+		t.Fatal("unexpected", clients)
+	}
+	if reslens, err = sqlite.GetReslenByIP("", duration); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(reslens, []NameValue{{"10.0.0.5", 1000}}) {
+		t.Fatal("unexpected", reslens)
+	}
+	// whole minutes exclude the end minute
+	if counts, err = sqlite.GetOpsCounts("2023-03-01T10:00,2023-03-01T10:05", ""); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(counts, []NameValue{{"find", 2}}) {
+		t.Fatal("unexpected", counts)
+	}
+}
@@ -0,0 +1,255 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * sqlite3_sharding.go
+ */
+
+package hatchet
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// GetShardingStats returns migrations, step durations, failures, balancer rounds, splits,
+// range deletions and StaleConfig errors summaries
+func (ptr *SQLite3DB) GetShardingStats() (ShardingStats, error) {
+	var err error
+	hatchetName := ptr.hatchetName
+	stats := ShardingStats{}
+	if stats.Migrations, err = ptr.getMigrationStats(); err != nil {
+		return stats, err
+	}
+	query := fmt.Sprintf(`SELECT CASE WHEN detail = '' THEN result ELSE detail END reason, COUNT(*) FROM %v_sharding
+		WHERE type = ? AND result NOT IN ('', 'success') GROUP BY reason ORDER BY COUNT(*) DESC;`, hatchetName)
+	if stats.Failures, err = ptr.getNameValues(query, SHARD_MIGRATION); err != nil {
+		return stats, err
+	}
+	if stats.Steps, err = ptr.getMigrationSteps(); err != nil {
+		return stats, err
+	}
+	if stats.Balancer, err = ptr.getBalancerStats(); err != nil {
+		return stats, err
+	}
+	query = fmt.Sprintf(`SELECT ns, COUNT(*) FROM %v_sharding WHERE type = ?
+		GROUP BY ns ORDER BY COUNT(*) DESC;`, hatchetName)
+	if stats.Splits, err = ptr.getNameValues(query, SHARD_SPLIT); err != nil {
+		return stats, err
+	}
+	if stats.StaleConfig, err = ptr.getNameValues(query, SHARD_STALE_CONFIG); err != nil {
+		return stats, err
+	}
+
+	stats.RangeDeletions = []NameValues{}
+	query = fmt.Sprintf(`SELECT ns, SUM(CASE WHEN name = ? THEN 1 ELSE 0 END), SUM(CASE WHEN name = ? THEN 1 ELSE 0 END)
+		FROM %v_sharding WHERE type = ? GROUP BY ns ORDER BY ns;`, hatchetName)
+	if ptr.verbose {
+		log.Println(query)
+	}
+	rows, err := ptr.db.Query(query, RANGE_SCHEDULED, RANGE_DELETED, SHARD_RANGE_DELETION)
+	if err != nil {
+		return stats, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var ns string
+		var scheduled, deleted int
+		if err = rows.Scan(&ns, &scheduled, &deleted); err != nil {
+			return stats, err
+		}
+		backlog := scheduled - deleted
+		if backlog < 0 {
+			backlog = 0
+		}
+		stats.RangeDeletions = append(stats.RangeDeletions, NameValues{ns, []int{scheduled, deleted, backlog}})
+	}
+	return stats, err
+}
+
+// GetShardingTimeSeries returns counts and average durations of a type of sharding events over a period of time
+func (ptr *SQLite3DB) GetShardingTimeSeries(eventType string, duration string) ([]OpCount, error) {
+	hatchetName := ptr.hatchetName
+	docs := []OpCount{}
+	builder := NewQueryBuilder().Where("a.id = b.id").Where("b.type = ?", eventType)
+	start, end, err := ptr.whereDuration(builder, "a.date", duration)
+	if err != nil {
+		return docs, err
+	}
+	substr := strings.ReplaceAll(GetDateSubString(start, end), "date", "a.date")
+	op, ns := "b.name", "b.ns"
+	if eventType == SHARD_MIGRATION {
+		op = "b.name||' '||b.result"
+		ns = "b.ns||' '||b.from_shard||' -> '||b.to_shard"
+	}
+	query := fmt.Sprintf(`SELECT %v dt, AVG(b.milli), COUNT(*), %v event, %v target FROM %v a, %v_sharding b
+		%v GROUP by dt, event, target ORDER BY dt;`,
+		substr, op, ns, hatchetName, hatchetName, builder.Clause())
+	if ptr.verbose {
+		log.Println(query, builder.Args())
+	}
+	rows, err := ptr.db.Query(query, builder.Args()...)
+	if err != nil {
+		return docs, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var doc OpCount
+		if err = rows.Scan(&doc.Date, &doc.Milli, &doc.Count, &doc.Op, &doc.Namespace); err != nil {
+			return docs, err
+		}
+		docs = append(docs, doc)
+	}
+	if eventType == SHARD_RANGE_DELETION {
+		docs = append(docs, getRangeDeleterBacklog(docs)...)
+	}
+	return docs, err
+}
+
+// getRangeDeleterBacklog returns outstanding range deletions at the end of each time bucket
+func getRangeDeleterBacklog(docs []OpCount) []OpCount {
+	backlogs := []OpCount{}
+	backlog := 0
+	for i, doc := range docs {
+		if doc.Op == RANGE_SCHEDULED {
+			backlog += doc.Count
+		} else if doc.Op == RANGE_DELETED {
+			backlog -= doc.Count
+		}
+		if backlog < 0 {
+			backlog = 0
+		}
+		if i == len(docs)-1 || docs[i+1].Date != doc.Date {
+			backlogs = append(backlogs, OpCount{Date: doc.Date, Count: backlog, Op: "backlog"})
+		}
+	}
+	return backlogs
+}
+
+func (ptr *SQLite3DB) getMigrationStats() ([]MigrationStat, error) {
+	docs := []MigrationStat{}
+	query := fmt.Sprintf(`SELECT ns, from_shard, to_shard, name, COUNT(*),
+			SUM(CASE WHEN result = 'success' THEN 1 ELSE 0 END), SUM(CASE WHEN result = 'success' THEN 0 ELSE 1 END),
+			CAST(AVG(milli) AS INTEGER), MAX(milli)
+		FROM %v_sharding WHERE type = ? GROUP BY ns, from_shard, to_shard, name ORDER BY COUNT(*) DESC;`, ptr.hatchetName)
+	if ptr.verbose {
+		log.Println(query)
+	}
+	rows, err := ptr.db.Query(query, SHARD_MIGRATION)
+	if err != nil {
+		return docs, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var doc MigrationStat
+		if err = rows.Scan(&doc.Namespace, &doc.From, &doc.To, &doc.Role, &doc.Total,
+			&doc.Succeeded, &doc.Failed, &doc.AvgMilli, &doc.MaxMilli); err != nil {
+			return docs, err
+		}
+		docs = append(docs, doc)
+	}
+	return docs, err
+}
+
+// getMigrationSteps returns count, average and max milliseconds of each donor and recipient step
+func (ptr *SQLite3DB) getMigrationSteps() ([]NameValues, error) {
+	docs := []NameValues{}
+	query := fmt.Sprintf(`SELECT name, metrics FROM %v_sharding WHERE type = ? AND metrics != '';`, ptr.hatchetName)
+	if ptr.verbose {
+		log.Println(query)
+	}
+	rows, err := ptr.db.Query(query, SHARD_MIGRATION)
+	if err != nil {
+		return docs, err
+	}
+	defer rows.Close()
+	steps := map[string][]int{}
+	for rows.Next() {
+		var name, metrics string
+		if err = rows.Scan(&name, &metrics); err != nil {
+			return docs, err
+		}
+		var values map[string]int
+		if json.Unmarshal([]byte(metrics), &values) != nil {
+			continue
+		}
+		for step, milli := range values {
+			key := name + " " + step
+			if steps[key] == nil {
+				steps[key] = []int{0, 0, 0}
+			}
+			steps[key][0]++
+			steps[key][1] += milli
+			if milli > steps[key][2] {
+				steps[key][2] = milli
+			}
+		}
+	}
+	for key, values := range steps {
+		docs = append(docs, NameValues{key, []int{values[0], values[1] / values[0], values[2]}})
+	}
+	sort.Slice(docs, func(i, j int) bool {
+		return docs[i].Name < docs[j].Name
+	})
+	return docs, err
+}
+
+// getBalancerStats returns totals of balancer rounds, errors, chunks moved and round durations
+func (ptr *SQLite3DB) getBalancerStats() ([]NameValue, error) {
+	query := fmt.Sprintf(`SELECT result, milli, metrics FROM %v_sharding WHERE type = ?;`, ptr.hatchetName)
+	if ptr.verbose {
+		log.Println(query)
+	}
+	rows, err := ptr.db.Query(query, SHARD_BALANCER)
+	if err != nil {
+		return []NameValue{}, err
+	}
+	defer rows.Close()
+	var rounds, errs, moved, total, max int
+	for rows.Next() {
+		var result, metrics string
+		var milli int
+		if err = rows.Scan(&result, &milli, &metrics); err != nil {
+			return []NameValue{}, err
+		}
+		rounds++
+		if result == "error" {
+			errs++
+		}
+		var values map[string]int
+		if json.Unmarshal([]byte(metrics), &values) == nil {
+			moved += values["chunksMoved"]
+		}
+		total += milli
+		if milli > max {
+			max = milli
+		}
+	}
+	if rounds == 0 {
+		return []NameValue{}, err
+	}
+	return []NameValue{{"rounds", rounds}, {"errors", errs}, {"chunks moved", moved},
+		{"avg round (ms)", total / rounds}, {"max round (ms)", max}}, err
+}
+
+// getNameValues returns name and count pairs of a query
+func (ptr *SQLite3DB) getNameValues(query string, args ...interface{}) ([]NameValue, error) {
+	docs := []NameValue{}
+	if ptr.verbose {
+		log.Println(query, args)
+	}
+	rows, err := ptr.db.Query(query, args...)
+	if err != nil {
+		return docs, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var doc NameValue
+		if err = rows.Scan(&doc.Name, &doc.Value); err != nil {
+			return docs, err
+		}
+		docs = append(docs, doc)
+	}
+	return docs, err
+}
@@ -0,0 +1,88 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * sqlite3_storage.go
+ */
+
+package hatchet
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// GetStorageStats returns checkpoint durations, counts of storage events, and cache pressure,
+// journal and error messages
+func (ptr *SQLite3DB) GetStorageStats() (StorageStats, error) {
+	var err error
+	hatchetName := ptr.hatchetName
+	stats := StorageStats{Checkpoints: []NameValue{}, Warnings: []StorageWarning{}}
+	query := fmt.Sprintf(`SELECT type, COUNT(*) FROM %v_storage GROUP BY type ORDER BY COUNT(*) DESC;`, hatchetName)
+	if stats.Events, err = ptr.getNameValues(query); err != nil {
+		return stats, err
+	}
+
+	query = fmt.Sprintf(`SELECT COUNT(*), IFNULL(AVG(milli), 0), IFNULL(MAX(milli), 0) FROM %v_storage WHERE type = ?;`,
+		hatchetName)
+	if ptr.verbose {
+		log.Println(query)
+	}
+	var count, avg, max int
+	if err = ptr.db.QueryRow(query, STORAGE_CHECKPOINT).Scan(&count, &avg, &max); err != nil {
+		return stats, err
+	}
+	if count > 0 {
+		stats.Checkpoints = []NameValue{{"checkpoints", count}, {"avg (ms)", avg}, {"max (ms)", max}}
+	}
+
+	query = fmt.Sprintf(`SELECT type, detail FROM %v_storage WHERE type IN (?, ?, ?) ORDER BY id;`, hatchetName)
+	if ptr.verbose {
+		log.Println(query)
+	}
+	rows, err := ptr.db.Query(query, STORAGE_ERROR, STORAGE_EVICTION, STORAGE_JOURNAL)
+	if err != nil {
+		return stats, err
+	}
+	defer rows.Close()
+	events := []StorageEvent{}
+	for rows.Next() {
+		var event StorageEvent
+		if err = rows.Scan(&event.Type, &event.Detail); err != nil {
+			return stats, err
+		}
+		events = append(events, event)
+	}
+	stats.Warnings = getStorageWarnings(events)
+	return stats, err
+}
+
+// GetCheckpointDurations returns counts and average durations of checkpoints over a period of time
+func (ptr *SQLite3DB) GetCheckpointDurations(duration string) ([]OpCount, error) {
+	hatchetName := ptr.hatchetName
+	docs := []OpCount{}
+	builder := NewQueryBuilder().Where("a.id = b.id").Where("b.type = ?", STORAGE_CHECKPOINT)
+	start, end, err := ptr.whereDuration(builder, "a.date", duration)
+	if err != nil {
+		return docs, err
+	}
+	substr := strings.ReplaceAll(GetDateSubString(start, end), "date", "a.date")
+	query := fmt.Sprintf(`SELECT %v dt, AVG(b.milli), COUNT(*), a.component FROM %v a, %v_storage b
+		%v GROUP by dt, a.component ORDER BY dt;`,
+		substr, hatchetName, hatchetName, builder.Clause())
+	if ptr.verbose {
+		log.Println(query, builder.Args())
+	}
+	rows, err := ptr.db.Query(query, builder.Args()...)
+	if err != nil {
+		return docs, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		doc := OpCount{Op: STORAGE_CHECKPOINT}
+		if err = rows.Scan(&doc.Date, &doc.Milli, &doc.Count, &doc.Namespace); err != nil {
+			return docs, err
+		}
+		docs = append(docs, doc)
+	}
+	return docs, err
+}
@@ -0,0 +1,110 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * sqlite3_transactions.go
+ */
+
+package hatchet
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// TXN_BUCKETS defines time distribution buckets of transactions in microseconds
+var TXN_BUCKETS = []NameValue{{"< 1ms", 1000}, {"1ms - 10ms", 10000}, {"10ms - 100ms", 100000},
+	{"100ms - 1s", 1000000}, {"1s - 10s", 10000000}, {">= 10s", 0}}
+
+// GetTransactionStats returns counts of terminations, abort causes and time distributions
+func (ptr *SQLite3DB) GetTransactionStats() (map[string][]NameValues, error) {
+	db := ptr.db
+	data := map[string][]NameValues{}
+	query := fmt.Sprintf(`SELECT type, name, value FROM %v_audit
+		WHERE type IN ('txn', 'txn-abort') ORDER BY type, value DESC;`, ptr.hatchetName)
+	if ptr.verbose {
+		log.Println(query)
+	}
+	rows, err := db.Query(query)
+	if err != nil {
+		return data, err
+	}
+	for rows.Next() {
+		var category string
+		var doc NameValues
+		var value int
+		if err = rows.Scan(&category, &doc.Name, &value); err != nil {
+			rows.Close()
+			return data, err
+		}
+		doc.Values = append(doc.Values, value)
+		data[category] = append(data[category], doc)
+	}
+	rows.Close()
+
+	category := "distribution"
+	for _, bucket := range TXN_BUCKETS {
+		data[category] = append(data[category], NameValues{bucket.Name, []int{0, 0}})
+	}
+	for n, column := range []string{"time_active", "time_inactive"} {
+		query = fmt.Sprintf(`SELECT %v bucket, COUNT(*) FROM %v_txns GROUP BY bucket ORDER BY bucket;`,
+			getBucketCaseExpr(column, TXN_BUCKETS), ptr.hatchetName)
+		if ptr.verbose {
+			log.Println(query)
+		}
+		if rows, err = db.Query(query); err != nil {
+			return data, err
+		}
+		for rows.Next() {
+			var bucket, count int
+			if err = rows.Scan(&bucket, &count); err != nil {
+				rows.Close()
+				return data, err
+			}
+			data[category][bucket].Values[n] = count
+		}
+		rows.Close()
+	}
+	return data, err
+}
+
+// GetLongestTransactions returns the topN longest transactions
+func (ptr *SQLite3DB) GetLongestTransactions(topN int) ([]Transaction, error) {
+	docs := []Transaction{}
+	query := fmt.Sprintf(`SELECT a.date, b.lsid, b.txn_number, b.termination, b.abort_cause, b.milli,
+			b.time_active, b.time_inactive, b.num_yields, b.keys_examined, b.docs_examined, b.nreturned,
+			b.ninserted, b.nmodified, b.ndeleted, b.read_concern, b.was_prepared
+		FROM %v a, %v_txns b WHERE a.id = b.id ORDER BY b.milli DESC LIMIT ?`, ptr.hatchetName, ptr.hatchetName)
+	db := ptr.db
+	if ptr.verbose {
+		log.Println(query, topN)
+	}
+	rows, err := db.Query(query, topN)
+	if err != nil {
+		return docs, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var doc Transaction
+		if err = rows.Scan(&doc.Date, &doc.LSID, &doc.TxnNumber, &doc.Termination, &doc.AbortCause, &doc.Milli,
+			&doc.TimeActive, &doc.TimeInactive, &doc.NumYields, &doc.KeysExamined, &doc.DocsExamined, &doc.NReturned,
+			&doc.NInserted, &doc.NModified, &doc.NDeleted, &doc.ReadConcern, &doc.WasPrepared); err != nil {
+			return docs, err
+		}
+		docs = append(docs, doc)
+	}
+	return docs, err
+}
+
+// getBucketCaseExpr returns a CASE expression mapping a column to the index of its bucket,
+// the last bucket takes everything greater than the previous upper bound
+func getBucketCaseExpr(column string, buckets []NameValue) string {
+	cases := []string{}
+	for i, bucket := range buckets {
+		if i == len(buckets)-1 {
+			cases = append(cases, fmt.Sprintf("ELSE %d", i))
+			break
+		}
+		cases = append(cases, fmt.Sprintf("WHEN %v < %d THEN %d", column, bucket.Value, i))
+	}
+	return "CASE " + strings.Join(cases, " ") + " END"
+}
@@ -0,0 +1,84 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * sqlite3_uptime.go
+ */
+
+package hatchet
+
+import (
+	"fmt"
+	"log"
+)
+
+// GetUptimeSegments returns process lifetimes split by restarts
+func (ptr *SQLite3DB) GetUptimeSegments() ([]UptimeSegment, error) {
+	segments := []UptimeSegment{}
+	query := fmt.Sprintf(`SELECT id, pid, host, port, start, end, end_id, status, crash_id, reason
+		FROM %v_uptime ORDER BY id;`, ptr.hatchetName)
+	if ptr.verbose {
+		log.Println(query)
+	}
+	rows, err := ptr.db.Query(query)
+	if err != nil {
+		return segments, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var segment UptimeSegment
+		if err = rows.Scan(&segment.ID, &segment.PID, &segment.Host, &segment.Port, &segment.Start, &segment.End,
+			&segment.EndID, &segment.Status, &segment.CrashID, &segment.Reason); err != nil {
+			return segments, err
+		}
+		segments = append(segments, segment)
+	}
+	return segments, err
+}
+
+// GetCrashDetail returns logs preceding a crash and the stack trace of an uptime segment, logs
+// before the last line are returned for an unclean shutdown
+func (ptr *SQLite3DB) GetCrashDetail(id int) (CrashDetail, error) {
+	detail := CrashDetail{Preceding: []LegacyLog{}, StackTrace: []LegacyLog{}}
+	segment := &detail.Segment
+	query := fmt.Sprintf(`SELECT id, pid, host, port, start, end, end_id, status, crash_id, reason
+		FROM %v_uptime WHERE id = ?;`, ptr.hatchetName)
+	if ptr.verbose {
+		log.Println(query, id)
+	}
+	err := ptr.db.QueryRow(query, id).Scan(&segment.ID, &segment.PID, &segment.Host, &segment.Port, &segment.Start,
+		&segment.End, &segment.EndID, &segment.Status, &segment.CrashID, &segment.Reason)
+	if err != nil {
+		return detail, err
+	}
+	last := segment.EndID + 1
+	if segment.CrashID > 0 {
+		last = segment.CrashID
+		if detail.StackTrace, err = ptr.getLogsByRange(segment.CrashID, segment.EndID, CRASH_TRACE_LINES); err != nil {
+			return detail, err
+		}
+	}
+	detail.Preceding, err = ptr.getLogsByRange(last-CRASH_PRECEDING_LINES, last-1, CRASH_PRECEDING_LINES)
+	return detail, err
+}
+
+// getLogsByRange returns logs between two line numbers
+func (ptr *SQLite3DB) getLogsByRange(from int, to int, limit int) ([]LegacyLog, error) {
+	docs := []LegacyLog{}
+	query := fmt.Sprintf(`SELECT date, severity, component, context, message FROM %v
+		WHERE id BETWEEN ? AND ? ORDER BY id LIMIT ?;`, ptr.hatchetName)
+	if ptr.verbose {
+		log.Println(query, from, to)
+	}
+	rows, err := ptr.db.Query(query, from, to, limit)
+	if err != nil {
+		return docs, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var doc LegacyLog
+		if err = rows.Scan(&doc.Timestamp, &doc.Severity, &doc.Component, &doc.Context, &doc.Message); err != nil {
+			return docs, err
+		}
+		docs = append(docs, doc)
+	}
+	return docs, err
+}
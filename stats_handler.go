@@ -9,19 +9,75 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
 )
 
+const (
+	T_CONCERNS     = "concerns"
+	T_CONNECTIONS  = "connections"
+	T_CURSORS      = "cursors"
+	T_DRIVERS      = "drivers"
+	T_ERROR_CODES  = "errors"
+	T_FINDINGS     = "findings"
+	T_INDEX_BUILDS = "index-builds"
+	T_REPLICATION  = "replication"
+	T_SHARDING     = "sharding"
+	T_TRANSACTIONS = "transactions"
+	T_UPTIME       = "uptime"
+)
+
+var reports = map[string]Chart{
+	"instruction": {0, "select a report", "", ""},
+	T_TRANSACTIONS: {1, "Transactions",
+		"Display multi-document transactions commits, aborts and durations", "/stats/transactions"},
+	T_REPLICATION: {2, "Replication",
+		"Display elections, member states, sync source changes and rollbacks", "/stats/replication"},
+	T_SHARDING: {3, "Sharding",
+		"Display chunk migrations, balancer rounds, range deletions and StaleConfig errors", "/stats/sharding"},
+	T_CONNECTIONS: {4, "Connections",
+		"Display connection lifetimes, connection storms and possible connection leaks", "/stats/connections"},
+	T_INDEX_BUILDS: {5, "Index Builds",
+		"Display index builds, durations, commit quorum and phase timings", "/stats/index-builds"},
+	T_UPTIME: {6, "Restarts and Crashes",
+		"Display process restarts, clean and unclean shutdowns, and fatal assertions", "/stats/uptime"},
+	T_ERROR_CODES: {7, "Error Codes",
+		"Display error codes by namespaces, operations and clients", "/stats/errors"},
+	T_CURSORS: {8, "Cursors",
+		"Display long-lived and heavy cursors, and killed and timed out cursors", "/stats/cursors"},
+	T_DRIVERS: {9, "Driver Compatibility",
+		"Display drivers unsupported by the server version or past end-of-life, and clients using them", "/stats/drivers"},
+	T_CONCERNS: {10, "Read & Write Concerns",
+		"Display read and write concerns and read preferences by namespaces and applications", "/stats/concerns"},
+	T_FINDINGS: {11, "Query Anti-Patterns",
+		"Display query anti-patterns found in slow ops by severities", "/stats/findings"},
+}
+
 // StatsHandler responds to API calls
-func StatsHandler(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+func (ptr *Server) StatsHandler(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
 	/** APIs
 	 * /hatchets/{hatchet}/stats/audit
+	 * /hatchets/{hatchet}/stats/concerns
+	 * /hatchets/{hatchet}/stats/connections
+	 * /hatchets/{hatchet}/stats/cursors
+	 * /hatchets/{hatchet}/stats/drivers
+	 * /hatchets/{hatchet}/stats/errors
+	 * /hatchets/{hatchet}/stats/findings
+	 * /hatchets/{hatchet}/stats/index-builds
+	 * /hatchets/{hatchet}/stats/replication
+	 * /hatchets/{hatchet}/stats/sharding
 	 * /hatchets/{hatchet}/stats/slowops
+	 * /hatchets/{hatchet}/stats/transactions
+	 * /hatchets/{hatchet}/stats/uptime
 	 */
 	hatchetName := params.ByName("hatchet")
 	attr := params.ByName("attr")
-	dbase, err := GetDatabase(hatchetName)
+	if err := validateRequest(hatchetName, r); err != nil {
+		writeError(w, err)
+		return
+	}
+	dbase, err := GetDatabase(ptr.opts, hatchetName)
 	if err != nil {
 		json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
 		return
@@ -40,12 +96,23 @@ func StatsHandler(w http.ResponseWriter, r *http.Request, params httprouter.Para
 			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
 			return
 		}
+		auth, err := dbase.GetAuthStats()
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		storage, err := dbase.GetStorageStats()
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
 		templ, err := GetAuditTablesTemplate()
 		if err != nil {
 			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
 			return
 		}
-		doc := map[string]interface{}{"Hatchet": hatchetName, "Info": info, "Summary": summary, "Data": data}
+		doc := map[string]interface{}{"Hatchet": hatchetName, "Info": info, "Summary": summary, "Data": data, "Auth": auth,
+			"Storage": storage}
 		if err = templ.Execute(w, doc); err != nil {
 			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
 			return
@@ -60,32 +127,285 @@ func StatsHandler(w http.ResponseWriter, r *http.Request, params httprouter.Para
 		orderBy = r.URL.Query().Get("orderBy")
 		if orderBy == "" {
 			orderBy = "avg_ms"
-		} else if orderBy == "index" || orderBy == "_index" {
-			orderBy = "_index"
 		}
 		order = r.URL.Query().Get("order")
 		if order == "" {
-			if orderBy == "op" || orderBy == "ns" {
+			if orderBy == "op" || orderBy == "ns" || orderBy == "app" {
 				order = "ASC"
 			} else {
 				order = "DESC"
 			}
 		}
-		ops, err := dbase.GetSlowOps(orderBy, order, collscan)
+		app := r.URL.Query().Get("app")
+		ops, err := dbase.GetSlowOps(orderBy, order, collscan, app)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		apps, err := dbase.GetAppCounts("")
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		templ, err := GetStatsTableTemplate(collscan, orderBy, download, app)
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		doc := map[string]interface{}{"Hatchet": hatchetName, "Ops": ops, "Summary": summary, "App": app, "Apps": apps}
+		if err = templ.Execute(w, doc); err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		return
+	} else if attr == T_TRANSACTIONS {
+		data, err := dbase.GetTransactionStats()
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		topN := ToInt(r.URL.Query().Get("topN"))
+		if topN == 0 {
+			topN = TOP_N
+		}
+		txns, err := dbase.GetLongestTransactions(topN)
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		templ, err := GetTransactionsTemplate()
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		doc := map[string]interface{}{"Hatchet": hatchetName, "Data": data, "Transactions": txns,
+			"Summary": summary, "Report": reports[attr]}
+		if err = templ.Execute(w, doc); err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		return
+	} else if attr == T_REPLICATION {
+		events, err := dbase.GetReplEvents("", "")
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		data := GetReplicationData(events, info.End)
+		templ, err := GetReplicationTemplate()
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		doc := map[string]interface{}{"Hatchet": hatchetName, "Data": data, "Summary": summary, "Report": reports[attr]}
+		if err = templ.Execute(w, doc); err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		return
+	} else if attr == T_SHARDING {
+		stats, err := dbase.GetShardingStats()
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		templ, err := GetShardingTemplate()
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		doc := map[string]interface{}{"Hatchet": hatchetName, "Data": stats, "Summary": summary, "Report": reports[attr]}
+		if err = templ.Execute(w, doc); err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		return
+	} else if attr == T_CONNECTIONS {
+		duration := r.URL.Query().Get("duration")
+		data, err := getConnectionsData(dbase, duration)
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		templ, err := GetConnectionsTemplate()
 		if err != nil {
 			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
 			return
 		}
-		templ, err := GetStatsTableTemplate(collscan, orderBy, download)
+		doc := map[string]interface{}{"Hatchet": hatchetName, "Data": data, "Summary": summary, "Report": reports[attr]}
+		if err = templ.Execute(w, doc); err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		return
+	} else if attr == T_INDEX_BUILDS {
+		builds, err := dbase.GetIndexBuilds(r.URL.Query().Get("duration"))
 		if err != nil {
 			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
 			return
 		}
-		doc := map[string]interface{}{"Hatchet": hatchetName, "Ops": ops, "Summary": summary}
+		templ, err := GetIndexBuildsTemplate()
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		doc := map[string]interface{}{"Hatchet": hatchetName, "IndexBuilds": builds, "End": info.End,
+			"Summary": summary, "Report": reports[attr]}
 		if err = templ.Execute(w, doc); err != nil {
 			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
 			return
 		}
 		return
+	} else if attr == T_CURSORS {
+		topN := ToInt(r.URL.Query().Get("topN"))
+		if topN == 0 {
+			topN = TOP_N
+		}
+		stats, err := dbase.GetCursorStats(topN)
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		templ, err := GetCursorsTemplate()
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		doc := map[string]interface{}{"Hatchet": hatchetName, "Data": stats, "Summary": summary, "Report": reports[attr]}
+		if err = templ.Execute(w, doc); err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		return
+	} else if attr == T_ERROR_CODES {
+		codes, err := dbase.GetErrorCodes()
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		templ, err := GetErrorCodesTemplate()
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		doc := map[string]interface{}{"Hatchet": hatchetName, "ErrorCodes": codes, "Summary": summary,
+			"Report": reports[attr]}
+		if err = templ.Execute(w, doc); err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		return
+	} else if attr == T_CONCERNS {
+		topN := ToInt(r.URL.Query().Get("topN"))
+		if topN == 0 {
+			topN = TOP_N
+		}
+		stats, err := dbase.GetConcernStats(topN)
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		templ, err := GetConcernsTemplate()
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		doc := map[string]interface{}{"Hatchet": hatchetName, "Data": stats, "Summary": summary, "Report": reports[attr]}
+		if err = templ.Execute(w, doc); err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		return
+	} else if attr == T_FINDINGS {
+		findings, err := dbase.GetFindings()
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		templ, err := GetFindingsTemplate()
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		doc := map[string]interface{}{"Hatchet": hatchetName, "Findings": findings, "Rules": ANTI_PATTERN_RULES,
+			"Summary": summary, "Report": reports[attr]}
+		if err = templ.Execute(w, doc); err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		return
+	} else if attr == T_DRIVERS {
+		drivers, err := getDriversData(dbase)
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		templ, err := GetDriversTemplate()
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		doc := map[string]interface{}{"Hatchet": hatchetName, "Drivers": drivers, "Releases": SERVER_RELEASES,
+			"Version": info.Version, "Summary": summary, "Report": reports[attr]}
+		if err = templ.Execute(w, doc); err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		return
+	} else if attr == T_UPTIME {
+		doc := map[string]interface{}{"Hatchet": hatchetName, "Summary": summary, "Report": reports[attr]}
+		if id := r.URL.Query().Get("id"); id != "" {
+			detail, err := dbase.GetCrashDetail(ToInt(id))
+			if err != nil {
+				json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+				return
+			}
+			doc["Detail"] = detail
+		} else {
+			segments, err := dbase.GetUptimeSegments()
+			if err != nil {
+				json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+				return
+			}
+			doc["Segments"] = segments
+		}
+		templ, err := GetUptimeTemplate()
+		if err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		if err = templ.Execute(w, doc); err != nil {
+			json.NewEncoder(w).Encode(map[string]interface{}{"ok": 0, "error": err.Error()})
+			return
+		}
+		return
+	}
+}
+
+// getConnectionsData returns connection lifetimes distribution, stats by IPs and apps, and accepted spikes
+func getConnectionsData(dbase Database, duration string) (map[string]interface{}, error) {
+	var err error
+	var distribution []NameValue
+	var lifetimes []ConnectionStat
+	var rate AcceptedRate
+	if distribution, err = dbase.GetConnectionDistribution(duration); err != nil {
+		return nil, err
+	}
+	if lifetimes, err = dbase.GetConnectionLifetimes(duration); err != nil {
+		return nil, err
+	}
+	if rate, err = dbase.GetAcceptedRate(duration); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"distribution": distribution, "lifetimes": lifetimes,
+		"spikes": rate.Spikes, "threshold": rate.Threshold, "mean": rate.Mean}, err
+}
+
+// getDriversData returns drivers checked against the server version as of today
+func getDriversData(dbase Database) ([]DriverUsage, error) {
+	usages, err := dbase.GetDriverUsages()
+	if err != nil {
+		return usages, err
 	}
+	return GetDriverCompatibility(usages, dbase.GetHatchetInfo().Version, time.Now().Format("2006-01-02")), err
 }
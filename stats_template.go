@@ -17,16 +17,19 @@ import (
 const MIN_MONGO_VER = "5.0"
 
 // GetStatsTableTemplate returns HTML
-func GetStatsTableTemplate(collscan bool, orderBy string, download string) (*template.Template, error) {
+func GetStatsTableTemplate(collscan bool, orderBy string, download string, app string) (*template.Template, error) {
 	html := headers
 	if download == "" {
 		html = getContentHTML()
 	}
-	html += getStatsTable(collscan, orderBy, download) + "</body></html>"
+	html += getStatsTable(collscan, orderBy, download, app) + "</body></html>"
 	return template.New("hatchet").Funcs(template.FuncMap{
 		"add": func(a int, b int) int {
 			return a + b
 		},
+		"getRuleColor": func(rule string) string {
+			return getSeverityColor(ANTI_PATTERN_RULES[rule])
+		},
 		"hasPrefix": func(str string, pre string) bool {
 			return strings.HasPrefix(str, pre)
 		},
@@ -36,7 +39,7 @@ func GetStatsTableTemplate(collscan bool, orderBy string, download string) (*tem
 		}}).Parse(html)
 }
 
-func getStatsTable(collscan bool, orderBy string, download string) string {
+func getStatsTable(collscan bool, orderBy string, download string, app string) string {
 	checked := ""
 	if collscan {
 		checked = "checked"
@@ -45,13 +48,14 @@ func getStatsTable(collscan bool, orderBy string, download string) string {
 <script>
 	function getSlowopsStats() {
 		var b = document.getElementById('collscan').checked;
-		window.location.href = '/hatchets/{{.Hatchet}}/stats/slowops?orderBy=%v&COLLSCAN=' + b;
+		var app = document.getElementById('app').value;
+		window.location.href = '/hatchets/{{.Hatchet}}/stats/slowops?orderBy=%v&COLLSCAN=' + b + '&app=' + encodeURIComponent(app);
 	}
 	
 	function downloadStats() {
         anchor = document.createElement('a');
         anchor.download = '{{.Hatchet}}_stats.html';
-        anchor.href = '/hatchets/{{.Hatchet}}/stats/slowops?type=stats&download=true';
+        anchor.href = '/hatchets/{{.Hatchet}}/stats/slowops?type=stats&download=true&app={{.App}}';
         anchor.dataset.downloadurl = ['text/html', anchor.download, anchor.href].join(':');
         anchor.click();
     }
@@ -61,20 +65,30 @@ func getStatsTable(collscan bool, orderBy string, download string) string {
 	html += `<div align='left'>`
 	if download == "" {
 		html += `<button id="download" onClick="downloadStats(); return false;"
-			class="btn" style="float: right;"><i class="fa fa-download"></i></button>`
+			class="btn" style="float: right;"><i class="fa fa-download"></i></button>
+		<select id='app' style="float: right; margin: 5px 10px;" onchange='getSlowopsStats(); return false;'>
+			<option value=''>all applications</option>
+			<option value='all' {{if eq .App "all"}}selected{{end}}>by applications</option>
+		{{range $n, $value := .Apps}}
+			<option value='{{$value.Name}}' {{if eq $.App $value.Name}}selected{{end}}>{{$value.Name}} ({{numPrinter $value.Value}})</option>
+		{{end}}
+		</select>`
 	} else {
 		html += "<div align='center'>{{.Summary}}</div>"
 		asc = ""
 		desc = ""
 	}
 	html += `<table width='100%'><tr><th>#</th>`
-	html += fmt.Sprintf(`<th>op <a class='sort' href='/hatchets/{{.Hatchet}}/stats/slowops?orderBy=op&COLLSCAN=%v'>%v</th>`, collscan, asc)
-	html += fmt.Sprintf(`<th>namespace <a class='sort' href='/hatchets/{{.Hatchet}}/stats/slowops?orderBy=ns&order=ASC&COLLSCAN=%v'>%v</th>`, collscan, asc)
-	html += fmt.Sprintf(`<th>count <a class='sort' href='/hatchets/{{.Hatchet}}/stats/slowops?orderBy=count&COLLSCAN=%v'>%v</th>`, collscan, desc)
-	html += fmt.Sprintf(`<th>avg ms <a class='sort' href='/hatchets/{{.Hatchet}}/stats/slowops?orderBy=avg_ms&COLLSCAN=%v'>%v</th>`, collscan, desc)
-	html += fmt.Sprintf(`<th>max ms <a class='sort' href='/hatchets/{{.Hatchet}}/stats/slowops?orderBy=max_ms&COLLSCAN=%v'>%v</th>`, collscan, desc)
-	html += fmt.Sprintf(`<th>total ms <a class='sort' href='/hatchets/{{.Hatchet}}/stats/slowops?orderBy=total_ms&COLLSCAN=%v'>%v</th>`, collscan, desc)
-	html += fmt.Sprintf(`<th>reslen <a class='sort' href='/hatchets/{{.Hatchet}}/stats/slowops?orderBy=reslen&COLLSCAN=%v'>%v</th>`, collscan, desc)
+	html += fmt.Sprintf(`<th>op <a class='sort' href='/hatchets/{{.Hatchet}}/stats/slowops?orderBy=op&COLLSCAN=%v&app={{.App}}'>%v</th>`, collscan, asc)
+	if app == APP_ALL {
+		html += fmt.Sprintf(`<th>app <a class='sort' href='/hatchets/{{.Hatchet}}/stats/slowops?orderBy=app&order=ASC&COLLSCAN=%v&app={{.App}}'>%v</th>`, collscan, asc)
+	}
+	html += fmt.Sprintf(`<th>namespace <a class='sort' href='/hatchets/{{.Hatchet}}/stats/slowops?orderBy=ns&order=ASC&COLLSCAN=%v&app={{.App}}'>%v</th>`, collscan, asc)
+	html += fmt.Sprintf(`<th>count <a class='sort' href='/hatchets/{{.Hatchet}}/stats/slowops?orderBy=count&COLLSCAN=%v&app={{.App}}'>%v</th>`, collscan, desc)
+	html += fmt.Sprintf(`<th>avg ms <a class='sort' href='/hatchets/{{.Hatchet}}/stats/slowops?orderBy=avg_ms&COLLSCAN=%v&app={{.App}}'>%v</th>`, collscan, desc)
+	html += fmt.Sprintf(`<th>max ms <a class='sort' href='/hatchets/{{.Hatchet}}/stats/slowops?orderBy=max_ms&COLLSCAN=%v&app={{.App}}'>%v</th>`, collscan, desc)
+	html += fmt.Sprintf(`<th>total ms <a class='sort' href='/hatchets/{{.Hatchet}}/stats/slowops?orderBy=total_ms&COLLSCAN=%v&app={{.App}}'>%v</th>`, collscan, desc)
+	html += fmt.Sprintf(`<th>reslen <a class='sort' href='/hatchets/{{.Hatchet}}/stats/slowops?orderBy=reslen&COLLSCAN=%v&app={{.App}}'>%v</th>`, collscan, desc)
 	if download == "" {
 		html += fmt.Sprintf(`<th valign='middle'>index <input type='checkbox' id='collscan' onchange='getSlowopsStats(); return false;' %v></th>`, checked)
 	} else {
@@ -86,6 +100,9 @@ func getStatsTable(collscan bool, orderBy string, download string) string {
 		<tr>
 			<td align='right'>{{ add $n 1 }}</td>
 			<td class='break'>{{ $value.Op }}</td>
+		{{ if eq $.App "all" }}
+			<td class='break'>{{ if $value.AppName }}<a href='/hatchets/{{$.Hatchet}}/charts/ops?type=stats&app={{$value.AppName}}'>{{ $value.AppName }}</a>{{ end }}</td>
+		{{ end }}
 			<td class='break'>{{ $value.Namespace }}</td>
 			<td align='right'>{{ numPrinter $value.Count }}</td>
 			<td align='right'>{{ numPrinter $value.AvgMilli }}</td>
@@ -102,7 +119,11 @@ func getStatsTable(collscan bool, orderBy string, download string) string {
 		{{else}}
 			<td>{{ $value.Index }}</td>
 		{{end}}
-			<td class='break'>{{ $value.QueryPattern }}</td>
+			<td class='break'>{{ $value.QueryPattern }}
+			{{range $rule := $value.Findings}}
+				<a href='/hatchets/{{$.Hatchet}}/stats/findings'><span class='badge' style='background-color: {{getRuleColor $rule}};'>{{$rule}}</span></a>
+			{{end}}
+			</td>
 		</tr>
 {{end}}
 	</table>
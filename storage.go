@@ -0,0 +1,138 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * storage.go
+ */
+
+package hatchet
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	STORAGE_CHECKPOINT = "checkpoint"
+	STORAGE_ERROR      = "error"
+	STORAGE_EVICTION   = "eviction"
+	STORAGE_JOURNAL    = "journal"
+	STORAGE_RECOVERY   = "recovery"
+)
+
+var (
+	wtHeaderRe     = regexp.MustCompile(`^(\[[^\]]*\])+,?\s*`)
+	wtCategoryRe   = regexp.MustCompile(`\[(WT_VERB_\w+)\]\s*`)
+	wtCheckpointRe = regexp.MustCompile(`(?i)checkpoint ran for (\d+) seconds`)
+	digitsRe       = regexp.MustCompile(`\d+`)
+)
+
+// StorageEvent stores a classified WiredTiger or storage engine message
+type StorageEvent struct {
+	Category string `json:"category"`
+	Detail   string `json:"detail"`
+	Milli    int    `json:"duration_ms"`
+	Type     string `json:"type"`
+}
+
+// StorageWarning stores counts of similar cache pressure, journal and error messages
+type StorageWarning struct {
+	Count   int    `json:"count"`
+	Message string `json:"message"`
+	Type    string `json:"type"`
+}
+
+// StorageStats stores storage engine summaries
+type StorageStats struct {
+	Checkpoints []NameValue      `json:"checkpoints"` // counts, average and max durations
+	Events      []NameValue      `json:"events"`
+	Warnings    []StorageWarning `json:"warnings"`
+}
+
+// AnalyzeStorageEvent classifies STORAGE and WiredTiger (WT*) logs into checkpoints, cache eviction,
+// journal, recovery and errors
+func AnalyzeStorageEvent(doc *Logv2Info) (*StorageEvent, error) {
+	if doc.Component != "STORAGE" && !strings.HasPrefix(doc.Component, "WT") {
+		return nil, errors.New("not a storage log")
+	}
+	attrMap := doc.Attr.Map()
+	event := &StorageEvent{}
+	text := doc.Msg
+	if attrMap["message"] != nil {
+		event.Category, text = getWiredTigerMessage(attrMap["message"])
+	}
+	lower := strings.ToLower(text)
+	if matches := wtCheckpointRe.FindStringSubmatch(text); matches != nil {
+		event.Type = STORAGE_CHECKPOINT
+		event.Milli = ToInt(matches[1]) * 1000
+	} else if doc.Msg == "WiredTiger error" || strings.Contains(text, "__wt_") {
+		event.Type = STORAGE_ERROR
+	} else if doc.Component == "WTEVICT" || strings.HasPrefix(event.Category, "WT_VERB_EVICT") ||
+		strings.Contains(lower, "evict") || strings.Contains(lower, "cache stuck") || strings.Contains(lower, "cache full") {
+		event.Type = STORAGE_EVICTION
+	} else if doc.Severity != "I" && (doc.Context == "WTJournalFlusher" || doc.Component == "WTWRTLOG" ||
+		strings.Contains(lower, "journal")) {
+		event.Type = STORAGE_JOURNAL
+	} else if doc.Component == "WTRECOV" || strings.HasPrefix(event.Category, "WT_VERB_RECOVERY") ||
+		strings.HasPrefix(lower, "recovering log") {
+		event.Type = STORAGE_RECOVERY
+	} else if doc.Severity != "I" && !strings.HasPrefix(doc.Severity, "D") && strings.HasPrefix(doc.Msg, "WiredTiger") {
+		event.Type = STORAGE_ERROR
+	} else {
+		return nil, errors.New("not a storage event")
+	}
+	event.Detail = text
+	return event, nil
+}
+
+// getWiredTigerMessage returns the verbose category and the message without headers, messages
+// are strings before 6.0 and documents afterward
+func getWiredTigerMessage(message interface{}) (string, string) {
+	switch m := message.(type) {
+	case string:
+		text := wtHeaderRe.ReplaceAllString(m, "")
+		category := ""
+		if matches := wtCategoryRe.FindStringSubmatch(text); matches != nil {
+			category = matches[1]
+			text = wtCategoryRe.ReplaceAllString(text, "")
+		}
+		return category, text
+	case bson.D:
+		doc := m.Map()
+		category, _ := doc["category"].(string)
+		text, _ := doc["msg"].(string)
+		return category, text
+	}
+	return "", ""
+}
+
+// getStorageWarnings returns counts of storage warnings of similar messages, sorted by counts
+func getStorageWarnings(events []StorageEvent) []StorageWarning {
+	docs := []StorageWarning{}
+	warnings := map[string]*StorageWarning{}
+	for _, event := range events {
+		pattern := getMessagePattern(event.Detail)
+		key := event.Type + "/" + pattern
+		if warnings[key] == nil {
+			warnings[key] = &StorageWarning{Message: pattern, Type: event.Type}
+		}
+		warnings[key].Count++
+	}
+	for _, warning := range warnings {
+		docs = append(docs, *warning)
+	}
+	sort.Slice(docs, func(i, j int) bool {
+		if docs[i].Count == docs[j].Count {
+			return docs[i].Message < docs[j].Message
+		}
+		return docs[i].Count > docs[j].Count
+	})
+	return docs
+}
+
+// getMessagePattern replaces numbers of a message to group similar messages
+func getMessagePattern(message string) string {
+	return digitsRe.ReplaceAllString(message, "N")
+}
@@ -0,0 +1,45 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * storage_test.go
+ */
+
+package hatchet
+
+import (
+	"testing"
+
+	"github.com/simagix/gox"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestAnalyzeStorageEvent(t *testing.T) {
+	strs := []string{
+		`{"t":{"$date":"2023-03-01T11:34:30.000+00:00"},"s":"I",  "c":"STORAGE",  "id":22430,   "ctx":"WTCheckpointThread","msg":"WiredTiger message","attr":{"message":"[1677670470:123456][1234:0x7f0000000000], WT_SESSION.checkpoint: [WT_VERB_CHECKPOINT_PROGRESS] Checkpoint ran for 50 seconds and wrote: 34500 pages (1800 MB)"}}`,
+		`{"t":{"$date":"2023-03-01T11:35:30.000+00:00"},"s":"I",  "c":"WTCHKPT",  "id":22430,   "ctx":"Checkpointer","msg":"WiredTiger message","attr":{"message":{"ts_sec":1677670530,"ts_usec":0,"thread":"1234:0x7f","session_name":"WT_SESSION.checkpoint","category":"WT_VERB_CHECKPOINT_PROGRESS","category_id":6,"verbose_level":"DEBUG_1","verbose_level_id":1,"msg":"Checkpoint ran for 12 seconds and wrote: 500 pages (40 MB)"}}}`,
+		`{"t":{"$date":"2023-03-01T11:35:40.000+00:00"},"s":"W",  "c":"STORAGE",  "id":22430,   "ctx":"conn12","msg":"WiredTiger message","attr":{"message":"[1677670540:1][1234:0x7f], eviction-server: cache stuck for too long, giving up"}}`,
+		`{"t":{"$date":"2023-03-01T11:35:50.000+00:00"},"s":"E",  "c":"STORAGE",  "id":22435,   "ctx":"conn14","msg":"WiredTiger error","attr":{"error":-31802,"message":"[1677670550:1][1234:0x7f], file:collection-2.wt, WT_CURSOR.search: __wt_block_read_off, 283: collection-2.wt: read checksum error for 4096B block at offset 8192"}}`,
+	}
+	expected := []StorageEvent{
+		{Category: "WT_VERB_CHECKPOINT_PROGRESS", Milli: 50000, Type: STORAGE_CHECKPOINT},
+		{Category: "WT_VERB_CHECKPOINT_PROGRESS", Milli: 12000, Type: STORAGE_CHECKPOINT},
+		{Type: STORAGE_EVICTION, Detail: "eviction-server: cache stuck for too long, giving up"},
+		{Type: STORAGE_ERROR},
+	}
+	for i, str := range strs {
+		doc := Logv2Info{}
+		if err := bson.UnmarshalExtJSON([]byte(str), false, &doc); err != nil {
+			t.Fatalf("bson unmarshal error %v", err)
+		}
+		event, err := AnalyzeStorageEvent(&doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if event.Type != expected[i].Type || event.Milli != expected[i].Milli || event.Category != expected[i].Category ||
+			(expected[i].Detail != "" && event.Detail != expected[i].Detail) {
+			t.Fatal("expected", gox.Stringify(expected[i]), "but got", gox.Stringify(event))
+		}
+	}
+	if pattern := getMessagePattern("collection-2.wt: read checksum error at offset 8192"); pattern != "collection-N.wt: read checksum error at offset N" {
+		t.Fatal("unexpected", pattern)
+	}
+}
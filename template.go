@@ -128,6 +128,14 @@ const headers = `<!DOCTYPE html>
       font-size: 1em;
       border-radius: .25em;
     }
+    .badge {
+      color: #fff;
+      font-size: .8em;
+      white-space: nowrap;
+      padding: 1px 5px;
+      margin: 2px 2px;
+      border-radius: .5em;
+    }
     .tooltip {
       position: relative;
       display: inline-block;
@@ -240,6 +248,15 @@ func getContentHTML() string {
 		}
 		window.location.href = value
 	}
+
+	function gotoReport() {
+		var sel = document.getElementById('nextReport')
+		var value = sel.options[sel.selectedIndex].value;
+		if(value == "") {
+			return;
+		}
+		window.location.href = value
+	}
 </script>
 <div align='center'>
 	<div style="float: left; margin-right: 10px;"><button id="title" onClick="javascript:location.href='/'; return false;"
@@ -254,20 +271,16 @@ func getContentHTML() string {
   <div style="float: left; margin-right: 10px;"><button id="search" onClick="javascript:location.href='/hatchets/{{.Hatchet}}/logs/all?component=NONE'; return false;"
     class="btn"><i class="fa fa-search"></i></button>Search</div>
 
-	<select id='nextChart' style="float: right;" onchange='gotoChart()'>`
-	items := []Chart{}
-	for _, chart := range charts {
-		items = append(items, chart)
+	<select id='nextReport' style="float: left;" onchange='gotoReport()'>`
+	html += "<option value=''>select a report</option>"
+	for _, item := range getSortedCharts(reports) {
+		html += fmt.Sprintf("<option value='/hatchets/{{.Hatchet}}%v'>%v</option>", item.URL, item.Title)
 	}
-	sort.Slice(items, func(i int, j int) bool {
-		return items[i].Index < items[j].Index
-	})
+	html += `</select>
 
+	<select id='nextChart' style="float: right;" onchange='gotoChart()'>`
 	html += "<option value=''>select a chart</option>"
-	for i, item := range items {
-		if i == 0 {
-			continue
-		}
+	for _, item := range getSortedCharts(charts) {
 		html += fmt.Sprintf("<option value='/hatchets/{{.Hatchet}}/charts%v'>%v</option>", item.URL, item.Title)
 	}
 
@@ -282,8 +295,8 @@ func getContentHTML() string {
 	}
 
 	function refreshChart() {
-		var sd = document.getElementById('start').value;
-		var ed = document.getElementById('end').value;
+		var sd = document.getElementById('start').value.substring(0, 16); // whole minutes of rollups
+		var ed = document.getElementById('end').value.substring(0, 16);
 		window.location.href = '/hatchets/{{.Hatchet}}/charts{{.Chart.URL}}&duration=' + sd + ',' + ed;
 	}
 </script>
@@ -292,6 +305,21 @@ func getContentHTML() string {
 	return html
 }
 
+// getSortedCharts returns charts sorted by index without the instruction
+func getSortedCharts(items map[string]Chart) []Chart {
+	sorted := []Chart{}
+	for _, item := range items {
+		if item.Index == 0 {
+			continue
+		}
+		sorted = append(sorted, item)
+	}
+	sort.Slice(sorted, func(i int, j int) bool {
+		return sorted[i].Index < sorted[j].Index
+	})
+	return sorted
+}
+
 func getMainPage() string {
 	template := `
 <script>
@@ -303,6 +331,74 @@ func getMainPage() string {
 		}
 		window.location.href='/hatchets/' + value + '/stats/audit'
 	} 
+
+	function callHatchetAPI(method, url) {
+		fetch(url, {method: method})
+			.then(res => res.json())
+			.then(doc => {
+				if(doc.ok != 1) {
+					alert(doc.error);
+				}
+				window.location.reload();
+			})
+			.catch(err => alert(err));
+	}
+
+	function renameHatchet(name) {
+		var value = prompt('rename hatchet ' + name + ' to', name);
+		if(value == null || value == '' || value == name) {
+			return;
+		}
+		callHatchetAPI('PATCH', '/api/hatchet/v1.0/hatchets/' + name + '?name=' + encodeURIComponent(value));
+	}
+
+	function pinHatchet(name, pinned) {
+		callHatchetAPI('PATCH', '/api/hatchet/v1.0/hatchets/' + name + '?pinned=' + pinned);
+	}
+
+	function deleteHatchet(name) {
+		if(confirm('delete hatchet ' + name + '?')) {
+			callHatchetAPI('DELETE', '/api/hatchet/v1.0/hatchets/' + name);
+		}
+	}
+
+	function vacuum() {
+		callHatchetAPI('POST', '/api/hatchet/v1.0/vacuum');
+	}
+
+	function formatBytes(size) {
+		var units = ['B', 'KB', 'MB', 'GB', 'TB'];
+		var i = 0;
+		while(size >= 1024 && i < units.length - 1) {
+			size /= 1024;
+			i++;
+		}
+		return (i == 0 ? size : size.toFixed(1)) + ' ' + units[i];
+	}
+
+	function loadHatchets() {
+		fetch('/api/hatchet/v1.0/hatchets')
+			.then(res => res.json())
+			.then(doc => {
+				var table = document.getElementById('hatchets');
+				(doc.hatchets || []).forEach(usage => {
+					var row = table.insertRow();
+					[usage.Name, usage.Start, usage.End, usage.Version, formatBytes(usage.Size), usage.Analyzed, usage.Status].forEach(value => {
+						row.insertCell().textContent = value;
+					});
+					var cell = row.insertCell();
+					cell.align = 'center';
+					cell.innerHTML = "<button class='btn' title='" + (usage.Pinned ? "unpin" : "pin") + "'>" +
+						"<i class='fa " + (usage.Pinned ? "fa-lock" : "fa-unlock") + "'></i></button>" +
+						"<button class='btn' title='rename'><i class='fa fa-pencil'></i></button>" +
+						"<button class='btn' title='delete'><i class='fa fa-trash'></i></button>";
+					cell.children[0].onclick = () => pinHatchet(usage.Name, !usage.Pinned);
+					cell.children[1].onclick = () => renameHatchet(usage.Name);
+					cell.children[2].onclick = () => deleteHatchet(usage.Name);
+				});
+			});
+	}
+	window.addEventListener('load', loadHatchets);
 </script>
 
 <div align='center'>
@@ -310,11 +406,20 @@ func getMainPage() string {
 	<select id='table' class='hatchet-sel' onchange='redirect()'>
 		<option value=''>select a hatchet</option>
 {{range $n, $value := .Hatchets}}
+	{{- $status := index $.Statuses $value}}
+	{{- if $status}}
+		<option value='{{$value}}' disabled>{{$value}} ({{$status}})</option>
+	{{- else}}
 		<option value='{{$value}}'>{{$value}}</option>
+	{{- end}}
 {{end}}
 	</select>
 </div>
 <hr/>
+<h3>Hatchets <button class='button' onclick='vacuum()'>Vacuum</button></h3>
+    <table id='hatchets' width='100%'>
+      <tr><th>Hatchet</th><th>Start</th><th>End</th><th>Version</th><th>Disk Usage</th><th>Analyzed</th><th>Status</th><th></th></tr>
+    </table>
 <h3>Reports</h3>
     <table width='100%'>
       <tr><th></th><th>Title</th><th>Description</th></tr>
@@ -322,7 +427,12 @@ func getMainPage() string {
       <tr><td align=center><i class="fa fa-bar-chart"></i></td><td>Charts</td><td>A number of charts are available for security audits and performance metrics</td></tr>
       <tr><td align=center><i class="fa fa-search"></i></td><td>Search</td><td>Powerful log searching function with key metrics highlighted</td></tr>
       <tr><td align=center><i class="fa fa-info"></i></td><td>Stats</td><td>Summary of slow operational query patterns and duration</td></tr>
-      <tr><td align=center><i class="fa fa-list"></i></td><td>TopN</td><td>Display the slowest 23 operation logs</td></tr>
+      <tr><td align=center><i class="fa fa-list"></i></td><td>TopN</td><td>Display the slowest 23 operation logs</td></tr>`
+	for _, report := range getSortedCharts(reports) {
+		template += fmt.Sprintf("<tr><td align=center><i class=\"fa fa-file-text-o\"></i></td><td>%v</td><td>%v</td></tr>\n",
+			report.Title, report.Descr)
+	}
+	template += `
     </table>
 <h3>Charts</h3>
     <table width='100%'>
@@ -344,17 +454,45 @@ func getMainPage() string {
 <ul class="api">
 	<li>/</li>
 	<li>/hatchets/{hatchet}/charts/{chart}[?type={str}]</li>
-	<li>/hatchets/{hatchet}/logs/all[?component={str}&context={str}&duration={date},{date}&severity={str}&limit=[{offset},]{int}]</li>
+	<li>/hatchets/{hatchet}/logs/all[?attr.{path}{op}{value}&component={str}&context={str}&duration={date},{date}&errcode={int}&severity={str}&txn={lsid},{txnNumber}&limit=[{offset},]{int}]</li>
 	<li>/hatchets/{hatchet}/logs/slowops[?topN={int}]</li>
-	<li>/hatchets/{hatchet}/stats/slowops[?COLLSCAN={bool}&orderBy={str}]</li>
+	<li>/hatchets/{hatchet}/stats/concerns[?topN={int}]</li>
+	<li>/hatchets/{hatchet}/stats/connections[?duration={date},{date}]</li>
+	<li>/hatchets/{hatchet}/stats/cursors[?topN={int}]</li>
+	<li>/hatchets/{hatchet}/stats/drivers</li>
+	<li>/hatchets/{hatchet}/stats/errors</li>
+	<li>/hatchets/{hatchet}/stats/findings</li>
+	<li>/hatchets/{hatchet}/stats/index-builds[?duration={date},{date}]</li>
+	<li>/hatchets/{hatchet}/stats/replication</li>
+	<li>/hatchets/{hatchet}/stats/sharding</li>
+	<li>/hatchets/{hatchet}/stats/slowops[?app={str}&COLLSCAN={bool}&orderBy={str}]</li>
+	<li>/hatchets/{hatchet}/stats/transactions[?topN={int}]</li>
+	<li>/hatchets/{hatchet}/stats/uptime[?id={int}]</li>
 </ul>
 
 <h3>API</h3>
 <ul class="api">
-	<li>/api/hatchet/v1.0/hatchets/{hatchet}/logs/all[?component={str}&context={str}&duration={date},{date}&severity={str}&limit=[{offset},]{int}]</li>
+	<li>GET /api/hatchet/v1.0/hatchets</li>
+	<li>GET /api/hatchet/v1.0/hatchets/{hatchet}</li>
+	<li>DELETE /api/hatchet/v1.0/hatchets/{hatchet}</li>
+	<li>PATCH /api/hatchet/v1.0/hatchets/{hatchet}?name={str}</li>
+	<li>PATCH /api/hatchet/v1.0/hatchets/{hatchet}?pinned={bool}</li>
+	<li>POST /api/hatchet/v1.0/vacuum</li>
+	<li>/api/hatchet/v1.0/hatchets/{hatchet}/logs/all[?attr.{path}{op}{value}&component={str}&context={str}&duration={date},{date}&errcode={int}&severity={str}&txn={lsid},{txnNumber}&limit=[{offset},]{int}]</li>
 	<li>/api/hatchet/v1.0/hatchets/{hatchet}/logs/slowops[?topN={int}]</li>
 	<li>/api/hatchet/v1.0/hatchets/{hatchet}/stats/audit</li>
-	<li>/api/hatchet/v1.0/hatchets/{hatchet}/stats/slowops[?COLLSCAN={bool}&orderBy={str}]</li>
+	<li>/api/hatchet/v1.0/hatchets/{hatchet}/stats/concerns[?topN={int}]</li>
+	<li>/api/hatchet/v1.0/hatchets/{hatchet}/stats/connections[?duration={date},{date}]</li>
+	<li>/api/hatchet/v1.0/hatchets/{hatchet}/stats/cursors[?topN={int}]</li>
+	<li>/api/hatchet/v1.0/hatchets/{hatchet}/stats/drivers</li>
+	<li>/api/hatchet/v1.0/hatchets/{hatchet}/stats/errors</li>
+	<li>/api/hatchet/v1.0/hatchets/{hatchet}/stats/findings</li>
+	<li>/api/hatchet/v1.0/hatchets/{hatchet}/stats/index-builds[?duration={date},{date}]</li>
+	<li>/api/hatchet/v1.0/hatchets/{hatchet}/stats/replication</li>
+	<li>/api/hatchet/v1.0/hatchets/{hatchet}/stats/sharding</li>
+	<li>/api/hatchet/v1.0/hatchets/{hatchet}/stats/slowops[?app={str}&COLLSCAN={bool}&orderBy={str}]</li>
+	<li>/api/hatchet/v1.0/hatchets/{hatchet}/stats/transactions[?topN={int}]</li>
+	<li>/api/hatchet/v1.0/hatchets/{hatchet}/stats/uptime[?id={int}]</li>
 </ul>
 <h4 align='center'><hr/>{{.Version}}</h4>
 `
@@ -0,0 +1,128 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * transactions.go
+ */
+
+package hatchet
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	TXN_ABORTED   = "aborted"
+	TXN_COMMITTED = "committed"
+)
+
+// Transaction stores a multi-document transaction
+type Transaction struct {
+	AbortCause    string `json:"abort_cause"`
+	Date          string `json:"date"`
+	DocsExamined  int    `json:"docs_examined"`
+	KeysExamined  int    `json:"keys_examined"`
+	LSID          string `json:"lsid"`
+	Milli         int    `json:"duration_ms"`
+	NDeleted      int    `json:"ndeleted"`
+	NInserted     int    `json:"ninserted"`
+	NModified     int    `json:"nmodified"`
+	NReturned     int    `json:"nreturned"`
+	NumYields     int    `json:"num_yields"`
+	ReadConcern   string `json:"read_concern"`
+	ReadTimestamp string `json:"read_timestamp"`
+	Termination   string `json:"termination_cause"` // committed or aborted
+	TimeActive    int    `json:"time_active_micros"`
+	TimeInactive  int    `json:"time_inactive_micros"`
+	TxnNumber     int    `json:"txn_number"`
+	WasPrepared   bool   `json:"was_prepared"`
+}
+
+// AnalyzeTransaction analyzes slow transaction logs
+func AnalyzeTransaction(doc *Logv2Info) (*Transaction, error) {
+	if doc.Component != "TXN" || doc.Msg != "transaction" {
+		return nil, errors.New("not a transaction")
+	}
+	attrMap := doc.Attr.Map()
+	txn := &Transaction{}
+	if params, ok := attrMap["parameters"].(bson.D); ok {
+		paramsMap := params.Map()
+		if lsid, ok := paramsMap["lsid"].(bson.D); ok {
+			txn.LSID = getUUIDString(lsid.Map()["id"])
+		}
+		txn.TxnNumber = ToInt(paramsMap["txnNumber"])
+		if readConcern, ok := paramsMap["readConcern"].(bson.D); ok {
+			txn.ReadConcern, _ = readConcern.Map()["level"].(string)
+		}
+	}
+	if txn.LSID == "" {
+		return nil, errors.New("no lsid found")
+	}
+	txn.Termination, _ = attrMap["terminationCause"].(string)
+	txn.ReadTimestamp, _ = attrMap["readTimestamp"].(string)
+	txn.WasPrepared, _ = attrMap["wasPrepared"].(bool)
+	txn.TimeActive = ToInt(attrMap["timeActiveMicros"])
+	txn.TimeInactive = ToInt(attrMap["timeInactiveMicros"])
+	txn.NumYields = ToInt(attrMap["numYields"])
+	txn.Milli = ToInt(attrMap["durationMillis"])
+	txn.KeysExamined = ToInt(attrMap["keysExamined"])
+	txn.DocsExamined = ToInt(attrMap["docsExamined"])
+	txn.NReturned = ToInt(attrMap["nreturned"])
+	txn.NInserted = ToInt(attrMap["ninserted"])
+	txn.NModified = ToInt(attrMap["nModified"])
+	txn.NDeleted = ToInt(attrMap["ndeleted"])
+	if txn.Termination == TXN_ABORTED {
+		for _, key := range []string{"abortCause", "errName", "errCodeName", "errMsg"} {
+			if cause, ok := attrMap[key].(string); ok && cause != "" {
+				txn.AbortCause = cause
+				break
+			}
+		}
+		if txn.AbortCause == "" {
+			txn.AbortCause = "unknown"
+		}
+	}
+	return txn, nil
+}
+
+// GetTxnKey returns the lsid and txnNumber of a log of a transaction, i.e. an operation in a transaction or
+// its termination, false if the log is not of a transaction
+func GetTxnKey(doc *Logv2Info) (string, int, bool) {
+	attrMap := doc.Attr.Map()
+	for _, key := range []string{"command", "parameters"} {
+		params, ok := attrMap[key].(bson.D)
+		if !ok {
+			continue
+		}
+		paramsMap := params.Map()
+		lsid, ok := paramsMap["lsid"].(bson.D)
+		if !ok || paramsMap["txnNumber"] == nil {
+			continue
+		}
+		if id := getUUIDString(lsid.Map()["id"]); id != "" {
+			return id, ToInt(paramsMap["txnNumber"]), true
+		}
+	}
+	return "", 0, false
+}
+
+// ParseTxnKey parses {lsid},{txnNumber} into its lsid and txnNumber, returns an InvalidInputError if the
+// key is malformed
+func ParseTxnKey(key string) (string, int, error) {
+	toks := strings.Split(key, ",")
+	if len(toks) != 2 {
+		return "", 0, NewInvalidInputError("invalid transaction key %v", key)
+	}
+	lsid := strings.ToLower(toks[0])
+	if !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`).MatchString(lsid) {
+		return "", 0, NewInvalidInputError("invalid lsid %v", toks[0])
+	}
+	txnNumber, err := strconv.Atoi(toks[1])
+	if err != nil {
+		return "", 0, NewInvalidInputError("invalid txnNumber %v", toks[1])
+	}
+	return lsid, txnNumber, nil
+}
@@ -0,0 +1,139 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * transactions_template.go
+ */
+
+package hatchet
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// GetTransactionsTemplate returns HTML
+func GetTransactionsTemplate() (*template.Template, error) {
+	html := getContentHTML()
+	html += `{{$name := .Hatchet}}
+<script>
+	document.getElementById('nextReport').selectedIndex = {{.Report.Index}};
+</script>
+{{if hasData .Data "txn"}}
+	<table style='float: left; margin: 10px 10px; clear: left;'>
+		<caption><span style="font-size: 16px; padding: 5px 5px;"><i class="fa fa-exchange"></i></span>Transactions</caption>
+		<tr><th></th><th>Termination Cause</th><th>Total</th></tr>
+	{{range $n, $val := index .Data "txn"}}
+		<tr><td align=right>{{add $n 1}}</td><td>{{$val.Name}}</td>
+			<td align=right>{{getFormattedNumber $val.Values 0}}</td></tr>
+	{{end}}
+	</table>
+{{end}}
+
+{{if hasData .Data "txn-abort"}}
+	<table style='float: left; margin: 10px 10px;'>
+		<caption><span style="font-size: 16px; padding: 5px 5px;"><i class="fa fa-ban"></i></span>Abort Causes</caption>
+		<tr><th></th><th>Cause</th><th>Total</th></tr>
+	{{range $n, $val := index .Data "txn-abort"}}
+		<tr><td align=right>{{add $n 1}}</td><td>{{$val.Name}}</td>
+			<td align=right>{{getFormattedNumber $val.Values 0}}</td></tr>
+	{{end}}
+	</table>
+{{end}}
+
+{{if .Transactions}}
+	<table style='float: left; margin: 10px 10px;'>
+		<caption><span style="font-size: 16px; padding: 5px 5px;"><i class="fa fa-clock-o"></i></span>Active vs Inactive Time</caption>
+		<tr><th></th><th>Duration</th><th>Active</th><th>Inactive</th></tr>
+	{{range $n, $val := index .Data "distribution"}}
+		<tr><td align=right>{{add $n 1}}</td><td>{{$val.Name}}</td>
+			<td align=right>{{getFormattedNumber $val.Values 0}}</td><td align=right>{{getFormattedNumber $val.Values 1}}</td></tr>
+	{{end}}
+	</table>
+	<div id='hatchetChart' style="float: left; width: 50%; margin: 10px 10px;"></div>
+<script>
+	google.charts.load('current', {'packages':['corechart']});
+	google.charts.setOnLoadCallback(drawChart);
+
+	function drawChart() {
+		var data = google.visualization.arrayToDataTable([
+			['Duration', 'Active', 'Inactive'],
+	{{range $i, $v := index .Data "distribution"}}
+			['{{$v.Name}}', {{index $v.Values 0}}, {{index $v.Values 1}}],
+	{{end}}
+		]);
+		var options = {
+			'backgroundColor': { 'fill': 'transparent' },
+			'title': 'Active vs Inactive Time Distribution',
+			'vAxis': {title: 'Count', minValue: 0},
+			'height': 320,
+			'legend': { 'position': 'right' } };
+		var chart = new google.visualization.ColumnChart(document.getElementById('hatchetChart'));
+		chart.draw(data, options);
+	}
+</script>
+
+	<table style='float: left; margin: 10px 10px; clear: left;' width='100%'>
+		<caption><span style="font-size: 16px; padding: 5px 5px;"><i class="fa fa-list"></i></span>Longest Transactions</caption>
+		<tr><th></th><th>date</th><th>lsid</th><th>txnNumber</th><th>termination</th><th>duration</th>
+			<th>active</th><th>inactive</th><th>yields</th><th>keys examined</th><th>docs examined</th>
+			<th>nreturned</th><th>ninserted</th><th>nModified</th><th>ndeleted</th><th>readConcern</th></tr>
+	{{range $n, $val := .Transactions}}
+		<tr><td align=right>{{add $n 1}}</td>
+			<td>{{formatDateTime $val.Date}}</td>
+			<td><button class='btn' onClick="javascript:location.href='/hatchets/{{$name}}/logs/all?txn={{$val.LSID}},{{$val.TxnNumber}}'; return false;">
+				<i class='fa fa-search'></i></button>{{$val.LSID}}</td>
+			<td align=right>{{$val.TxnNumber}}</td>
+		{{if eq $val.Termination "aborted"}}
+			<td><span style='color: red;'>{{$val.Termination}}</span> ({{$val.AbortCause}})</td>
+		{{else}}
+			<td>{{$val.Termination}}</td>
+		{{end}}
+			<td align=right>{{numPrinter $val.Milli}} ms</td>
+			<td align=right>{{getDurationFromMicros $val.TimeActive}}</td>
+			<td align=right>{{getDurationFromMicros $val.TimeInactive}}</td>
+			<td align=right>{{numPrinter $val.NumYields}}</td>
+			<td align=right>{{numPrinter $val.KeysExamined}}</td>
+			<td align=right>{{numPrinter $val.DocsExamined}}</td>
+			<td align=right>{{numPrinter $val.NReturned}}</td>
+			<td align=right>{{numPrinter $val.NInserted}}</td>
+			<td align=right>{{numPrinter $val.NModified}}</td>
+			<td align=right>{{numPrinter $val.NDeleted}}</td>
+			<td>{{$val.ReadConcern}}</td>
+		</tr>
+	{{end}}
+	</table>
+{{else}}
+	<div style='clear: left;' align='center' class='btn'><span style='color: red'>no transactions found</span></div>
+{{end}}
+	<div style='clear: left;' align='center'><hr/><p/>@simagix</div>
+</body></html>`
+	return template.New("hatchet").Funcs(template.FuncMap{
+		"add": func(a int, b int) int {
+			return a + b
+		},
+		"hasData": func(data map[string][]NameValues, key string) bool {
+			return len(data[key]) > 0
+		},
+		"numPrinter": func(n interface{}) string {
+			printer := message.NewPrinter(language.English)
+			return printer.Sprintf("%v", ToInt(n))
+		},
+		"getFormattedNumber": func(numbers []int, i int) string {
+			printer := message.NewPrinter(language.English)
+			return printer.Sprintf("%v", numbers[i])
+		},
+		"getDurationFromMicros": func(micros int) string {
+			if micros < 1000 {
+				return fmt.Sprintf("%d µs", micros)
+			} else if micros < 1000000 {
+				return fmt.Sprintf("%.1f ms", float64(micros)/1000)
+			}
+			return fmt.Sprintf("%.1f s", float64(micros)/1000000)
+		},
+		"formatDateTime": func(str string) string {
+			return strings.Replace(str, "T", " ", 1)
+		}}).Parse(html)
+}
@@ -0,0 +1,144 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * transactions_test.go
+ */
+
+package hatchet
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/simagix/gox"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestAnalyzeTransaction(t *testing.T) {
+	str := `{"t":{"$date":"2023-03-01T10:00:04.000+00:00"},"s":"I",  "c":"TXN",      "id":51802,   "ctx":"conn1","msg":"transaction","attr":{"parameters":{"lsid":{"id":{"$uuid":"9a1f813b-463a-4e7b-b8f8-c587441a9575"},"uid":{"$binary":{"base64":"47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=","subType":"0"}}},"txnNumber":7,"autocommit":false,"readConcern":{"level":"snapshot"}},"readTimestamp":"Timestamp(0, 0)","nModified":2,"ndeleted":1,"terminationCause":"aborted","errName":"WriteConflict","errCode":112,"timeActiveMicros":20000,"timeInactiveMicros":4000000,"numYields":0,"locks":{},"storage":{},"wasPrepared":false,"durationMillis":4020}}`
+	t.Log(str)
+	doc := Logv2Info{}
+	err := bson.UnmarshalExtJSON([]byte(str), false, &doc)
+	if err != nil {
+		t.Fatalf("bson unmarshal error %v", err)
+	}
+
+	txn, err := AnalyzeTransaction(&doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "9a1f813b-463a-4e7b-b8f8-c587441a9575"
+	if txn.LSID != expected {
+		t.Fatal("expected", expected, "but got", txn.LSID)
+	}
+	if txn.TxnNumber != 7 {
+		t.Fatal("expected", 7, "but got", txn.TxnNumber)
+	}
+	if txn.Termination != TXN_ABORTED || txn.AbortCause != "WriteConflict" {
+		t.Fatal("expected", TXN_ABORTED, "WriteConflict", "but got", txn.Termination, txn.AbortCause)
+	}
+	if txn.TimeActive != 20000 || txn.TimeInactive != 4000000 {
+		t.Fatal("expected", 20000, 4000000, "but got", txn.TimeActive, txn.TimeInactive)
+	}
+	t.Log(gox.Stringify(txn, "", "  "))
+}
+
+func TestAnalyzeTransactionNotTxn(t *testing.T) {
+	str := `{"t":{"$date":"2021-07-25T10:10:16.116+00:00"},"s":"I",  "c":"NETWORK",  "id":22943,   "ctx":"listener","msg":"Connection accepted","attr":{"remote":"192.168.240.37:29402","connectionId":1907,"connectionCount":151}}`
+	doc := Logv2Info{}
+	err := bson.UnmarshalExtJSON([]byte(str), false, &doc)
+	if err != nil {
+		t.Fatalf("bson unmarshal error %v", err)
+	}
+	if _, err = AnalyzeTransaction(&doc); err == nil {
+		t.Fatal("expected error but got nil")
+	}
+}
+
+func TestParseTxnKey(t *testing.T) {
+	lsid, txnNumber, err := ParseTxnKey("86CF813B-463a-4e7b-b8f8-c587441a9575,3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lsid != "86cf813b-463a-4e7b-b8f8-c587441a9575" || txnNumber != 3 {
+		t.Fatal("unexpected", lsid, txnNumber)
+	}
+	for _, key := range []string{"", "86cf813b,3", "86cf813b-463a-4e7b-b8f8-c587441a9575' OR 1=1,3",
+		"86cf813b-463a-4e7b-b8f8-c587441a9575,x"} {
+		if _, _, err = ParseTxnKey(key); !IsInvalidInput(err) {
+			t.Fatal("expected invalid input of", key, "but got", err)
+		}
+	}
+}
+
+func TestGetTxnKey(t *testing.T) {
+	tests := []struct {
+		log string
+		ok  bool
+	}{
+		{`{"t":{"$date":"2023-03-01T10:00:03.000+00:00"},"s":"I","c":"COMMAND","id":51803,"ctx":"conn1","msg":"Slow query","attr":{"type":"command","ns":"test.orders","command":{"update":"orders","lsid":{"id":{"$uuid":"9a1f813b-463a-4e7b-b8f8-c587441a9575"}},"txnNumber":7,"autocommit":false},"durationMillis":120}}`, true},
+		{`{"t":{"$date":"2023-03-01T10:00:04.000+00:00"},"s":"I","c":"TXN","id":51802,"ctx":"conn1","msg":"transaction","attr":{"parameters":{"lsid":{"id":{"$uuid":"9a1f813b-463a-4e7b-b8f8-c587441a9575"}},"txnNumber":7,"autocommit":false},"terminationCause":"committed","durationMillis":20}}`, true},
+		{`{"t":{"$date":"2023-03-01T10:00:05.000+00:00"},"s":"I","c":"COMMAND","id":51803,"ctx":"conn1","msg":"Slow query","attr":{"type":"command","ns":"test.orders","command":{"find":"orders","lsid":{"id":{"$uuid":"9a1f813b-463a-4e7b-b8f8-c587441a9575"}}},"durationMillis":120}}`, false},
+	}
+	for _, test := range tests {
+		doc := Logv2Info{}
+		if err := bson.UnmarshalExtJSON([]byte(test.log), false, &doc); err != nil {
+			t.Fatalf("bson unmarshal error %v", err)
+		}
+		lsid, txnNumber, ok := GetTxnKey(&doc)
+		if ok != test.ok {
+			t.Fatal("expected", test.ok, "but got", ok, test.log)
+		} else if ok && (lsid != "9a1f813b-463a-4e7b-b8f8-c587441a9575" || txnNumber != 7) {
+			t.Fatal("unexpected", lsid, txnNumber)
+		}
+	}
+}
+
+func TestGetLogsOfTransaction(t *testing.T) {
+	registerSQLite3Extended()
+	dir := t.TempDir()
+	logs := `{"t":{"$date":"2023-03-01T10:00:00.000+00:00"},"s":"I","c":"CONTROL","id":4615611,"ctx":"initandlisten","msg":"MongoDB starting","attr":{"pid":1234,"port":27017,"dbPath":"/data/db","architecture":"64-bit","host":"host1"}}
+{"t":{"$date":"2023-03-01T10:00:03.000+00:00"},"s":"I","c":"COMMAND","id":51803,"ctx":"conn1","msg":"Slow query","attr":{"type":"command","ns":"test.orders","command":{"update":"orders","lsid":{"id":{"$uuid":"9a1f813b-463a-4e7b-b8f8-c587441a9575"}},"txnNumber":7,"autocommit":false},"durationMillis":120}}
+{"t":{"$date":"2023-03-01T10:00:03.500+00:00"},"s":"I","c":"COMMAND","id":51803,"ctx":"conn2","msg":"Slow query","attr":{"type":"command","ns":"test.orders","command":{"update":"orders","lsid":{"id":{"$uuid":"9a1f813b-463a-4e7b-b8f8-c587441a9575"}},"txnNumber":71,"autocommit":false},"durationMillis":120}}
+{"t":{"$date":"2023-03-01T10:00:04.000+00:00"},"s":"I","c":"TXN","id":51802,"ctx":"conn1","msg":"transaction","attr":{"parameters":{"lsid":{"id":{"$uuid":"9a1f813b-463a-4e7b-b8f8-c587441a9575"}},"txnNumber":7,"autocommit":false},"terminationCause":"committed","durationMillis":20}}
+`
+	filename := filepath.Join(dir, "mongod.log")
+	if err := os.WriteFile(filename, []byte(logs), 0644); err != nil {
+		t.Fatal(err)
+	}
+	opts := Options{DBFile: filepath.Join(dir, "hatchet.db")}
+	logv2 := NewLogv2(opts)
+	logv2.testing = true
+	if err := logv2.Analyze(filename); err != nil {
+		t.Fatal(err)
+	}
+	usages, err := GetHatchetUsages(opts)
+	if err != nil || len(usages) != 1 {
+		t.Fatal("expected", 1, "but got", usages, err)
+	}
+	dbase, err := GetDatabase(opts, usages[0].Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dbase.Close()
+	docs, err := dbase.GetLogs("txn=9a1f813b-463a-4e7b-b8f8-c587441a9575,7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 2 || docs[0].Context != "conn1" || docs[1].Component != "TXN" {
+		t.Fatal("expected", 2, "logs of conn1", "but got", docs)
+	}
+
+	// hatchets analyzed before logs were linked match messages
+	pool, err := GetSQLite3Pool(opts.DBFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = pool.Exec(fmt.Sprintf("DROP TABLE %v_txn_logs", usages[0].Name)); err != nil {
+		t.Fatal(err)
+	}
+	if docs, err = dbase.GetLogs("txn=9a1f813b-463a-4e7b-b8f8-c587441a9575,7"); err != nil || len(docs) != 2 {
+		t.Fatal("expected", 2, "but got", docs, err)
+	}
+}
@@ -0,0 +1,117 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * uptime.go
+ */
+
+package hatchet
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	UPTIME_CRASHED  = "crashed"
+	UPTIME_RUNNING  = "running"
+	UPTIME_SHUTDOWN = "shutdown"
+	UPTIME_UNCLEAN  = "unclean"
+
+	CRASH_PRECEDING_LINES = 50  // lines before a crash to display
+	CRASH_TRACE_LINES     = 200 // max lines of a stack trace to display
+	MAX_REASON_LENGTH     = 256
+)
+
+// UptimeSegment stores a process lifetime from start to shutdown, crash or restart
+type UptimeSegment struct {
+	CrashID int    `json:"crash_id"` // log line of the first fatal message
+	End     string `json:"end"`
+	EndID   int    `json:"end_id"` // last log line of the process
+	Host    string `json:"host"`
+	ID      int    `json:"id"` // log line of process started
+	PID     int    `json:"pid"`
+	Port    int    `json:"port"`
+	Reason  string `json:"reason"`
+	Start   string `json:"start"`
+	Status  string `json:"status"`
+}
+
+// CrashDetail stores logs before and after a crash or an unclean shutdown
+type CrashDetail struct {
+	Preceding  []LegacyLog   `json:"preceding"`
+	Segment    UptimeSegment `json:"segment"`
+	StackTrace []LegacyLog   `json:"stack_trace"`
+}
+
+// UptimeTracker splits logs into uptime segments by process starts
+type UptimeTracker struct {
+	segment *UptimeSegment
+}
+
+// NewUptimeTracker returns an UptimeTracker
+func NewUptimeTracker() *UptimeTracker {
+	return &UptimeTracker{}
+}
+
+// Analyze tracks a log and returns the previous segment when a process starts
+func (ptr *UptimeTracker) Analyze(index int, doc *Logv2Info) []*UptimeSegment {
+	segments := []*UptimeSegment{}
+	date := getDateTimeStr(doc.Timestamp)
+	attrMap := doc.Attr.Map()
+	if doc.Component == "CONTROL" && doc.Msg == "MongoDB starting" {
+		if ptr.segment != nil {
+			if ptr.segment.Status == UPTIME_RUNNING {
+				ptr.segment.Status = UPTIME_UNCLEAN
+				ptr.segment.Reason = "restarted without a shutdown"
+			}
+			segments = append(segments, ptr.segment)
+		}
+		ptr.segment = &UptimeSegment{ID: index, PID: ToInt(attrMap["pid"]), Port: ToInt(attrMap["port"]),
+			Start: date, Status: UPTIME_RUNNING}
+		ptr.segment.Host, _ = attrMap["host"].(string)
+	} else if ptr.segment == nil { // logs began after the process started
+		ptr.segment = &UptimeSegment{ID: index, Start: date, Status: UPTIME_RUNNING}
+	}
+	segment := ptr.segment
+	segment.End = date
+	segment.EndID = index
+	if segment.Status == UPTIME_CRASHED || segment.Status == UPTIME_SHUTDOWN {
+		return segments
+	}
+	if isFatal(doc) {
+		segment.Status = UPTIME_CRASHED
+		segment.CrashID = index
+		segment.Reason = doc.Msg
+		if doc.Message != "" {
+			segment.Reason = doc.Message
+		}
+		if len(segment.Reason) > MAX_REASON_LENGTH {
+			segment.Reason = segment.Reason[:MAX_REASON_LENGTH] + "..."
+		}
+	} else if doc.Component == "CONTROL" && doc.Msg == "Shutting down" && attrMap["exitCode"] != nil {
+		if code := ToInt(attrMap["exitCode"]); code == 0 {
+			segment.Status = UPTIME_SHUTDOWN
+		} else {
+			segment.Status = UPTIME_CRASHED
+			segment.CrashID = index
+			segment.Reason = fmt.Sprintf("exit code %d", code)
+		}
+	} else if doc.Component == "CONTROL" && doc.Msg == "Now exiting" {
+		segment.Status = UPTIME_SHUTDOWN
+	}
+	return segments
+}
+
+// Close returns the last segment
+func (ptr *UptimeTracker) Close() []*UptimeSegment {
+	if ptr.segment == nil {
+		return []*UptimeSegment{}
+	}
+	segment := ptr.segment
+	ptr.segment = nil
+	return []*UptimeSegment{segment}
+}
+
+// isFatal returns true if a log is a fatal assertion, an invariant failure or a backtrace
+func isFatal(doc *Logv2Info) bool {
+	return doc.Severity == "F" || doc.Msg == "BACKTRACE" || strings.HasPrefix(doc.Msg, "Invariant failure")
+}
@@ -0,0 +1,106 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * uptime_template.go
+ */
+
+package hatchet
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+)
+
+// GetUptimeTemplate returns HTML of process restarts and crashes, or the detail of a crash
+func GetUptimeTemplate() (*template.Template, error) {
+	html := getContentHTML()
+	html += `{{$name := .Hatchet}}
+<script>
+	document.getElementById('nextReport').selectedIndex = {{.Report.Index}};
+</script>
+{{if .Detail}}
+	{{$seg := .Detail.Segment}}
+	<div style='clear: left; margin: 10px 10px;'>
+		<button class='button' onClick="javascript:location.href='/hatchets/{{$name}}/stats/uptime'; return false;">
+			<i class='fa fa-arrow-left'></i> Restarts and Crashes</button>
+	</div>
+	<table style='float: left; margin: 10px 10px; clear: left;'>
+		<caption><span style="font-size: 16px; padding: 5px 5px;"><i class="fa fa-bolt"></i></span>Process {{$seg.PID}}</caption>
+		<tr><td>Host</td><td>{{$seg.Host}}:{{$seg.Port}}</td></tr>
+		<tr><td>Started</td><td>{{formatDateTime $seg.Start}}</td></tr>
+		<tr><td>Last Log</td><td>{{formatDateTime $seg.End}}</td></tr>
+		<tr><td>Status</td><td><span style='color: {{getStatusColor $seg.Status}};'>{{$seg.Status}}</span></td></tr>
+		<tr><td>Reason</td><td>{{$seg.Reason}}</td></tr>
+	</table>
+	{{if .Detail.StackTrace}}
+	<table style='float: left; margin: 10px 10px; clear: left; width: 95%;'>
+		<caption><span style="font-size: 16px; padding: 5px 5px;"><i class="fa fa-bug"></i></span>Stack Trace</caption>
+		<tr><th>Date</th><th>S</th><th>Component</th><th>Context</th><th>Message</th></tr>
+		{{range $n, $val := .Detail.StackTrace}}
+		<tr><td class='break'>{{$val.Timestamp}}</td><td>{{$val.Severity}}</td><td>{{$val.Component}}</td>
+			<td>{{$val.Context}}</td><td class='break'>{{$val.Message}}</td></tr>
+		{{end}}
+	</table>
+	{{end}}
+	<table style='float: left; margin: 10px 10px; clear: left; width: 95%;'>
+		<caption><span style="font-size: 16px; padding: 5px 5px;"><i class="fa fa-list"></i></span>Preceding Logs</caption>
+		<tr><th>Date</th><th>S</th><th>Component</th><th>Context</th><th>Message</th></tr>
+		{{range $n, $val := .Detail.Preceding}}
+		<tr><td class='break'>{{$val.Timestamp}}</td><td>{{$val.Severity}}</td><td>{{$val.Component}}</td>
+			<td>{{$val.Context}}</td><td class='break'>{{$val.Message}}</td></tr>
+		{{end}}
+	</table>
+{{else if .Segments}}
+	<table style='float: left; margin: 10px 10px; clear: left;'>
+		<caption><span style="font-size: 16px; padding: 5px 5px;"><i class="fa fa-power-off"></i></span>Restarts and Crashes</caption>
+		<tr><th></th><th>PID</th><th>Host</th><th>Started</th><th>Last Log</th><th>Uptime</th><th>Status</th><th>Reason</th></tr>
+	{{range $n, $val := .Segments}}
+		<tr><td align=right>{{add $n 1}}</td>
+			<td align=right>{{$val.PID}}</td>
+			<td>{{$val.Host}}{{if $val.Port}}:{{$val.Port}}{{end}}</td>
+			<td><button class='btn' onClick="javascript:location.href='/hatchets/{{$name}}/logs/all?duration={{$val.Start}},{{$val.End}}'; return false;">
+				<i class='fa fa-search'></i></button>{{formatDateTime $val.Start}}</td>
+			<td>{{formatDateTime $val.End}}</td>
+			<td align=right>{{getUptime $val}}</td>
+		{{if or (eq $val.Status "crashed") (eq $val.Status "unclean")}}
+			<td><button class='btn' onClick="javascript:location.href='/hatchets/{{$name}}/stats/uptime?id={{$val.ID}}'; return false;">
+				<i class='fa fa-bug'></i></button><span style='color: {{getStatusColor $val.Status}};'>{{$val.Status}}</span></td>
+		{{else}}
+			<td>{{$val.Status}}</td>
+		{{end}}
+			<td class='break'>{{$val.Reason}}</td>
+		</tr>
+	{{end}}
+	</table>
+{{else}}
+	<div style='clear: left;' align='center' class='btn'><span style='color: red'>no process starts found</span></div>
+{{end}}
+	<div style='clear: left;' align='center'><hr/><p/>@simagix</div>
+</body></html>`
+	return template.New("hatchet").Funcs(template.FuncMap{
+		"add": func(a int, b int) int {
+			return a + b
+		},
+		"getStatusColor": func(status string) string {
+			if status == UPTIME_CRASHED {
+				return "red"
+			} else if status == UPTIME_UNCLEAN {
+				return "orange"
+			}
+			return "black"
+		},
+		"getUptime": func(segment UptimeSegment) string {
+			milli := getMilliseconds(segment.Start, segment.End)
+			if milli < 60000 {
+				return fmt.Sprintf("%.1f s", float64(milli)/1000)
+			} else if milli < 3600000 {
+				return fmt.Sprintf("%.1f m", float64(milli)/60000)
+			} else if milli < 86400000 {
+				return fmt.Sprintf("%.1f h", float64(milli)/3600000)
+			}
+			return fmt.Sprintf("%.1f d", float64(milli)/86400000)
+		},
+		"formatDateTime": func(str string) string {
+			return strings.Replace(str, "T", " ", 1)
+		}}).Parse(html)
+}
@@ -0,0 +1,54 @@
+/*
+ * Copyright 2022-present Kuei-chun Chen. All rights reserved.
+ * uptime_test.go
+ */
+
+package hatchet
+
+import (
+	"testing"
+
+	"github.com/simagix/gox"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestUptimeTracker(t *testing.T) {
+	strs := []string{
+		`{"t":{"$date":"2023-03-01T10:00:00.000+00:00"},"s":"I",  "c":"CONTROL",  "id":4615611, "ctx":"initandlisten","msg":"MongoDB starting","attr":{"pid":1234,"port":27017,"host":"host1"}}`,
+		`{"t":{"$date":"2023-03-01T11:00:00.000+00:00"},"s":"I",  "c":"CONTROL",  "id":20698,   "ctx":"conn1","msg":"Shutting down","attr":{"exitCode":0}}`,
+		`{"t":{"$date":"2023-03-01T11:05:00.000+00:00"},"s":"I",  "c":"CONTROL",  "id":4615611, "ctx":"initandlisten","msg":"MongoDB starting","attr":{"pid":2345,"port":27017,"host":"host1"}}`,
+		`{"t":{"$date":"2023-03-01T11:10:00.000+00:00"},"s":"F",  "c":"ASSERT",   "id":23081,   "ctx":"conn5","msg":"Invariant failure","attr":{"expr":"false","file":"query.cpp","line":123}}`,
+		`{"t":{"$date":"2023-03-01T11:10:00.001+00:00"},"s":"I",  "c":"CONTROL",  "id":31380,   "ctx":"conn5","msg":"BACKTRACE","attr":{"bt":{}}}`,
+		`{"t":{"$date":"2023-03-01T11:15:00.000+00:00"},"s":"I",  "c":"CONTROL",  "id":4615611, "ctx":"initandlisten","msg":"MongoDB starting","attr":{"pid":3456,"port":27017,"host":"host1"}}`,
+		`{"t":{"$date":"2023-03-01T11:16:00.000+00:00"},"s":"I",  "c":"NETWORK",  "id":23016,   "ctx":"listener","msg":"Waiting for connections","attr":{"port":27017}}`,
+		`{"t":{"$date":"2023-03-01T11:20:00.000+00:00"},"s":"I",  "c":"CONTROL",  "id":4615611, "ctx":"initandlisten","msg":"MongoDB starting","attr":{"pid":4567,"port":27017,"host":"host1"}}`,
+	}
+	tracker := NewUptimeTracker()
+	segments := []*UptimeSegment{}
+	for i, str := range strs {
+		doc := Logv2Info{}
+		if err := bson.UnmarshalExtJSON([]byte(str), false, &doc); err != nil {
+			t.Fatalf("bson unmarshal error %v", err)
+		}
+		if err := AddLegacyString(&doc); err != nil {
+			t.Fatal(err)
+		}
+		segments = append(segments, tracker.Analyze(i+1, &doc)...)
+	}
+	segments = append(segments, tracker.Close()...)
+	expected := []UptimeSegment{
+		{ID: 1, PID: 1234, EndID: 2, Status: UPTIME_SHUTDOWN},
+		{ID: 3, PID: 2345, EndID: 5, CrashID: 4, Status: UPTIME_CRASHED},
+		{ID: 6, PID: 3456, EndID: 7, Status: UPTIME_UNCLEAN},
+		{ID: 8, PID: 4567, EndID: 8, Status: UPTIME_RUNNING},
+	}
+	if len(segments) != len(expected) {
+		t.Fatal("expected", len(expected), "segments but got", len(segments))
+	}
+	for i, segment := range segments {
+		if segment.ID != expected[i].ID || segment.PID != expected[i].PID || segment.EndID != expected[i].EndID ||
+			segment.CrashID != expected[i].CrashID || segment.Status != expected[i].Status {
+			t.Fatal("expected", gox.Stringify(expected[i]), "but got", gox.Stringify(segment))
+		}
+	}
+}
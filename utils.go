@@ -9,13 +9,17 @@ import (
 	"bufio"
 	"bytes"
 	"compress/gzip"
+	"encoding/hex"
 	"fmt"
+	"math"
 	"math/rand"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 const (
@@ -33,6 +37,11 @@ func ToInt(num interface{}) int {
 	return int(x)
 }
 
+// init seeds random numbers once, reseeding per call repeats numbers of concurrent calls
+func init() {
+	rand.Seed(time.Now().UnixNano())
+}
+
 func replaceSpecialChars(name string) string {
 	for _, sep := range []string{"-", ".", " ", ":", ","} {
 		name = strings.ReplaceAll(name, sep, "_")
@@ -53,7 +62,6 @@ func getHatchetName(filename string) string {
 	if i = strings.LastIndex(hatchetName, "_gz"); i > 0 {
 		hatchetName = hatchetName[:i]
 	}
-	rand.Seed(time.Now().UnixNano())
 	b := make([]byte, TAIL_SIZE)
 	rand.Read(b)
 	tail := fmt.Sprintf("%x", b)[:TAIL_SIZE-1]
@@ -74,32 +82,41 @@ func EscapeString(value string) string {
 }
 
 func GetDateSubString(start string, end string) string {
+	length, suffix := GetDateBucket(start, end)
+	if suffix == "" {
+		return fmt.Sprintf("SUBSTR(date, 1, %d)", length)
+	}
+	return fmt.Sprintf("SUBSTR(date, 1, %d)||'%v'", length, suffix)
+}
+
+// GetDateBucket returns the length of a date prefix and the suffix to append of time buckets
+// of a period of time
+func GetDateBucket(start string, end string) (int, string) {
 	var err error
-	substr := "SUBSTR(date, 1, 16)"
 	if len(start) < 16 || len(end) < 16 {
-		return substr
+		return 16, ""
 	}
 	var stime, etime time.Time
 	layout := "2006-01-02T15:04"
 	if stime, err = time.Parse(layout, start[:16]); err != nil {
-		return substr
+		return 16, ""
 	}
 	if etime, err = time.Parse(layout, end[:16]); err != nil {
-		return substr
+		return 16, ""
 	}
 	minutes := etime.Sub(stime).Minutes()
 	if minutes < 1 {
-		return "SUBSTR(date, 1, 19)"
+		return 19, ""
 	} else if minutes < 10 {
-		return "SUBSTR(date, 1, 18)||'9'"
+		return 18, "9"
 	} else if minutes < 60 {
-		return "SUBSTR(date, 1, 16)||':59'"
+		return 16, ":59"
 	} else if minutes < 600 {
-		return "SUBSTR(date, 1, 15)||'9:59'"
+		return 15, "9:59"
 	} else if minutes < 3600 {
-		return "SUBSTR(date, 1, 13)||':59:59'"
+		return 13, ":59:59"
 	} else {
-		return "SUBSTR(date, 1, 10)||'T23:59:59'"
+		return 10, "T23:59:59"
 	}
 }
 
@@ -120,6 +137,39 @@ func GetHatchetSummary(info HatchetInfo) string {
 	return info.Name + strings.Join(arr, ", ")
 }
 
+// FormatBytes returns a size in bytes in a human readable unit, i.e. 1.5 MB
+func FormatBytes(size int64) string {
+	units := []string{"KB", "MB", "GB", "TB"}
+	if size < 1024 {
+		return fmt.Sprintf("%d B", size)
+	}
+	value := float64(size) / 1024
+	i := 0
+	for value >= 1024 && i < len(units)-1 {
+		value /= 1024
+		i++
+	}
+	return fmt.Sprintf("%.1f %v", value, units[i])
+}
+
+// ParseBytes returns a size in bytes of a number of a unit, i.e. 512MB, 1.5 GB or 1000
+func ParseBytes(size string) (int64, error) {
+	str := strings.ToUpper(strings.TrimSpace(size))
+	multiplier := 1.0
+	for i, unit := range []string{"KB", "MB", "GB", "TB"} {
+		if strings.HasSuffix(str, unit) {
+			multiplier = math.Pow(1024, float64(i+1))
+			str = strings.TrimSuffix(str, unit)
+			break
+		}
+	}
+	value, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(str, "B")), 64)
+	if err != nil || value < 0 {
+		return 0, NewInvalidInputError("invalid size %v", size)
+	}
+	return int64(value * multiplier), nil
+}
+
 // GetOffsetLimit returns offset, limit
 func GetOffsetLimit(str string) (int, int) {
 	toks := strings.Split(str, ",")
@@ -159,3 +209,18 @@ func GetBufioReader(data []byte) (*bufio.Reader, error) {
 
 	return bufio.NewReader(bytes.NewReader(data)), nil
 }
+
+// getUUIDString returns the UUID string of a binary subtype 4 value
+func getUUIDString(o interface{}) string {
+	switch data := o.(type) {
+	case primitive.Binary:
+		if data.Subtype != 4 || len(data.Data) != 16 {
+			return ""
+		}
+		x := hex.EncodeToString(data.Data)
+		return fmt.Sprintf("%s-%s-%s-%s-%s", x[:8], x[8:12], x[12:16], x[16:20], x[20:])
+	case string:
+		return data
+	}
+	return ""
+}
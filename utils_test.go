@@ -114,3 +114,26 @@ func TestGetOffsetLimit(t *testing.T) {
 		t.Fatal("expected", 100, 100, "but got", o, l)
 	}
 }
+
+func TestFormatBytes(t *testing.T) {
+	for size, expected := range map[int64]string{0: "0 B", 1000: "1000 B", 1536: "1.5 KB", 3 * 1024 * 1024: "3.0 MB",
+		5 * 1024 * 1024 * 1024: "5.0 GB"} {
+		if value := FormatBytes(size); value != expected {
+			t.Fatal("expected", expected, "but got", value)
+		}
+	}
+}
+
+func TestParseBytes(t *testing.T) {
+	for str, expected := range map[string]int64{"1000": 1000, "1000 B": 1000, "1.5KB": 1536, "3 MB": 3 * 1024 * 1024,
+		"5gb": 5 * 1024 * 1024 * 1024} {
+		if value, err := ParseBytes(str); err != nil || value != expected {
+			t.Fatal("expected", expected, "but got", value, err)
+		}
+	}
+	for _, str := range []string{"", "GB", "-1 MB", "1 PB"} {
+		if _, err := ParseBytes(str); err == nil {
+			t.Fatal("expected", "an error", "but got", nil)
+		}
+	}
+}